@@ -17,7 +17,13 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+	"net/netip"
+	"strings"
+
 	"github.com/k0sproject/k0smotron/internal/cloudinit"
+	"github.com/k0sproject/k0smotron/internal/util/v1beta2conditions"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
@@ -51,6 +57,26 @@ func (k *K0sWorkerConfig) GetConditions() clusterv1.Conditions {
 
 func (k *K0sWorkerConfig) SetConditions(conditions clusterv1.Conditions) {
 	k.Status.Conditions = conditions
+	k.SetV1Beta2Conditions(v1beta2conditions.Mirror(k.GetV1Beta2Conditions(), conditions, k.Generation))
+}
+
+// GetV1Beta2Conditions returns the conditions following the Cluster API v1beta2 contract.
+func (k *K0sWorkerConfig) GetV1Beta2Conditions() []metav1.Condition {
+	if k.Status.V1Beta2 == nil {
+		return nil
+	}
+	return k.Status.V1Beta2.Conditions
+}
+
+// SetV1Beta2Conditions sets the conditions following the Cluster API v1beta2 contract.
+func (k *K0sWorkerConfig) SetV1Beta2Conditions(conditions []metav1.Condition) {
+	if k.Status.V1Beta2 == nil {
+		if conditions == nil {
+			return
+		}
+		k.Status.V1Beta2 = &K0sWorkerConfigV1Beta2Status{}
+	}
+	k.Status.V1Beta2.Conditions = conditions
 }
 
 // +kubebuilder:object:root=true
@@ -143,6 +169,21 @@ type K0sWorkerConfigStatus struct {
 	// Conditions defines current service state of the K0sWorkerConfig.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// V1Beta2 groups the status fields following the Cluster API v1beta2 contract.
+	// +optional
+	V1Beta2 *K0sWorkerConfigV1Beta2Status `json:"v1beta2,omitempty"`
+}
+
+// K0sWorkerConfigV1Beta2Status groups the status fields of the K0sWorkerConfig following the Cluster API v1beta2 contract.
+type K0sWorkerConfigV1Beta2Status struct {
+	// Conditions represents the observations of the current state of the K0sWorkerConfig.
+	// The Ready condition summarizes the other conditions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=32
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -169,6 +210,21 @@ type K0sControllerConfigStatus struct {
 	// Conditions defines current service state of the K0sControllerConfig.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// V1Beta2 groups the status fields following the Cluster API v1beta2 contract.
+	// +optional
+	V1Beta2 *K0sControllerConfigV1Beta2Status `json:"v1beta2,omitempty"`
+}
+
+// K0sControllerConfigV1Beta2Status groups the status fields of the K0sControllerConfig following the Cluster API v1beta2 contract.
+type K0sControllerConfigV1Beta2Status struct {
+	// Conditions represents the observations of the current state of the K0sControllerConfig.
+	// The Ready condition summarizes the other conditions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=32
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 func (k *K0sControllerConfig) GetConditions() clusterv1.Conditions {
@@ -177,6 +233,26 @@ func (k *K0sControllerConfig) GetConditions() clusterv1.Conditions {
 
 func (k *K0sControllerConfig) SetConditions(conditions clusterv1.Conditions) {
 	k.Status.Conditions = conditions
+	k.SetV1Beta2Conditions(v1beta2conditions.Mirror(k.GetV1Beta2Conditions(), conditions, k.Generation))
+}
+
+// GetV1Beta2Conditions returns the conditions following the Cluster API v1beta2 contract.
+func (k *K0sControllerConfig) GetV1Beta2Conditions() []metav1.Condition {
+	if k.Status.V1Beta2 == nil {
+		return nil
+	}
+	return k.Status.V1Beta2.Conditions
+}
+
+// SetV1Beta2Conditions sets the conditions following the Cluster API v1beta2 contract.
+func (k *K0sControllerConfig) SetV1Beta2Conditions(conditions []metav1.Condition) {
+	if k.Status.V1Beta2 == nil {
+		if conditions == nil {
+			return
+		}
+		k.Status.V1Beta2 = &K0sControllerConfigV1Beta2Status{}
+	}
+	k.Status.V1Beta2.Conditions = conditions
 }
 
 // +kubebuilder:object:root=true
@@ -280,6 +356,17 @@ type TunnelingSpec struct {
 	//+kubebuilder:validation:Optional
 	//+kubebuilder:default=false
 	Enabled bool `json:"enabled,omitempty"`
+	// AutoEnable enables tunneling in tunnel mode when the control plane endpoint of the cluster is not routable:
+	// it is not set once the infrastructure is ready, it is a loopback or link-local address, or it is a private
+	// address outside of RoutableNetworks. Enabled is left as is, the TunnelingAutoEnabled condition of the
+	// K0sControlPlane records that tunneling was enabled.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default=false
+	AutoEnable bool `json:"autoEnable,omitempty"`
+	// RoutableNetworks are the CIDRs of the networks reachable from the management cluster and the workers.
+	// If empty, private control plane endpoint addresses are considered routable.
+	//+kubebuilder:validation:Optional
+	RoutableNetworks []string `json:"routableNetworks,omitempty"`
 	// Server address of the tunneling server.
 	// If empty, k0smotron will try to detect worker node address for.
 	//+kubebuilder:validation:Optional
@@ -299,4 +386,347 @@ type TunnelingSpec struct {
 	//+kubebuilder:validation:Enum=tunnel;proxy
 	//+kubebuilder:default=tunnel
 	Mode string `json:"mode,omitempty"`
+	// Provider is the tunneling implementation.
+	// With konnectivity, k0smotron deploys konnectivity agents instead of frpc and the API server is always exposed
+	// in proxy mode through the konnectivity server. With wireguard, the API server is always exposed in tunnel mode
+	// through a WireGuard tunnel. Token and TLS settings only apply to frp.
+	// If empty, k0smotron will use frp.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=frp;konnectivity;wireguard
+	//+kubebuilder:default=frp
+	Provider TunnelingProvider `json:"provider,omitempty"`
+	// TokenSecretRef is a reference to a secret in the same namespace that contains the token used to authenticate
+	// against the tunneling server. If set, k0smotron uses the referenced token instead of generating one, which
+	// allows sharing the token with an externally operated tunneling server.
+	//+kubebuilder:validation:Optional
+	TokenSecretRef *ContentSourceRef `json:"tokenSecretRef,omitempty"`
+	// TLS configures TLS between the tunneling clients and the tunneling server.
+	//+kubebuilder:validation:Optional
+	TLS *TunnelingTLSSpec `json:"tls,omitempty"`
+	// ClientReplicas is the number of frpc replicas running in the child cluster. With more than one replica, the
+	// replicas are load balanced by the tunneling server and a replica failing its health check is taken out of
+	// rotation, so a single crashed pod doesn't cut off access to the API server.
+	// Only applies to frp.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=1
+	ClientReplicas int32 `json:"clientReplicas,omitempty"`
+	// ProxyProtocol makes frpc pass the source address of the tunneled connections with the PROXY protocol, so it
+	// is preserved for audit logs and IP based authorization. The kube-apiserver does not accept the PROXY protocol,
+	// so the connections are forwarded to a proxy in front of the API server that does, instead of the API server.
+	// If not set, the PROXY protocol is not used. Only applies to frp.
+	//+kubebuilder:validation:Optional
+	ProxyProtocol *TunnelingProxyProtocolSpec `json:"proxyProtocol,omitempty"`
+	// Limits configures the bandwidth, connection pool and compression of the tunnel.
+	// Only applies to frp.
+	//+kubebuilder:validation:Optional
+	Limits *TunnelingLimitsSpec `json:"limits,omitempty"`
+	// ExtraPorts are additional ports of the control plane published through the tunnel, such as the konnectivity
+	// agent port the workers connect to, which kubectl logs, exec and port-forward go through.
+	// Only applies to frp and is not supported with ServerRef.
+	//+kubebuilder:validation:Optional
+	ExtraPorts []TunnelingPort `json:"extraPorts,omitempty"`
+	// ServerRef is a reference to a TunnelServer shared with other clusters. If set, k0smotron does not deploy a
+	// dedicated tunneling server for the cluster and takes the server address and ports from the TunnelServer.
+	// Only applies to frp.
+	//+kubebuilder:validation:Optional
+	ServerRef *TunnelServerReference `json:"serverRef,omitempty"`
+	// SNIHostname is the hostname the API server is reachable at through a tunneling server routing the connections
+	// by SNI. It is set by k0smotron when the TunnelServer referenced by ServerRef has SNI routing enabled.
+	//+kubebuilder:validation:Optional
+	SNIHostname string `json:"sniHostname,omitempty"`
+	// ServerDeployment configures the scheduling and resources of the tunneling server deployment k0smotron creates
+	// in the management cluster.
+	//+kubebuilder:validation:Optional
+	ServerDeployment *TunnelingDeploymentSpec `json:"serverDeployment,omitempty"`
+	// Images configures the images of the tunneling server and clients.
+	//+kubebuilder:validation:Optional
+	Images *TunnelingImagesSpec `json:"images,omitempty"`
+	// WireGuard configures the WireGuard tunnel.
+	// Only applies to wireguard.
+	//+kubebuilder:validation:Optional
+	WireGuard *TunnelingWireGuardSpec `json:"wireGuard,omitempty"`
+}
+
+// TunnelingWireGuardSpec configures the WireGuard tunnel between the management cluster and the child cluster.
+type TunnelingWireGuardSpec struct {
+	// Network is the network of the WireGuard tunnel, in CIDR notation. The peer in the management cluster gets the
+	// first address of the network and the peer in the child cluster the second one, so it must hold at least four
+	// addresses and must not overlap with the pod, service or node networks of either cluster.
+	// If empty, k0smotron will use 10.222.222.0/30.
+	//+kubebuilder:validation:Optional
+	Network string `json:"network,omitempty"`
+}
+
+const defaultWireGuardNetwork = "10.222.222.0/30"
+
+// GetWireGuardPeerAddresses returns the tunnel addresses of the WireGuard peers of the management cluster and of the
+// child cluster, with the prefix length of the WireGuard network.
+func (t *TunnelingSpec) GetWireGuardPeerAddresses() (server netip.Prefix, client netip.Prefix, err error) {
+	network := defaultWireGuardNetwork
+	if t.WireGuard != nil && t.WireGuard.Network != "" {
+		network = t.WireGuard.Network
+	}
+	prefix, err := netip.ParsePrefix(network)
+	if err != nil {
+		return server, client, fmt.Errorf("invalid WireGuard network %q: %w", network, err)
+	}
+	if prefix.Addr().BitLen()-prefix.Bits() < 2 {
+		return server, client, fmt.Errorf("invalid WireGuard network %q: it must hold at least four addresses", network)
+	}
+	serverAddr := prefix.Masked().Addr().Next()
+	return netip.PrefixFrom(serverAddr, prefix.Bits()), netip.PrefixFrom(serverAddr.Next(), prefix.Bits()), nil
+}
+
+// TunnelingProxyProtocolSpec configures the PROXY protocol between frpc and the proxy in front of the API server.
+type TunnelingProxyProtocolSpec struct {
+	// Version is the version of the PROXY protocol.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=v1;v2
+	//+kubebuilder:default=v2
+	Version string `json:"version,omitempty"`
+	// Address is the address of the proxy accepting the PROXY protocol in front of the API server, such as an
+	// HAProxy or an Envoy reachable from the frpc pods. The tunneled API server connections are forwarded to it.
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+	// Port is the port of the proxy.
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+}
+
+// TunnelingImagesSpec configures the images of the tunneling server and clients.
+type TunnelingImagesSpec struct {
+	// Registry is a mirror registry, optionally with a path, the tunneling images are pulled from instead of their
+	// original registry, e.g. registry.example.com/mirror. It applies to the images of all the tunneling providers.
+	//+kubebuilder:validation:Optional
+	Registry string `json:"registry,omitempty"`
+	// ServerImage is the frps image, without the tag.
+	// If empty, k0smotron will use snowdreamtech/frps.
+	//+kubebuilder:validation:Optional
+	ServerImage string `json:"serverImage,omitempty"`
+	// ClientImage is the frpc image, without the tag.
+	// If empty, k0smotron will use snowdreamtech/frpc.
+	//+kubebuilder:validation:Optional
+	ClientImage string `json:"clientImage,omitempty"`
+	// WireGuardImage is the image of the WireGuard peers, with the tag. It must configure the wg0 interface from the
+	// wg0.conf file mounted in /config/wg_confs, as the linuxserver.io image does.
+	// If empty, k0smotron will use lscr.io/linuxserver/wireguard:1.0.20210914.
+	//+kubebuilder:validation:Optional
+	WireGuardImage string `json:"wireGuardImage,omitempty"`
+	// ForwarderImage is the socat image forwarding the connections through the WireGuard tunnel, with the tag.
+	// If empty, k0smotron will use alpine/socat:1.7.4.4.
+	//+kubebuilder:validation:Optional
+	ForwarderImage string `json:"forwarderImage,omitempty"`
+	// Version is the tag of the frps and frpc images.
+	// If empty, k0smotron will use the default one.
+	//+kubebuilder:validation:Optional
+	Version string `json:"version,omitempty"`
+	// PullPolicy is the pull policy of the tunneling images.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	//+kubebuilder:default=IfNotPresent
+	PullPolicy corev1.PullPolicy `json:"pullPolicy,omitempty"`
+}
+
+const (
+	defaultFRPServerImage = "snowdreamtech/frps"
+	defaultFRPClientImage = "snowdreamtech/frpc"
+	DefaultFRPVersion     = "0.51.3"
+	defaultWireGuardImage = "lscr.io/linuxserver/wireguard:1.0.20210914"
+	defaultForwarderImage = "alpine/socat:1.7.4.4"
+)
+
+// GetFRPServerImage returns the frps image, pulled from the mirror registry if one is configured.
+func (t *TunnelingSpec) GetFRPServerImage() string {
+	image, version := defaultFRPServerImage, DefaultFRPVersion
+	if t.Images != nil {
+		if t.Images.ServerImage != "" {
+			image = t.Images.ServerImage
+		}
+		if t.Images.Version != "" {
+			version = t.Images.Version
+		}
+	}
+	return t.GetImage(fmt.Sprintf("%s:%s", image, version))
+}
+
+// GetFRPClientImage returns the frpc image, pulled from the mirror registry if one is configured.
+func (t *TunnelingSpec) GetFRPClientImage() string {
+	image, version := defaultFRPClientImage, DefaultFRPVersion
+	if t.Images != nil {
+		if t.Images.ClientImage != "" {
+			image = t.Images.ClientImage
+		}
+		if t.Images.Version != "" {
+			version = t.Images.Version
+		}
+	}
+	return t.GetImage(fmt.Sprintf("%s:%s", image, version))
+}
+
+// GetWireGuardImage returns the WireGuard image, pulled from the mirror registry if one is configured.
+func (t *TunnelingSpec) GetWireGuardImage() string {
+	if t.Images != nil && t.Images.WireGuardImage != "" {
+		return t.GetImage(t.Images.WireGuardImage)
+	}
+	return t.GetImage(defaultWireGuardImage)
+}
+
+// GetForwarderImage returns the socat image of the WireGuard peers, pulled from the mirror registry if one is configured.
+func (t *TunnelingSpec) GetForwarderImage() string {
+	if t.Images != nil && t.Images.ForwarderImage != "" {
+		return t.GetImage(t.Images.ForwarderImage)
+	}
+	return t.GetImage(defaultForwarderImage)
+}
+
+// GetImage returns the given tunneling image with its registry replaced by the mirror registry, if one is configured.
+func (t *TunnelingSpec) GetImage(image string) string {
+	if t.Images == nil || t.Images.Registry == "" {
+		return image
+	}
+	// The first component of the image is a registry if it looks like a host, as with docker.
+	if registry, name, ok := strings.Cut(image, "/"); ok && (strings.ContainsAny(registry, ".:") || registry == "localhost") {
+		image = name
+	}
+	return strings.TrimSuffix(t.Images.Registry, "/") + "/" + image
+}
+
+// GetImagePullPolicy returns the pull policy of the tunneling images.
+func (t *TunnelingSpec) GetImagePullPolicy() corev1.PullPolicy {
+	if t.Images == nil || t.Images.PullPolicy == "" {
+		return corev1.PullIfNotPresent
+	}
+	return t.Images.PullPolicy
+}
+
+// TunnelingLimitsSpec configures the bandwidth, connection pool and compression of a tunnel.
+type TunnelingLimitsSpec struct {
+	// BandwidthLimit limits the bandwidth of each tunneled port, e.g. 512KB or 10MB per second.
+	// If empty, the bandwidth is not limited.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Pattern=`^[0-9]+(KB|MB)$`
+	BandwidthLimit string `json:"bandwidthLimit,omitempty"`
+	// PoolCount is the number of connections the tunneling client establishes to the tunneling server in advance,
+	// which reduces the latency of new tunneled connections. The tunneling server accepts at most this many pooled
+	// connections per client.
+	// If empty, the connections are established on demand.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:validation:Maximum=100
+	PoolCount int32 `json:"poolCount,omitempty"`
+	// Compression compresses the tunneled traffic. The API server traffic is TLS encrypted, so compression mostly
+	// pays off for the extra ports carrying plain traffic.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default=false
+	Compression bool `json:"compression,omitempty"`
+}
+
+// TunnelingPort is a port of the control plane published through the tunnel.
+type TunnelingPort struct {
+	// Name is the name of the port. It must be unique among the extra ports and must not be api or tunnel, the
+	// names of the ports of the tunneling server.
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	//+kubebuilder:validation:MaxLength=15
+	Name string `json:"name"`
+	// Address is the address the tunneling client forwards the connections to.
+	// If empty, k0smotron will use the host of the control plane endpoint of the cluster.
+	//+kubebuilder:validation:Optional
+	Address string `json:"address,omitempty"`
+	// Port is the port the tunneling client forwards the connections to.
+	//+kubebuilder:validation:Required
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+	// NodePort is the NodePort the port is published on by the tunneling server.
+	//+kubebuilder:validation:Required
+	NodePort int32 `json:"nodePort"`
+}
+
+// TunnelServerReference is a reference to a TunnelServer.
+type TunnelServerReference struct {
+	// Name is the name of the TunnelServer.
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Namespace is the namespace of the TunnelServer. If empty, the namespace of the control plane is used.
+	//+kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// TunnelingDeploymentSpec configures the scheduling and resources of a tunneling deployment.
+type TunnelingDeploymentSpec struct {
+	// Replicas is the number of replicas of the deployment.
+	// frp and WireGuard keep the tunnel in a single pod, so more than one replica is only allowed with konnectivity.
+	// The frps deployment of a TunnelServer always runs a single replica.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=1
+	Replicas *int32 `json:"replicas,omitempty"`
+	// NodeSelector is a selector which must match a node's labels for the pods to be scheduled on that node.
+	//+kubebuilder:validation:Optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations are the tolerations of the pods.
+	//+kubebuilder:validation:Optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Affinity is the scheduling constraints of the pods.
+	//+kubebuilder:validation:Optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Resources describes the compute resource requirements for the tunneling container.
+	//+kubebuilder:validation:Optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// TunnelingProvider is the implementation used for tunneling.
+type TunnelingProvider string
+
+const (
+	// TunnelingProviderFRP tunnels the API server with frp.
+	TunnelingProviderFRP TunnelingProvider = "frp"
+	// TunnelingProviderKonnectivity tunnels the API server with konnectivity.
+	TunnelingProviderKonnectivity TunnelingProvider = "konnectivity"
+	// TunnelingProviderWireGuard tunnels the API server with WireGuard.
+	TunnelingProviderWireGuard TunnelingProvider = "wireguard"
+)
+
+// TunnelingTLSSpec configures TLS between the tunneling clients and the tunneling server.
+type TunnelingTLSSpec struct {
+	// Enabled specifies whether the traffic between the tunneling clients and the tunneling server is encrypted with TLS.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default=false
+	Enabled bool `json:"enabled,omitempty"`
+	// ServerCertSecretName is the name of a kubernetes.io/tls secret in the same namespace that contains the certificate
+	// and key of the tunneling server. If the secret contains a ca.crt, the server only accepts clients presenting a
+	// certificate signed by that CA.
+	// If empty, k0smotron uses the <cluster-name>-frps-tls secret.
+	//+kubebuilder:validation:Optional
+	ServerCertSecretName string `json:"serverCertSecretName,omitempty"`
+	// ClientCertSecretName is the name of a kubernetes.io/tls secret in the same namespace that contains the certificate
+	// and key presented by the tunneling clients. If the secret contains a ca.crt, the clients verify the certificate
+	// of the server against that CA.
+	// If empty, k0smotron uses the <cluster-name>-frpc-tls secret.
+	//+kubebuilder:validation:Optional
+	ClientCertSecretName string `json:"clientCertSecretName,omitempty"`
+	// IssuerRef is a reference to a cert-manager issuer. If set, k0smotron requests the server and client
+	// certificates from cert-manager instead of expecting the secrets to exist.
+	//+kubebuilder:validation:Optional
+	IssuerRef *CertManagerIssuerRef `json:"issuerRef,omitempty"`
+}
+
+// CertManagerIssuerRef is a reference to a cert-manager issuer.
+type CertManagerIssuerRef struct {
+	// Name is the name of the issuer.
+	//+kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Kind is the kind of the issuer.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default=Issuer
+	Kind string `json:"kind,omitempty"`
+	// Group is the API group of the issuer.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default=cert-manager.io
+	Group string `json:"group,omitempty"`
 }
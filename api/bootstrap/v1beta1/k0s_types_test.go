@@ -0,0 +1,116 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestTunnelingSpec_GetImages(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         *TunnelingSpec
+		server       string
+		client       string
+		konnectivity string
+		wireGuard    string
+		forwarder    string
+		pullPolicy   corev1.PullPolicy
+	}{
+		{
+			name:         "Nothing given",
+			spec:         &TunnelingSpec{},
+			server:       "snowdreamtech/frps:0.51.3",
+			client:       "snowdreamtech/frpc:0.51.3",
+			konnectivity: "registry.k8s.io/kas-network-proxy/proxy-agent:v0.30.3",
+			wireGuard:    "lscr.io/linuxserver/wireguard:1.0.20210914",
+			forwarder:    "alpine/socat:1.7.4.4",
+			pullPolicy:   corev1.PullIfNotPresent,
+		},
+		{
+			name: "Images and version given",
+			spec: &TunnelingSpec{Images: &TunnelingImagesSpec{
+				ServerImage:    "example.com/frps",
+				ClientImage:    "example.com/frpc",
+				WireGuardImage: "example.com/wireguard:1.0.20250521",
+				ForwarderImage: "example.com/socat:1.8.0.3",
+				Version:        "0.52.0",
+				PullPolicy:     corev1.PullAlways,
+			}},
+			server:       "example.com/frps:0.52.0",
+			client:       "example.com/frpc:0.52.0",
+			konnectivity: "registry.k8s.io/kas-network-proxy/proxy-agent:v0.30.3",
+			wireGuard:    "example.com/wireguard:1.0.20250521",
+			forwarder:    "example.com/socat:1.8.0.3",
+			pullPolicy:   corev1.PullAlways,
+		},
+		{
+			name: "Mirror registry given",
+			spec: &TunnelingSpec{Images: &TunnelingImagesSpec{
+				Registry: "mirror.example.com:5000/k0smotron/",
+			}},
+			server:       "mirror.example.com:5000/k0smotron/snowdreamtech/frps:0.51.3",
+			client:       "mirror.example.com:5000/k0smotron/snowdreamtech/frpc:0.51.3",
+			konnectivity: "mirror.example.com:5000/k0smotron/kas-network-proxy/proxy-agent:v0.30.3",
+			wireGuard:    "mirror.example.com:5000/k0smotron/linuxserver/wireguard:1.0.20210914",
+			forwarder:    "mirror.example.com:5000/k0smotron/alpine/socat:1.7.4.4",
+			pullPolicy:   corev1.PullIfNotPresent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.server, tt.spec.GetFRPServerImage())
+			require.Equal(t, tt.client, tt.spec.GetFRPClientImage())
+			require.Equal(t, tt.konnectivity, tt.spec.GetImage("registry.k8s.io/kas-network-proxy/proxy-agent:v0.30.3"))
+			require.Equal(t, tt.wireGuard, tt.spec.GetWireGuardImage())
+			require.Equal(t, tt.forwarder, tt.spec.GetForwarderImage())
+			require.Equal(t, tt.pullPolicy, tt.spec.GetImagePullPolicy())
+		})
+	}
+}
+
+func TestTunnelingSpec_GetWireGuardPeerAddresses(t *testing.T) {
+	tests := []struct {
+		network string
+		server  string
+		client  string
+		wantErr bool
+	}{
+		{network: "", server: "10.222.222.1/30", client: "10.222.222.2/30"},
+		{network: "192.168.77.0/29", server: "192.168.77.1/29", client: "192.168.77.2/29"},
+		{network: "192.168.77.4/30", server: "192.168.77.5/30", client: "192.168.77.6/30"},
+		{network: "fd00:222::/126", server: "fd00:222::1/126", client: "fd00:222::2/126"},
+		{network: "10.222.222.0/31", wantErr: true},
+		{network: "10.222.222.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.network, func(t *testing.T) {
+			spec := &TunnelingSpec{WireGuard: &TunnelingWireGuardSpec{Network: tt.network}}
+			server, client, err := spec.GetWireGuardPeerAddresses()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.server, server.String())
+			require.Equal(t, tt.client, client.String())
+		})
+	}
+}
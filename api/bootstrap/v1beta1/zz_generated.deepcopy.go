@@ -21,10 +21,27 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	apiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerIssuerRef) DeepCopyInto(out *CertManagerIssuerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerIssuerRef.
+func (in *CertManagerIssuerRef) DeepCopy() *CertManagerIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContentSource) DeepCopyInto(out *ContentSource) {
 	*out = *in
@@ -130,7 +147,7 @@ func (in *K0sConfigSpec) DeepCopyInto(out *K0sConfigSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	out.Tunneling = in.Tunneling
+	in.Tunneling.DeepCopyInto(&out.Tunneling)
 	if in.CustomUserDataRef != nil {
 		in, out := &in.CustomUserDataRef, &out.CustomUserDataRef
 		*out = new(ContentSource)
@@ -242,6 +259,11 @@ func (in *K0sControllerConfigStatus) DeepCopyInto(out *K0sControllerConfigStatus
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.V1Beta2 != nil {
+		in, out := &in.V1Beta2, &out.V1Beta2
+		*out = new(K0sControllerConfigV1Beta2Status)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K0sControllerConfigStatus.
@@ -254,6 +276,28 @@ func (in *K0sControllerConfigStatus) DeepCopy() *K0sControllerConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *K0sControllerConfigV1Beta2Status) DeepCopyInto(out *K0sControllerConfigV1Beta2Status) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K0sControllerConfigV1Beta2Status.
+func (in *K0sControllerConfigV1Beta2Status) DeepCopy() *K0sControllerConfigV1Beta2Status {
+	if in == nil {
+		return nil
+	}
+	out := new(K0sControllerConfigV1Beta2Status)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *K0sWorkerConfig) DeepCopyInto(out *K0sWorkerConfig) {
 	*out = *in
@@ -375,6 +419,11 @@ func (in *K0sWorkerConfigStatus) DeepCopyInto(out *K0sWorkerConfigStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.V1Beta2 != nil {
+		in, out := &in.V1Beta2, &out.V1Beta2
+		*out = new(K0sWorkerConfigV1Beta2Status)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K0sWorkerConfigStatus.
@@ -478,6 +527,28 @@ func (in *K0sWorkerConfigTemplateSpec) DeepCopy() *K0sWorkerConfigTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *K0sWorkerConfigV1Beta2Status) DeepCopyInto(out *K0sWorkerConfigV1Beta2Status) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K0sWorkerConfigV1Beta2Status.
+func (in *K0sWorkerConfigV1Beta2Status) DeepCopy() *K0sWorkerConfigV1Beta2Status {
+	if in == nil {
+		return nil
+	}
+	out := new(K0sWorkerConfigV1Beta2Status)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretMetadata) DeepCopyInto(out *SecretMetadata) {
 	*out = *in
@@ -507,9 +578,174 @@ func (in *SecretMetadata) DeepCopy() *SecretMetadata {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelServerReference) DeepCopyInto(out *TunnelServerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelServerReference.
+func (in *TunnelServerReference) DeepCopy() *TunnelServerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelServerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelingDeploymentSpec) DeepCopyInto(out *TunnelingDeploymentSpec) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(corev1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelingDeploymentSpec.
+func (in *TunnelingDeploymentSpec) DeepCopy() *TunnelingDeploymentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelingDeploymentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelingImagesSpec) DeepCopyInto(out *TunnelingImagesSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelingImagesSpec.
+func (in *TunnelingImagesSpec) DeepCopy() *TunnelingImagesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelingImagesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelingLimitsSpec) DeepCopyInto(out *TunnelingLimitsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelingLimitsSpec.
+func (in *TunnelingLimitsSpec) DeepCopy() *TunnelingLimitsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelingLimitsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelingPort) DeepCopyInto(out *TunnelingPort) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelingPort.
+func (in *TunnelingPort) DeepCopy() *TunnelingPort {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelingPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelingProxyProtocolSpec) DeepCopyInto(out *TunnelingProxyProtocolSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelingProxyProtocolSpec.
+func (in *TunnelingProxyProtocolSpec) DeepCopy() *TunnelingProxyProtocolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelingProxyProtocolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TunnelingSpec) DeepCopyInto(out *TunnelingSpec) {
 	*out = *in
+	if in.RoutableNetworks != nil {
+		in, out := &in.RoutableNetworks, &out.RoutableNetworks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TokenSecretRef != nil {
+		in, out := &in.TokenSecretRef, &out.TokenSecretRef
+		*out = new(ContentSourceRef)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TunnelingTLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProxyProtocol != nil {
+		in, out := &in.ProxyProtocol, &out.ProxyProtocol
+		*out = new(TunnelingProxyProtocolSpec)
+		**out = **in
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(TunnelingLimitsSpec)
+		**out = **in
+	}
+	if in.ExtraPorts != nil {
+		in, out := &in.ExtraPorts, &out.ExtraPorts
+		*out = make([]TunnelingPort, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServerRef != nil {
+		in, out := &in.ServerRef, &out.ServerRef
+		*out = new(TunnelServerReference)
+		**out = **in
+	}
+	if in.ServerDeployment != nil {
+		in, out := &in.ServerDeployment, &out.ServerDeployment
+		*out = new(TunnelingDeploymentSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = new(TunnelingImagesSpec)
+		**out = **in
+	}
+	if in.WireGuard != nil {
+		in, out := &in.WireGuard, &out.WireGuard
+		*out = new(TunnelingWireGuardSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelingSpec.
@@ -521,3 +757,38 @@ func (in *TunnelingSpec) DeepCopy() *TunnelingSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelingTLSSpec) DeepCopyInto(out *TunnelingTLSSpec) {
+	*out = *in
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(CertManagerIssuerRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelingTLSSpec.
+func (in *TunnelingTLSSpec) DeepCopy() *TunnelingTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelingTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelingWireGuardSpec) DeepCopyInto(out *TunnelingWireGuardSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelingWireGuardSpec.
+func (in *TunnelingWireGuardSpec) DeepCopy() *TunnelingWireGuardSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelingWireGuardSpec)
+	in.DeepCopyInto(out)
+	return out
+}
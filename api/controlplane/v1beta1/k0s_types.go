@@ -20,6 +20,8 @@ import (
 	"slices"
 
 	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
+	kmapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/util/v1beta2conditions"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -40,14 +42,50 @@ const (
 	// ControlPlaneReadyCondition documents the status of the control plane
 	ControlPlaneReadyCondition clusterv1.ConditionType = "ControlPlaneReady"
 
+	// MachinesReadyCondition reports an aggregate of the Ready conditions of the control plane machines.
+	MachinesReadyCondition clusterv1.ConditionType = "MachinesReady"
+
+	// ClusterClientCreationFailedReason (Severity=Warning) documents the client of the workload cluster could not be created.
+	ClusterClientCreationFailedReason = "ClusterClientCreationFailed"
+
+	// KubeSystemNamespaceNotAccessibleReason (Severity=Warning) documents the workload cluster API could not be reached
+	// to get the kube-system namespace.
+	KubeSystemNamespaceNotAccessibleReason = "KubeSystemNamespaceNotAccessible"
+
 	// RemediationInProgressAnnotation is used to keep track that a remediation is in progress,
 	// and more specifically it tracks that the system is in between having deleted an unhealthy machine
 	// and recreating its replacement.
 	RemediationInProgressAnnotation = "controlplane.cluster.x-k8s.io/remediation-in-progress"
 
+	// AllowedNamespacesAnnotation is set on an infrastructure machine template to list the namespaces, separated by
+	// commas, of the K0sControlPlanes allowed to reference it from another namespace. "*" allows all the namespaces.
+	AllowedNamespacesAnnotation = "controlplane.k0smotron.io/allowed-namespaces"
+
+	// UpgradeApprovalRequiredAnnotation is set on a K0sControlPlane to hold the upgrades of its machines until the
+	// new version is approved with the UpgradeApprovedVersionAnnotation.
+	UpgradeApprovalRequiredAnnotation = "controlplane.k0smotron.io/upgrade-approval-required"
+
+	// UpgradeApprovedVersionAnnotation is the version of the control plane the machines are allowed to be upgraded to.
+	UpgradeApprovedVersionAnnotation = "controlplane.k0smotron.io/approved-version"
+
 	// ControlPlanePausedCondition documents the reconciliation of the control plane is paused.
 	ControlPlanePausedCondition clusterv1.ConditionType = "Paused"
 
+	// TunnelingAutoEnabledCondition documents that tunneling was enabled because the control plane endpoint
+	// is not routable.
+	TunnelingAutoEnabledCondition clusterv1.ConditionType = "TunnelingAutoEnabled"
+
+	// ExternalDNSReadyCondition documents the DNSEndpoint of the control plane is up to date with the addresses of
+	// the controller machines. It is only set while ExternalDNS is, so the DNSEndpoint is only deleted once it is unset.
+	ExternalDNSReadyCondition clusterv1.ConditionType = "ExternalDNSReady"
+
+	// LifecycleHookBlockingCondition documents an upgrade held back by a Cluster API Runtime SDK lifecycle hook.
+	// Its reason is the name of the blocking hook.
+	LifecycleHookBlockingCondition clusterv1.ConditionType = "LifecycleHookBlocking"
+
+	// UpgradeAwaitingApprovalCondition documents an upgrade held back until its version is approved.
+	UpgradeAwaitingApprovalCondition clusterv1.ConditionType = "UpgradeAwaitingApproval"
+
 	// K0sControlPlaneFinalizer is the finalizer applied to KubeadmControlPlane resources
 	// by its managing controller.
 	K0sControlPlaneFinalizer = "k0s.controlplane.cluster.x-k8s.io"
@@ -98,6 +136,25 @@ type K0sControlPlaneSpec struct {
 	// just the Kubernetes version (e.g. v1.27.1). If left empty, k0smotron will select one automatically.
 	//+kubebuilder:validation:Optional
 	Version string `json:"version,omitempty"`
+	// ExternalDNS manages a DNS record pointing at the addresses of the controller machines with external-dns.
+	// The record is updated as the machines are replaced.
+	// +optional
+	ExternalDNS *ExternalDNS `json:"externalDNS,omitempty"`
+}
+
+// ExternalDNS defines the DNS record of the controller machines, published by external-dns from a DNSEndpoint.
+// external-dns must run with the crd source.
+type ExternalDNS struct {
+	// Hostname is the DNS name of the record. It is added to the SANs of the API server certificate.
+	Hostname string `json:"hostname"`
+	// AddressType is the type of the machine addresses the record points at.
+	// +kubebuilder:validation:Enum=ExternalIP;InternalIP
+	// +kubebuilder:default=ExternalIP
+	// +optional
+	AddressType clusterv1.MachineAddressType `json:"addressType,omitempty"`
+	// TTL is the TTL of the record in seconds. Defaults to the TTL of the DNS provider.
+	// +optional
+	TTL int64 `json:"ttl,omitempty"`
 }
 
 type K0sControlPlaneMachineTemplate struct {
@@ -108,6 +165,9 @@ type K0sControlPlaneMachineTemplate struct {
 
 	// InfrastructureRef is a required reference to a custom resource
 	// offered by an infrastructure provider.
+	// The template is looked up in the namespace of the K0sControlPlane, unless the namespace of the reference is set
+	// and the cross-namespace machine templates are enabled, see the controlplane.k0smotron.io/allowed-namespaces
+	// annotation.
 	InfrastructureRef corev1.ObjectReference `json:"infrastructureRef"`
 
 	// NodeDrainTimeout is the total amount of time that the controller will spend on draining a controlplane node
@@ -192,9 +252,35 @@ type K0sControlPlaneStatus struct {
 	// +optional
 	UpdatedReplicas int32 `json:"updatedReplicas"`
 
+	// tunnelingEndpoint is the endpoint the kubeconfig to access the cluster through the tunnel was generated for.
+	// The kubeconfig is regenerated when the tunneling settings change.
+	// +optional
+	TunnelingEndpoint string `json:"tunnelingEndpoint,omitempty"`
+
+	// operations is the bounded history of the significant operations done on the control plane, like scaling,
+	// upgrades, remediations and token rotations. The oldest operations are dropped first.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	Operations []kmapi.Operation `json:"operations,omitempty"`
+
 	// Conditions defines current service state of the K0sControlPlane.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// V1Beta2 groups the status fields following the Cluster API v1beta2 contract.
+	// +optional
+	V1Beta2 *K0sControlPlaneV1Beta2Status `json:"v1beta2,omitempty"`
+}
+
+// K0sControlPlaneV1Beta2Status groups the status fields of the K0sControlPlane following the Cluster API v1beta2 contract.
+type K0sControlPlaneV1Beta2Status struct {
+	// Conditions represents the observations of the current state of the K0sControlPlane.
+	// The Ready condition summarizes the other conditions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=32
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 func (k *K0sControlPlane) GetConditions() clusterv1.Conditions {
@@ -203,6 +289,26 @@ func (k *K0sControlPlane) GetConditions() clusterv1.Conditions {
 
 func (k *K0sControlPlane) SetConditions(conditions clusterv1.Conditions) {
 	k.Status.Conditions = conditions
+	k.SetV1Beta2Conditions(v1beta2conditions.Mirror(k.GetV1Beta2Conditions(), conditions, k.Generation, ControlPlanePausedCondition, TunnelingAutoEnabledCondition, ExternalDNSReadyCondition, LifecycleHookBlockingCondition, UpgradeAwaitingApprovalCondition))
+}
+
+// GetV1Beta2Conditions returns the conditions following the Cluster API v1beta2 contract.
+func (k *K0sControlPlane) GetV1Beta2Conditions() []metav1.Condition {
+	if k.Status.V1Beta2 == nil {
+		return nil
+	}
+	return k.Status.V1Beta2.Conditions
+}
+
+// SetV1Beta2Conditions sets the conditions following the Cluster API v1beta2 contract.
+func (k *K0sControlPlane) SetV1Beta2Conditions(conditions []metav1.Condition) {
+	if k.Status.V1Beta2 == nil {
+		if conditions == nil {
+			return
+		}
+		k.Status.V1Beta2 = &K0sControlPlaneV1Beta2Status{}
+	}
+	k.Status.V1Beta2.Conditions = conditions
 }
 
 func (k *K0sControlPlane) WorkerEnabled() bool {
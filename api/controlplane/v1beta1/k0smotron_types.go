@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	kmapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/util/v1beta2conditions"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -111,6 +112,21 @@ type K0smotronControlPlaneStatus struct {
 	// Conditions defines current service state of the K0smotronControlPlane.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// V1Beta2 groups the status fields following the Cluster API v1beta2 contract.
+	// +optional
+	V1Beta2 *K0smotronControlPlaneV1Beta2Status `json:"v1beta2,omitempty"`
+}
+
+// K0smotronControlPlaneV1Beta2Status groups the status fields of the K0smotronControlPlane following the Cluster API v1beta2 contract.
+type K0smotronControlPlaneV1Beta2Status struct {
+	// Conditions represents the observations of the current state of the K0smotronControlPlane.
+	// The Ready condition summarizes the other conditions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=32
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 func (k *K0smotronControlPlane) GetConditions() clusterv1.Conditions {
@@ -119,4 +135,24 @@ func (k *K0smotronControlPlane) GetConditions() clusterv1.Conditions {
 
 func (k *K0smotronControlPlane) SetConditions(conditions clusterv1.Conditions) {
 	k.Status.Conditions = conditions
+	k.SetV1Beta2Conditions(v1beta2conditions.Mirror(k.GetV1Beta2Conditions(), conditions, k.Generation, ControlPlanePausedCondition))
+}
+
+// GetV1Beta2Conditions returns the conditions following the Cluster API v1beta2 contract.
+func (k *K0smotronControlPlane) GetV1Beta2Conditions() []metav1.Condition {
+	if k.Status.V1Beta2 == nil {
+		return nil
+	}
+	return k.Status.V1Beta2.Conditions
+}
+
+// SetV1Beta2Conditions sets the conditions following the Cluster API v1beta2 contract.
+func (k *K0smotronControlPlane) SetV1Beta2Conditions(conditions []metav1.Condition) {
+	if k.Status.V1Beta2 == nil {
+		if conditions == nil {
+			return
+		}
+		k.Status.V1Beta2 = &K0smotronControlPlaneV1Beta2Status{}
+	}
+	k.Status.V1Beta2.Conditions = conditions
 }
@@ -0,0 +1,138 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
+)
+
+func init() {
+	SchemeBuilder.Register(&TunnelServer{}, &TunnelServerList{})
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:metadata:labels="cluster.x-k8s.io/v1beta1=v1beta1"
+// +kubebuilder:metadata:labels="cluster.x-k8s.io/provider=control-plane-k0smotron"
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".spec.serverAddress",description="Address of the tunneling server"
+// +kubebuilder:printcolumn:name="Allocated",type=integer,JSONPath=".status.allocatedPorts",description="Number of clusters with an allocated tunneling port"
+// +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=".status.ready",description="Whether the tunneling server is deployed"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of TunnelServer"
+
+// TunnelServer is a frp server shared by the control planes referencing it through spec.k0sConfigSpec.tunneling.serverRef.
+type TunnelServer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TunnelServerSpec   `json:"spec,omitempty"`
+	Status TunnelServerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type TunnelServerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TunnelServer `json:"items"`
+}
+
+type TunnelServerSpec struct {
+	// ServerAddress is the address the tunneling clients and the clients of the tunneled API servers connect to.
+	//+kubebuilder:validation:Required
+	ServerAddress string `json:"serverAddress"`
+	// ServerNodePort is the NodePort to publish for server port of the tunneling server.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default=31700
+	ServerNodePort int32 `json:"serverNodePort,omitempty"`
+	// ProxyNodePort is the NodePort to publish for the clusters in proxy mode. The clusters share the port, the
+	// traffic is routed by the host of their control plane endpoint.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default=31443
+	ProxyNodePort int32 `json:"proxyNodePort,omitempty"`
+	// PortRange is the range of NodePorts allocated to the clusters in tunnel mode, one port per cluster.
+	// The range must be within the NodePort range of the management cluster.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default={start:31800,end:31899}
+	PortRange TunnelServerPortRange `json:"portRange,omitempty"`
+	// SNI enables routing the connections of the clusters in tunnel mode to their API server by the SNI hostname
+	// through a single port, instead of allocating a port per cluster.
+	//+kubebuilder:validation:Optional
+	SNI *TunnelServerSNISpec `json:"sni,omitempty"`
+	// TokenSecretRef is a reference to a secret in the same namespace that contains the token used to authenticate
+	// the tunneling clients. If empty, k0smotron generates the token.
+	//+kubebuilder:validation:Optional
+	TokenSecretRef *bootstrapv1.ContentSourceRef `json:"tokenSecretRef,omitempty"`
+	// Deployment configures the scheduling and resources of the frps deployment.
+	//+kubebuilder:validation:Optional
+	Deployment *bootstrapv1.TunnelingDeploymentSpec `json:"deployment,omitempty"`
+	// Images configures the frps image. The client settings are taken from the control planes.
+	//+kubebuilder:validation:Optional
+	Images *bootstrapv1.TunnelingImagesSpec `json:"images,omitempty"`
+	// NamespaceSelector selects the namespaces of the control planes allowed to use the tunneling server, besides the
+	// namespace of the TunnelServer. The token of the tunneling server is shared with the control planes using it, so
+	// restrict the namespaces when the management cluster is shared between tenants. All namespaces are allowed if
+	// empty.
+	//+kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// TunnelServerSNISpec configures the routing of the API server connections by SNI hostname.
+type TunnelServerSNISpec struct {
+	// Domain is the parent domain of the hostnames of the clusters, <cluster-name>-<namespace>.<domain>.
+	// A wildcard DNS record of the domain must resolve to the address of the tunneling server.
+	//+kubebuilder:validation:Required
+	Domain string `json:"domain"`
+	// NodePort is the NodePort to publish for the port shared by the clusters.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:default=31444
+	NodePort int32 `json:"nodePort,omitempty"`
+}
+
+// TunnelServerPortRange is an inclusive range of ports.
+type TunnelServerPortRange struct {
+	// Start is the first port of the range.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	Start int32 `json:"start"`
+	// End is the last port of the range.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	End int32 `json:"end"`
+}
+
+type TunnelServerStatus struct {
+	// Ready indicates the tunneling server is deployed.
+	// +optional
+	Ready bool `json:"ready"`
+	// Allocations are the ports allocated to the clusters in tunnel mode.
+	// +optional
+	Allocations []TunnelServerAllocation `json:"allocations,omitempty"`
+	// AllocatedPorts is the number of allocated ports.
+	// +optional
+	AllocatedPorts int32 `json:"allocatedPorts"`
+}
+
+// TunnelServerAllocation is a port of the tunneling server allocated to a cluster.
+type TunnelServerAllocation struct {
+	// Cluster is the namespaced name of the cluster, as <namespace>/<name>.
+	Cluster string `json:"cluster"`
+	// Port is the port allocated to the cluster.
+	Port int32 `json:"port"`
+}
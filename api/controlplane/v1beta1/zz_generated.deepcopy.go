@@ -21,11 +21,28 @@ limitations under the License.
 package v1beta1
 
 import (
+	bootstrapv1beta1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
+	k0smotron_iov1beta1 "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	apiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalDNS) DeepCopyInto(out *ExternalDNS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalDNS.
+func (in *ExternalDNS) DeepCopy() *ExternalDNS {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalDNS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Initialization) DeepCopyInto(out *Initialization) {
 	*out = *in
@@ -141,6 +158,11 @@ func (in *K0sControlPlaneSpec) DeepCopyInto(out *K0sControlPlaneSpec) {
 		*out = new(K0sControlPlaneMachineTemplate)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ExternalDNS != nil {
+		in, out := &in.ExternalDNS, &out.ExternalDNS
+		*out = new(ExternalDNS)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K0sControlPlaneSpec.
@@ -157,6 +179,13 @@ func (in *K0sControlPlaneSpec) DeepCopy() *K0sControlPlaneSpec {
 func (in *K0sControlPlaneStatus) DeepCopyInto(out *K0sControlPlaneStatus) {
 	*out = *in
 	out.Initialization = in.Initialization
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]k0smotron_iov1beta1.Operation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(apiv1beta1.Conditions, len(*in))
@@ -164,6 +193,11 @@ func (in *K0sControlPlaneStatus) DeepCopyInto(out *K0sControlPlaneStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.V1Beta2 != nil {
+		in, out := &in.V1Beta2, &out.V1Beta2
+		*out = new(K0sControlPlaneV1Beta2Status)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K0sControlPlaneStatus.
@@ -319,6 +353,28 @@ func (in *K0sControlPlaneTemplateSpec) DeepCopy() *K0sControlPlaneTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *K0sControlPlaneV1Beta2Status) DeepCopyInto(out *K0sControlPlaneV1Beta2Status) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K0sControlPlaneV1Beta2Status.
+func (in *K0sControlPlaneV1Beta2Status) DeepCopy() *K0sControlPlaneV1Beta2Status {
+	if in == nil {
+		return nil
+	}
+	out := new(K0sControlPlaneV1Beta2Status)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *K0smotronControlPlane) DeepCopyInto(out *K0smotronControlPlane) {
 	*out = *in
@@ -389,6 +445,11 @@ func (in *K0smotronControlPlaneStatus) DeepCopyInto(out *K0smotronControlPlaneSt
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.V1Beta2 != nil {
+		in, out := &in.V1Beta2, &out.V1Beta2
+		*out = new(K0smotronControlPlaneV1Beta2Status)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K0smotronControlPlaneStatus.
@@ -491,3 +552,190 @@ func (in *K0smotronControlPlaneTemplateSpec) DeepCopy() *K0smotronControlPlaneTe
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *K0smotronControlPlaneV1Beta2Status) DeepCopyInto(out *K0smotronControlPlaneV1Beta2Status) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K0smotronControlPlaneV1Beta2Status.
+func (in *K0smotronControlPlaneV1Beta2Status) DeepCopy() *K0smotronControlPlaneV1Beta2Status {
+	if in == nil {
+		return nil
+	}
+	out := new(K0smotronControlPlaneV1Beta2Status)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelServer) DeepCopyInto(out *TunnelServer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelServer.
+func (in *TunnelServer) DeepCopy() *TunnelServer {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TunnelServer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelServerAllocation) DeepCopyInto(out *TunnelServerAllocation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelServerAllocation.
+func (in *TunnelServerAllocation) DeepCopy() *TunnelServerAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelServerAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelServerList) DeepCopyInto(out *TunnelServerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TunnelServer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelServerList.
+func (in *TunnelServerList) DeepCopy() *TunnelServerList {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelServerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TunnelServerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelServerPortRange) DeepCopyInto(out *TunnelServerPortRange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelServerPortRange.
+func (in *TunnelServerPortRange) DeepCopy() *TunnelServerPortRange {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelServerPortRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelServerSNISpec) DeepCopyInto(out *TunnelServerSNISpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelServerSNISpec.
+func (in *TunnelServerSNISpec) DeepCopy() *TunnelServerSNISpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelServerSNISpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelServerSpec) DeepCopyInto(out *TunnelServerSpec) {
+	*out = *in
+	out.PortRange = in.PortRange
+	if in.SNI != nil {
+		in, out := &in.SNI, &out.SNI
+		*out = new(TunnelServerSNISpec)
+		**out = **in
+	}
+	if in.TokenSecretRef != nil {
+		in, out := &in.TokenSecretRef, &out.TokenSecretRef
+		*out = new(bootstrapv1beta1.ContentSourceRef)
+		**out = **in
+	}
+	if in.Deployment != nil {
+		in, out := &in.Deployment, &out.Deployment
+		*out = new(bootstrapv1beta1.TunnelingDeploymentSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = new(bootstrapv1beta1.TunnelingImagesSpec)
+		**out = **in
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelServerSpec.
+func (in *TunnelServerSpec) DeepCopy() *TunnelServerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelServerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TunnelServerStatus) DeepCopyInto(out *TunnelServerStatus) {
+	*out = *in
+	if in.Allocations != nil {
+		in, out := &in.Allocations, &out.Allocations
+		*out = make([]TunnelServerAllocation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelServerStatus.
+func (in *TunnelServerStatus) DeepCopy() *TunnelServerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TunnelServerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
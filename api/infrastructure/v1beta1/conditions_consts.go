@@ -0,0 +1,127 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+// Conditions and condition Reasons for the PooledRemoteMachine objects
+
+const (
+	// PooledMachineHealthyCondition documents the result of the last health probe of a free pooled machine.
+	// Machines with this condition set to False are not reserved by RemoteMachines.
+	PooledMachineHealthyCondition clusterv1.ConditionType = "Healthy"
+
+	// PooledMachineUnreachableReason (Severity=Warning) documents a pooled machine that could not be reached over SSH.
+	PooledMachineUnreachableReason = "Unreachable"
+
+	// PooledMachineProbeFailedReason (Severity=Warning) documents a pooled machine which was reachable but
+	// did not meet the requirements of the configured health check.
+	PooledMachineProbeFailedReason = "ProbeFailed"
+)
+
+// Conditions and condition Reasons for the RemoteMachine objects
+
+const (
+	// RemoteMachineProvisionedCondition documents the provisioning of a RemoteMachine.
+	RemoteMachineProvisionedCondition clusterv1.ConditionType = "Provisioned"
+
+	// RemoteMachineProvisioningReason (Severity=Info) documents a RemoteMachine being provisioned.
+	RemoteMachineProvisioningReason = "Provisioning"
+
+	// RemoteMachineMissingFieldsReason (Severity=Error) documents a RemoteMachine missing the fields
+	// required to connect to the machine.
+	RemoteMachineMissingFieldsReason = "MissingFields"
+
+	// RemoteMachineConnectionFailedReason (Severity=Warning) documents a failure to connect to the machine.
+	RemoteMachineConnectionFailedReason = "ConnectionFailed"
+
+	// RemoteMachineUploadFailedReason (Severity=Warning) documents a failure to upload the bootstrap files to the machine.
+	RemoteMachineUploadFailedReason = "UploadFailed"
+
+	// RemoteMachineBootstrapFailedReason (Severity=Warning) documents a failure to run the bootstrap commands on the machine.
+	RemoteMachineBootstrapFailedReason = "BootstrapFailed"
+
+	// RemoteMachineHookFailedReason (Severity=Warning) documents a failure of a provisioning hook on the machine.
+	RemoteMachineHookFailedReason = "HookFailed"
+
+	// RemoteMachineAdoptionFailedReason (Severity=Warning) documents an adopted machine which does not run k0s
+	// with the expected role, or whose node could not be labeled.
+	RemoteMachineAdoptionFailedReason = "AdoptionFailed"
+
+	// RemoteMachineRebootFailedReason (Severity=Warning) documents a machine that did not come back after a reboot.
+	RemoteMachineRebootFailedReason = "RebootFailed"
+
+	// RemoteMachineNetworkConfigurationFailedReason (Severity=Warning) documents a failure to apply the static
+	// network configuration of the machine.
+	RemoteMachineNetworkConfigurationFailedReason = "NetworkConfigurationFailed"
+
+	// RemoteMachineInvalidProviderIDReason (Severity=Error) documents a provider ID template that cannot be rendered.
+	RemoteMachineInvalidProviderIDReason = "InvalidProviderID"
+
+	// RemoteMachineUnsupportedPlatformReason (Severity=Error) documents a machine running an operating system
+	// or an architecture k0s has no binaries for.
+	RemoteMachineUnsupportedPlatformReason = "UnsupportedPlatform"
+
+	// RemoteMachineVerifiedCondition documents the verification that k0s runs on a provisioned RemoteMachine.
+	// The RemoteMachine is not ready until the verification succeeds.
+	RemoteMachineVerifiedCondition clusterv1.ConditionType = "Verified"
+
+	// RemoteMachineVerificationPendingReason (Severity=Info) documents a provisioned RemoteMachine not verified yet.
+	RemoteMachineVerificationPendingReason = "VerificationPending"
+
+	// RemoteMachineK0sNotRunningReason (Severity=Warning) documents a provisioned RemoteMachine on which
+	// the k0s service is not running.
+	RemoteMachineK0sNotRunningReason = "K0sNotRunning"
+
+	// RemoteMachineNodeNotReadyReason (Severity=Warning) documents a provisioned RemoteMachine whose node
+	// is not registered in the workload cluster or is not ready.
+	RemoteMachineNodeNotReadyReason = "NodeNotReady"
+
+	// RemoteMachinePoolNamespaceNotAllowedReason (Severity=Error) documents a machine claiming pooled machines
+	// from a namespace the k0smotron manager is not configured to serve other namespaces from.
+	RemoteMachinePoolNamespaceNotAllowedReason = "PoolNamespaceNotAllowed"
+
+	// RemoteMachineRemediatedCondition documents the remediation of a deleted RemoteMachine through its BMC.
+	// The remediation action is only run once, even if the deletion of the RemoteMachine is retried.
+	RemoteMachineRemediatedCondition clusterv1.ConditionType = "Remediated"
+
+	// RemoteMachineRemediationFailedReason (Severity=Warning) documents a failed remediation through the BMC.
+	RemoteMachineRemediationFailedReason = "RemediationFailed"
+)
+
+// Conditions and condition Reasons for the RemoteCluster objects
+
+const (
+	// RemoteClusterEndpointAvailableCondition documents the control plane endpoint of a RemoteCluster managing it.
+	RemoteClusterEndpointAvailableCondition clusterv1.ConditionType = "EndpointAvailable"
+
+	// RemoteClusterEndpointPendingReason (Severity=Info) documents a control plane endpoint not discovered yet,
+	// e.g. while the first controller is provisioned.
+	RemoteClusterEndpointPendingReason = "EndpointPending"
+
+	// RemoteClusterEndpointInvalidReason (Severity=Error) documents an endpoint management configuration
+	// the control plane endpoint cannot be determined from.
+	RemoteClusterEndpointInvalidReason = "EndpointInvalid"
+
+	// RemoteClusterLoadBalancerReadyCondition documents the configuration of the load balancer in front of the
+	// control plane machines.
+	RemoteClusterLoadBalancerReadyCondition clusterv1.ConditionType = "LoadBalancerReady"
+
+	// RemoteClusterLoadBalancerConfigurationFailedReason (Severity=Warning) documents a failure to configure the
+	// load balancer backends.
+	RemoteClusterLoadBalancerConfigurationFailedReason = "LoadBalancerConfigurationFailed"
+)
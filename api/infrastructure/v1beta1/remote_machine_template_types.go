@@ -50,9 +50,26 @@ type RemoteMachineTemplateResource struct {
 }
 
 type RemoteMachineTemplateResourceSpec struct {
-	Pool string `json:"pool"`
+	// Pool is the name of the pool the machines are claimed from.
+	// +kubebuilder:validation:Optional
+	Pool string `json:"pool,omitempty"`
+	// PoolSelector selects the PooledRemoteMachines the machines are claimed from by their labels.
+	// +kubebuilder:validation:Optional
+	PoolSelector *metav1.LabelSelector `json:"poolSelector,omitempty"`
+	// PoolNamespace is the namespace the pooled machines are claimed from, defaults to the namespace of the machines.
+	// +kubebuilder:validation:Optional
+	PoolNamespace string `json:"poolNamespace,omitempty"`
+	// ProviderIDTemplate is a Go template rendering the provider ID of the machines.
+	// +kubebuilder:validation:Optional
+	ProviderIDTemplate string `json:"providerIDTemplate,omitempty"`
 	// ProvisionJob describes the kubernetes Job to use to provision the machine.
 	ProvisionJob *ProvisionJob `json:"provisionJob,omitempty"`
+	// ProvisionHooks are commands or scripts run on the machines before and after the bootstrap.
+	// +kubebuilder:validation:Optional
+	ProvisionHooks *ProvisionHooks `json:"provisionHooks,omitempty"`
+	// Airgap uploads the k0s binary and the airgap image bundle to the machines from the k0smotron manager.
+	// +kubebuilder:validation:Optional
+	Airgap *AirgapArtifacts `json:"airgap,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -19,6 +19,9 @@ package v1beta1
 import (
 	v1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/k0sproject/k0smotron/internal/util/v1beta2conditions"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -32,6 +35,14 @@ func init() {
 // +kubebuilder:subresource:status
 // +kubebuilder:metadata:labels="cluster.x-k8s.io/v1beta1=v1beta1"
 // +kubebuilder:metadata:labels="cluster.x-k8s.io/provider=infrastructure-k0smotron"
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".spec.address",description="Address of the machine"
+// +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=".status.ready",description="Whether the machine is provisioned"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Provisioning phase of the machine"
+// +kubebuilder:printcolumn:name="Arch",type="string",JSONPath=".status.architecture",description="Architecture of the machine",priority=1
+// +kubebuilder:printcolumn:name="Retries",type="integer",JSONPath=".status.retryCount",description="Number of failed provisioning attempts"
+// +kubebuilder:printcolumn:name="Steps",type="integer",JSONPath=".status.progress.completedSteps",description="Number of bootstrap steps done"
+// +kubebuilder:printcolumn:name="Total",type="integer",JSONPath=".status.progress.totalSteps",description="Total number of bootstrap steps"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of RemoteMachine"
 
 type RemoteMachine struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -47,10 +58,28 @@ type RemoteMachineSpec struct {
 	// +kubebuilder:validation:Optional
 	Pool string `json:"pool,omitempty"`
 
+	// PoolSelector selects the PooledRemoteMachines the machine can be claimed from by their labels.
+	// If Pool is set as well, the claimed machine must belong to the pool and match the selector.
+	// +kubebuilder:validation:Optional
+	PoolSelector *metav1.LabelSelector `json:"poolSelector,omitempty"`
+
+	// PoolNamespace is the namespace the pooled machines are claimed from, defaults to the namespace of the machine.
+	// Claiming from another namespace must be allowed with the --pool-namespaces flag of the k0smotron manager.
+	// +kubebuilder:validation:Optional
+	PoolNamespace string `json:"poolNamespace,omitempty"`
+
 	// ProviderID is the ID of the machine in the provider.
 	// +kubebuilder:validation:Optional
 	ProviderID string `json:"providerID,omitempty"`
 
+	// ProviderIDTemplate is a Go template rendering the provider ID of the machine, e.g. to include datacenter
+	// or rack identifiers. The available fields are .Name, .Namespace, .Address, .Port, .Labels, the labels of
+	// the RemoteMachine, and .PooledMachineLabels, the labels of the claimed PooledRemoteMachine.
+	// The rendered provider ID replaces ${K0S_PROVIDER_ID} in the bootstrap commands, so that it can be passed
+	// to the kubelet with --provider-id. Defaults to remote-machine://{{ .Address }}:{{ .Port }}.
+	// +kubebuilder:validation:Optional
+	ProviderIDTemplate string `json:"providerIDTemplate,omitempty"`
+
 	// Address is the IP address or DNS name of the remote machine.
 	// +kubebuilder:validation:Optional
 	Address string `json:"address,omitempty"`
@@ -69,16 +98,246 @@ type RemoteMachineSpec struct {
 	UseSudo bool `json:"useSudo,omitempty"`
 
 	// SSHKeyRef is a reference to a secret that contains the SSH private key.
-	// The key must be placed on the secret using the key "value".
+	// The key must be placed on the secret using the key "value". An OpenSSH user certificate signed
+	// for the key can be placed on the secret using the key "certificate" to use certificate authentication.
+	// The passphrase of an encrypted key can be placed on the secret using the key "passphrase".
 	// +kubebuilder:validation:Optional
 	SSHKeyRef SecretRef `json:"sshKeyRef,omitempty"`
 
+	// SSHConnection tunes the SSH connections to the machine, overriding the settings of the RemoteCluster.
+	// +kubebuilder:validation:Optional
+	SSHConnection *SSHConnectionSettings `json:"sshConnection,omitempty"`
+
 	// CustomCleanUpCommands allow the user to run custom command for the clean up process of the machine.
 	// +kubebuilder:validation:Optional
 	CustomCleanUpCommands []string `json:"customCleanUpCommands,omitempty"`
 
 	// ProvisionJob describes the kubernetes Job to use to provision the machine.
 	ProvisionJob *ProvisionJob `json:"provisionJob,omitempty"`
+
+	// PullBootstrap makes the machine fetch its bootstrap script from k0smotron over HTTPS instead of
+	// being provisioned over SSH. Use it for machines that cannot accept inbound SSH connections.
+	// +kubebuilder:validation:Optional
+	PullBootstrap *PullBootstrap `json:"pullBootstrap,omitempty"`
+
+	// CloudInit delivers the bootstrap data to the machine as a cloud-init payload, instead of translating it
+	// to shell commands, so that all the cloud-init directives of the bootstrap data are honored.
+	// cloud-init must be installed on the machine.
+	// +kubebuilder:validation:Optional
+	CloudInit *CloudInitDelivery `json:"cloudInit,omitempty"`
+
+	// ProvisionHooks are commands or scripts run on the machine by k0smotron before the bootstrap data is
+	// uploaded and after k0s has started, e.g. to set up RAID, update the OS or register the node in a CMDB.
+	// Unlike the preStartCommands and postStartCommands of the bootstrap config, they do not depend on the
+	// bootstrap provider.
+	// +kubebuilder:validation:Optional
+	ProvisionHooks *ProvisionHooks `json:"provisionHooks,omitempty"`
+
+	// Airgap uploads the k0s binary and the airgap image bundle to the machine from the k0smotron manager
+	// over SSH, so that the machine needs no outbound internet access. The bootstrap config should set
+	// preInstalledK0s so that k0s is not downloaded.
+	// +kubebuilder:validation:Optional
+	Airgap *AirgapArtifacts `json:"airgap,omitempty"`
+
+	// Adopt marks the machine as an already joined k0s node, e.g. to bring a manually built cluster under
+	// CAPI management. Instead of bootstrapping the machine, k0smotron only verifies over SSH that k0s is
+	// running with the role of the Machine, and labels the node so that it is matched with the Machine.
+	// +kubebuilder:validation:Optional
+	Adopt *Adoption `json:"adopt,omitempty"`
+
+	// Network is a static network configuration applied to the machine before k0s starts, e.g. to replace
+	// the DHCP address the machine booted with by a static one, or to set up bonds and VLANs.
+	// +kubebuilder:validation:Optional
+	Network *NetworkConfig `json:"network,omitempty"`
+
+	// BMC is the baseboard management controller of the machine. When set, a machine deleted because a
+	// MachineHealthCheck found it unhealthy is power-cycled, or reimaged, through the BMC.
+	// +kubebuilder:validation:Optional
+	BMC *BMC `json:"bmc,omitempty"`
+}
+
+// BMCProtocol is the protocol used to talk to a baseboard management controller.
+// +kubebuilder:validation:Enum=Redfish
+type BMCProtocol string
+
+const (
+	// BMCProtocolRedfish talks to the Redfish API of the BMC over HTTPS.
+	BMCProtocolRedfish BMCProtocol = "Redfish"
+)
+
+// RemediationAction is the action run through the BMC on an unhealthy machine.
+// +kubebuilder:validation:Enum=PowerCycle;Reimage
+type RemediationAction string
+
+const (
+	// RemediationActionPowerCycle power-cycles the machine, or powers it on if it is off.
+	RemediationActionPowerCycle RemediationAction = "PowerCycle"
+	// RemediationActionReimage sets the machine to boot once from the network, so that it is reinstalled
+	// by the PXE infrastructure, and power-cycles it.
+	RemediationActionReimage RemediationAction = "Reimage"
+)
+
+// BMC defines the baseboard management controller of a machine.
+type BMC struct {
+	// Protocol is the protocol used to talk to the BMC.
+	// +kubebuilder:validation:Required
+	Protocol BMCProtocol `json:"protocol"`
+
+	// Address is the https://<host>[:<port>] URL of the Redfish API of the BMC.
+	// +kubebuilder:validation:Required
+	Address string `json:"address"`
+
+	// CredentialsRef refers to a Secret holding the username and password keys used to authenticate to the BMC.
+	// The Secret is in the namespace of the machine, or of the pool for pooled machines.
+	// +kubebuilder:validation:Required
+	CredentialsRef SecretRef `json:"credentialsRef"`
+
+	// SystemID is the ID of the Redfish system of the machine. Defaults to the first system of the BMC.
+	// +kubebuilder:validation:Optional
+	SystemID string `json:"systemID,omitempty"`
+
+	// InsecureSkipVerify skips the verification of the TLS certificate of the Redfish API.
+	// +kubebuilder:validation:Optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// RemediationAction is the action run on the machine when it is remediated.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=PowerCycle
+	RemediationAction RemediationAction `json:"remediationAction,omitempty"`
+}
+
+// SSHConnectionSettings tunes the SSH connections to a machine, e.g. for slow hosts or lossy networks.
+type SSHConnectionSettings struct {
+	// ConnectTimeout is how long each connection attempt, including the SSH handshake, may take. Defaults to 10s.
+	// +kubebuilder:validation:Optional
+	ConnectTimeout metav1.Duration `json:"connectTimeout,omitempty"`
+
+	// ConnectRetries is the number of connection attempts after a failed one, 5 seconds apart. Defaults to 3.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	ConnectRetries *int32 `json:"connectRetries,omitempty"`
+
+	// KeepAliveInterval is the interval of the SSH keepalive requests sent while the connection is open,
+	// so that it is not dropped while long commands run. Keepalives are disabled if not set.
+	// +kubebuilder:validation:Optional
+	KeepAliveInterval metav1.Duration `json:"keepAliveInterval,omitempty"`
+
+	// CommandTimeout is how long each provisioning, hook or cleanup command may run. Commands are not limited if not set.
+	// +kubebuilder:validation:Optional
+	CommandTimeout metav1.Duration `json:"commandTimeout,omitempty"`
+}
+
+// Adoption configures the adoption of an already joined k0s node.
+type Adoption struct {
+	// NodeName is the name of the node of the machine. Defaults to the hostname of the machine.
+	// +kubebuilder:validation:Optional
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// NetworkConfig defines the static network configuration of a machine. Exactly one of Netplan and
+// NetworkManager must be set.
+type NetworkConfig struct {
+	// Netplan is a netplan configuration written to /etc/netplan/90-k0smotron.yaml and applied with netplan apply.
+	// +kubebuilder:validation:Optional
+	Netplan string `json:"netplan,omitempty"`
+
+	// NetworkManager are NetworkManager connection profiles, in the keyfile format, written to
+	// /etc/NetworkManager/system-connections and activated.
+	// +kubebuilder:validation:Optional
+	NetworkManager []NetworkManagerConnection `json:"networkManager,omitempty"`
+
+	// Address is the address the machine is reachable at over SSH once the configuration is applied.
+	// If it is not set, the address of the machine is expected to stay the same.
+	// +kubebuilder:validation:Optional
+	Address string `json:"address,omitempty"`
+}
+
+// NetworkManagerConnection is a NetworkManager connection profile.
+type NetworkManagerConnection struct {
+	// Name is the name of the connection, it must match the id of the connection in the profile.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_.-]+$`
+	Name string `json:"name"`
+
+	// Content is the connection profile in the keyfile format.
+	Content string `json:"content"`
+}
+
+// AirgapArtifacts defines the artifacts uploaded to the machine. The paths are relative to the airgap artifacts
+// directory of the k0smotron manager, set with --airgap-artifacts-dir, where e.g. a PersistentVolume holding the
+// artifacts is mounted. ${K0S_ARCH} in the paths is replaced with the architecture of the machine.
+type AirgapArtifacts struct {
+	// K0sBinary is the path of the k0s binary, uploaded to /usr/local/bin/k0s.
+	// +kubebuilder:validation:Optional
+	K0sBinary string `json:"k0sBinary,omitempty"`
+
+	// ImageBundle is the path of the airgap image bundle, uploaded to /var/lib/k0s/images.
+	// +kubebuilder:validation:Optional
+	ImageBundle string `json:"imageBundle,omitempty"`
+}
+
+// ProvisionHooks defines the hooks run on the machine around the bootstrap.
+type ProvisionHooks struct {
+	// PreBootstrap hooks are run, in order, before the bootstrap data is uploaded to the machine.
+	// +kubebuilder:validation:Optional
+	PreBootstrap []ProvisionHook `json:"preBootstrap,omitempty"`
+
+	// PostBootstrap hooks are run, in order, once the bootstrap has succeeded and k0s has started.
+	// +kubebuilder:validation:Optional
+	PostBootstrap []ProvisionHook `json:"postBootstrap,omitempty"`
+}
+
+// ProvisionHook is a command or a script run on the machine. Exactly one of Command and Script must be set.
+// A failing hook fails the provisioning attempt.
+type ProvisionHook struct {
+	// Name identifies the hook.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_.-]+$`
+	Name string `json:"name"`
+
+	// Command is a shell command run on the machine.
+	// +kubebuilder:validation:Optional
+	Command string `json:"command,omitempty"`
+
+	// Script is the content of an executable uploaded to the machine and run. It must start with a shebang line.
+	// +kubebuilder:validation:Optional
+	Script string `json:"script,omitempty"`
+
+	// RebootAfter reboots the machine once the hook has succeeded, e.g. after changing kernel parameters.
+	// The provisioning resumes with the next hook once the machine is reachable again. Only supported
+	// for machines provisioned over SSH.
+	// +kubebuilder:validation:Optional
+	RebootAfter bool `json:"rebootAfter,omitempty"`
+}
+
+// CloudInitDeliveryMethod defines how the bootstrap data is delivered to cloud-init.
+type CloudInitDeliveryMethod string
+
+const (
+	// CloudInitDeliverySSHSeed writes a NoCloud seed over SSH and runs cloud-init on the machine.
+	CloudInitDeliverySSHSeed CloudInitDeliveryMethod = "SSHSeed"
+	// CloudInitDeliveryURL serves a NoCloud datasource from the pull bootstrap server. The machine must boot
+	// with the seed URL found under the key "seedURL" of the pull bootstrap secret, e.g. in the kernel command line.
+	CloudInitDeliveryURL CloudInitDeliveryMethod = "URL"
+)
+
+// CloudInitDelivery configures the delivery of the bootstrap data to cloud-init.
+type CloudInitDelivery struct {
+	// Method defines how the bootstrap data is delivered to cloud-init.
+	// The URL method uses pull bootstrap, so PullBootstrap must be set as well.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=SSHSeed;URL
+	// +kubebuilder:default=SSHSeed
+	Method CloudInitDeliveryMethod `json:"method,omitempty"`
+}
+
+// PullBootstrap configures the pull-based bootstrap of a RemoteMachine.
+// k0smotron creates a Secret named <remotemachine>-pull-bootstrap holding a one-time token and, under the
+// key "command", the command to run on the machine to fetch and execute the bootstrap script.
+type PullBootstrap struct {
+	// TokenTTL is how long the one-time bootstrap token is valid. The machine must fetch the bootstrap script and
+	// report the completion before the token expires, otherwise the token is replaced by a new one.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="24h"
+	TokenTTL metav1.Duration `json:"tokenTTL,omitempty"`
 }
 
 type ProvisionJob struct {
@@ -100,6 +359,152 @@ type RemoteMachineStatus struct {
 
 	FailureReason  string `json:"failureReason,omitempty"`
 	FailureMessage string `json:"failureMessage,omitempty"`
+
+	// Phase is the provisioning phase the machine is in.
+	// +optional
+	Phase RemoteMachinePhase `json:"phase,omitempty"`
+
+	// Progress describes how far the provisioning of the machine has got.
+	// +optional
+	Progress *ProvisioningProgress `json:"progress,omitempty"`
+
+	// Address is the address k0smotron connects to the machine at, once a static network configuration
+	// changed the address of the machine.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// RebootedAfterHooks are the names of the hooks after which the machine was rebooted. These hooks, and the
+	// hooks before them, are not run again when the provisioning is retried.
+	// +optional
+	RebootedAfterHooks []string `json:"rebootedAfterHooks,omitempty"`
+
+	// OperatingSystem is the operating system of the machine, as detected during provisioning, e.g. linux.
+	// +optional
+	OperatingSystem string `json:"operatingSystem,omitempty"`
+
+	// Architecture is the architecture of the machine, as detected during provisioning, in the naming of
+	// the k0s binaries: amd64, arm64 or arm.
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
+	// ProvisionLog lists the commands run over SSH during the last provisioning attempt, by name and exit status.
+	// Only the last 100 commands are kept.
+	// +optional
+	ProvisionLog []ProvisionStep `json:"provisionLog,omitempty"`
+
+	// RetryCount is the number of failed provisioning attempts since the last successful one.
+	// +optional
+	RetryCount int32 `json:"retryCount,omitempty"`
+
+	// NextRetryTime is the earliest time the provisioning is attempted again after a failure.
+	// +optional
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
+
+	// Conditions defines current service state of the RemoteMachine.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// V1Beta2 groups the status fields following the Cluster API v1beta2 contract.
+	// +optional
+	V1Beta2 *RemoteMachineV1Beta2Status `json:"v1beta2,omitempty"`
+}
+
+// RemoteMachineV1Beta2Status groups the status fields of the RemoteMachine following the Cluster API v1beta2 contract.
+type RemoteMachineV1Beta2Status struct {
+	// Conditions represents the observations of the current state of the RemoteMachine.
+	// The Ready condition summarizes the other conditions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=32
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// RemoteMachinePhase is the provisioning phase of a RemoteMachine.
+type RemoteMachinePhase string
+
+const (
+	// RemoteMachinePhaseAwaitingPull is the phase in which the machine is expected to fetch its bootstrap script.
+	RemoteMachinePhaseAwaitingPull RemoteMachinePhase = "AwaitingPull"
+	// RemoteMachinePhaseConnecting is the phase in which the connection to the machine is opened.
+	RemoteMachinePhaseConnecting RemoteMachinePhase = "Connecting"
+	// RemoteMachinePhaseUploading is the phase in which the bootstrap files are uploaded to the machine.
+	RemoteMachinePhaseUploading RemoteMachinePhase = "Uploading"
+	// RemoteMachinePhaseVerifying is the phase in which a provisioned or adopted machine is verified to run k0s.
+	RemoteMachinePhaseVerifying RemoteMachinePhase = "Verifying"
+	// RemoteMachinePhaseConfiguringNetwork is the phase in which the static network configuration is applied to the machine.
+	RemoteMachinePhaseConfiguringNetwork RemoteMachinePhase = "ConfiguringNetwork"
+	// RemoteMachinePhaseRunningPreBootstrapHooks is the phase in which the pre-bootstrap hooks are run on the machine.
+	RemoteMachinePhaseRunningPreBootstrapHooks RemoteMachinePhase = "RunningPreBootstrapHooks"
+	// RemoteMachinePhaseRunningBootstrap is the phase in which the bootstrap commands are run on the machine.
+	RemoteMachinePhaseRunningBootstrap RemoteMachinePhase = "RunningBootstrap"
+	// RemoteMachinePhaseRunningPostBootstrapHooks is the phase in which the post-bootstrap hooks are run on the machine.
+	RemoteMachinePhaseRunningPostBootstrapHooks RemoteMachinePhase = "RunningPostBootstrapHooks"
+	// RemoteMachinePhaseRebooting is the phase in which the machine is rebooted after a provisioning hook.
+	RemoteMachinePhaseRebooting RemoteMachinePhase = "Rebooting"
+	// RemoteMachinePhaseDone is the phase of a successfully provisioned machine.
+	RemoteMachinePhaseDone RemoteMachinePhase = "Done"
+)
+
+func (r *RemoteMachine) GetConditions() clusterv1.Conditions {
+	return r.Status.Conditions
+}
+
+func (r *RemoteMachine) SetConditions(conditions clusterv1.Conditions) {
+	r.Status.Conditions = conditions
+	r.SetV1Beta2Conditions(v1beta2conditions.Mirror(r.GetV1Beta2Conditions(), conditions, r.Generation))
+}
+
+// GetV1Beta2Conditions returns the conditions following the Cluster API v1beta2 contract.
+func (r *RemoteMachine) GetV1Beta2Conditions() []metav1.Condition {
+	if r.Status.V1Beta2 == nil {
+		return nil
+	}
+	return r.Status.V1Beta2.Conditions
+}
+
+// SetV1Beta2Conditions sets the conditions following the Cluster API v1beta2 contract.
+func (r *RemoteMachine) SetV1Beta2Conditions(conditions []metav1.Condition) {
+	if r.Status.V1Beta2 == nil {
+		if conditions == nil {
+			return
+		}
+		r.Status.V1Beta2 = &RemoteMachineV1Beta2Status{}
+	}
+	r.Status.V1Beta2.Conditions = conditions
+}
+
+// ProvisioningProgress describes the progress of the provisioning of a RemoteMachine.
+type ProvisioningProgress struct {
+	// StartTime is the time the provisioning of the machine started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the provisioning of the machine finished successfully.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// CompletedSteps is the number of bootstrap steps (uploaded files and executed commands) done so far.
+	// +optional
+	CompletedSteps int32 `json:"completedSteps,omitempty"`
+
+	// TotalSteps is the total number of bootstrap steps to run on the machine.
+	// +optional
+	TotalSteps int32 `json:"totalSteps,omitempty"`
+}
+
+// ProvisionStep is a command run on the machine during provisioning. The command itself and its output
+// are not recorded, as they may hold secrets.
+type ProvisionStep struct {
+	// Name is the name of the step: its position in the provisioning attempt and the program it runs, e.g. "3: k0s".
+	Name string `json:"name"`
+
+	// ExitStatus is the exit status of the command, "timeout" if the command timed out, or "error" if the
+	// command could not be run.
+	ExitStatus string `json:"exitStatus"`
+
+	// Time is the time the command finished.
+	Time metav1.Time `json:"time"`
 }
 
 type SecretRef struct {
@@ -120,6 +525,12 @@ type RemoteMachineList struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:metadata:labels="cluster.x-k8s.io/v1beta1=v1beta1"
 // +kubebuilder:metadata:labels="cluster.x-k8s.io/provider=infrastructure-k0smotron"
+// +kubebuilder:printcolumn:name="Pool",type="string",JSONPath=".spec.pool",description="Pool the machine belongs to"
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".spec.machine.address",description="Address of the machine"
+// +kubebuilder:printcolumn:name="Reserved",type=boolean,JSONPath=".status.reserved",description="Whether the machine is reserved by a RemoteMachine"
+// +kubebuilder:printcolumn:name="Healthy",type="string",JSONPath=".status.conditions[?(@.type=='Healthy')].status",description="Result of the last health probe"
+// +kubebuilder:printcolumn:name="Release",type="string",JSONPath=".status.releaseState",description="Why the released machine is kept out of the pool"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of PooledRemoteMachine"
 
 type PooledRemoteMachine struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -132,6 +543,70 @@ type PooledRemoteMachine struct {
 type PooledRemoteMachineSpec struct {
 	Pool    string            `json:"pool"`
 	Machine PooledMachineSpec `json:"machine"`
+
+	// HealthCheck configures periodic probing of the machine while it is free in the pool.
+	// Machines failing the probe are not handed out to RemoteMachines until they recover.
+	// +kubebuilder:validation:Optional
+	HealthCheck *PooledMachineHealthCheck `json:"healthCheck,omitempty"`
+
+	// ReusePolicy controls what happens to the machine once it is released by its RemoteMachine and cleaned up.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=WipeAndReturn
+	ReusePolicy PooledMachineReusePolicy `json:"reusePolicy,omitempty"`
+}
+
+// PooledMachineReusePolicy defines what happens to a pooled machine released by its RemoteMachine.
+// +kubebuilder:validation:Enum=WipeAndReturn;Decommission;ManualApproval
+type PooledMachineReusePolicy string
+
+const (
+	// PooledMachineReusePolicyWipeAndReturn returns the machine to the pool as soon as it is cleaned up.
+	PooledMachineReusePolicyWipeAndReturn PooledMachineReusePolicy = "WipeAndReturn"
+	// PooledMachineReusePolicyDecommission marks the machine as decommissioned, it is never handed out again
+	// and is meant to be removed from the pool.
+	PooledMachineReusePolicyDecommission PooledMachineReusePolicy = "Decommission"
+	// PooledMachineReusePolicyManualApproval keeps the machine out of the pool until an operator approves its
+	// reuse with the PooledMachineApproveReuseAnnotation.
+	PooledMachineReusePolicyManualApproval PooledMachineReusePolicy = "ManualApproval"
+)
+
+// PooledMachineApproveReuseAnnotation is set by an operator on a PooledRemoteMachine awaiting approval to return it
+// to the pool. It is removed once the machine is returned.
+const PooledMachineApproveReuseAnnotation = "pooledremotemachine.k0smotron.io/approve-reuse"
+
+// PooledMachineReleaseState is the state of a released pooled machine which is not returned to the pool.
+type PooledMachineReleaseState string
+
+const (
+	// PooledMachineDecommissioned is the state of a released machine with the Decommission reuse policy.
+	PooledMachineDecommissioned PooledMachineReleaseState = "Decommissioned"
+	// PooledMachineAwaitingApproval is the state of a released machine with the ManualApproval reuse policy,
+	// until its reuse is approved.
+	PooledMachineAwaitingApproval PooledMachineReleaseState = "AwaitingApproval"
+)
+
+// PooledMachineHealthCheck defines how a free pooled machine is probed over SSH.
+type PooledMachineHealthCheck struct {
+	// Interval is the time between two consecutive probes.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="5m"
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// DiskPath is the path on the machine whose filesystem is checked for free space.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="/"
+	DiskPath string `json:"diskPath,omitempty"`
+
+	// MinFreeDiskSpaceMB is the minimum free space, in megabytes, required on DiskPath.
+	// Zero disables the disk space check.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	MinFreeDiskSpaceMB int64 `json:"minFreeDiskSpaceMB,omitempty"`
+
+	// MinUptime is the minimum time the machine must have been up to be considered healthy.
+	// This keeps machines that keep rebooting out of the pool. Zero disables the uptime check.
+	// +kubebuilder:validation:Optional
+	MinUptime metav1.Duration `json:"minUptime,omitempty"`
 }
 
 type PooledMachineSpec struct {
@@ -157,14 +632,95 @@ type PooledMachineSpec struct {
 	CustomCleanUpCommands []string `json:"customCleanUpCommands,omitempty"`
 
 	// SSHKeyRef is a reference to a secret that contains the SSH private key.
-	// The key must be placed on the secret using the key "value".
-	// +kubebuilder:validation:Required
-	SSHKeyRef SecretRef `json:"sshKeyRef"`
+	// The key must be placed on the secret using the key "value". An OpenSSH user certificate signed
+	// for the key can be placed on the secret using the key "certificate" to use certificate authentication.
+	// The passphrase of an encrypted key can be placed on the secret using the key "passphrase".
+	// If not set, the SSH key of the pool, set in the ProviderConfig of the namespace, is used. Machines without
+	// a key are neither health checked nor handed out to RemoteMachines provisioned over SSH.
+	// +kubebuilder:validation:Optional
+	SSHKeyRef SecretRef `json:"sshKeyRef,omitempty"`
+
+	// Network is a static network configuration applied to the machine before k0s starts.
+	// +kubebuilder:validation:Optional
+	Network *NetworkConfig `json:"network,omitempty"`
+
+	// BMC is the baseboard management controller of the machine, used to remediate the machine.
+	// +kubebuilder:validation:Optional
+	BMC *BMC `json:"bmc,omitempty"`
 }
 
 type PooledRemoteMachineStatus struct {
 	Reserved   bool             `json:"reserved"`
 	MachineRef RemoteMachineRef `json:"machineRef"`
+
+	// ReleaseState is set on a released machine kept out of the pool by its reuse policy.
+	// +optional
+	ReleaseState PooledMachineReleaseState `json:"releaseState,omitempty"`
+
+	// LastProbe holds the details of the last health probe of the machine.
+	// +optional
+	LastProbe *PooledMachineProbeResult `json:"lastProbe,omitempty"`
+
+	// Conditions defines current service state of the PooledRemoteMachine.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// V1Beta2 groups the status fields following the Cluster API v1beta2 contract.
+	// +optional
+	V1Beta2 *PooledRemoteMachineV1Beta2Status `json:"v1beta2,omitempty"`
+}
+
+// PooledRemoteMachineV1Beta2Status groups the status fields of the PooledRemoteMachine following the Cluster API v1beta2 contract.
+type PooledRemoteMachineV1Beta2Status struct {
+	// Conditions represents the observations of the current state of the PooledRemoteMachine.
+	// The Ready condition summarizes the other conditions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=32
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// PooledMachineProbeResult holds the values gathered during a health probe.
+type PooledMachineProbeResult struct {
+	// Time is the time the probe was run.
+	Time metav1.Time `json:"time"`
+
+	// UptimeSeconds is the uptime of the machine reported by the probe.
+	// +optional
+	UptimeSeconds int64 `json:"uptimeSeconds,omitempty"`
+
+	// FreeDiskSpaceMB is the free space, in megabytes, of the probed filesystem.
+	// +optional
+	FreeDiskSpaceMB int64 `json:"freeDiskSpaceMB,omitempty"`
+}
+
+func (p *PooledRemoteMachine) GetConditions() clusterv1.Conditions {
+	return p.Status.Conditions
+}
+
+func (p *PooledRemoteMachine) SetConditions(conditions clusterv1.Conditions) {
+	p.Status.Conditions = conditions
+	p.SetV1Beta2Conditions(v1beta2conditions.Mirror(p.GetV1Beta2Conditions(), conditions, p.Generation))
+}
+
+// GetV1Beta2Conditions returns the conditions following the Cluster API v1beta2 contract.
+func (p *PooledRemoteMachine) GetV1Beta2Conditions() []metav1.Condition {
+	if p.Status.V1Beta2 == nil {
+		return nil
+	}
+	return p.Status.V1Beta2.Conditions
+}
+
+// SetV1Beta2Conditions sets the conditions following the Cluster API v1beta2 contract.
+func (p *PooledRemoteMachine) SetV1Beta2Conditions(conditions []metav1.Condition) {
+	if p.Status.V1Beta2 == nil {
+		if conditions == nil {
+			return
+		}
+		p.Status.V1Beta2 = &PooledRemoteMachineV1Beta2Status{}
+	}
+	p.Status.V1Beta2.Conditions = conditions
 }
 
 type RemoteMachineRef struct {
@@ -3,6 +3,8 @@ package v1beta1
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/k0sproject/k0smotron/internal/util/v1beta2conditions"
 )
 
 func init() {
@@ -27,6 +29,126 @@ type RemoteClusterSpec struct {
 	// ControlPlaneEndpoint represents the endpoint used to communicate with the control plane.
 	// +optional
 	ControlPlaneEndpoint clusterv1.APIEndpoint `json:"controlPlaneEndpoint"`
+
+	// EndpointManagement configures k0smotron to manage the control plane endpoint, so it doesn't
+	// have to be maintained by hand in ControlPlaneEndpoint. An endpoint set in ControlPlaneEndpoint
+	// is never overridden.
+	// +optional
+	EndpointManagement *EndpointManagement `json:"endpointManagement,omitempty"`
+
+	// SSHProxy is a proxy the SSH connections to the RemoteMachines of the cluster are dialed through,
+	// e.g. when the management cluster cannot reach the machine network directly.
+	// +optional
+	SSHProxy *SSHProxy `json:"sshProxy,omitempty"`
+
+	// SSHConnection tunes the SSH connections to the RemoteMachines of the cluster. The settings of
+	// a RemoteMachine override the settings of the cluster.
+	// +optional
+	SSHConnection *SSHConnectionSettings `json:"sshConnection,omitempty"`
+}
+
+// SSHProxy defines a SOCKS5 or HTTP CONNECT proxy.
+type SSHProxy struct {
+	// URL is the URL of the proxy, either socks5://<host>:<port> or http://<host>:<port>.
+	// +kubebuilder:validation:Pattern=`^(socks5|http)://[^/@]+$`
+	URL string `json:"url"`
+
+	// CredentialsRef refers to a Secret in the namespace of the RemoteCluster holding the username and password
+	// keys used to authenticate to the proxy.
+	// +optional
+	CredentialsRef *SecretRef `json:"credentialsRef,omitempty"`
+}
+
+// EndpointManagementMode defines how the control plane endpoint is managed.
+type EndpointManagementMode string
+
+const (
+	// EndpointManagementModeFirstController uses the address of the first control plane machine as the endpoint.
+	EndpointManagementModeFirstController EndpointManagementMode = "FirstController"
+	// EndpointManagementModeVIP uses a virtual IP held by the control plane machines using keepalived.
+	EndpointManagementModeVIP EndpointManagementMode = "VIP"
+	// EndpointManagementModeLoadBalancer uses a HAProxy load balancer spreading the traffic over several endpoints.
+	EndpointManagementModeLoadBalancer EndpointManagementMode = "LoadBalancer"
+)
+
+// EndpointManagement defines how the control plane endpoint is managed.
+type EndpointManagement struct {
+	// Mode defines how the control plane endpoint is managed.
+	// FirstController discovers the endpoint from the address of the first control plane RemoteMachine.
+	// VIP configures keepalived on the control plane machines to hold the virtual IP set in VIP.
+	// LoadBalancer configures HAProxy on the host set in LoadBalancer to balance the traffic over the control plane.
+	// +kubebuilder:validation:Enum=FirstController;VIP;LoadBalancer
+	Mode EndpointManagementMode `json:"mode"`
+
+	// Port is the port of the control plane endpoint.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=6443
+	Port int32 `json:"port,omitempty"`
+
+	// VIP holds the virtual IP configuration. Required when Mode is VIP.
+	// +optional
+	VIP *VIPSpec `json:"vip,omitempty"`
+
+	// LoadBalancer holds the load balancer configuration. Required when Mode is LoadBalancer.
+	// +optional
+	LoadBalancer *LoadBalancerSpec `json:"loadBalancer,omitempty"`
+}
+
+// VIPSpec defines the virtual IP held by the control plane machines.
+// The keepalived configuration is pushed over SSH to the control plane machines, so keepalived must be
+// installed on the machines. Machines provisioned using a ProvisionJob are not configured.
+type VIPSpec struct {
+	// Address is the virtual IP address.
+	Address string `json:"address"`
+
+	// Interface is the network interface the virtual IP is bound to on the control plane machines.
+	Interface string `json:"interface"`
+
+	// VirtualRouterID is the VRRP virtual router ID. It must be unique within the network segment.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=255
+	// +kubebuilder:default=51
+	VirtualRouterID int32 `json:"virtualRouterID,omitempty"`
+}
+
+// LoadBalancerSpec defines the HAProxy load balancer in front of the control plane.
+// The HAProxy configuration is pushed over SSH to the load balancer host, so HAProxy must be installed on it.
+// The Kubernetes API, the k0s API and konnectivity are balanced round-robin over the endpoints passing the
+// Kubernetes API readiness check.
+type LoadBalancerSpec struct {
+	// Host is the machine running HAProxy. Its address is used as the control plane endpoint.
+	Host LoadBalancerHost `json:"host"`
+
+	// Endpoints are the addresses of the control plane nodes the traffic is balanced over.
+	// Defaults to the addresses of the control plane RemoteMachines of the cluster.
+	// +optional
+	Endpoints []string `json:"endpoints,omitempty"`
+}
+
+// LoadBalancerHost defines how to reach the load balancer host over SSH.
+type LoadBalancerHost struct {
+	// Address is the IP address or DNS name of the load balancer host.
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// Port is the SSH port of the host.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=22
+	Port int `json:"port,omitempty"`
+
+	// User is the SSH user of the host.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=root
+	User string `json:"user,omitempty"`
+
+	// UseSudo is a flag to use sudo to write the HAProxy configuration.
+	// +kubebuilder:validation:Optional
+	UseSudo bool `json:"useSudo,omitempty"`
+
+	// SSHKeyRef is a reference to a secret that contains the SSH private key.
+	// The key must be placed on the secret using the key "value", and its passphrase, if any, using the key "passphrase".
+	SSHKeyRef SecretRef `json:"sshKeyRef"`
 }
 
 // RemoteClusterStatus defines the observed state of RemoteCluster
@@ -35,6 +157,57 @@ type RemoteClusterStatus struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:default=false
 	Ready bool `json:"ready"`
+
+	// LoadBalancerEndpoints are the endpoints the load balancer was last configured with.
+	// +optional
+	LoadBalancerEndpoints []string `json:"loadBalancerEndpoints,omitempty"`
+
+	// Conditions defines current service state of the RemoteCluster.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// V1Beta2 groups the status fields following the Cluster API v1beta2 contract.
+	// +optional
+	V1Beta2 *RemoteClusterV1Beta2Status `json:"v1beta2,omitempty"`
+}
+
+// RemoteClusterV1Beta2Status groups the status fields of the RemoteCluster following the Cluster API v1beta2 contract.
+type RemoteClusterV1Beta2Status struct {
+	// Conditions represents the observations of the current state of the RemoteCluster.
+	// The Ready condition summarizes the other conditions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=32
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+func (c *RemoteCluster) GetConditions() clusterv1.Conditions {
+	return c.Status.Conditions
+}
+
+func (c *RemoteCluster) SetConditions(conditions clusterv1.Conditions) {
+	c.Status.Conditions = conditions
+	c.SetV1Beta2Conditions(v1beta2conditions.Mirror(c.GetV1Beta2Conditions(), conditions, c.Generation))
+}
+
+// GetV1Beta2Conditions returns the conditions following the Cluster API v1beta2 contract.
+func (c *RemoteCluster) GetV1Beta2Conditions() []metav1.Condition {
+	if c.Status.V1Beta2 == nil {
+		return nil
+	}
+	return c.Status.V1Beta2.Conditions
+}
+
+// SetV1Beta2Conditions sets the conditions following the Cluster API v1beta2 contract.
+func (c *RemoteCluster) SetV1Beta2Conditions(conditions []metav1.Condition) {
+	if c.Status.V1Beta2 == nil {
+		if conditions == nil {
+			return
+		}
+		c.Status.V1Beta2 = &RemoteClusterV1Beta2Status{}
+	}
+	c.Status.V1Beta2.Conditions = conditions
 }
 
 // +kubebuilder:object:root=true
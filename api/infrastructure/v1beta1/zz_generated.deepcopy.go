@@ -21,10 +21,203 @@ limitations under the License.
 package v1beta1
 
 import (
-	"k8s.io/api/batch/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	apiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Adoption) DeepCopyInto(out *Adoption) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Adoption.
+func (in *Adoption) DeepCopy() *Adoption {
+	if in == nil {
+		return nil
+	}
+	out := new(Adoption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AirgapArtifacts) DeepCopyInto(out *AirgapArtifacts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AirgapArtifacts.
+func (in *AirgapArtifacts) DeepCopy() *AirgapArtifacts {
+	if in == nil {
+		return nil
+	}
+	out := new(AirgapArtifacts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BMC) DeepCopyInto(out *BMC) {
+	*out = *in
+	out.CredentialsRef = in.CredentialsRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BMC.
+func (in *BMC) DeepCopy() *BMC {
+	if in == nil {
+		return nil
+	}
+	out := new(BMC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudInitDelivery) DeepCopyInto(out *CloudInitDelivery) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudInitDelivery.
+func (in *CloudInitDelivery) DeepCopy() *CloudInitDelivery {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudInitDelivery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EndpointManagement) DeepCopyInto(out *EndpointManagement) {
+	*out = *in
+	if in.VIP != nil {
+		in, out := &in.VIP, &out.VIP
+		*out = new(VIPSpec)
+		**out = **in
+	}
+	if in.LoadBalancer != nil {
+		in, out := &in.LoadBalancer, &out.LoadBalancer
+		*out = new(LoadBalancerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointManagement.
+func (in *EndpointManagement) DeepCopy() *EndpointManagement {
+	if in == nil {
+		return nil
+	}
+	out := new(EndpointManagement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerHost) DeepCopyInto(out *LoadBalancerHost) {
+	*out = *in
+	out.SSHKeyRef = in.SSHKeyRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerHost.
+func (in *LoadBalancerHost) DeepCopy() *LoadBalancerHost {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerHost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerSpec) DeepCopyInto(out *LoadBalancerSpec) {
+	*out = *in
+	out.Host = in.Host
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerSpec.
+func (in *LoadBalancerSpec) DeepCopy() *LoadBalancerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkConfig) DeepCopyInto(out *NetworkConfig) {
+	*out = *in
+	if in.NetworkManager != nil {
+		in, out := &in.NetworkManager, &out.NetworkManager
+		*out = make([]NetworkManagerConnection, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkConfig.
+func (in *NetworkConfig) DeepCopy() *NetworkConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkManagerConnection) DeepCopyInto(out *NetworkManagerConnection) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkManagerConnection.
+func (in *NetworkManagerConnection) DeepCopy() *NetworkManagerConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkManagerConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PooledMachineHealthCheck) DeepCopyInto(out *PooledMachineHealthCheck) {
+	*out = *in
+	out.Interval = in.Interval
+	out.MinUptime = in.MinUptime
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PooledMachineHealthCheck.
+func (in *PooledMachineHealthCheck) DeepCopy() *PooledMachineHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(PooledMachineHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PooledMachineProbeResult) DeepCopyInto(out *PooledMachineProbeResult) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PooledMachineProbeResult.
+func (in *PooledMachineProbeResult) DeepCopy() *PooledMachineProbeResult {
+	if in == nil {
+		return nil
+	}
+	out := new(PooledMachineProbeResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PooledMachineSpec) DeepCopyInto(out *PooledMachineSpec) {
 	*out = *in
@@ -34,6 +227,16 @@ func (in *PooledMachineSpec) DeepCopyInto(out *PooledMachineSpec) {
 		copy(*out, *in)
 	}
 	out.SSHKeyRef = in.SSHKeyRef
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BMC != nil {
+		in, out := &in.BMC, &out.BMC
+		*out = new(BMC)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PooledMachineSpec.
@@ -52,7 +255,7 @@ func (in *PooledRemoteMachine) DeepCopyInto(out *PooledRemoteMachine) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PooledRemoteMachine.
@@ -109,6 +312,11 @@ func (in *PooledRemoteMachineList) DeepCopyObject() runtime.Object {
 func (in *PooledRemoteMachineSpec) DeepCopyInto(out *PooledRemoteMachineSpec) {
 	*out = *in
 	in.Machine.DeepCopyInto(&out.Machine)
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(PooledMachineHealthCheck)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PooledRemoteMachineSpec.
@@ -125,6 +333,23 @@ func (in *PooledRemoteMachineSpec) DeepCopy() *PooledRemoteMachineSpec {
 func (in *PooledRemoteMachineStatus) DeepCopyInto(out *PooledRemoteMachineStatus) {
 	*out = *in
 	out.MachineRef = in.MachineRef
+	if in.LastProbe != nil {
+		in, out := &in.LastProbe, &out.LastProbe
+		*out = new(PooledMachineProbeResult)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.V1Beta2 != nil {
+		in, out := &in.V1Beta2, &out.V1Beta2
+		*out = new(PooledRemoteMachineV1Beta2Status)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PooledRemoteMachineStatus.
@@ -137,12 +362,74 @@ func (in *PooledRemoteMachineStatus) DeepCopy() *PooledRemoteMachineStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PooledRemoteMachineV1Beta2Status) DeepCopyInto(out *PooledRemoteMachineV1Beta2Status) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PooledRemoteMachineV1Beta2Status.
+func (in *PooledRemoteMachineV1Beta2Status) DeepCopy() *PooledRemoteMachineV1Beta2Status {
+	if in == nil {
+		return nil
+	}
+	out := new(PooledRemoteMachineV1Beta2Status)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisionHook) DeepCopyInto(out *ProvisionHook) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisionHook.
+func (in *ProvisionHook) DeepCopy() *ProvisionHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisionHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisionHooks) DeepCopyInto(out *ProvisionHooks) {
+	*out = *in
+	if in.PreBootstrap != nil {
+		in, out := &in.PreBootstrap, &out.PreBootstrap
+		*out = make([]ProvisionHook, len(*in))
+		copy(*out, *in)
+	}
+	if in.PostBootstrap != nil {
+		in, out := &in.PostBootstrap, &out.PostBootstrap
+		*out = make([]ProvisionHook, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisionHooks.
+func (in *ProvisionHooks) DeepCopy() *ProvisionHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisionHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProvisionJob) DeepCopyInto(out *ProvisionJob) {
 	*out = *in
 	if in.JobTemplate != nil {
 		in, out := &in.JobTemplate, &out.JobTemplate
-		*out = new(v1.JobTemplateSpec)
+		*out = new(batchv1.JobTemplateSpec)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -157,13 +444,68 @@ func (in *ProvisionJob) DeepCopy() *ProvisionJob {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisionStep) DeepCopyInto(out *ProvisionStep) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisionStep.
+func (in *ProvisionStep) DeepCopy() *ProvisionStep {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisionStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisioningProgress) DeepCopyInto(out *ProvisioningProgress) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisioningProgress.
+func (in *ProvisioningProgress) DeepCopy() *ProvisioningProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisioningProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PullBootstrap) DeepCopyInto(out *PullBootstrap) {
+	*out = *in
+	out.TokenTTL = in.TokenTTL
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PullBootstrap.
+func (in *PullBootstrap) DeepCopy() *PullBootstrap {
+	if in == nil {
+		return nil
+	}
+	out := new(PullBootstrap)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemoteCluster) DeepCopyInto(out *RemoteCluster) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteCluster.
@@ -220,6 +562,21 @@ func (in *RemoteClusterList) DeepCopyObject() runtime.Object {
 func (in *RemoteClusterSpec) DeepCopyInto(out *RemoteClusterSpec) {
 	*out = *in
 	out.ControlPlaneEndpoint = in.ControlPlaneEndpoint
+	if in.EndpointManagement != nil {
+		in, out := &in.EndpointManagement, &out.EndpointManagement
+		*out = new(EndpointManagement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SSHProxy != nil {
+		in, out := &in.SSHProxy, &out.SSHProxy
+		*out = new(SSHProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SSHConnection != nil {
+		in, out := &in.SSHConnection, &out.SSHConnection
+		*out = new(SSHConnectionSettings)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteClusterSpec.
@@ -235,6 +592,23 @@ func (in *RemoteClusterSpec) DeepCopy() *RemoteClusterSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemoteClusterStatus) DeepCopyInto(out *RemoteClusterStatus) {
 	*out = *in
+	if in.LoadBalancerEndpoints != nil {
+		in, out := &in.LoadBalancerEndpoints, &out.LoadBalancerEndpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.V1Beta2 != nil {
+		in, out := &in.V1Beta2, &out.V1Beta2
+		*out = new(RemoteClusterV1Beta2Status)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteClusterStatus.
@@ -309,7 +683,7 @@ func (in *RemoteClusterTemplateList) DeepCopyObject() runtime.Object {
 func (in *RemoteClusterTemplateResource) DeepCopyInto(out *RemoteClusterTemplateResource) {
 	*out = *in
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteClusterTemplateResource.
@@ -338,13 +712,35 @@ func (in *RemoteClusterTemplateSpec) DeepCopy() *RemoteClusterTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteClusterV1Beta2Status) DeepCopyInto(out *RemoteClusterV1Beta2Status) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteClusterV1Beta2Status.
+func (in *RemoteClusterV1Beta2Status) DeepCopy() *RemoteClusterV1Beta2Status {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteClusterV1Beta2Status)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemoteMachine) DeepCopyInto(out *RemoteMachine) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteMachine.
@@ -415,7 +811,17 @@ func (in *RemoteMachineRef) DeepCopy() *RemoteMachineRef {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemoteMachineSpec) DeepCopyInto(out *RemoteMachineSpec) {
 	*out = *in
+	if in.PoolSelector != nil {
+		in, out := &in.PoolSelector, &out.PoolSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	out.SSHKeyRef = in.SSHKeyRef
+	if in.SSHConnection != nil {
+		in, out := &in.SSHConnection, &out.SSHConnection
+		*out = new(SSHConnectionSettings)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CustomCleanUpCommands != nil {
 		in, out := &in.CustomCleanUpCommands, &out.CustomCleanUpCommands
 		*out = make([]string, len(*in))
@@ -426,6 +832,41 @@ func (in *RemoteMachineSpec) DeepCopyInto(out *RemoteMachineSpec) {
 		*out = new(ProvisionJob)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PullBootstrap != nil {
+		in, out := &in.PullBootstrap, &out.PullBootstrap
+		*out = new(PullBootstrap)
+		**out = **in
+	}
+	if in.CloudInit != nil {
+		in, out := &in.CloudInit, &out.CloudInit
+		*out = new(CloudInitDelivery)
+		**out = **in
+	}
+	if in.ProvisionHooks != nil {
+		in, out := &in.ProvisionHooks, &out.ProvisionHooks
+		*out = new(ProvisionHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Airgap != nil {
+		in, out := &in.Airgap, &out.Airgap
+		*out = new(AirgapArtifacts)
+		**out = **in
+	}
+	if in.Adopt != nil {
+		in, out := &in.Adopt, &out.Adopt
+		*out = new(Adoption)
+		**out = **in
+	}
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BMC != nil {
+		in, out := &in.BMC, &out.BMC
+		*out = new(BMC)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteMachineSpec.
@@ -441,6 +882,39 @@ func (in *RemoteMachineSpec) DeepCopy() *RemoteMachineSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemoteMachineStatus) DeepCopyInto(out *RemoteMachineStatus) {
 	*out = *in
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(ProvisioningProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RebootedAfterHooks != nil {
+		in, out := &in.RebootedAfterHooks, &out.RebootedAfterHooks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ProvisionLog != nil {
+		in, out := &in.ProvisionLog, &out.ProvisionLog
+		*out = make([]ProvisionStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NextRetryTime != nil {
+		in, out := &in.NextRetryTime, &out.NextRetryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apiv1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.V1Beta2 != nil {
+		in, out := &in.V1Beta2, &out.V1Beta2
+		*out = new(RemoteMachineV1Beta2Status)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteMachineStatus.
@@ -531,11 +1005,26 @@ func (in *RemoteMachineTemplateResource) DeepCopy() *RemoteMachineTemplateResour
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemoteMachineTemplateResourceSpec) DeepCopyInto(out *RemoteMachineTemplateResourceSpec) {
 	*out = *in
+	if in.PoolSelector != nil {
+		in, out := &in.PoolSelector, &out.PoolSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ProvisionJob != nil {
 		in, out := &in.ProvisionJob, &out.ProvisionJob
 		*out = new(ProvisionJob)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ProvisionHooks != nil {
+		in, out := &in.ProvisionHooks, &out.ProvisionHooks
+		*out = new(ProvisionHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Airgap != nil {
+		in, out := &in.Airgap, &out.Airgap
+		*out = new(AirgapArtifacts)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteMachineTemplateResourceSpec.
@@ -564,6 +1053,71 @@ func (in *RemoteMachineTemplateSpec) DeepCopy() *RemoteMachineTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteMachineV1Beta2Status) DeepCopyInto(out *RemoteMachineV1Beta2Status) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteMachineV1Beta2Status.
+func (in *RemoteMachineV1Beta2Status) DeepCopy() *RemoteMachineV1Beta2Status {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteMachineV1Beta2Status)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHConnectionSettings) DeepCopyInto(out *SSHConnectionSettings) {
+	*out = *in
+	out.ConnectTimeout = in.ConnectTimeout
+	if in.ConnectRetries != nil {
+		in, out := &in.ConnectRetries, &out.ConnectRetries
+		*out = new(int32)
+		**out = **in
+	}
+	out.KeepAliveInterval = in.KeepAliveInterval
+	out.CommandTimeout = in.CommandTimeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHConnectionSettings.
+func (in *SSHConnectionSettings) DeepCopy() *SSHConnectionSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHConnectionSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHProxy) DeepCopyInto(out *SSHProxy) {
+	*out = *in
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(SecretRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHProxy.
+func (in *SSHProxy) DeepCopy() *SSHProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretRef) DeepCopyInto(out *SecretRef) {
 	*out = *in
@@ -578,3 +1132,18 @@ func (in *SecretRef) DeepCopy() *SecretRef {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VIPSpec) DeepCopyInto(out *VIPSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VIPSpec.
+func (in *VIPSpec) DeepCopy() *VIPSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VIPSpec)
+	in.DeepCopyInto(out)
+	return out
+}
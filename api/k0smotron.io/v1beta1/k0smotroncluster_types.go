@@ -154,6 +154,11 @@ type ClusterStatus struct {
 	Replicas             int32  `json:"replicas,omitempty"`
 	// selector is the label selector for pods that should match the replicas count.
 	Selector string `json:"selector,omitempty"`
+	// operations is the bounded history of the significant operations done on the cluster, like scaling and
+	// upgrades. The oldest operations are dropped first.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	Operations []Operation `json:"operations,omitempty"`
 }
 
 //+kubebuilder:object:root=true
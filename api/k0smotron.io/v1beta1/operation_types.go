@@ -0,0 +1,147 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MaxOperations is the number of operations kept in the status of an object. The oldest operations are dropped first.
+const MaxOperations = 20
+
+// OperationType is the kind of a significant operation done on a cluster.
+// +kubebuilder:validation:Enum=Scale;Upgrade;Remediation;TokenRotation
+type OperationType string
+
+const (
+	// OperationScale is the change of the number of control plane replicas.
+	OperationScale OperationType = "Scale"
+	// OperationUpgrade is the rollout of a new k0s version.
+	OperationUpgrade OperationType = "Upgrade"
+	// OperationRemediation is the replacement of an unhealthy control plane machine.
+	OperationRemediation OperationType = "Remediation"
+	// OperationTokenRotation is the generation of a new token used by the cluster components to authenticate.
+	OperationTokenRotation OperationType = "TokenRotation"
+)
+
+// OperationOutcome is the result of an operation.
+// +kubebuilder:validation:Enum=InProgress;Succeeded;Failed
+type OperationOutcome string
+
+const (
+	OperationInProgress OperationOutcome = "InProgress"
+	OperationSucceeded  OperationOutcome = "Succeeded"
+	OperationFailed     OperationOutcome = "Failed"
+)
+
+// Operation records a significant operation done on a cluster, for post-incident review.
+type Operation struct {
+	// Type is the kind of the operation.
+	Type OperationType `json:"type"`
+	// Actor is the controller or webhook which started the operation.
+	Actor string `json:"actor"`
+	// Target is the state the operation converges to, like the number of replicas for a scale operation or the
+	// version for an upgrade.
+	// +optional
+	Target string `json:"target,omitempty"`
+	// Outcome is the result of the operation.
+	Outcome OperationOutcome `json:"outcome"`
+	// Message is a human readable description of the operation.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// StartTime is the time the operation was started.
+	StartTime metav1.Time `json:"startTime"`
+	// CompletionTime is the time the operation succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// LastOperation returns the most recent operation of the type, or nil if there is none.
+func LastOperation(history []Operation, opType OperationType) *Operation {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Type == opType {
+			return &history[i]
+		}
+	}
+	return nil
+}
+
+// StartOperation appends an in-progress operation to the history, unless the last operation of the same type already
+// has the same target, so it can be called on every reconciliation. An operation of the same type still in progress
+// is superseded by the new one and marked as failed.
+func StartOperation(history []Operation, opType OperationType, actor, target, message string) []Operation {
+	now := metav1.Now()
+	if last := LastOperation(history, opType); last != nil {
+		if last.Target == target {
+			return history
+		}
+		if last.Outcome == OperationInProgress {
+			last.Outcome = OperationFailed
+			last.Message = fmt.Sprintf("Superseded by the operation targeting %s", target)
+			last.CompletionTime = &now
+		}
+	}
+	return appendOperation(history, Operation{
+		Type:      opType,
+		Actor:     actor,
+		Target:    target,
+		Outcome:   OperationInProgress,
+		Message:   message,
+		StartTime: now,
+	})
+}
+
+// CompleteOperation sets the outcome of the last operation of the type if it is still in progress.
+func CompleteOperation(history []Operation, opType OperationType, outcome OperationOutcome, message string) []Operation {
+	if last := LastOperation(history, opType); last != nil && last.Outcome == OperationInProgress {
+		now := metav1.Now()
+		last.Outcome = outcome
+		last.CompletionTime = &now
+		if message != "" {
+			last.Message = message
+		}
+	}
+	return history
+}
+
+// RecordOperation appends an operation which completed right away. Recording the same outcome for the same target
+// twice in a row, e.g. when a failing attempt is retried, does not add a new entry.
+func RecordOperation(history []Operation, opType OperationType, actor, target string, outcome OperationOutcome, message string) []Operation {
+	if last := LastOperation(history, opType); last != nil && last.Target == target && last.Outcome == outcome {
+		return history
+	}
+	now := metav1.Now()
+	return appendOperation(history, Operation{
+		Type:           opType,
+		Actor:          actor,
+		Target:         target,
+		Outcome:        outcome,
+		Message:        message,
+		StartTime:      now,
+		CompletionTime: &now,
+	})
+}
+
+func appendOperation(history []Operation, op Operation) []Operation {
+	history = append(history, op)
+	if len(history) > MaxOperations {
+		history = history[len(history)-MaxOperations:]
+	}
+	return history
+}
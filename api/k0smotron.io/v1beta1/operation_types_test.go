@@ -0,0 +1,81 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartOperation(t *testing.T) {
+	var history []Operation
+
+	history = StartOperation(history, OperationScale, "test", "3", "Scaling from 1 to 3 replicas")
+	require.Len(t, history, 1)
+	require.Equal(t, OperationInProgress, history[0].Outcome)
+	require.Nil(t, history[0].CompletionTime)
+
+	// Starting the same operation again is a no-op.
+	history = StartOperation(history, OperationScale, "test", "3", "Scaling from 1 to 3 replicas")
+	require.Len(t, history, 1)
+
+	history = StartOperation(history, OperationUpgrade, "test", "v1.30.0+k0s.0", "Upgrading")
+	require.Len(t, history, 2)
+
+	// A new target supersedes the operation in progress.
+	history = StartOperation(history, OperationScale, "test", "5", "Scaling from 3 to 5 replicas")
+	require.Len(t, history, 3)
+	require.Equal(t, OperationFailed, history[0].Outcome)
+	require.NotNil(t, history[0].CompletionTime)
+	require.Equal(t, OperationInProgress, history[1].Outcome)
+	require.Equal(t, OperationInProgress, history[2].Outcome)
+
+	history = CompleteOperation(history, OperationScale, OperationSucceeded, "")
+	require.Equal(t, OperationSucceeded, history[2].Outcome)
+	require.Equal(t, "Scaling from 3 to 5 replicas", history[2].Message)
+	require.NotNil(t, history[2].CompletionTime)
+
+	// Completing an operation which is not in progress does nothing.
+	history = CompleteOperation(history, OperationScale, OperationFailed, "failed")
+	require.Equal(t, OperationSucceeded, history[2].Outcome)
+	history = CompleteOperation(history, OperationRemediation, OperationSucceeded, "")
+	require.Len(t, history, 3)
+}
+
+func TestRecordOperation(t *testing.T) {
+	var history []Operation
+
+	history = RecordOperation(history, OperationRemediation, "test", "m1", OperationFailed, "Failed to delete machine m1")
+	history = RecordOperation(history, OperationRemediation, "test", "m1", OperationFailed, "Failed to delete machine m1 again")
+	require.Len(t, history, 1)
+	require.NotNil(t, history[0].CompletionTime)
+
+	history = RecordOperation(history, OperationRemediation, "test", "m2", OperationFailed, "Failed to delete machine m2")
+	require.Len(t, history, 2)
+}
+
+func TestOperationHistoryIsBounded(t *testing.T) {
+	var history []Operation
+	for i := 0; i < MaxOperations+5; i++ {
+		history = StartOperation(history, OperationScale, "test", strconv.Itoa(i), "")
+	}
+	require.Len(t, history, MaxOperations)
+	require.Equal(t, "5", history[0].Target)
+	require.Equal(t, strconv.Itoa(MaxOperations+4), history[MaxOperations-1].Target)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultProviderConfigName is the name of the ProviderConfig applying to the objects of its namespace.
+const DefaultProviderConfigName = "default"
+
+// ProviderConfigSpec defines the settings k0smotron uses for the objects of a namespace. The secrets it references are
+// in the namespace of the ProviderConfig, so the tenants of a shared management cluster each bring their own.
+type ProviderConfigSpec struct {
+	// Tunneling sets the tunneling defaults of the K0sControlPlanes of the namespace.
+	// +optional
+	Tunneling *ProviderConfigTunneling `json:"tunneling,omitempty"`
+	// SSH sets the SSH defaults of the RemoteMachines of the namespace.
+	// +optional
+	SSH *ProviderConfigSSH `json:"ssh,omitempty"`
+	// Backup sets where the backups of the clusters of the namespace are stored.
+	// +optional
+	Backup *ProviderConfigBackup `json:"backup,omitempty"`
+	// Pools sets the defaults of the PooledRemoteMachines of the namespace, per pool.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Pools []ProviderConfigPool `json:"pools,omitempty"`
+}
+
+// ProviderConfigTunneling sets the tunneling defaults of the K0sControlPlanes of a namespace.
+type ProviderConfigTunneling struct {
+	// ServerAddress is the address of the frps server of the K0sControlPlanes of the namespace without one.
+	// Defaults to the address of a node of the management cluster.
+	// +optional
+	ServerAddress string `json:"serverAddress,omitempty"`
+}
+
+// ProviderConfigSSH sets the SSH defaults of the RemoteMachines of a namespace.
+type ProviderConfigSSH struct {
+	// KeyRef is the name of the secret holding the SSH private key of the RemoteMachines of the namespace without
+	// sshKeyRef. The secret has the same layout as the sshKeyRef of a RemoteMachine.
+	// +optional
+	KeyRef string `json:"keyRef,omitempty"`
+}
+
+// ProviderConfigPool sets the defaults of the PooledRemoteMachines of a pool.
+type ProviderConfigPool struct {
+	// Name is the name of the pool, as set in the spec.pool of its PooledRemoteMachines.
+	Name string `json:"name"`
+	// SSHKeyRef is the name of the secret holding the SSH private key of the PooledRemoteMachines of the pool without
+	// sshKeyRef. The secret has the same layout as the sshKeyRef of a PooledRemoteMachine.
+	// +optional
+	SSHKeyRef string `json:"sshKeyRef,omitempty"`
+}
+
+// ProviderConfigBackup sets where the backups of the clusters of a namespace are stored.
+type ProviderConfigBackup struct {
+	// StorageLocation is the name of the Velero BackupStorageLocation, i.e. the bucket, the backups of the clusters of
+	// the namespace are stored in. Defaults to the default storage location of Velero.
+	// +optional
+	StorageLocation string `json:"storageLocation,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=providerconfigs,scope=Namespaced
+
+// ProviderConfig holds the settings k0smotron uses for the objects of its namespace. Only the ProviderConfig named
+// "default" is used.
+type ProviderConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ProviderConfigSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ProviderConfigList contains a list of ProviderConfig
+type ProviderConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProviderConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProviderConfig{}, &ProviderConfigList{})
+}
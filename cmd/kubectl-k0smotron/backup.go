@@ -0,0 +1,189 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	km "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
+)
+
+var veleroBackupGVK = schema.GroupVersionKind{Group: "velero.io", Version: "v1", Kind: "Backup"}
+
+// backupPollInterval is the interval the phase of the Velero backup is checked at.
+var backupPollInterval = 5 * time.Second
+
+func newTriggerBackupCommand(o *options) *cobra.Command {
+	var veleroNamespace string
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "backup CLUSTER",
+		Short: "Back up the namespace of a cluster with Velero",
+		Long: "Back up the namespace of a cluster with Velero. A Cluster API cluster is paused until the backup " +
+			"completes, so no machine is created or deleted while the objects are saved.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			c, namespace, err := o.getClient()
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			key := client.ObjectKey{Namespace: namespace, Name: args[0]}
+
+			cluster := &clusterv1.Cluster{}
+			// The outcome of the backup is recorded as an event of the cluster
+			var backedUp client.Object = cluster
+			if err := c.Get(ctx, key, cluster); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return err
+				}
+				// A k0smotron cluster without Cluster API has no machines to pause
+				kmc := &km.Cluster{}
+				if err := c.Get(ctx, key, kmc); err != nil {
+					return err
+				}
+				backedUp = kmc
+			} else if !cluster.Spec.Paused {
+				if err := setPaused(ctx, c, cluster, true); err != nil {
+					return fmt.Errorf("error pausing the cluster: %w", err)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "cluster %s/%s paused\n", namespace, cluster.Name)
+				defer func() {
+					if unpauseErr := setPaused(context.Background(), c, cluster, false); unpauseErr != nil {
+						err = kerrors.NewAggregate([]error{err, fmt.Errorf("error unpausing the cluster: %w", unpauseErr)})
+						return
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "cluster %s/%s unpaused\n", namespace, cluster.Name)
+				}()
+			}
+
+			backup := &unstructured.Unstructured{}
+			backup.SetGroupVersionKind(veleroBackupGVK)
+			backup.SetNamespace(veleroNamespace)
+			backup.SetName(fmt.Sprintf("%s-%s", args[0], time.Now().UTC().Format("20060102150405")))
+			backup.SetLabels(map[string]string{clusterv1.ClusterNameLabel: args[0]})
+			if err := unstructured.SetNestedStringSlice(backup.Object, []string{namespace}, "spec", "includedNamespaces"); err != nil {
+				return err
+			}
+			// The backups of the clusters of a tenant are stored in the bucket of its provider config
+			pc, err := util.GetProviderConfig(ctx, c, namespace)
+			if err != nil {
+				return fmt.Errorf("error getting the provider config: %w", err)
+			}
+			if pc != nil && pc.Spec.Backup != nil && pc.Spec.Backup.StorageLocation != "" {
+				if err := unstructured.SetNestedField(backup.Object, pc.Spec.Backup.StorageLocation, "spec", "storageLocation"); err != nil {
+					return err
+				}
+			}
+			if err := c.Create(ctx, backup); err != nil {
+				return fmt.Errorf("error creating the Velero backup: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "backup %s/%s created\n", veleroNamespace, backup.GetName())
+
+			phase, err := waitForBackup(ctx, c, client.ObjectKeyFromObject(backup), timeout)
+			if err != nil {
+				return fmt.Errorf("error waiting for the Velero backup: %w", err)
+			}
+			if phase != "Completed" {
+				recordBackupEvent(ctx, cmd, c, backedUp, corev1.EventTypeWarning, "BackupFailed",
+					fmt.Sprintf("Backup %s/%s finished with phase %s", veleroNamespace, backup.GetName(), phase))
+				return fmt.Errorf("backup %s/%s finished with phase %s", veleroNamespace, backup.GetName(), phase)
+			}
+			recordBackupEvent(ctx, cmd, c, backedUp, corev1.EventTypeNormal, "BackupSucceeded",
+				fmt.Sprintf("Backup %s/%s completed", veleroNamespace, backup.GetName()))
+			fmt.Fprintf(cmd.OutOrStdout(), "backup %s/%s completed\n", veleroNamespace, backup.GetName())
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&veleroNamespace, "velero-namespace", "velero", "The namespace Velero is installed in")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Minute, "The time to wait for the backup to complete")
+	return cmd
+}
+
+// recordBackupEvent records an event on the backed up cluster, so that its backups show up in kubectl describe. The
+// backup itself succeeded or failed already, so failing to record the event is only reported.
+func recordBackupEvent(ctx context.Context, cmd *cobra.Command, c client.Client, obj client.Object, eventType, reason, message string) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "unable to record the %s event: %v\n", reason, err)
+		return
+	}
+	now := metav1.Now()
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", obj.GetName(), now.UnixNano()),
+			Namespace: obj.GetNamespace(),
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+			UID:        obj.GetUID(),
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: "kubectl-k0smotron"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if err := c.Create(ctx, event); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "unable to record the %s event: %v\n", reason, err)
+	}
+}
+
+func setPaused(ctx context.Context, c client.Client, cluster *clusterv1.Cluster, paused bool) error {
+	patch := client.MergeFrom(cluster.DeepCopy())
+	cluster.Spec.Paused = paused
+	return c.Patch(ctx, cluster, patch)
+}
+
+// waitForBackup waits for the Velero backup to complete or fail and returns its final phase.
+func waitForBackup(ctx context.Context, c client.Client, key client.ObjectKey, timeout time.Duration) (string, error) {
+	var phase string
+	err := wait.PollUntilContextTimeout(ctx, backupPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		backup := &unstructured.Unstructured{}
+		backup.SetGroupVersionKind(veleroBackupGVK)
+		if err := c.Get(ctx, key, backup); err != nil {
+			return false, err
+		}
+		phase, _, _ = unstructured.NestedString(backup.Object, "status", "phase")
+		switch phase {
+		case "Completed", "PartiallyFailed", "Failed", "FailedValidation":
+			return true, nil
+		}
+		return false, nil
+	})
+	return phase, err
+}
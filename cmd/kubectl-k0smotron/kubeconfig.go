@@ -0,0 +1,59 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newGetKubeconfigCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "kubeconfig CLUSTER",
+		Short: "Print the admin kubeconfig of a cluster",
+		Long: "Print the admin kubeconfig of a k0smotron or Cluster API cluster, read from the <cluster>-kubeconfig Secret " +
+			"the controllers generate.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, namespace, err := o.getClient()
+			if err != nil {
+				return err
+			}
+
+			s := &corev1.Secret{}
+			key := client.ObjectKey{Namespace: namespace, Name: secret.Name(args[0], secret.Kubeconfig)}
+			if err := c.Get(cmd.Context(), key, s); err != nil {
+				if apierrors.IsNotFound(err) {
+					return fmt.Errorf("the kubeconfig of cluster %s/%s is not generated yet", namespace, args[0])
+				}
+				return err
+			}
+			kubeconfig, ok := s.Data[secret.KubeconfigDataName]
+			if !ok {
+				return fmt.Errorf("secret %s/%s has no %s key", namespace, key.Name, secret.KubeconfigDataName)
+			}
+
+			_, err = cmd.OutOrStdout().Write(kubeconfig)
+			return err
+		},
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kubectl-k0smotron is a kubectl plugin for the day-2 operations on the clusters managed by k0smotron. It drives the
+// secrets and annotations the controllers read, which are awkward to handle by hand.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	infrastructurev1beta1 "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	k0smotronv1beta1 "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(clusterv1.AddToScheme(scheme))
+	utilruntime.Must(cpv1beta1.AddToScheme(scheme))
+	utilruntime.Must(infrastructurev1beta1.AddToScheme(scheme))
+	utilruntime.Must(k0smotronv1beta1.AddToScheme(scheme))
+}
+
+func main() {
+	// The commands stop on interrupt, and still undo the changes they made, e.g. unpause the cluster of a backup
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := newRootCommand(&options{}).ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// options are the flags shared by all the commands.
+type options struct {
+	kubeconfig string
+	context    string
+	namespace  string
+
+	// client is created from the kubeconfig on first use, unless it is set beforehand.
+	client client.Client
+}
+
+// getClient returns the client of the management cluster and the namespace the command applies to.
+func (o *options) getClient() (client.Client, string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.kubeconfig
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{
+		CurrentContext: o.context,
+		Context:        clientcmdapi.Context{Namespace: o.namespace},
+	})
+
+	namespace, _, err := config.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+	if o.client != nil {
+		return o.client, namespace, nil
+	}
+
+	restConfig, err := config.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	o.client, err = client.New(restConfig, client.Options{Scheme: scheme})
+	return o.client, namespace, err
+}
+
+func newRootCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "kubectl-k0smotron",
+		Short:        "Day-2 operations on the clusters managed by k0smotron",
+		SilenceUsage: true,
+		Annotations: map[string]string{
+			cobra.CommandDisplayNameAnnotation: "kubectl k0smotron",
+		},
+	}
+	cmd.PersistentFlags().StringVar(&o.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file of the management cluster")
+	cmd.PersistentFlags().StringVar(&o.context, "context", "", "The kubeconfig context to use")
+	cmd.PersistentFlags().StringVarP(&o.namespace, "namespace", "n", "", "The namespace of the objects, defaults to the namespace of the kubeconfig context")
+
+	get := &cobra.Command{Use: "get", Short: "Get the resources of a cluster"}
+	get.AddCommand(newGetKubeconfigCommand(o))
+
+	trigger := &cobra.Command{Use: "trigger", Short: "Trigger an operation on a cluster"}
+	trigger.AddCommand(newTriggerBackupCommand(o))
+
+	approve := &cobra.Command{Use: "approve", Short: "Approve an operation awaiting an operator"}
+	approve.AddCommand(newApproveReuseCommand(o), newApproveUpgradeCommand(o))
+
+	list := &cobra.Command{Use: "list", Short: "List the resources managed by k0smotron"}
+	list.AddCommand(newListPooledMachinesCommand(o))
+
+	cmd.AddCommand(get, trigger, approve, list)
+	return cmd
+}
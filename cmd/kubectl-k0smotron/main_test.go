@@ -0,0 +1,207 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+func TestGetKubeconfig(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-kubeconfig", Namespace: "team-a"},
+		Data:       map[string][]byte{"value": []byte("apiVersion: v1\nkind: Config\n")},
+	}).Build()
+
+	out, err := run(c, "get", "kubeconfig", "test", "-n", "team-a")
+	require.NoError(t, err)
+	assert.Equal(t, "apiVersion: v1\nkind: Config\n", out)
+
+	_, err = run(c, "get", "kubeconfig", "missing", "-n", "team-a")
+	assert.ErrorContains(t, err, "not generated yet")
+}
+
+func TestPooledMachines(t *testing.T) {
+	released := &infrastructure.PooledRemoteMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "released", Namespace: "default"},
+		Spec: infrastructure.PooledRemoteMachineSpec{
+			Pool:    "pool-a",
+			Machine: infrastructure.PooledMachineSpec{Address: "10.0.0.1"},
+		},
+		Status: infrastructure.PooledRemoteMachineStatus{ReleaseState: infrastructure.PooledMachineAwaitingApproval},
+	}
+	reserved := &infrastructure.PooledRemoteMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "reserved", Namespace: "default"},
+		Spec: infrastructure.PooledRemoteMachineSpec{
+			Pool:    "pool-b",
+			Machine: infrastructure.PooledMachineSpec{Address: "10.0.0.2"},
+		},
+		Status: infrastructure.PooledRemoteMachineStatus{
+			Reserved:   true,
+			MachineRef: infrastructure.RemoteMachineRef{Name: "worker-0", Namespace: "team-a"},
+			Conditions: clusterv1.Conditions{{Type: infrastructure.PooledMachineHealthyCondition, Status: corev1.ConditionTrue}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(released, reserved).Build()
+
+	out, err := run(c, "list", "pooled-machines", "-n", "default")
+	require.NoError(t, err)
+	assert.Contains(t, out, "released   pool-a   10.0.0.1   -")
+	assert.Contains(t, out, "AwaitingApproval")
+	assert.Contains(t, out, "team-a/worker-0")
+
+	out, err = run(c, "list", "pooled-machines", "-n", "default", "--pool", "pool-b")
+	require.NoError(t, err)
+	assert.NotContains(t, out, "released")
+
+	_, err = run(c, "approve", "reuse", "reserved", "-n", "default")
+	assert.ErrorContains(t, err, "not awaiting approval")
+
+	_, err = run(c, "approve", "reuse", "released", "-n", "default")
+	require.NoError(t, err)
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(released), released))
+	assert.Contains(t, released.Annotations, infrastructure.PooledMachineApproveReuseAnnotation)
+}
+
+func TestTriggerBackup(t *testing.T) {
+	backupPollInterval = 10 * time.Millisecond
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "team-a"}}
+
+	// The fake client does not know the Velero types, the backup completes as soon as it is created
+	completeBackup := func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+		if obj.GetObjectKind().GroupVersionKind() == veleroBackupGVK {
+			return nil
+		}
+		return c.Create(ctx, obj, opts...)
+	}
+	getBackup := func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+		if u, ok := obj.(*unstructured.Unstructured); ok && u.GroupVersionKind() == veleroBackupGVK {
+			return unstructured.SetNestedField(u.Object, "Completed", "status", "phase")
+		}
+		return c.Get(ctx, key, obj, opts...)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster.DeepCopy()).
+		WithInterceptorFuncs(interceptor.Funcs{Create: completeBackup, Get: getBackup}).Build()
+
+	out, err := run(c, "trigger", "backup", "test", "-n", "team-a")
+	require.NoError(t, err)
+	assert.Contains(t, out, "cluster team-a/test paused")
+	assert.Contains(t, out, "completed")
+	assert.Contains(t, out, "cluster team-a/test unpaused")
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster))
+	assert.False(t, cluster.Spec.Paused)
+	events := &corev1.EventList{}
+	require.NoError(t, c.List(context.Background(), events, client.InNamespace("team-a")))
+	require.Len(t, events.Items, 1)
+	assert.Equal(t, "BackupSucceeded", events.Items[0].Reason)
+	assert.Equal(t, corev1.EventTypeNormal, events.Items[0].Type)
+	assert.Equal(t, corev1.ObjectReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "Cluster",
+		Namespace:  "team-a",
+		Name:       "test",
+	}, events.Items[0].InvolvedObject)
+
+	// The cluster is unpaused when the command is interrupted while the backup runs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var paused bool
+	c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster.DeepCopy()).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				cl := &clusterv1.Cluster{}
+				if err := c.Get(ctx, client.ObjectKeyFromObject(cluster), cl); err != nil {
+					return err
+				}
+				paused = cl.Spec.Paused
+				cancel()
+				return nil
+			},
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if u, ok := obj.(*unstructured.Unstructured); ok && u.GroupVersionKind() == veleroBackupGVK {
+					return nil
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+
+	_, err = runContext(ctx, c, "trigger", "backup", "test", "-n", "team-a")
+	require.ErrorIs(t, err, context.Canceled)
+	assert.True(t, paused)
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster))
+	assert.False(t, cluster.Spec.Paused)
+}
+
+func TestApproveUpgrade(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "team-a"},
+		Spec: clusterv1.ClusterSpec{ControlPlaneRef: &corev1.ObjectReference{
+			APIVersion: cpv1beta1.GroupVersion.String(),
+			Kind:       "K0sControlPlane",
+			Name:       "test-cp",
+		}},
+	}
+	kcp := &cpv1beta1.K0sControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cp", Namespace: "team-a"},
+		Spec:       cpv1beta1.K0sControlPlaneSpec{Version: "v1.31.2+k0s.0"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, kcp).Build()
+
+	_, err := run(c, "approve", "upgrade", "test", "-n", "team-a")
+	assert.ErrorContains(t, err, "does not require the approval")
+
+	kcp.Annotations = map[string]string{cpv1beta1.UpgradeApprovalRequiredAnnotation: "true"}
+	require.NoError(t, c.Update(context.Background(), kcp))
+
+	out, err := run(c, "approve", "upgrade", "test", "-n", "team-a")
+	require.NoError(t, err)
+	assert.Equal(t, "k0scontrolplane team-a/test-cp upgrade to v1.31.2+k0s.0 approved\n", out)
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(kcp), kcp))
+	assert.Equal(t, "v1.31.2+k0s.0", kcp.Annotations[cpv1beta1.UpgradeApprovedVersionAnnotation])
+
+	_, err = run(c, "approve", "upgrade", "test", "-n", "team-a")
+	assert.ErrorContains(t, err, "already approved")
+}
+
+func run(c client.Client, args ...string) (string, error) {
+	return runContext(context.Background(), c, args...)
+}
+
+func runContext(ctx context.Context, c client.Client, args ...string) (string, error) {
+	out := &bytes.Buffer{}
+	cmd := newRootCommand(&options{client: c})
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+	err := cmd.ExecuteContext(ctx)
+	return out.String(), err
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+func newListPooledMachinesCommand(o *options) *cobra.Command {
+	var pool string
+	var allNamespaces bool
+	cmd := &cobra.Command{
+		Use:     "pooled-machines",
+		Aliases: []string{"pooledmachines", "pooledremotemachines"},
+		Short:   "List the PooledRemoteMachines with the RemoteMachine reserving them",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, namespace, err := o.getClient()
+			if err != nil {
+				return err
+			}
+
+			var opts []client.ListOption
+			if !allNamespaces {
+				opts = append(opts, client.InNamespace(namespace))
+			}
+			machines := &infrastructure.PooledRemoteMachineList{}
+			if err := c.List(cmd.Context(), machines, opts...); err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 3, ' ', 0)
+			fmt.Fprintln(w, "NAMESPACE\tNAME\tPOOL\tADDRESS\tRESERVED BY\tHEALTHY\tRELEASE")
+			for _, m := range machines.Items {
+				if pool != "" && m.Spec.Pool != pool {
+					continue
+				}
+				reservedBy := "-"
+				if m.Status.Reserved {
+					reservedBy = m.Status.MachineRef.Namespace + "/" + m.Status.MachineRef.Name
+				}
+				healthy := "Unknown"
+				if cond := conditions.Get(&m, infrastructure.PooledMachineHealthyCondition); cond != nil {
+					healthy = string(cond.Status)
+				}
+				release := string(m.Status.ReleaseState)
+				if release == "" {
+					release = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", m.Namespace, m.Name, m.Spec.Pool, m.Spec.Machine.Address, reservedBy, healthy, release)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVar(&pool, "pool", "", "List the machines of the pool only")
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "List the machines of all the namespaces")
+	return cmd
+}
+
+func newApproveReuseCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reuse MACHINE...",
+		Short: "Approve the return of released PooledRemoteMachines to their pool",
+		Long: "Approve the return of PooledRemoteMachines with the ManualApproval reuse policy to their pool, once they " +
+			"were released by their RemoteMachine.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, namespace, err := o.getClient()
+			if err != nil {
+				return err
+			}
+
+			for _, name := range args {
+				m := &infrastructure.PooledRemoteMachine{}
+				if err := c.Get(cmd.Context(), client.ObjectKey{Namespace: namespace, Name: name}, m); err != nil {
+					return err
+				}
+				if m.Status.ReleaseState != infrastructure.PooledMachineAwaitingApproval {
+					return fmt.Errorf("pooled machine %s/%s is not awaiting approval", namespace, name)
+				}
+
+				patch := client.MergeFrom(m.DeepCopy())
+				annotations := m.GetAnnotations()
+				if annotations == nil {
+					annotations = map[string]string{}
+				}
+				annotations[infrastructure.PooledMachineApproveReuseAnnotation] = "true"
+				m.SetAnnotations(annotations)
+				if err := c.Patch(cmd.Context(), m, patch); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "pooledremotemachine %s/%s reuse approved\n", namespace, name)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+)
+
+func newApproveUpgradeCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "upgrade CLUSTER",
+		Short: "Approve the upgrade of the K0sControlPlane of a cluster",
+		Long: "Approve the upgrade of the machines of a K0sControlPlane annotated with " +
+			cpv1beta1.UpgradeApprovalRequiredAnnotation + " to the version of its spec.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, namespace, err := o.getClient()
+			if err != nil {
+				return err
+			}
+
+			cluster := &clusterv1.Cluster{}
+			if err := c.Get(cmd.Context(), client.ObjectKey{Namespace: namespace, Name: args[0]}, cluster); err != nil {
+				return err
+			}
+			ref := cluster.Spec.ControlPlaneRef
+			if ref == nil || ref.Kind != "K0sControlPlane" {
+				return fmt.Errorf("cluster %s/%s has no K0sControlPlane", namespace, cluster.Name)
+			}
+			kcp := &cpv1beta1.K0sControlPlane{}
+			if err := c.Get(cmd.Context(), client.ObjectKey{Namespace: namespace, Name: ref.Name}, kcp); err != nil {
+				return err
+			}
+			if _, required := kcp.Annotations[cpv1beta1.UpgradeApprovalRequiredAnnotation]; !required {
+				return fmt.Errorf("k0scontrolplane %s/%s does not require the approval of its upgrades", namespace, kcp.Name)
+			}
+			if kcp.Annotations[cpv1beta1.UpgradeApprovedVersionAnnotation] == kcp.Spec.Version {
+				return fmt.Errorf("the upgrade of k0scontrolplane %s/%s to %s is already approved", namespace, kcp.Name, kcp.Spec.Version)
+			}
+
+			patch := client.MergeFrom(kcp.DeepCopy())
+			kcp.Annotations[cpv1beta1.UpgradeApprovedVersionAnnotation] = kcp.Spec.Version
+			if err := c.Patch(cmd.Context(), kcp, patch); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "k0scontrolplane %s/%s upgrade to %s approved\n", namespace, kcp.Name, kcp.Spec.Version)
+			return nil
+		},
+	}
+}
@@ -17,24 +17,30 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/md5"
 	"crypto/tls"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"k8s.io/client-go/discovery"
 
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/selection"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/rest"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	runtimev1 "sigs.k8s.io/cluster-api/exp/runtime/api/v1alpha1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -42,6 +48,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
 
 	bootstrapv1beta1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
 	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
@@ -51,6 +58,12 @@ import (
 	"github.com/k0sproject/k0smotron/internal/controller/controlplane"
 	"github.com/k0sproject/k0smotron/internal/controller/infrastructure"
 	controller "github.com/k0sproject/k0smotron/internal/controller/k0smotron.io"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/keystore"
+	"github.com/k0sproject/k0smotron/internal/migration"
+	"github.com/k0sproject/k0smotron/internal/runtimehooks"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -82,6 +95,8 @@ func init() {
 	utilruntime.Must(clusterv1.AddToScheme(scheme))
 	utilruntime.Must(cpv1beta1.AddToScheme(scheme))
 	utilruntime.Must(infrastructurev1beta1.AddToScheme(scheme))
+	utilruntime.Must(runtimev1.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -92,6 +107,25 @@ func main() {
 	var enableHTTP2 bool
 	var probeAddr string
 	var enabledController string
+	var remoteMachineConcurrency int
+	var pullBootstrapAddr string
+	var pullBootstrapURL string
+	var pullBootstrapCertDir string
+	var airgapArtifactsDir string
+	var poolNamespaces string
+	var secretCache bool
+	var syncPeriod time.Duration
+	var clusterResyncPeriod time.Duration
+	var kubeAPIQPS float64
+	var kubeAPIBurst int
+	var watchFilter string
+	var watchNamespaces string
+	var tracingOpts tracing.Options
+	var vaultOpts keystore.VaultOptions
+	var runtimeHooks bool
+	var crossNamespaceMachineTemplates bool
+	var standalone bool
+	var storageVersionMigration bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8443", "The address the metric endpoint binds to. "+
 		"Use :8080 for http and :8443 for https. Setting to 0 will disable the metrics endpoint.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -104,6 +138,66 @@ func main() {
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
 
 	flag.StringVar(&enabledController, "enable-controller", "", "The controller to enable. Default: all")
+	flag.IntVar(&remoteMachineConcurrency, "remote-machine-concurrency", 10,
+		"The maximum number of RemoteMachines provisioned in parallel.")
+	flag.StringVar(&pullBootstrapAddr, "pull-bootstrap-bind-address", "0",
+		"The address the pull bootstrap server of RemoteMachines binds to. Setting to 0 disables the server.")
+	flag.StringVar(&pullBootstrapURL, "pull-bootstrap-url", "",
+		"The URL the RemoteMachines using pull bootstrap reach the pull bootstrap server at, e.g. https://k0smotron.example.com:9444")
+	flag.StringVar(&pullBootstrapCertDir, "pull-bootstrap-cert-dir", "/tmp/k8s-pull-bootstrap-server/serving-certs",
+		"The directory holding the tls.crt and tls.key files of the pull bootstrap server.")
+	flag.StringVar(&airgapArtifactsDir, "airgap-artifacts-dir", "",
+		"The directory holding the k0s binaries and airgap image bundles uploaded to the RemoteMachines using airgap provisioning.")
+	flag.StringVar(&poolNamespaces, "pool-namespaces", "",
+		"Comma separated list of the namespaces RemoteMachines of any namespace can claim PooledRemoteMachines from.")
+	flag.DurationVar(&health.StuckReconcileThreshold, "stuck-reconcile-threshold", health.StuckReconcileThreshold,
+		"The duration after which a reconciliation still running makes the health check of its controller fail.")
+	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Hour,
+		"The interval at which all the objects are reconciled again, correcting the drift of the resources k0smotron generates "+
+			"which are not watched, e.g. the ones in the workload clusters.")
+	flag.DurationVar(&clusterResyncPeriod, "cluster-resync-period", 0,
+		"If set, each k0smotron Cluster is reconciled again after a random duration between the period and 1.5 times the period. "+
+			"Combined with a longer --sync-period, it spreads the resyncs of the clusters over time on management clusters with many clusters.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 20,
+		"The maximum number of queries per second the manager sends to the API server of the management cluster.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 30,
+		"The maximum burst of queries the manager sends to the API server of the management cluster.")
+	flag.BoolVar(&secretCache, "secret-cache", true,
+		"If set, the Secrets labelled with a Cluster API cluster name are cached. Disabling it lowers the memory used by the manager "+
+			"on management clusters with many clusters, at the expense of reading the Secrets from the API server on every reconciliation.")
+	flag.StringVar(&watchFilter, "watch-filter", "",
+		fmt.Sprintf("Label value that the controllers watch, only the objects labelled with %s=<value> are reconciled, like with the Cluster API controllers. "+
+			"It shards the clusters between several k0smotron instances. Default: all objects.", clusterv1.WatchLabel))
+	flag.StringVar(&watchNamespaces, "namespace", "",
+		"Comma separated list of the namespaces the controllers watch. Default: all namespaces.")
+	flag.StringVar(&tracingOpts.Endpoint, "otlp-endpoint", "",
+		"The host:port of the OTLP gRPC collector the reconcile traces are exported to. Tracing is disabled if empty.")
+	flag.BoolVar(&tracingOpts.Insecure, "otlp-insecure", false,
+		"If set, the traces are exported to the OTLP collector without TLS.")
+	flag.Float64Var(&tracingOpts.SamplingRatio, "tracing-sampling-ratio", 1,
+		"The ratio of the reconciliations traced, between 0 and 1.")
+	flag.StringVar(&vaultOpts.Address, "vault-address", "",
+		"The URL of the Vault server the private keys of the K0sControlPlane cluster CAs are moved to. The keys are kept in the certificate Secrets if empty.")
+	flag.StringVar(&vaultOpts.TokenFile, "vault-token-file", "",
+		"The file holding the Vault token, read on every request. Default: the VAULT_TOKEN environment variable.")
+	flag.StringVar(&vaultOpts.Mount, "vault-kv-mount", "secret",
+		"The mount path of the Vault KV version 2 secrets engine the keys are stored in.")
+	flag.StringVar(&vaultOpts.Prefix, "vault-path-prefix", "k0smotron",
+		"The path the keys are stored under in the Vault KV secrets engine, as <prefix>/<namespace>/<cluster>/<certificate>.")
+	flag.StringVar(&vaultOpts.MachineAddress, "vault-machine-address", "",
+		"The URL of the Vault server the first controller machines fetch the keys from. Default: the --vault-address.")
+	flag.DurationVar(&vaultOpts.WrapTTL, "vault-wrap-ttl", 30*time.Minute,
+		"How long the single use tokens handing the keys over to the first controller machines are valid.")
+	flag.BoolVar(&runtimeHooks, "runtime-hooks", false,
+		"If set, the K0sControlPlane controller calls the BeforeClusterUpgrade and AfterControlPlaneUpgrade Cluster API Runtime SDK hooks. Requires the RuntimeSDK feature of Cluster API.")
+	flag.BoolVar(&crossNamespaceMachineTemplates, "cross-namespace-machine-templates", false,
+		"If set, the K0sControlPlanes can reference an infrastructure machine template of another namespace, if the template "+
+			"lists their namespace in its controlplane.k0smotron.io/allowed-namespaces annotation.")
+	flag.BoolVar(&standalone, "standalone", false,
+		"If set, only the k0smotron.io Cluster and JoinTokenRequest controllers run, and Cluster API does not need to be installed. "+
+			"Otherwise the Cluster API controllers run if Cluster API is installed.")
+	flag.BoolVar(&storageVersionMigration, "storage-version-migration", true,
+		"If set, the objects of the k0smotron CRDs stored in a former API version are migrated to the storage version on startup.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -118,6 +212,11 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	var keyStore keystore.Store
+	if vaultOpts.Address != "" {
+		keyStore = keystore.NewVault(vaultOpts)
+	}
+
 	var tlsOpts []func(*tls.Config)
 	disableHTTP2 := func(c *tls.Config) {
 		setupLog.Info("disabling http/2")
@@ -137,21 +236,63 @@ func main() {
 		metricsOpts.FilterProvider = filters.WithAuthenticationAndAuthorization
 	}
 
-	req, _ := labels.NewRequirement(clusterv1.ClusterNameLabel, selection.Exists, nil)
-	clusterSecretCacheSelector := labels.NewSelector().Add(*req)
+	restConfig, err := ctrl.GetConfig()
+	if err != nil {
+		setupLog.Error(err, "unable to get cluster config")
+		os.Exit(1)
+	}
+	restConfig.QPS = float32(kubeAPIQPS)
+	restConfig.Burst = kubeAPIBurst
+
+	// The Cluster API controllers are registered only if Cluster API is installed, their kinds cannot be watched
+	// otherwise. The standalone controllers don't depend on it.
+	runCAPIControllers, err := capiControllersEnabled(standalone, restConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to discover the Cluster API resources")
+		os.Exit(1)
+	}
+	switch {
+	case standalone:
+		setupLog.Info("Running in standalone mode, skipping cluster-api controllers setup")
+	case !runCAPIControllers:
+		setupLog.Info("Cluster API v1beta1 not installed, skipping cluster-api controllers setup")
+	}
+	if !runCAPIControllers && enabledController != "" && enabledController != allControllers && enabledController != controlPlaneController {
+		setupLog.Info("The enabled controller requires Cluster API, no controller will run", "controller", enabledController)
+	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	leaderElectionID := enabledController
+	watchFilterSelector, err := newWatchFilterSelector(watchFilter)
+	if err != nil {
+		setupLog.Error(err, "invalid watch filter")
+		os.Exit(1)
+	}
+	if watchFilterSelector != nil {
+		leaderElectionID += "/" + watchFilter
+	}
+	cacheByObject := newCacheByObject(secretCache, watchFilterSelector, runCAPIControllers)
+
+	var defaultNamespaces map[string]cache.Config
+	if namespaces := splitNonEmpty(watchNamespaces); len(namespaces) > 0 {
+		defaultNamespaces = make(map[string]cache.Config, len(namespaces))
+		for _, ns := range namespaces {
+			defaultNamespaces[ns] = cache.Config{}
+		}
+		leaderElectionID += "/" + watchNamespaces
+	}
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
 		Metrics:                metricsOpts,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       fmt.Sprintf("%x.k0smotron.io", md5.Sum([]byte(enabledController))),
+		LeaderElectionID:       fmt.Sprintf("%x.k0smotron.io", md5.Sum([]byte(leaderElectionID))),
 		Cache: cache.Options{
-			ByObject: map[client.Object]cache.ByObject{
-				&corev1.Secret{}: {
-					Label: clusterSecretCacheSelector,
-				},
-			},
+			DefaultNamespaces: defaultNamespaces,
+			ByObject:          cacheByObject,
+			SyncPeriod:        &syncPeriod,
+			// The managed fields and pod specs are never read from the cache, and account for a good part of its size.
+			DefaultTransform: util.TransformCachedObject(),
 		},
 		Client: client.Options{
 			Cache: &client.CacheOptions{
@@ -168,41 +309,42 @@ func main() {
 		os.Exit(1)
 	}
 
-	restConfig, err := ctrl.GetConfig()
-	if err != nil {
-		setupLog.Error(err, "unable to get cluster config")
-		os.Exit(1)
-	}
 	clientSet, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		setupLog.Error(err, "unable to get kubernetes clientset")
 		os.Exit(1)
 	}
 
-	secretCachingClient, err := client.New(mgr.GetConfig(), client.Options{
-		HTTPClient: mgr.GetHTTPClient(),
-		Cache: &client.CacheOptions{
-			Reader: mgr.GetCache(),
-		},
-	})
-	if err != nil {
-		setupLog.Error(err, "unable to create secret caching client")
-		os.Exit(1)
+	// Without the secret cache, the manager client reading the Secrets from the API server is used instead.
+	secretCachingClient := mgr.GetClient()
+	if secretCache {
+		secretCachingClient, err = client.New(mgr.GetConfig(), client.Options{
+			HTTPClient: mgr.GetHTTPClient(),
+			Cache: &client.CacheOptions{
+				Reader: mgr.GetCache(),
+			},
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to create secret caching client")
+			os.Exit(1)
+		}
 	}
 
-	// Absent CAPI Machine kind means we are not running in a CAPI environment, so we don't need to run CAPI controllers.
-	var runCAPIControllers bool
-	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
-	if err != nil {
-		setupLog.Error(err, "unable to create discovery client")
-		os.Exit(1)
+	if runCAPIControllers {
+		if err := util.AddIndexes(context.Background(), mgr); err != nil {
+			setupLog.Error(err, "unable to set up the cache indexes")
+			os.Exit(1)
+		}
 	}
 
-	resources, err := discoveryClient.ServerResourcesForGroupVersion(schema.GroupVersion{Group: "cluster.x-k8s.io", Version: "v1beta1"}.String())
-	if err == nil && len(resources.APIResources) > 0 {
-		runCAPIControllers = true
-	} else {
-		mgr.GetLogger().Info("Cluster API v1beta1 not installed, skipping cluster-api controllers setup")
+	// Each provider serves the conversion webhook of its CRDs, the admission webhooks are only served by the control
+	// plane controllers.
+	servesWebhooks := isControllerEnabled(controlPlaneController) ||
+		runCAPIControllers && (isControllerEnabled(bootstrapController) || isControllerEnabled(infrastructureController))
+	if servesWebhooks {
+		// The builder only registers the conversion webhook for the kinds served in several versions, while the CRDs
+		// may be converted beforehand.
+		mgr.GetWebhookServer().Register("/convert", conversion.NewWebhookHandler(mgr.GetScheme()))
 	}
 
 	//+kubebuilder:scaffold:builder
@@ -214,6 +356,7 @@ func main() {
 			Scheme:              mgr.GetScheme(),
 			ClientSet:           clientSet,
 			RESTConfig:          restConfig,
+			Recorder:            mgr.GetEventRecorderFor("k0s-bootstrap"),
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Bootstrap")
 			os.Exit(1)
@@ -224,10 +367,18 @@ func main() {
 			Scheme:              mgr.GetScheme(),
 			ClientSet:           clientSet,
 			RESTConfig:          restConfig,
+			Recorder:            mgr.GetEventRecorderFor("k0s-bootstrap"),
+			KeyStore:            keyStore,
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Bootstrap")
 			os.Exit(1)
 		}
+		if err = (&bootstrap.AutoscalerController{
+			Client: mgr.GetClient(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Autoscaler")
+			os.Exit(1)
+		}
 		if err = (&bootstrap.ProviderIDController{
 			Client:    mgr.GetClient(),
 			Scheme:    mgr.GetScheme(),
@@ -240,11 +391,13 @@ func main() {
 
 	if isControllerEnabled(controlPlaneController) {
 		if err = (&controller.ClusterReconciler{
-			Client:     mgr.GetClient(),
-			Scheme:     mgr.GetScheme(),
-			ClientSet:  clientSet,
-			RESTConfig: restConfig,
-			Recorder:   mgr.GetEventRecorderFor("cluster-reconciler"),
+			Client:       mgr.GetClient(),
+			Scheme:       mgr.GetScheme(),
+			ClientSet:    clientSet,
+			RESTConfig:   restConfig,
+			Recorder:     mgr.GetEventRecorderFor("cluster-reconciler"),
+			ResyncPeriod: clusterResyncPeriod,
+			WatchSecrets: secretCache,
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "K0smotronCluster")
 			os.Exit(1)
@@ -277,17 +430,36 @@ func main() {
 				os.Exit(1)
 			}
 
+			var hookCaller *runtimehooks.Caller
+			if runtimeHooks {
+				hookCaller = &runtimehooks.Caller{Client: mgr.GetClient()}
+			}
 			if err = (&controlplane.K0sController{
+				Client:                         mgr.GetClient(),
+				SecretCachingClient:            secretCachingClient,
+				ClientSet:                      clientSet,
+				RESTConfig:                     restConfig,
+				KeyStore:                       keyStore,
+				RuntimeHooks:                   hookCaller,
+				CrossNamespaceMachineTemplates: crossNamespaceMachineTemplates,
+				WatchSecrets:                   secretCache,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "K0sController")
+				os.Exit(1)
+			}
+
+			if err = (&controlplane.TunnelServerController{
 				Client:              mgr.GetClient(),
 				SecretCachingClient: secretCachingClient,
-				ClientSet:           clientSet,
-				RESTConfig:          restConfig,
+				WatchSecrets:        secretCache,
 			}).SetupWithManager(mgr); err != nil {
-				setupLog.Error(err, "unable to create controller", "controller", "K0sController")
+				setupLog.Error(err, "unable to create controller", "controller", "TunnelServerController")
 				os.Exit(1)
 			}
 
-			if err = (&controlplane.K0sControlPlaneValidator{}).SetupK0sControlPlaneWebhookWithManager(mgr); err != nil {
+			if err = (&controlplane.K0sControlPlaneValidator{
+				CrossNamespaceMachineTemplates: crossNamespaceMachineTemplates,
+			}).SetupK0sControlPlaneWebhookWithManager(mgr); err != nil {
 				setupLog.Error(err, "unable to create validation webhook", "webhook", "K0sControlPlaneValidator")
 				os.Exit(1)
 			}
@@ -300,17 +472,38 @@ func main() {
 	}
 
 	if isControllerEnabled(infrastructureController) && runCAPIControllers {
+		if err := util.AddInfrastructureIndexes(context.Background(), mgr); err != nil {
+			setupLog.Error(err, "unable to set up the infrastructure cache indexes")
+			os.Exit(1)
+		}
+
 		if err = (&infrastructure.RemoteMachineController{
-			Client:              mgr.GetClient(),
-			SecretCachingClient: secretCachingClient,
-			Scheme:              mgr.GetScheme(),
-			ClientSet:           clientSet,
-			RESTConfig:          restConfig,
+			Client:                  mgr.GetClient(),
+			SecretCachingClient:     secretCachingClient,
+			Scheme:                  mgr.GetScheme(),
+			ClientSet:               clientSet,
+			RESTConfig:              restConfig,
+			MaxConcurrentReconciles: remoteMachineConcurrency,
+			PullBootstrapURL:        pullBootstrapURL,
+			AirgapArtifactsDir:      airgapArtifactsDir,
+			PoolNamespaces:          splitNonEmpty(poolNamespaces),
+			Recorder:                mgr.GetEventRecorderFor("remotemachine-controller"),
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "RemoteMachine")
 			os.Exit(1)
 		}
 
+		if pullBootstrapAddr != "0" {
+			if err = mgr.Add(&infrastructure.PullBootstrapServer{
+				Client:      mgr.GetClient(),
+				BindAddress: pullBootstrapAddr,
+				CertDir:     pullBootstrapCertDir,
+			}); err != nil {
+				setupLog.Error(err, "unable to create pull bootstrap server")
+				os.Exit(1)
+			}
+		}
+
 		if err = (&infrastructure.ClusterController{
 			Client: mgr.GetClient(),
 			Scheme: mgr.GetScheme(),
@@ -318,6 +511,39 @@ func main() {
 			setupLog.Error(err, "unable to create controller", "controller", "RemoteCluster")
 			os.Exit(1)
 		}
+
+		if err = (&infrastructure.PooledRemoteMachineController{
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			Scheme:    mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PooledRemoteMachine")
+			os.Exit(1)
+		}
+	}
+
+	if storageVersionMigration {
+		var groups []string
+		if isControllerEnabled(bootstrapController) && runCAPIControllers {
+			groups = append(groups, bootstrapv1beta1.GroupVersion.Group)
+		}
+		if isControllerEnabled(controlPlaneController) {
+			groups = append(groups, k0smotronv1beta1.GroupVersion.Group)
+			if runCAPIControllers {
+				groups = append(groups, cpv1beta1.GroupVersion.Group)
+			}
+		}
+		if isControllerEnabled(infrastructureController) && runCAPIControllers {
+			groups = append(groups, infrastructurev1beta1.GroupVersion.Group)
+		}
+		if err = mgr.Add(&migration.StorageVersionMigrator{
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			Groups:    groups,
+		}); err != nil {
+			setupLog.Error(err, "unable to create storage version migrator")
+			os.Exit(1)
+		}
 	}
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -328,14 +554,122 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if servesWebhooks {
+		if err := mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+			setupLog.Error(err, "unable to set up webhook ready check")
+			os.Exit(1)
+		}
+	}
+
+	// Tracing is set up last, so that none of the exits above skips flushing the traces
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingOpts)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
-		setupLog.Error(err, "problem running manager")
+	mgrErr := mgr.Start(ctrl.SetupSignalHandler())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(ctx); err != nil {
+		setupLog.Error(err, "unable to flush the traces")
+	}
+	cancel()
+
+	if mgrErr != nil {
+		setupLog.Error(mgrErr, "problem running manager")
 		os.Exit(1)
 	}
 }
 
+// capiControllersEnabled returns whether the Cluster API controllers run. They don't in standalone mode, where the
+// API server is not queried, nor when Cluster API is not installed.
+func capiControllersEnabled(standalone bool, restConfig *rest.Config) (bool, error) {
+	if standalone {
+		return false, nil
+	}
+	return clusterAPIInstalled(restConfig)
+}
+
+// clusterAPIInstalled returns whether the Cluster API v1beta1 resources are served by the API server.
+func clusterAPIInstalled(restConfig *rest.Config) (bool, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return false, err
+	}
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(clusterv1.GroupVersion.String())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(resources.APIResources) > 0, nil
+}
+
+// newWatchFilterSelector returns the selector of the objects labelled with the watch filter value, or nil without
+// watch filter.
+func newWatchFilterSelector(watchFilter string) (labels.Selector, error) {
+	if watchFilter == "" {
+		return nil, nil
+	}
+	return labels.ValidatedSelectorFromSet(labels.Set{clusterv1.WatchLabel: watchFilter})
+}
+
+// newCacheByObject returns the selectors of the objects cached by the manager. The ConfigMaps are limited to the ones
+// applied by k0smotron, the controllers only watch their metadata. The Secrets are limited to the ones labelled with
+// a cluster name, and only cached with the secret cache: without it, no Secret is cached nor watched.
+func newCacheByObject(secretCache bool, watchFilter labels.Selector, capi bool) map[client.Object]cache.ByObject {
+	cacheByObject := map[client.Object]cache.ByObject{
+		&corev1.ConfigMap{}: {Label: labels.SelectorFromSet(labels.Set{util.ManagedByLabel: util.FieldOwner})},
+	}
+	if secretCache {
+		req, _ := labels.NewRequirement(clusterv1.ClusterNameLabel, selection.Exists, nil)
+		cacheByObject[&corev1.Secret{}] = cache.ByObject{Label: labels.NewSelector().Add(*req)}
+	}
+	if watchFilter != nil {
+		for _, obj := range watchFilterObjects(capi) {
+			cacheByObject[obj] = cache.ByObject{Label: watchFilter}
+		}
+	}
+	return cacheByObject
+}
+
+// watchFilterObjects returns the kinds reconciled by the k0smotron controllers, whose cache is restricted to the
+// objects matching the watch filter. The objects k0smotron only reads, like Cluster API Clusters and Machines, are
+// not filtered: their controller is responsible for them. Neither are the RemoteClusters and PooledRemoteMachines,
+// which don't carry the watch filter label: the pooled machines are claimed by the RemoteMachines of every instance.
+// The kinds of the Cluster API providers are left out when their controllers don't run, their CRDs may not be installed.
+func watchFilterObjects(capi bool) []client.Object {
+	objs := []client.Object{
+		&k0smotronv1beta1.Cluster{},
+		&k0smotronv1beta1.JoinTokenRequest{},
+	}
+	if !capi {
+		return objs
+	}
+	return append(objs,
+		&bootstrapv1beta1.K0sWorkerConfig{},
+		&bootstrapv1beta1.K0sControllerConfig{},
+		&cpv1beta1.K0sControlPlane{},
+		&cpv1beta1.K0smotronControlPlane{},
+		&cpv1beta1.TunnelServer{},
+		&infrastructurev1beta1.RemoteMachine{},
+	)
+}
+
 func isControllerEnabled(controllerName string) bool {
 	return enabledControllers[controllerName]
 }
+
+// splitNonEmpty splits a comma separated flag value, ignoring empty items.
+func splitNonEmpty(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructurev1beta1 "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	k0smotronv1beta1 "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+)
+
+func TestNewCacheByObject(t *testing.T) {
+	byKind := func(byObject map[client.Object]cache.ByObject) map[string]string {
+		selectors := map[string]string{}
+		for obj, config := range byObject {
+			kind := "unknown"
+			switch obj.(type) {
+			case *corev1.ConfigMap:
+				kind = "ConfigMap"
+			case *corev1.Secret:
+				kind = "Secret"
+			case *k0smotronv1beta1.Cluster:
+				kind = "Cluster"
+			}
+			selectors[kind] = config.Label.String()
+		}
+		return selectors
+	}
+
+	assert.Equal(t, map[string]string{
+		"ConfigMap": "app.kubernetes.io/managed-by=k0smotron",
+		"Secret":    "cluster.x-k8s.io/cluster-name",
+	}, byKind(newCacheByObject(true, nil, false)))
+
+	// Without the secret cache, the Secrets are neither cached nor watched
+	assert.Equal(t, map[string]string{
+		"ConfigMap": "app.kubernetes.io/managed-by=k0smotron",
+	}, byKind(newCacheByObject(false, nil, false)))
+
+	selector, err := newWatchFilterSelector("shard-a")
+	require.NoError(t, err)
+	byObject := newCacheByObject(false, selector, false)
+	assert.Len(t, byObject, 3)
+	assert.Equal(t, "cluster.x-k8s.io/watch-filter=shard-a", byKind(byObject)["Cluster"])
+}
+
+func TestNewWatchFilterSelector(t *testing.T) {
+	selector, err := newWatchFilterSelector("")
+	require.NoError(t, err)
+	assert.Nil(t, selector)
+
+	selector, err = newWatchFilterSelector("shard-a")
+	require.NoError(t, err)
+	assert.True(t, selector.Matches(labels.Set{"cluster.x-k8s.io/watch-filter": "shard-a"}))
+	assert.False(t, selector.Matches(labels.Set{"cluster.x-k8s.io/watch-filter": "shard-b"}))
+	assert.False(t, selector.Matches(labels.Set{}))
+
+	// The watch filter is a label value, not a selector
+	_, err = newWatchFilterSelector("shard in (a, b)")
+	assert.Error(t, err)
+}
+
+func TestCAPIControllersEnabled(t *testing.T) {
+	discoveryServer := func(t *testing.T, status int, resources ...metav1.APIResource) (*rest.Config, *atomic.Int32) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if r.URL.Path != "/apis/"+clusterv1.GroupVersion.String() || status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(&metav1.APIResourceList{
+				TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+				GroupVersion: clusterv1.GroupVersion.String(),
+				APIResources: resources,
+			})
+		}))
+		t.Cleanup(server.Close)
+		return &rest.Config{Host: server.URL}, &requests
+	}
+	machines := metav1.APIResource{Name: "machines", Namespaced: true, Kind: "Machine"}
+
+	t.Run("installed", func(t *testing.T) {
+		config, _ := discoveryServer(t, http.StatusOK, machines)
+		enabled, err := capiControllersEnabled(false, config)
+		require.NoError(t, err)
+		assert.True(t, enabled)
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		config, _ := discoveryServer(t, http.StatusNotFound)
+		enabled, err := capiControllersEnabled(false, config)
+		require.NoError(t, err)
+		assert.False(t, enabled)
+
+		config, _ = discoveryServer(t, http.StatusOK)
+		enabled, err = capiControllersEnabled(false, config)
+		require.NoError(t, err)
+		assert.False(t, enabled)
+	})
+
+	t.Run("discovery failure", func(t *testing.T) {
+		config, _ := discoveryServer(t, http.StatusForbidden)
+		_, err := capiControllersEnabled(false, config)
+		assert.Error(t, err)
+	})
+
+	t.Run("standalone", func(t *testing.T) {
+		// The API server is not queried, even if Cluster API is installed
+		config, requests := discoveryServer(t, http.StatusOK, machines)
+		enabled, err := capiControllersEnabled(true, config)
+		require.NoError(t, err)
+		assert.False(t, enabled)
+		assert.Zero(t, requests.Load())
+	})
+}
+
+func TestWatchFilterObjectsStandalone(t *testing.T) {
+	// Without Cluster API, only the kinds of the standalone controllers are filtered, the CRDs of the Cluster API
+	// providers may not be installed.
+	assert.ElementsMatch(t, []client.Object{
+		&k0smotronv1beta1.Cluster{},
+		&k0smotronv1beta1.JoinTokenRequest{},
+	}, watchFilterObjects(false))
+	assert.Greater(t, len(watchFilterObjects(true)), 2)
+	for _, obj := range watchFilterObjects(true) {
+		switch obj.(type) {
+		case *infrastructurev1beta1.RemoteCluster, *infrastructurev1beta1.PooledRemoteMachine:
+			// They are not labelled, the RemoteMachines of every instance must see all the pooled machines
+			t.Errorf("unexpected filtered kind %T", obj)
+		}
+	}
+
+	selector, err := newWatchFilterSelector("shard-a")
+	require.NoError(t, err)
+	byObject := newCacheByObject(true, selector, false)
+	for obj := range byObject {
+		switch obj.(type) {
+		case *corev1.ConfigMap, *corev1.Secret, *k0smotronv1beta1.Cluster, *k0smotronv1beta1.JoinTokenRequest:
+		default:
+			t.Errorf("unexpected cached kind %T in standalone mode", obj)
+		}
+	}
+}
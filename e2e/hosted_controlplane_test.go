@@ -0,0 +1,151 @@
+//go:build e2e
+
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/k0sproject/k0smotron/e2e/util"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+	capiframework "sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/cluster-api/test/framework/clusterctl"
+	capiutil "sigs.k8s.io/cluster-api/util"
+)
+
+func TestHostedControlPlane(t *testing.T) {
+	setupAndRun(t, hostedControlPlaneSpec)
+}
+
+// Validation of the correct operation of k0smotron when the
+// K0smotronControlPlane object is updated. It simulates a typical user workflow that includes:
+//
+// 1. Creation of a cluster with a hosted control plane.
+//   - Ensures the control plane becomes ready and its API server answers.
+//
+// 2. Scaling the control plane up and down.
+//   - Verifies the control plane pods are rolled out after each scaling.
+//
+// 3. Upgrading the control plane version.
+//   - Confirms the control plane and kube-proxy run the new version.
+func hostedControlPlaneSpec(t *testing.T) {
+	testName := "hosted-control-plane"
+
+	// Setup a Namespace where to host objects for this spec and create a watcher for the namespace events.
+	namespace, _ := util.SetupSpecNamespace(ctx, testName, bootstrapClusterProxy, artifactFolder)
+
+	clusterName := fmt.Sprintf("%s-%s", testName, capiutil.RandomString(6))
+
+	workloadClusterTemplate := clusterctl.ConfigCluster(ctx, clusterctl.ConfigClusterInput{
+		ClusterctlConfigPath: clusterctlConfigPath,
+		KubeconfigPath:       bootstrapClusterProxy.GetKubeconfigPath(),
+		// select cluster templates
+		Flavor: "hosted-control-plane",
+
+		Namespace:                namespace.Name,
+		ClusterName:              clusterName,
+		KubernetesVersion:        e2eConfig.GetVariable(KubernetesVersionHosted),
+		ControlPlaneMachineCount: ptr.To[int64](1),
+		// TODO: make infra provider configurable
+		InfrastructureProvider: "docker",
+		LogFolder:              filepath.Join(artifactFolder, "clusters", bootstrapClusterProxy.GetName()),
+		ClusterctlVariables: map[string]string{
+			"CLUSTER_NAME": clusterName,
+			"NAMESPACE":    namespace.Name,
+		},
+	})
+	require.NotNil(t, workloadClusterTemplate)
+
+	require.Eventually(t, func() bool {
+		return bootstrapClusterProxy.CreateOrUpdate(ctx, workloadClusterTemplate) == nil
+	}, 10*time.Second, 1*time.Second, "Failed to apply the cluster template")
+
+	cluster, err := util.DiscoveryAndWaitForCluster(ctx, capiframework.DiscoveryAndWaitForClusterInput{
+		Getter:    bootstrapClusterProxy.GetClient(),
+		Namespace: namespace.Name,
+		Name:      clusterName,
+	}, util.GetInterval(e2eConfig, testName, "wait-cluster"))
+	require.NoError(t, err)
+
+	defer func() {
+		util.DumpSpecResourcesAndCleanup(
+			ctx,
+			testName,
+			bootstrapClusterProxy,
+			artifactFolder,
+			namespace,
+			cancelWatches,
+			cluster,
+			util.GetInterval(e2eConfig, testName, "wait-delete-cluster"),
+			skipCleanup,
+		)
+	}()
+
+	controlPlane, err := util.DiscoveryAndWaitForHCPToBeReady(ctx, util.DiscoveryAndWaitForHCPReadyInput{
+		Lister:  bootstrapClusterProxy.GetClient(),
+		Getter:  bootstrapClusterProxy.GetClient(),
+		Cluster: cluster,
+	}, util.GetInterval(e2eConfig, testName, "wait-control-plane"))
+	require.NoError(t, err)
+
+	err = util.WaitForHostedControlPlaneReady(ctx, util.WaitForHostedControlPlaneReadyInput{
+		Getter:       bootstrapClusterProxy.GetClient(),
+		Cluster:      cluster,
+		ControlPlane: controlPlane,
+	}, util.GetInterval(e2eConfig, testName, "wait-control-plane"))
+	require.NoError(t, err)
+
+	_, err = util.GetHostedClusterProxy(ctx, util.GetHostedClusterProxyInput{
+		ClusterProxy: bootstrapClusterProxy,
+		Cluster:      cluster,
+	}, util.GetInterval(e2eConfig, testName, "wait-control-plane"))
+	require.NoError(t, err)
+
+	fmt.Println("Scaling up the hosted control plane")
+	err = util.ScaleHostedControlPlaneAndWaitForReady(ctx, util.ScaleHostedControlPlaneAndWaitForReadyInput{
+		ClusterProxy: bootstrapClusterProxy,
+		Cluster:      cluster,
+		ControlPlane: controlPlane,
+		Replicas:     3,
+	}, util.GetInterval(e2eConfig, testName, "wait-control-plane"))
+	require.NoError(t, err)
+
+	fmt.Println("Upgrading the Kubernetes version of the hosted control plane")
+	err = util.UpgradeHostedControlPlaneAndWaitForUpgrade(ctx, util.UpgradeHostedControlPlaneAndWaitForUpgradeInput{
+		ClusterProxy:                     bootstrapClusterProxy,
+		Cluster:                          cluster,
+		ControlPlane:                     controlPlane,
+		KubernetesUpgradeVersion:         e2eConfig.GetVariable(KubernetesVersionHostedUpgradeTo),
+		WaitForKubeProxyUpgradeInterval:  util.GetInterval(e2eConfig, testName, "wait-kube-proxy-upgrade"),
+		WaitForControlPlaneReadyInterval: util.GetInterval(e2eConfig, testName, "wait-control-plane"),
+	})
+	require.NoError(t, err)
+
+	fmt.Println("Scaling down the hosted control plane")
+	err = util.ScaleHostedControlPlaneAndWaitForReady(ctx, util.ScaleHostedControlPlaneAndWaitForReadyInput{
+		ClusterProxy: bootstrapClusterProxy,
+		Cluster:      cluster,
+		ControlPlane: controlPlane,
+		Replicas:     1,
+	}, util.GetInterval(e2eConfig, testName, "wait-control-plane"))
+	require.NoError(t, err)
+}
@@ -66,6 +66,8 @@ func TestMachinedeployment(t *testing.T) {
 			ClusterctlVariables: map[string]string{
 				"CLUSTER_NAME": clusterName,
 				"NAMESPACE":    namespace.Name,
+				// The workers start one minor version behind the control plane and are upgraded to its version
+				"WORKER_KUBERNETES_VERSION": "v1.31.5",
 			},
 		})
 		require.NotNil(t, workloadClusterTemplate)
@@ -111,8 +113,8 @@ func TestMachinedeployment(t *testing.T) {
 		workloadClusterKubeconfig := getWorkloadClusterKubeconfig(ctx, t, bootstrapClusterProxy, clusterName, namespace.Name)
 
 		fmt.Print("Waiting for MachineDeployment to be ready\n")
+		md := &clusterv1.MachineDeployment{}
 		require.Eventually(t, func() bool {
-			md := &clusterv1.MachineDeployment{}
 			err := bootstrapClusterProxy.GetClient().Get(ctx, client.ObjectKey{
 				Namespace: namespace.Name,
 				Name:      clusterName,
@@ -126,6 +128,17 @@ func TestMachinedeployment(t *testing.T) {
 		fmt.Print("Verifying worker nodes are ready in the workload cluster\n")
 		verifyWorkerNodesReady(ctx, t, workloadClusterKubeconfig, 2)
 
+		fmt.Print("Upgrading the workers of the MachineDeployment\n")
+		err = util.UpgradeMachineDeploymentAndWaitForUpgrade(ctx, util.UpgradeMachineDeploymentAndWaitForUpgradeInput{
+			ClusterProxy:                            bootstrapClusterProxy,
+			Cluster:                                 cluster,
+			MachineDeployment:                       md,
+			KubernetesUpgradeVersion:                "v1.32.2",
+			WaitForMachineDeploymentRolloutInterval: util.GetInterval(e2eConfig, testName, "wait-machine-upgrade"),
+			WaitForNodesUpgradeInterval:             util.GetInterval(e2eConfig, testName, "wait-worker-nodes"),
+		})
+		require.NoError(t, err)
+
 		fmt.Print("MachineDeployment test completed successfully\n")
 	})
 }
@@ -49,6 +49,8 @@ const (
 	KubernetesVersionManagement      = "KUBERNETES_VERSION_MANAGEMENT"
 	KubernetesVersionFirstUpgradeTo  = "KUBERNETES_VERSION_FIRST_UPGRADE_TO"
 	KubernetesVersionSecondUpgradeTo = "KUBERNETES_VERSION_SECOND_UPGRADE_TO"
+	KubernetesVersionHosted          = "KUBERNETES_VERSION_HOSTED"
+	KubernetesVersionHostedUpgradeTo = "KUBERNETES_VERSION_HOSTED_UPGRADE_TO"
 	ControlPlaneMachineCount         = "CONTROL_PLANE_MACHINE_COUNT"
 	IPFamily                         = "IP_FAMILY"
 )
@@ -21,14 +21,20 @@ package util
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
 	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	capiframework "sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/secret"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -130,3 +136,158 @@ func getK0smotronControlPlaneByCluster(ctx context.Context, input GetK0smotronCo
 
 	return nil, nil
 }
+
+// WaitForHostedControlPlaneReadyInput is the input type for WaitForHostedControlPlaneReady.
+type WaitForHostedControlPlaneReadyInput struct {
+	Getter       capiframework.Getter
+	Cluster      *clusterv1.Cluster
+	ControlPlane *cpv1beta1.K0smotronControlPlane
+}
+
+// WaitForHostedControlPlaneReady waits until all the replicas of a K0smotronControlPlane are ready and run its version,
+// and the StatefulSet of the hosted control plane is rolled out.
+func WaitForHostedControlPlaneReady(ctx context.Context, input WaitForHostedControlPlaneReadyInput, interval Interval) error {
+	fmt.Println("Waiting for the hosted control plane to be ready")
+	if err := WaitForHCPToBeReady(ctx, input.Getter, input.ControlPlane, interval); err != nil {
+		return err
+	}
+
+	// The k0smotron Cluster of the control plane is named after the Cluster API cluster
+	statefulSetKey := crclient.ObjectKey{Name: kapi.GetStatefulSetName(input.Cluster.Name), Namespace: input.Cluster.Namespace}
+	statefulSet := &appsv1.StatefulSet{}
+	err := wait.PollUntilContextTimeout(ctx, interval.tick, interval.timeout, true, func(ctx context.Context) (done bool, err error) {
+		if err := input.Getter.Get(ctx, statefulSetKey, statefulSet); err != nil {
+			return false, nil
+		}
+
+		replicas := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			replicas = *statefulSet.Spec.Replicas
+		}
+		return statefulSet.Status.ObservedGeneration >= statefulSet.Generation &&
+			replicas == input.ControlPlane.Spec.Replicas &&
+			statefulSet.Status.ReadyReplicas == replicas &&
+			statefulSet.Status.UpdatedReplicas == replicas &&
+			statefulSet.Status.CurrentRevision == statefulSet.Status.UpdateRevision, nil
+	})
+	if err != nil {
+		return fmt.Errorf("statefulset %s not rolled out: %s: %w", statefulSetKey, capiframework.PrettyPrint(statefulSet.Status), err)
+	}
+
+	return nil
+}
+
+// GetHostedClusterProxyInput is the input type for GetHostedClusterProxy.
+type GetHostedClusterProxyInput struct {
+	ClusterProxy capiframework.ClusterProxy
+	Cluster      *clusterv1.Cluster
+}
+
+// GetHostedClusterProxy returns a proxy to the cluster of a hosted control plane, once its API server answers with the
+// kubeconfig generated by k0smotron.
+func GetHostedClusterProxy(ctx context.Context, input GetHostedClusterProxyInput, interval Interval) (capiframework.ClusterProxy, error) {
+	fmt.Printf("Waiting for the API server of %s to answer\n", klog.KObj(input.Cluster))
+
+	secretKey := crclient.ObjectKey{Name: secret.Name(input.Cluster.Name, secret.Kubeconfig), Namespace: input.Cluster.Namespace}
+	err := wait.PollUntilContextTimeout(ctx, interval.tick, interval.timeout, true, func(ctx context.Context) (done bool, err error) {
+		return input.ClusterProxy.GetClient().Get(ctx, secretKey, &corev1.Secret{}) == nil, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kubeconfig secret %s not found: %w", secretKey, err)
+	}
+
+	hostedClusterProxy := input.ClusterProxy.GetWorkloadCluster(ctx, input.Cluster.Namespace, input.Cluster.Name)
+	err = wait.PollUntilContextTimeout(ctx, interval.tick, interval.timeout, true, func(ctx context.Context) (done bool, err error) {
+		_, err = hostedClusterProxy.GetClientSet().Discovery().ServerVersion()
+		return err == nil, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("API server of %s not answering: %w", klog.KObj(input.Cluster), err)
+	}
+
+	return hostedClusterProxy, nil
+}
+
+// ScaleHostedControlPlaneAndWaitForReadyInput is the input type for ScaleHostedControlPlaneAndWaitForReady.
+type ScaleHostedControlPlaneAndWaitForReadyInput struct {
+	ClusterProxy capiframework.ClusterProxy
+	Cluster      *clusterv1.Cluster
+	ControlPlane *cpv1beta1.K0smotronControlPlane
+	Replicas     int32
+}
+
+// ScaleHostedControlPlaneAndWaitForReady scales a K0smotronControlPlane and waits for the replicas to be ready.
+func ScaleHostedControlPlaneAndWaitForReady(ctx context.Context, input ScaleHostedControlPlaneAndWaitForReadyInput, interval Interval) error {
+	fmt.Printf("Scaling %s to %d replicas\n", klog.KObj(input.ControlPlane), input.Replicas)
+	if err := patchHostedControlPlane(ctx, input.ClusterProxy.GetClient(), input.ControlPlane, func(cp *cpv1beta1.K0smotronControlPlane) {
+		cp.Spec.Replicas = input.Replicas
+	}); err != nil {
+		return err
+	}
+
+	return WaitForHostedControlPlaneReady(ctx, WaitForHostedControlPlaneReadyInput{
+		Getter:       input.ClusterProxy.GetClient(),
+		Cluster:      input.Cluster,
+		ControlPlane: input.ControlPlane,
+	}, interval)
+}
+
+// UpgradeHostedControlPlaneAndWaitForUpgradeInput is the input type for UpgradeHostedControlPlaneAndWaitForUpgrade.
+type UpgradeHostedControlPlaneAndWaitForUpgradeInput struct {
+	ClusterProxy                     capiframework.ClusterProxy
+	Cluster                          *clusterv1.Cluster
+	ControlPlane                     *cpv1beta1.K0smotronControlPlane
+	KubernetesUpgradeVersion         string
+	WaitForKubeProxyUpgradeInterval  Interval
+	WaitForControlPlaneReadyInterval Interval
+}
+
+// UpgradeHostedControlPlaneAndWaitForUpgrade upgrades a K0smotronControlPlane and waits for it to be upgraded.
+func UpgradeHostedControlPlaneAndWaitForUpgrade(ctx context.Context, input UpgradeHostedControlPlaneAndWaitForUpgradeInput) error {
+	fmt.Println("Patching the new kubernetes version to the K0smotronControlPlane")
+	if err := patchHostedControlPlane(ctx, input.ClusterProxy.GetClient(), input.ControlPlane, func(cp *cpv1beta1.K0smotronControlPlane) {
+		cp.Spec.Version = input.KubernetesUpgradeVersion
+	}); err != nil {
+		return err
+	}
+
+	err := WaitForHostedControlPlaneReady(ctx, WaitForHostedControlPlaneReadyInput{
+		Getter:       input.ClusterProxy.GetClient(),
+		Cluster:      input.Cluster,
+		ControlPlane: input.ControlPlane,
+	}, input.WaitForControlPlaneReadyInterval)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Waiting for kube-proxy to have the upgraded kubernetes version")
+	hostedClusterProxy, err := GetHostedClusterProxy(ctx, GetHostedClusterProxyInput{
+		ClusterProxy: input.ClusterProxy,
+		Cluster:      input.Cluster,
+	}, input.WaitForControlPlaneReadyInterval)
+	if err != nil {
+		return err
+	}
+	// The version of a hosted control plane is the tag of the k0s image, e.g. v1.31.2-k0s.0
+	return WaitForKubeProxyUpgrade(ctx, WaitForKubeProxyUpgradeInput{
+		Getter:            hostedClusterProxy.GetClient(),
+		KubernetesVersion: strings.Split(input.KubernetesUpgradeVersion, "-k0s.")[0],
+	}, input.WaitForKubeProxyUpgradeInterval)
+}
+
+func patchHostedControlPlane(ctx context.Context, client crclient.Client, cp *cpv1beta1.K0smotronControlPlane, mutate func(*cpv1beta1.K0smotronControlPlane)) error {
+	patchHelper, err := patch.NewHelper(cp, client)
+	if err != nil {
+		return err
+	}
+
+	mutate(cp)
+
+	err = wait.PollUntilContextTimeout(ctx, time.Second, time.Minute, true, func(ctx context.Context) (done bool, err error) {
+		return patchHelper.Patch(ctx, cp) == nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to patch K0smotronControlPlane %s: %w", klog.KObj(cp), err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+//go:build e2e
+
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capiframework "sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/cluster-api/util/patch"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UpgradeMachineDeploymentAndWaitForUpgradeInput is the input type for UpgradeMachineDeploymentAndWaitForUpgrade.
+type UpgradeMachineDeploymentAndWaitForUpgradeInput struct {
+	ClusterProxy                            capiframework.ClusterProxy
+	Cluster                                 *clusterv1.Cluster
+	MachineDeployment                       *clusterv1.MachineDeployment
+	KubernetesUpgradeVersion                string
+	WaitForMachineDeploymentRolloutInterval Interval
+	WaitForNodesUpgradeInterval             Interval
+}
+
+// UpgradeMachineDeploymentAndWaitForUpgrade upgrades the workers of a MachineDeployment bootstrapped with a
+// K0sWorkerConfigTemplate and waits for the machines to be rolled out and the nodes to run the new version.
+// The version set in the K0sWorkerConfigTemplate takes precedence over the one of the machines, so a template setting
+// one is copied with the new version and the MachineDeployment is pointed to the copy.
+func UpgradeMachineDeploymentAndWaitForUpgrade(ctx context.Context, input UpgradeMachineDeploymentAndWaitForUpgradeInput) error {
+	mgmtClient := input.ClusterProxy.GetClient()
+	md := input.MachineDeployment
+
+	patchHelper, err := patch.NewHelper(md, mgmtClient)
+	if err != nil {
+		return err
+	}
+
+	configRef := md.Spec.Template.Spec.Bootstrap.ConfigRef
+	if configRef != nil && configRef.Kind == "K0sWorkerConfigTemplate" {
+		fmt.Println("Copying the K0sWorkerConfigTemplate with the new kubernetes version")
+		templateName, err := copyK0sWorkerConfigTemplate(ctx, mgmtClient, md.Namespace, configRef, input.KubernetesUpgradeVersion)
+		if err != nil {
+			return err
+		}
+		configRef.Name = templateName
+	}
+
+	fmt.Println("Patching the new kubernetes version to the MachineDeployment")
+	md.Spec.Template.Spec.Version = &input.KubernetesUpgradeVersion
+	err = wait.PollUntilContextTimeout(ctx, time.Second, time.Minute, true, func(ctx context.Context) (done bool, err error) {
+		return patchHelper.Patch(ctx, md) == nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to patch the new kubernetes version to machine deployment %s: %w", klog.KObj(md), err)
+	}
+
+	err = WaitForMachineDeploymentRollout(ctx, WaitForMachineDeploymentRolloutInput{
+		Lister:            mgmtClient,
+		MachineDeployment: md,
+		KubernetesVersion: input.KubernetesUpgradeVersion,
+	}, input.WaitForMachineDeploymentRolloutInterval)
+	if err != nil {
+		return err
+	}
+
+	workloadClient := input.ClusterProxy.GetWorkloadCluster(ctx, input.Cluster.Namespace, input.Cluster.Name).GetClient()
+	return WaitForMachineDeploymentNodesUpgrade(ctx, WaitForMachineDeploymentNodesUpgradeInput{
+		Lister:            mgmtClient,
+		WorkloadGetter:    workloadClient,
+		MachineDeployment: md,
+		KubernetesVersion: input.KubernetesUpgradeVersion,
+	}, input.WaitForNodesUpgradeInterval)
+}
+
+// copyK0sWorkerConfigTemplate copies a K0sWorkerConfigTemplate with the given k0s version and returns the name of the
+// copy. The name of the template is returned as is when it doesn't set a version, as the workers then take the version
+// of their machines.
+func copyK0sWorkerConfigTemplate(ctx context.Context, client crclient.Client, namespace string, ref *corev1.ObjectReference, version string) (string, error) {
+	template := &unstructured.Unstructured{}
+	template.SetAPIVersion(ref.APIVersion)
+	template.SetKind(ref.Kind)
+	if err := client.Get(ctx, crclient.ObjectKey{Namespace: namespace, Name: ref.Name}, template); err != nil {
+		return "", fmt.Errorf("failed to get K0sWorkerConfigTemplate %s: %w", ref.Name, err)
+	}
+	currentVersion, _, _ := unstructured.NestedString(template.Object, "spec", "template", "spec", "version")
+	if currentVersion == "" {
+		return ref.Name, nil
+	}
+
+	if !strings.Contains(version, "+k0s.") {
+		version += "+k0s.0"
+	}
+	upgraded := &unstructured.Unstructured{}
+	upgraded.SetAPIVersion(ref.APIVersion)
+	upgraded.SetKind(ref.Kind)
+	upgraded.SetNamespace(namespace)
+	upgraded.SetName(fmt.Sprintf("%s-%s", ref.Name, strings.NewReplacer(".", "-", "+", "-").Replace(version)))
+	upgraded.SetLabels(template.GetLabels())
+	spec, _, _ := unstructured.NestedMap(template.Object, "spec")
+	if err := unstructured.SetNestedMap(upgraded.Object, spec, "spec"); err != nil {
+		return "", err
+	}
+	if err := unstructured.SetNestedField(upgraded.Object, version, "spec", "template", "spec", "version"); err != nil {
+		return "", err
+	}
+	if err := client.Create(ctx, upgraded); err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("failed to create K0sWorkerConfigTemplate %s: %w", upgraded.GetName(), err)
+	}
+	return upgraded.GetName(), nil
+}
+
+// WaitForMachineDeploymentRolloutInput is the input type for WaitForMachineDeploymentRollout.
+type WaitForMachineDeploymentRolloutInput struct {
+	Lister            capiframework.Lister
+	MachineDeployment *clusterv1.MachineDeployment
+	KubernetesVersion string
+}
+
+// WaitForMachineDeploymentRollout waits until all the machines of a MachineDeployment have the given kubernetes version
+// and a node, and the machines of the previous version are gone.
+func WaitForMachineDeploymentRollout(ctx context.Context, input WaitForMachineDeploymentRolloutInput, interval Interval) error {
+	fmt.Printf("Waiting for the machines of %s to be rolled out\n", klog.KObj(input.MachineDeployment))
+
+	var machines []clusterv1.Machine
+	err := wait.PollUntilContextTimeout(ctx, interval.tick, interval.timeout, true, func(ctx context.Context) (done bool, err error) {
+		machineList := &clusterv1.MachineList{}
+		if err := input.Lister.List(ctx, machineList, crclient.InNamespace(input.MachineDeployment.Namespace), crclient.MatchingLabels{
+			clusterv1.MachineDeploymentNameLabel: input.MachineDeployment.Name,
+		}); err != nil {
+			return false, err
+		}
+		machines = machineList.Items
+
+		replicas := int32(1)
+		if input.MachineDeployment.Spec.Replicas != nil {
+			replicas = *input.MachineDeployment.Spec.Replicas
+		}
+		if int32(len(machines)) != replicas {
+			return false, nil
+		}
+		for _, machine := range machines {
+			if machine.Spec.Version == nil || *machine.Spec.Version != input.KubernetesVersion ||
+				machine.Status.NodeRef == nil || !machine.DeletionTimestamp.IsZero() {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("machines of %s not rolled out to %s: %s: %w", klog.KObj(input.MachineDeployment), input.KubernetesVersion, capiframework.PrettyPrint(machines), err)
+	}
+
+	return nil
+}
+
+// WaitForMachineDeploymentNodesUpgradeInput is the input type for WaitForMachineDeploymentNodesUpgrade.
+type WaitForMachineDeploymentNodesUpgradeInput struct {
+	Lister            capiframework.Lister
+	WorkloadGetter    capiframework.Getter
+	MachineDeployment *clusterv1.MachineDeployment
+	KubernetesVersion string
+}
+
+// WaitForMachineDeploymentNodesUpgrade waits until the nodes of the machines of a MachineDeployment are ready and their
+// kubelet runs the given kubernetes version.
+func WaitForMachineDeploymentNodesUpgrade(ctx context.Context, input WaitForMachineDeploymentNodesUpgradeInput, interval Interval) error {
+	fmt.Printf("Waiting for the nodes of %s to run the upgraded kubernetes version\n", klog.KObj(input.MachineDeployment))
+
+	// k0s reports the kubelet version with its own build metadata, e.g. v1.31.2+k0s
+	versionPrefix := strings.Split(input.KubernetesVersion, "+")[0]
+	return wait.PollUntilContextTimeout(ctx, interval.tick, interval.timeout, true, func(ctx context.Context) (done bool, err error) {
+		machineList := &clusterv1.MachineList{}
+		if err := input.Lister.List(ctx, machineList, crclient.InNamespace(input.MachineDeployment.Namespace), crclient.MatchingLabels{
+			clusterv1.MachineDeploymentNameLabel: input.MachineDeployment.Name,
+		}); err != nil {
+			return false, err
+		}
+
+		for _, machine := range machineList.Items {
+			if machine.Status.NodeRef == nil {
+				return false, nil
+			}
+			node := &corev1.Node{}
+			if err := input.WorkloadGetter.Get(ctx, crclient.ObjectKey{Name: machine.Status.NodeRef.Name}, node); err != nil {
+				return false, nil
+			}
+			if strings.Split(node.Status.NodeInfo.KubeletVersion, "+")[0] != versionPrefix || !isNodeReady(node) {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/tracing"
+)
+
+const (
+	// AutoscalerLabelsAnnotation holds the labels the nodes of a node group register with, for the cluster autoscaler
+	// to scale the group from zero.
+	AutoscalerLabelsAnnotation = "capacity.cluster-autoscaler.kubernetes.io/labels"
+	// AutoscalerTaintsAnnotation holds the taints the nodes of a node group register with.
+	AutoscalerTaintsAnnotation = "capacity.cluster-autoscaler.kubernetes.io/taints"
+	// AutoscalerMaxPodsAnnotation holds the number of pods the nodes of a node group can run.
+	AutoscalerMaxPodsAnnotation = "capacity.cluster-autoscaler.kubernetes.io/maxPods"
+)
+
+// autoscalerStartupTaintPrefixes are the prefixes of the taints the cluster autoscaler expects to be removed once the
+// node is ready. They are not part of the node group template, otherwise pods not tolerating them would never
+// trigger a scale up.
+var autoscalerStartupTaintPrefixes = []string{
+	"startup-taint.cluster-autoscaler.kubernetes.io/",
+	"ignore-taint.cluster-autoscaler.kubernetes.io/",
+}
+
+// AutoscalerController sets the annotations the cluster autoscaler builds the node group template from on the
+// MachineDeployments bootstrapped with a K0sWorkerConfigTemplate, so the empty MachineDeployments can be scaled from
+// zero. The capacity of the nodes depends on the infrastructure and is left to the infrastructure provider or to the
+// user.
+type AutoscalerController struct {
+	client.Client
+}
+
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=k0sworkerconfigtemplates,verbs=get;list;watch
+
+func (r *AutoscalerController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithValues("machinedeployment", req.NamespacedName)
+
+	md := &clusterv1.MachineDeployment{}
+	if err := r.Get(ctx, req.NamespacedName, md); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	ref := md.Spec.Template.Spec.Bootstrap.ConfigRef
+	if ref == nil || ref.Kind != "K0sWorkerConfigTemplate" || !md.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	template := &bootstrapv1.K0sWorkerConfigTemplate{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: md.Namespace, Name: ref.Name}, template); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Applying no annotations removes the ones previously applied, e.g. once the args no longer set labels.
+	annotations := autoscalerAnnotations(template.Spec.Template.Spec.Args)
+	// The ownership is not forced, the annotations set by the user take precedence. The conflicting annotations are
+	// left to their owner and the others are applied again.
+	err := r.applyAnnotations(ctx, md, annotations)
+	if apierrors.IsConflict(err) {
+		conflicting := conflictingAnnotations(err, annotations)
+		if len(conflicting) == 0 {
+			return ctrl.Result{}, err
+		}
+		log.Info("Some autoscaler annotations are set by another field manager, leaving them untouched", "annotations", conflicting)
+		for _, key := range conflicting {
+			delete(annotations, key)
+		}
+		err = r.applyAnnotations(ctx, md, annotations)
+	}
+	return ctrl.Result{}, err
+}
+
+// applyAnnotations applies the annotations to the MachineDeployment. The object applied only holds the metadata, as a
+// typed MachineDeployment would claim the ownership of the fields without omitempty, e.g. the cluster name.
+func (r *AutoscalerController) applyAnnotations(ctx context.Context, md *clusterv1.MachineDeployment, annotations map[string]string) error {
+	patch := &unstructured.Unstructured{}
+	patch.SetAPIVersion(clusterv1.GroupVersion.String())
+	patch.SetKind("MachineDeployment")
+	patch.SetName(md.Name)
+	patch.SetNamespace(md.Namespace)
+	patch.SetAnnotations(annotations)
+	return r.Patch(ctx, patch, client.Apply, client.FieldOwner(util.FieldOwner))
+}
+
+// conflictingAnnotations returns the annotations the server side apply conflict is reported for.
+func conflictingAnnotations(err error, annotations map[string]string) []string {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) || status.Status().Details == nil {
+		return nil
+	}
+	var conflicting []string
+	for _, cause := range status.Status().Details.Causes {
+		for key := range annotations {
+			if cause.Field == ".metadata.annotations."+key {
+				conflicting = append(conflicting, key)
+			}
+		}
+	}
+	sort.Strings(conflicting)
+	return conflicting
+}
+
+// autoscalerAnnotations returns the autoscaler annotations matching the labels, taints and maximum number of pods set
+// in the args of the k0s worker.
+func autoscalerAnnotations(args []string) map[string]string {
+	var labels, taints []string
+	var maxPods string
+	for i := 0; i < len(args); i++ {
+		name, value, found := strings.Cut(args[i], "=")
+		if !found && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+			value = args[i]
+		}
+		value = strings.Trim(value, `"'`)
+		switch name {
+		case "--labels":
+			labels = append(labels, splitNonEmpty(value)...)
+		case "--taints":
+			for _, taint := range splitNonEmpty(value) {
+				if !isAutoscalerStartupTaint(taint) {
+					taints = append(taints, taint)
+				}
+			}
+		case "--kubelet-extra-args":
+			if pods := kubeletMaxPods(value); pods != "" {
+				maxPods = pods
+			}
+		}
+	}
+
+	annotations := map[string]string{}
+	if len(labels) > 0 {
+		sort.Strings(labels)
+		annotations[AutoscalerLabelsAnnotation] = strings.Join(labels, ",")
+	}
+	if len(taints) > 0 {
+		sort.Strings(taints)
+		annotations[AutoscalerTaintsAnnotation] = strings.Join(taints, ",")
+	}
+	if maxPods != "" {
+		annotations[AutoscalerMaxPodsAnnotation] = maxPods
+	}
+	return annotations
+}
+
+func kubeletMaxPods(kubeletArgs string) string {
+	fields := strings.Fields(kubeletArgs)
+	for i, f := range fields {
+		if pods, ok := strings.CutPrefix(f, "--max-pods="); ok {
+			return pods
+		}
+		if f == "--max-pods" && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+func isAutoscalerStartupTaint(taint string) bool {
+	for _, prefix := range autoscalerStartupTaintPrefixes {
+		if strings.HasPrefix(taint, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitNonEmpty(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// machineDeploymentsForTemplate returns the MachineDeployments bootstrapped with the K0sWorkerConfigTemplate.
+func (r *AutoscalerController) machineDeploymentsForTemplate(ctx context.Context, o client.Object) []reconcile.Request {
+	mds := &clusterv1.MachineDeploymentList{}
+	if err := r.List(ctx, mds, client.InNamespace(o.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list the MachineDeployments", "template", o.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, md := range mds.Items {
+		ref := md.Spec.Template.Spec.Bootstrap.ConfigRef
+		if ref != nil && ref.Kind == "K0sWorkerConfigTemplate" && ref.Name == o.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&md)})
+		}
+	}
+	return requests
+}
+
+func (r *AutoscalerController) SetupWithManager(mgr ctrl.Manager) error {
+	if err := health.AddChecks(mgr, "autoscaler", &clusterv1.MachineDeployment{}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("autoscaler").
+		For(&clusterv1.MachineDeployment{}).
+		Watches(&bootstrapv1.K0sWorkerConfigTemplate{}, handler.EnqueueRequestsFromMapFunc(r.machineDeploymentsForTemplate)).
+		Complete(metrics.Instrument("autoscaler", mgr.GetClient(), func() client.Object { return &clusterv1.MachineDeployment{} }, nil, tracing.Instrument("autoscaler", health.Instrument("autoscaler", r))))
+}
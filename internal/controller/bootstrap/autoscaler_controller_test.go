@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
+)
+
+func TestAutoscalerAnnotations(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{
+			name: "no labels nor taints",
+			args: []string{"--debug"},
+			want: map[string]string{},
+		},
+		{
+			name: "labels and taints",
+			args: []string{
+				"--labels=zone=b,pool=gpu",
+				"--taints", "gpu=true:NoSchedule,startup-taint.cluster-autoscaler.kubernetes.io/cni=false:NoSchedule",
+				`--kubelet-extra-args="--node-ip=10.0.0.1 --max-pods=50"`,
+			},
+			want: map[string]string{
+				AutoscalerLabelsAnnotation:  "pool=gpu,zone=b",
+				AutoscalerTaintsAnnotation:  "gpu=true:NoSchedule",
+				AutoscalerMaxPodsAnnotation: "50",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, autoscalerAnnotations(tt.args))
+		})
+	}
+}
+
+func TestAutoscalerReconcile(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-autoscaler-reconcile")
+	require.NoError(t, err)
+
+	template := &bootstrapv1.K0sWorkerConfigTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu", Namespace: ns.Name},
+		Spec: bootstrapv1.K0sWorkerConfigTemplateSpec{
+			Template: bootstrapv1.K0sWorkerConfigTemplateResource{
+				Spec: bootstrapv1.K0sWorkerConfigSpec{
+					Args: []string{"--labels=pool=gpu", "--taints=gpu=true:NoSchedule"},
+				},
+			},
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, template))
+
+	md := &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu", Namespace: ns.Name},
+		Spec: clusterv1.MachineDeploymentSpec{
+			ClusterName: "test",
+			Selector:    metav1.LabelSelector{MatchLabels: map[string]string{"pool": "gpu"}},
+			Template: clusterv1.MachineTemplateSpec{
+				ObjectMeta: clusterv1.ObjectMeta{Labels: map[string]string{"pool": "gpu"}},
+				Spec: clusterv1.MachineSpec{
+					ClusterName: "test",
+					Bootstrap: clusterv1.Bootstrap{
+						ConfigRef: &corev1.ObjectReference{
+							APIVersion: bootstrapv1.GroupVersion.String(),
+							Kind:       "K0sWorkerConfigTemplate",
+							Name:       template.Name,
+						},
+					},
+					InfrastructureRef: corev1.ObjectReference{
+						APIVersion: "infrastructure.cluster.x-k8s.io/v1beta1",
+						Kind:       "GenericInfrastructureMachineTemplate",
+						Name:       "gpu",
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, md))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(md, template, ns)
+
+	// The user sets the labels of the node group, they are left untouched.
+	userPatch := &unstructured.Unstructured{}
+	userPatch.SetAPIVersion(clusterv1.GroupVersion.String())
+	userPatch.SetKind("MachineDeployment")
+	userPatch.SetName(md.Name)
+	userPatch.SetNamespace(md.Namespace)
+	userPatch.SetAnnotations(map[string]string{AutoscalerLabelsAnnotation: "pool=gpu,zone=a"})
+	require.NoError(t, testEnv.Patch(ctx, userPatch, client.Apply, client.FieldOwner("user")))
+
+	r := &AutoscalerController{Client: testEnv}
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(md)})
+		assert.NoError(c, err)
+
+		got := &clusterv1.MachineDeployment{}
+		if !assert.NoError(c, testEnv.Get(ctx, client.ObjectKeyFromObject(md), got)) {
+			return
+		}
+		assert.Equal(c, "pool=gpu,zone=a", got.Annotations[AutoscalerLabelsAnnotation])
+		assert.Equal(c, "gpu=true:NoSchedule", got.Annotations[AutoscalerTaintsAnnotation])
+
+		// Only the annotations are owned by k0smotron.
+		for _, entry := range got.ManagedFields {
+			if entry.Manager == util.FieldOwner {
+				assert.NotContains(c, string(entry.FieldsV1.Raw), "f:spec")
+			}
+		}
+	}, 10*time.Second, 100*time.Millisecond)
+}
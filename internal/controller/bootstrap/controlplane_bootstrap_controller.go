@@ -19,8 +19,10 @@ package bootstrap
 import (
 	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/netip"
 	"sort"
@@ -35,7 +37,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/kubernetes/cmd/kubeadm/app/constants"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	kubeadmbootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
@@ -55,6 +57,10 @@ import (
 	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
 	"github.com/k0sproject/k0smotron/internal/cloudinit"
 	"github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/keystore"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 	kutil "github.com/k0sproject/k0smotron/internal/util"
 	"github.com/k0sproject/version"
 )
@@ -65,10 +71,17 @@ type ControlPlaneController struct {
 	Scheme              *runtime.Scheme
 	ClientSet           *kubernetes.Clientset
 	RESTConfig          *rest.Config
+	Recorder            record.EventRecorder
+	// KeyStore holds the private keys of the cluster CAs moved out of the certificate secrets, if any.
+	KeyStore keystore.Store
 }
 
 const joinTokenFilePath = "/etc/k0s.token"
 
+const (
+	konnectivityAgentImage = "registry.k8s.io/kas-network-proxy/proxy-agent:v0.30.3"
+)
+
 var minVersionForETCDName = version.MustParse("v1.31.1+k0s.0")
 var errInitialControllerMachineNotInitialize = errors.New("initial controller machine has not completed its initialization")
 
@@ -171,6 +184,12 @@ func (c *ControlPlaneController) Reconcile(ctx context.Context, req ctrl.Request
 	}
 
 	if scope.Config.Status.Ready {
+		// The bootstrap data secret restored from a backup is owned by the config again, see util.RestoredAnnotation
+		if scope.Config.Status.DataSecretName != nil {
+			if err := util.ReadoptSecrets(ctx, c.SecretCachingClient, scope.Config, bootstrapv1.GroupVersion.WithKind("K0sControllerConfig"), *scope.Config.Status.DataSecretName); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
 		// Bootstrapdata field is ready to be consumed, skipping the generation of the bootstrap data secret
 		log.Info("Bootstrapdata already created, reconciled succesfully")
 		return ctrl.Result{}, nil
@@ -227,6 +246,7 @@ func (c *ControlPlaneController) Reconcile(ctx context.Context, req ctrl.Request
 		}
 
 		conditions.MarkFalse(config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityError, err.Error())
+		c.Recorder.Eventf(config, corev1.EventTypeWarning, "DataSecretGenerationFailed", "Failed to generate the bootstrap data: %s", err)
 		return ctrl.Result{}, err
 	}
 
@@ -258,13 +278,14 @@ func (c *ControlPlaneController) Reconcile(ctx context.Context, req ctrl.Request
 		Type: clusterv1.ClusterSecretType,
 	}
 
-	if err := c.Client.Patch(ctx, bootstrapSecret, client.Apply, &client.PatchOptions{FieldManager: "k0s-bootstrap"}); err != nil {
+	if err := util.Apply(ctx, c.Client, bootstrapSecret); err != nil {
 		log.Error(err, "Failed to patch bootstrap secret")
 		conditions.MarkFalse(config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityError, err.Error())
 		return ctrl.Result{}, err
 	}
 	conditions.MarkTrue(config, bootstrapv1.DataSecretAvailableCondition)
 	log.Info("Bootstrap secret created", "secret", bootstrapSecret.Name)
+	c.Recorder.Eventf(config, corev1.EventTypeNormal, "DataSecretCreated", "Created the bootstrap data secret %s", bootstrapSecret.Name)
 
 	// Set the status to ready
 	config.Status.Ready = true
@@ -277,9 +298,10 @@ func (c *ControlPlaneController) Reconcile(ctx context.Context, req ctrl.Request
 
 func (c *ControlPlaneController) generateBootstrapDataForController(ctx context.Context, log logr.Logger, scope *ControllerScope) ([]byte, error) {
 	var (
-		files      []cloudinit.File
-		installCmd string
-		err        error
+		files       []cloudinit.File
+		keyCommands []string
+		installCmd  string
+		err         error
 	)
 
 	currentKCPVersion, err := version.NewVersion(scope.Config.Spec.Version)
@@ -323,7 +345,7 @@ func (c *ControlPlaneController) generateBootstrapDataForController(ctx context.
 	}
 
 	if scope.machines.Oldest().Name == scope.Config.Name {
-		files, err = c.genInitialControlPlaneFiles(ctx, scope, files)
+		files, keyCommands, err = c.genInitialControlPlaneFiles(ctx, scope, files)
 		if err != nil {
 			return nil, fmt.Errorf("error generating initial control plane files: %v", err)
 		}
@@ -363,6 +385,7 @@ func (c *ControlPlaneController) generateBootstrapDataForController(ctx context.
 	commands = append(commands, "(command -v systemctl > /dev/null 2>&1 && (cp /k0s/k0sleave.service /etc/systemd/system/k0sleave.service && systemctl daemon-reload && systemctl enable k0sleave.service && systemctl start k0sleave.service) || true)")
 	commands = append(commands, "(command -v rc-service > /dev/null 2>&1 && (cp /k0s/k0sleave-openrc /etc/init.d/k0sleave && rc-update add k0sleave shutdown) || true)")
 	commands = append(commands, "(command -v service > /dev/null 2>&1 && (cp /k0s/k0sleave-sysv /etc/init.d/k0sleave && update-rc.d k0sleave defaults && service k0sleave start) || true)")
+	commands = append(commands, keyCommands...)
 	commands = append(commands, installCmd, "k0s start")
 	commands = append(commands, scope.Config.Spec.PostStartCommands...)
 	// Create the sentinel file as the last step so we know all previous _stuff_ has completed
@@ -384,27 +407,42 @@ func (c *ControlPlaneController) generateBootstrapDataForController(ctx context.
 	return ci.AsBytes()
 }
 
-func (c *ControlPlaneController) genInitialControlPlaneFiles(ctx context.Context, scope *ControllerScope, files []cloudinit.File) ([]cloudinit.File, error) {
+// genInitialControlPlaneFiles returns the files of the first controller, and the commands fetching the CA keys moved
+// out of the certificate secrets. k0s needs the CA keys on the first controller, the other controllers get them when
+// joining.
+func (c *ControlPlaneController) genInitialControlPlaneFiles(ctx context.Context, scope *ControllerScope, files []cloudinit.File) ([]cloudinit.File, []string, error) {
 	log := log.FromContext(ctx).WithValues("K0sControllerConfig cluster", scope.Cluster.Name)
 
-	certs, _, err := c.getCerts(ctx, scope)
+	certificates, err := c.lookupCerts(ctx, scope)
 	if err != nil {
 		log.Error(err, "Failed to get certs")
-		return nil, err
+		return nil, nil, err
+	}
+	for _, cert := range certificates.AsFiles() {
+		files = append(files, cloudinit.File{
+			Path:        cert.Path,
+			Permissions: "0644",
+			Content:     cert.Content,
+		})
+	}
+
+	keyCommands, err := keystore.KeyCommands(ctx, c.KeyStore, capiutil.ObjectKey(scope.Cluster), certificates)
+	if err != nil {
+		return nil, nil, err
 	}
-	files = append(files, certs...)
 
-	return files, nil
+	return files, keyCommands, nil
 }
 
 func (c *ControlPlaneController) genControlPlaneJoinFiles(ctx context.Context, scope *ControllerScope, files []cloudinit.File, firstControllerMachine *clusterv1.Machine) ([]cloudinit.File, error) {
 	log := log.FromContext(ctx).WithValues("K0sControllerConfig cluster", scope.Cluster.Name)
 
-	_, ca, err := c.getCerts(ctx, scope)
+	certificates, err := c.lookupCerts(ctx, scope)
 	if err != nil {
 		log.Error(err, "Failed to create certs")
 		return nil, err
 	}
+	ca := certificates.GetByPurpose(secret.ClusterCA)
 
 	// Create the token using the child cluster client
 	tokenID := kutil.RandomString(6)
@@ -423,6 +461,7 @@ func (c *ControlPlaneController) genControlPlaneJoinFiles(ctx context.Context, s
 		log.Error(err, "Failed to create token secret in the child cluster")
 		return nil, err
 	}
+	c.Recorder.Eventf(scope.Config, corev1.EventTypeNormal, "JoinTokenIssued", "Issued the controller join token %s in cluster %s", tokenID, scope.Cluster.Name)
 
 	host, err := c.detectJoinHost(ctx, scope, firstControllerMachine)
 	if err != nil {
@@ -442,21 +481,28 @@ func (c *ControlPlaneController) genControlPlaneJoinFiles(ctx context.Context, s
 }
 
 func (c *ControlPlaneController) genTunnelingFiles(ctx context.Context, scope *ControllerScope) ([]cloudinit.File, error) {
-	secretName := scope.Cluster.Name + "-frp-token"
+	switch scope.Config.Spec.Tunneling.Provider {
+	case bootstrapv1.TunnelingProviderKonnectivity:
+		return c.genKonnectivityTunnelingFiles(ctx, scope)
+	case bootstrapv1.TunnelingProviderWireGuard:
+		return c.genWireGuardTunnelingFiles(ctx, scope)
+	}
+
+	secretName, secretKey := scope.Cluster.Name+"-frp-token", "value"
+	// With a shared tunneling server, the control plane controller copies the server token to the cluster secret.
+	if ref := scope.Config.Spec.Tunneling.TokenSecretRef; ref != nil && scope.Config.Spec.Tunneling.ServerRef == nil {
+		secretName, secretKey = ref.Name, ref.Key
+	}
 	frpSecret := corev1.Secret{}
 	err := c.Client.Get(ctx, client.ObjectKey{Namespace: scope.Cluster.Namespace, Name: secretName}, &frpSecret)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get frp secret: %w", err)
 	}
-	frpToken := string(frpSecret.Data["value"])
+	frpToken := string(frpSecret.Data[secretKey])
 
-	localIP := "10.96.0.1"
-	if scope.Cluster.Spec.ClusterNetwork != nil && scope.Cluster.Spec.ClusterNetwork.Services != nil {
-		kubeSvcIP, err := constants.GetAPIServerVirtualIP(scope.Cluster.Spec.ClusterNetwork.Services.String())
-		if err != nil {
-			return nil, err
-		}
-		localIP = kubeSvcIP.String()
+	localIP, err := util.KubernetesServiceIP(scope.Cluster)
+	if err != nil {
+		return nil, err
 	}
 
 	var modeConfig string
@@ -466,12 +512,149 @@ func (c *ControlPlaneController) genTunnelingFiles(ctx context.Context, scope *C
     custom_domains = %s
     multiplexer = httpconnect
 `, scope.Cluster.Spec.ControlPlaneEndpoint.Host)
+	} else if scope.Config.Spec.Tunneling.SNIHostname != "" {
+		// A shared tunneling server routes the connections by SNI hostname without terminating TLS.
+		modeConfig = fmt.Sprintf(`
+    type = https
+    custom_domains = %s
+`, scope.Config.Spec.Tunneling.SNIHostname)
+	} else if scope.Config.Spec.Tunneling.ServerRef != nil {
+		// A shared tunneling server listens on the port allocated to the cluster.
+		modeConfig = fmt.Sprintf(`
+    remote_port = %d
+`, scope.Config.Spec.Tunneling.TunnelingNodePort)
 	} else {
 		modeConfig = `
     remote_port = 6443
 `
 	}
 
+	// The kube-apiserver does not accept the PROXY protocol, the connections go through the proxy in front of it
+	localPort := 443
+	if pp := scope.Config.Spec.Tunneling.ProxyProtocol; pp != nil {
+		version := pp.Version
+		if version == "" {
+			version = "v2"
+		}
+		localIP, localPort = pp.Address, int(pp.Port)
+		modeConfig += fmt.Sprintf(`    proxy_protocol_version = %s
+`, version)
+	}
+
+	var poolConfig, proxyOptions string
+	if limits := scope.Config.Spec.Tunneling.Limits; limits != nil {
+		if limits.PoolCount > 0 {
+			poolConfig = fmt.Sprintf(`
+    pool_count = %d`, limits.PoolCount)
+		}
+		if limits.BandwidthLimit != "" {
+			proxyOptions += fmt.Sprintf(`    bandwidth_limit = %s
+`, limits.BandwidthLimit)
+		}
+		if limits.Compression {
+			proxyOptions += `    use_compression = true
+`
+		}
+	}
+	modeConfig += proxyOptions
+
+	// A shared tunneling server serves the proxies of several clusters. frps prefixes the proxy names with the
+	// user, so the clusters don't register duplicated proxies. The load balancing groups aren't prefixed by frps,
+	// so they are prefixed with the user as well.
+	var userConfig, groupPrefix string
+	if scope.Config.Spec.Tunneling.ServerRef != nil {
+		user := fmt.Sprintf("%s-%s", scope.Cluster.Namespace, scope.Cluster.Name)
+		userConfig = fmt.Sprintf(`
+    user = %s`, user)
+		groupPrefix = user + "-"
+	}
+
+	// With several replicas, each replica registers its own proxy in a load balanced group. The proxy names must
+	// be unique, so they are suffixed with the name of the pod.
+	replicas := scope.Config.Spec.Tunneling.ClientReplicas
+	if replicas < 1 {
+		replicas = 1
+	}
+	var proxyNameSuffix string
+	if replicas > 1 {
+		proxyNameSuffix = "-{{ .Envs.POD_NAME }}"
+		modeConfig += fmt.Sprintf(`    group = %skube-apiserver
+    group_key = %s
+    health_check_type = tcp
+    health_check_timeout_s = 3
+    health_check_max_failed = 3
+    health_check_interval_s = 10
+`, groupPrefix, frpToken)
+	}
+
+	var extraProxies string
+	if scope.Config.Spec.Tunneling.ServerRef == nil {
+		for _, p := range scope.Config.Spec.Tunneling.ExtraPorts {
+			address := p.Address
+			if address == "" {
+				address = scope.Cluster.Spec.ControlPlaneEndpoint.Host
+			}
+			extraProxies += fmt.Sprintf(`
+    [%s%s]
+    type = tcp
+    local_ip = %s
+    local_port = %d
+    remote_port = %d
+%s`, p.Name, proxyNameSuffix, address, p.Port, p.NodePort, proxyOptions)
+			if replicas > 1 {
+				extraProxies += fmt.Sprintf(`    group = %s
+    group_key = %s
+`, p.Name, frpToken)
+			}
+		}
+	}
+
+	var tlsConfig, tlsResources, tlsVolumeMounts, tlsVolumes string
+	if tlsSpec := scope.Config.Spec.Tunneling.TLS; tlsSpec != nil && tlsSpec.Enabled {
+		tlsSecretName := tlsSpec.ClientCertSecretName
+		if tlsSecretName == "" {
+			tlsSecretName = scope.Cluster.Name + "-frpc-tls"
+		}
+		tlsSecret := corev1.Secret{}
+		err := c.Client.Get(ctx, client.ObjectKey{Namespace: scope.Cluster.Namespace, Name: tlsSecretName}, &tlsSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get frpc tls secret: %w", err)
+		}
+
+		tlsConfig = `
+    tls_enable = true
+    tls_cert_file = /etc/frp/tls/tls.crt
+    tls_key_file = /etc/frp/tls/tls.key`
+		// With a CA in the secret the client verifies the certificate of the server.
+		if len(tlsSecret.Data["ca.crt"]) > 0 {
+			tlsConfig += fmt.Sprintf(`
+    tls_trusted_ca_file = /etc/frp/tls/ca.crt
+    tls_server_name = %s`, util.SANHost(scope.Config.Spec.Tunneling.ServerAddress))
+		}
+
+		tlsResources = `
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: frpc-tls
+  namespace: kube-system
+data:`
+		for _, key := range []string{"tls.crt", "tls.key", "ca.crt"} {
+			if len(tlsSecret.Data[key]) > 0 {
+				tlsResources += fmt.Sprintf("\n  %s: %s", key, base64.StdEncoding.EncodeToString(tlsSecret.Data[key]))
+			}
+		}
+		tlsVolumeMounts = `
+            - name: frpc-tls
+              mountPath: /etc/frp/tls
+              readOnly: true`
+		tlsVolumes = `
+        - name: frpc-tls
+          secret:
+            secretName: frpc-tls`
+	}
+
 	tunnelingResources := `
 ---
 apiVersion: v1
@@ -485,13 +668,13 @@ data:
     authentication_method = token
     server_addr = %s
     server_port = %d
-    token = %s
+    token = %s%s
 
-    [kube-apiserver]
+    [kube-apiserver%s]
     type = tcp
     local_ip = %s
-    local_port = 443
-    %s
+    local_port = %d
+    %s%s
 ---
 apiVersion: apps/v1
 kind: Deployment
@@ -499,7 +682,7 @@ metadata:
   name: frpc
   namespace: kube-system
 spec:
-  replicas: 1
+  replicas: %d
   selector:
     matchLabels:
       app: frpc
@@ -508,59 +691,224 @@ spec:
       labels:
         app: frpc
     spec:
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+            - weight: 100
+              podAffinityTerm:
+                topologyKey: kubernetes.io/hostname
+                labelSelector:
+                  matchLabels:
+                    app: frpc
       containers:
         - name: frpc
-          image: snowdreamtech/frpc:0.51.3
-          imagePullPolicy: "IfNotPresent"
+          image: %s
+          imagePullPolicy: %q
+          env:
+            - name: POD_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.name
           volumeMounts:
             - name: frpc-config
               mountPath: /etc/frp/frpc.ini
-              subPath: frpc.ini
+              subPath: frpc.ini%s
       volumes:
         - name: frpc-config
           configMap:
             name: frpc-config
             items:
               - key: frpc.ini
-                path: frpc.ini
+                path: frpc.ini%s
+%s
+`
+	return []cloudinit.File{{
+		Path:        "/var/lib/k0s/manifests/k0smotron-tunneling/manifest.yaml",
+		Permissions: "0644",
+		Content:     fmt.Sprintf(tunnelingResources, util.SANHost(scope.Config.Spec.Tunneling.ServerAddress), scope.Config.Spec.Tunneling.ServerNodePort, frpToken, userConfig+poolConfig+tlsConfig, proxyNameSuffix, localIP, localPort, modeConfig, extraProxies, replicas, scope.Config.Spec.Tunneling.GetFRPClientImage(), scope.Config.Spec.Tunneling.GetImagePullPolicy(), tlsVolumeMounts, tlsVolumes, tlsResources),
+	}}, nil
+}
+
+// genKonnectivityTunnelingFiles generates the manifest of the konnectivity agents connecting the child cluster to
+// the konnectivity server running in the management cluster.
+func (c *ControlPlaneController) genKonnectivityTunnelingFiles(ctx context.Context, scope *ControllerScope) ([]cloudinit.File, error) {
+	secretName := scope.Cluster.Name + "-konnectivity-tunnel"
+	certsSecret := corev1.Secret{}
+	err := c.Client.Get(ctx, client.ObjectKey{Namespace: scope.Cluster.Namespace, Name: secretName}, &certsSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get konnectivity tunnel secret: %w", err)
+	}
+
+	tunnelingResources := `
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: k0smotron-konnectivity-agent
+  namespace: kube-system
+data:
+  ca.crt: %s
+  agent.crt: %s
+  agent.key: %s
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: k0smotron-konnectivity-agent
+  namespace: kube-system
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: k0smotron-konnectivity-agent
+  template:
+    metadata:
+      labels:
+        app: k0smotron-konnectivity-agent
+    spec:
+      containers:
+        - name: konnectivity-agent
+          image: %s
+          imagePullPolicy: %q
+          command:
+            - /proxy-agent
+          args:
+            - --proxy-server-host=%s
+            - --proxy-server-port=%d
+            - --ca-cert=/etc/konnectivity/ca.crt
+            - --agent-cert=/etc/konnectivity/agent.crt
+            - --agent-key=/etc/konnectivity/agent.key
+          volumeMounts:
+            - name: konnectivity-certs
+              mountPath: /etc/konnectivity
+              readOnly: true
+      volumes:
+        - name: konnectivity-certs
+          secret:
+            secretName: k0smotron-konnectivity-agent
+
+`
+	encode := func(key string) string {
+		return base64.StdEncoding.EncodeToString(certsSecret.Data[key])
+	}
+	return []cloudinit.File{{
+		Path:        "/var/lib/k0s/manifests/k0smotron-tunneling/manifest.yaml",
+		Permissions: "0644",
+		Content:     fmt.Sprintf(tunnelingResources, encode("ca.crt"), encode("agent.crt"), encode("agent.key"), scope.Config.Spec.Tunneling.GetImage(konnectivityAgentImage), scope.Config.Spec.Tunneling.GetImagePullPolicy(), util.SANHost(scope.Config.Spec.Tunneling.ServerAddress), scope.Config.Spec.Tunneling.ServerNodePort),
+	}}, nil
+}
+
+// genWireGuardTunnelingFiles generates the manifest of the WireGuard peer of the child cluster, which forwards the
+// connections coming through the tunnel to the kubernetes service.
+func (c *ControlPlaneController) genWireGuardTunnelingFiles(ctx context.Context, scope *ControllerScope) ([]cloudinit.File, error) {
+	secretName := scope.Cluster.Name + "-wireguard-keys"
+	keysSecret := corev1.Secret{}
+	err := c.Client.Get(ctx, client.ObjectKey{Namespace: scope.Cluster.Namespace, Name: secretName}, &keysSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wireguard keys secret: %w", err)
+	}
+
+	localIP, err := util.KubernetesServiceIP(scope.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	tunneling := &scope.Config.Spec.Tunneling
+	serverAddr, clientAddr, err := tunneling.GetWireGuardPeerAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	wgConfig := fmt.Sprintf(`[Interface]
+Address = %s
+PrivateKey = %s
+
+[Peer]
+PublicKey = %s
+PresharedKey = %s
+Endpoint = %s
+AllowedIPs = %s
+PersistentKeepalive = 25
+`, clientAddr, keysSecret.Data["client.key"], keysSecret.Data["server.pub"], keysSecret.Data["preshared.key"],
+		net.JoinHostPort(util.SANHost(tunneling.ServerAddress), strconv.Itoa(int(tunneling.ServerNodePort))),
+		netip.PrefixFrom(serverAddr.Addr(), serverAddr.Addr().BitLen()))
+
+	tunnelingResources := `
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: k0smotron-wireguard
+  namespace: kube-system
+data:
+  wg0.conf: %s
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: k0smotron-wireguard
+  namespace: kube-system
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: k0smotron-wireguard
+  template:
+    metadata:
+      labels:
+        app: k0smotron-wireguard
+    spec:
+      containers:
+        - name: wireguard
+          image: %s
+          imagePullPolicy: %q
+          securityContext:
+            capabilities:
+              add:
+                - NET_ADMIN
+          volumeMounts:
+            - name: wireguard-config
+              mountPath: /config/wg_confs
+              readOnly: true
+        - name: forwarder
+          image: %s
+          imagePullPolicy: %q
+          args:
+            - TCP-LISTEN:6443,fork,reuseaddr
+            - TCP:%s
+      volumes:
+        - name: wireguard-config
+          secret:
+            secretName: k0smotron-wireguard
 
 `
 	return []cloudinit.File{{
 		Path:        "/var/lib/k0s/manifests/k0smotron-tunneling/manifest.yaml",
 		Permissions: "0644",
-		Content:     fmt.Sprintf(tunnelingResources, scope.Config.Spec.Tunneling.ServerAddress, scope.Config.Spec.Tunneling.ServerNodePort, frpToken, localIP, modeConfig),
+		Content:     fmt.Sprintf(tunnelingResources, base64.StdEncoding.EncodeToString([]byte(wgConfig)), tunneling.GetWireGuardImage(), tunneling.GetImagePullPolicy(), tunneling.GetForwarderImage(), tunneling.GetImagePullPolicy(), net.JoinHostPort(localIP, "443")),
 	}}, nil
 }
 
-func (c *ControlPlaneController) getCerts(ctx context.Context, scope *ControllerScope) ([]cloudinit.File, *secret.Certificate, error) {
-	var files []cloudinit.File
+// lookupCerts looks up the certificates of the cluster. The private keys moved to the key store are not filled in.
+func (c *ControlPlaneController) lookupCerts(ctx context.Context, scope *ControllerScope) (secret.Certificates, error) {
 	certificates := secret.NewCertificatesForInitialControlPlane(&kubeadmbootstrapv1.ClusterConfiguration{
 		CertificatesDir: "/var/lib/k0s/pki",
 	})
 
 	s := &corev1.Secret{}
-	err := c.SecretCachingClient.Get(ctx, client.ObjectKey{Namespace: scope.Cluster.Namespace, Name: secret.Name(scope.Cluster.Name, secret.Kubeconfig)}, s)
+	err := c.Client.Get(ctx, client.ObjectKey{Namespace: scope.Cluster.Namespace, Name: secret.Name(scope.Cluster.Name, secret.Kubeconfig)}, s)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			return nil, nil, fmt.Errorf("cluster's kubeconfig secret not found, waiting for secret")
+			return nil, fmt.Errorf("cluster's kubeconfig secret not found, waiting for secret")
 		}
-		return nil, nil, err
+		return nil, err
 	}
 
 	err = certificates.LookupCached(ctx, c.SecretCachingClient, c.Client, capiutil.ObjectKey(scope.Cluster))
 	if err != nil {
-		return nil, nil, err
-	}
-	ca := certificates.GetByPurpose(secret.ClusterCA)
-	for _, cert := range certificates.AsFiles() {
-		files = append(files, cloudinit.File{
-			Path:        cert.Path,
-			Permissions: "0644",
-			Content:     cert.Content,
-		})
+		return nil, err
 	}
-
-	return files, ca, nil
+	return certificates, nil
 }
 
 func createTokenSecret(tokenID, tokenSecret string) *corev1.Secret {
@@ -589,9 +937,15 @@ func createTokenSecret(tokenID, tokenSecret string) *corev1.Secret {
 }
 
 func (c *ControlPlaneController) SetupWithManager(mgr ctrl.Manager) error {
+	metrics.RegisterObjects("K0sControllerConfig", mgr.GetClient(), func() client.ObjectList { return &bootstrapv1.K0sControllerConfigList{} }, nil)
+
+	if err := health.AddChecks(mgr, "k0scontrollerconfig", &bootstrapv1.K0sControllerConfig{}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&bootstrapv1.K0sControllerConfig{}).
-		Complete(c)
+		Complete(metrics.Instrument("k0scontrollerconfig", mgr.GetClient(), func() client.Object { return &bootstrapv1.K0sControllerConfig{} }, nil, tracing.Instrument("k0scontrollerconfig", health.Instrument("k0scontrollerconfig", c))))
 }
 
 func createCPInstallCmd(scope *ControllerScope) string {
@@ -644,7 +998,7 @@ func (c *ControlPlaneController) detectJoinHost(ctx context.Context, scope *Cont
 	if found && k0sAPIPort > 0 {
 		port = strconv.Itoa(int(k0sAPIPort))
 	}
-	host := fmt.Sprintf("https://%s:%s", scope.Cluster.Spec.ControlPlaneEndpoint.Host, port)
+	host := util.EndpointURL(scope.Cluster.Spec.ControlPlaneEndpoint.Host, port)
 
 	_, err = httpClient.Get(fmt.Sprintf("%s/v1beta1/ca", host))
 	if err == nil {
@@ -656,7 +1010,7 @@ func (c *ControlPlaneController) detectJoinHost(ctx context.Context, scope *Cont
 		return "", fmt.Errorf("failed to get first controller IP: %w", err)
 	}
 
-	return fmt.Sprintf("https://%s:%s", firstControllerIP, port), nil
+	return util.EndpointURL(firstControllerIP, port), nil
 }
 
 func (c *ControlPlaneController) findFirstControllerIP(ctx context.Context, firstControllerMachine *clusterv1.Machine) (string, error) {
@@ -23,12 +23,14 @@ import (
 
 	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
 	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	kubeadmbootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
@@ -104,7 +106,8 @@ func TestReconcileNoK0sControllerConfig(t *testing.T) {
 	}(cluster, ns)
 
 	r := &ControlPlaneController{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	nonExistingK0sControllerConfig := client.ObjectKey{
@@ -141,7 +144,8 @@ func TestReconcileReturnErrorWhenControllerConfigOwnerIsNotFound(t *testing.T) {
 	}(k0sControllerConfig, ns)
 
 	r := &ControlPlaneController{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: util.ObjectKey(k0sControllerConfig)})
@@ -167,7 +171,8 @@ func TestReconcileReturnErrorWhenControllerConfigOwnerRefIsMissing(t *testing.T)
 	}(k0sControllerConfig, ns)
 
 	r := &ControlPlaneController{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: util.ObjectKey(k0sControllerConfig)})
@@ -212,7 +217,8 @@ func TestReconcileReturnErrorWhenClusterControllerConfigBelongsIsNotFound(t *tes
 	}(k0sControllerConfig, machineForControllerConfig, ns)
 
 	r := &ControlPlaneController{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	// Cluster is not created yet.
@@ -270,7 +276,8 @@ func TestReconcileControllerConfigPausedCluster(t *testing.T) {
 	}(k0sControllerConfig, cluster, machineForControllerConfig, ns)
 
 	r := &ControlPlaneController{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: util.ObjectKey(k0sControllerConfig)})
@@ -326,7 +333,8 @@ func TestReconcilePausedK0sControllerConfig(t *testing.T) {
 	}(k0sControllerConfig, cluster, machineForControllerConfig, ns)
 
 	r := &ControlPlaneController{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: util.ObjectKey(k0sControllerConfig)})
@@ -383,7 +391,8 @@ func TestReconcileControllerBootstrapDataAlreadyCreated(t *testing.T) {
 	}(k0sControllerConfig, cluster, machineForControllerConfig, ns)
 
 	r := &ControlPlaneController{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 	require.EventuallyWithT(t, func(c *assert.CollectT) {
 		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: util.ObjectKey(k0sControllerConfig)})
@@ -445,7 +454,8 @@ func TestReconcileControllerConfigControlPlaneIsZero(t *testing.T) {
 	}(k0sControllerConfig, cluster, machineForControllerConfig, ns)
 
 	r := &ControlPlaneController{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 	require.EventuallyWithT(t, func(c *assert.CollectT) {
 		// Cluster.Spec.ControlPlaneEndpoint is not set by infra provider
@@ -516,6 +526,7 @@ func TestReconcileControllerConfigGenerateBootstrapData(t *testing.T) {
 	}(k0sControllerConfig, cluster, machineForControllerConfig, ns)
 
 	r := &ControlPlaneController{
+		Recorder:            record.NewFakeRecorder(100),
 		Client:              testEnv,
 		SecretCachingClient: secretCachingClient,
 	}
@@ -566,3 +577,340 @@ func TestReconcileControllerConfigGenerateBootstrapData(t *testing.T) {
 		assert.True(c, conditions.IsTrue(updatedK0sControllerConfig, bootstrapv1.DataSecretAvailableCondition))
 	}, 20*time.Second, 100*time.Millisecond)
 }
+
+func TestGenTunnelingFilesWithClientReplicas(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-gen-tunneling-files-client-replicas")
+	require.NoError(t, err)
+
+	cluster := newCluster(ns.Name)
+	frpToken := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.Name + "-frp-token",
+			Namespace: ns.Name,
+		},
+		Data: map[string][]byte{
+			"value": []byte("token"),
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, frpToken))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(frpToken, ns)
+
+	scope := &ControllerScope{
+		Cluster: cluster,
+		Config: &bootstrapv1.K0sControllerConfig{
+			Spec: bootstrapv1.K0sControllerConfigSpec{
+				K0sConfigSpec: &bootstrapv1.K0sConfigSpec{
+					Tunneling: bootstrapv1.TunnelingSpec{
+						Enabled:        true,
+						ServerAddress:  "1.2.3.4",
+						ServerNodePort: 31700,
+						Mode:           "tunnel",
+						ClientReplicas: 3,
+					},
+				},
+			},
+		},
+	}
+
+	r := &ControlPlaneController{
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
+	}
+	var files []cloudinit.File
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		files, err = r.genTunnelingFiles(ctx, scope)
+		assert.NoError(c, err)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	require.Len(t, files, 1)
+	require.Contains(t, files[0].Content, "[kube-apiserver-{{ .Envs.POD_NAME }}]")
+	require.Contains(t, files[0].Content, "group = kube-apiserver\n    group_key = token\n")
+	require.Contains(t, files[0].Content, "health_check_type = tcp")
+	require.Contains(t, files[0].Content, "replicas: 3")
+}
+
+func TestGenTunnelingFilesWithProxyProtocol(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-gen-tunneling-files-proxy-protocol")
+	require.NoError(t, err)
+
+	cluster := newCluster(ns.Name)
+	frpToken := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.Name + "-frp-token",
+			Namespace: ns.Name,
+		},
+		Data: map[string][]byte{
+			"value": []byte("token"),
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, frpToken))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(frpToken, ns)
+
+	scope := &ControllerScope{
+		Cluster: cluster,
+		Config: &bootstrapv1.K0sControllerConfig{
+			Spec: bootstrapv1.K0sControllerConfigSpec{
+				K0sConfigSpec: &bootstrapv1.K0sConfigSpec{
+					Tunneling: bootstrapv1.TunnelingSpec{
+						Enabled:        true,
+						ServerAddress:  "1.2.3.4",
+						ServerNodePort: 31700,
+						Mode:           "tunnel",
+						ProxyProtocol: &bootstrapv1.TunnelingProxyProtocolSpec{
+							Address: "haproxy.kube-system.svc",
+							Port:    8443,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r := &ControlPlaneController{
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
+	}
+	var files []cloudinit.File
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		files, err = r.genTunnelingFiles(ctx, scope)
+		assert.NoError(c, err)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	require.Len(t, files, 1)
+	// The connections are forwarded to the proxy accepting the PROXY protocol instead of the API server
+	require.Contains(t, files[0].Content, "local_ip = haproxy.kube-system.svc\n    local_port = 8443\n")
+	require.Contains(t, files[0].Content, "remote_port = 6443\n    proxy_protocol_version = v2\n")
+}
+
+func TestGenTunnelingFilesWithExtraPorts(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-gen-tunneling-files-extra-ports")
+	require.NoError(t, err)
+
+	cluster := newCluster(ns.Name)
+	cluster.Spec.ControlPlaneEndpoint.Host = "10.0.0.10"
+	frpToken := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.Name + "-frp-token",
+			Namespace: ns.Name,
+		},
+		Data: map[string][]byte{
+			"value": []byte("token"),
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, frpToken))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(frpToken, ns)
+
+	scope := &ControllerScope{
+		Cluster: cluster,
+		Config: &bootstrapv1.K0sControllerConfig{
+			Spec: bootstrapv1.K0sControllerConfigSpec{
+				K0sConfigSpec: &bootstrapv1.K0sConfigSpec{
+					Tunneling: bootstrapv1.TunnelingSpec{
+						Enabled:        true,
+						ServerAddress:  "1.2.3.4",
+						ServerNodePort: 31700,
+						Mode:           "tunnel",
+						ExtraPorts: []bootstrapv1.TunnelingPort{
+							{Name: "konnectivity", Port: 8132, NodePort: 31132},
+							{Name: "metrics", Address: "10.0.0.20", Port: 9100, NodePort: 31100},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r := &ControlPlaneController{
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
+	}
+	var files []cloudinit.File
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		files, err = r.genTunnelingFiles(ctx, scope)
+		assert.NoError(c, err)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	require.Len(t, files, 1)
+	require.Contains(t, files[0].Content, "[konnectivity]\n    type = tcp\n    local_ip = 10.0.0.10\n    local_port = 8132\n    remote_port = 31132\n")
+	require.Contains(t, files[0].Content, "[metrics]\n    type = tcp\n    local_ip = 10.0.0.20\n    local_port = 9100\n    remote_port = 31100\n")
+}
+
+func TestGenTunnelingFilesWithSNIHostname(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-gen-tunneling-files-sni-hostname")
+	require.NoError(t, err)
+
+	cluster := newCluster(ns.Name)
+	frpToken := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.Name + "-frp-token",
+			Namespace: ns.Name,
+		},
+		Data: map[string][]byte{
+			"value": []byte("token"),
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, frpToken))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(frpToken, ns)
+
+	scope := &ControllerScope{
+		Cluster: cluster,
+		Config: &bootstrapv1.K0sControllerConfig{
+			Spec: bootstrapv1.K0sControllerConfigSpec{
+				K0sConfigSpec: &bootstrapv1.K0sConfigSpec{
+					Tunneling: bootstrapv1.TunnelingSpec{
+						Enabled:           true,
+						ServerAddress:     "1.2.3.4",
+						ServerNodePort:    31700,
+						TunnelingNodePort: 31444,
+						Mode:              "tunnel",
+						ServerRef:         &bootstrapv1.TunnelServerReference{Name: "shared"},
+						SNIHostname:       "test-default.tunnel.example.com",
+					},
+				},
+			},
+		},
+	}
+
+	r := &ControlPlaneController{
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
+	}
+	var files []cloudinit.File
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		files, err = r.genTunnelingFiles(ctx, scope)
+		assert.NoError(c, err)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	require.Len(t, files, 1)
+	require.Contains(t, files[0].Content, "type = https\n    custom_domains = test-default.tunnel.example.com\n")
+	require.NotContains(t, files[0].Content, "remote_port")
+}
+
+func TestGenTunnelingFilesWithSharedServer(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-gen-tunneling-files-shared-server")
+	require.NoError(t, err)
+
+	cluster := newCluster(ns.Name)
+	frpToken := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.Name + "-frp-token",
+			Namespace: ns.Name,
+		},
+		Data: map[string][]byte{
+			"value": []byte("token"),
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, frpToken))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(frpToken, ns)
+
+	scope := &ControllerScope{
+		Cluster: cluster,
+		Config: &bootstrapv1.K0sControllerConfig{
+			Spec: bootstrapv1.K0sControllerConfigSpec{
+				K0sConfigSpec: &bootstrapv1.K0sConfigSpec{
+					Tunneling: bootstrapv1.TunnelingSpec{
+						Enabled:           true,
+						ServerAddress:     "1.2.3.4",
+						ServerNodePort:    31700,
+						TunnelingNodePort: 31444,
+						Mode:              "tunnel",
+						ServerRef:         &bootstrapv1.TunnelServerReference{Name: "shared"},
+						ClientReplicas:    2,
+					},
+				},
+			},
+		},
+	}
+
+	r := &ControlPlaneController{
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
+	}
+	var files []cloudinit.File
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		files, err = r.genTunnelingFiles(ctx, scope)
+		assert.NoError(c, err)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	user := ns.Name + "-" + cluster.Name
+	require.Len(t, files, 1)
+	require.Contains(t, files[0].Content, "    user = "+user+"\n")
+	require.Contains(t, files[0].Content, "[kube-apiserver-{{ .Envs.POD_NAME }}]")
+	require.Contains(t, files[0].Content, "group = "+user+"-kube-apiserver\n")
+	require.Contains(t, files[0].Content, "remote_port = 31444\n")
+}
+
+func TestGenTunnelingFilesWithLimits(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-gen-tunneling-files-limits")
+	require.NoError(t, err)
+
+	cluster := newCluster(ns.Name)
+	frpToken := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.Name + "-frp-token",
+			Namespace: ns.Name,
+		},
+		Data: map[string][]byte{
+			"value": []byte("token"),
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, frpToken))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(frpToken, ns)
+
+	scope := &ControllerScope{
+		Cluster: cluster,
+		Config: &bootstrapv1.K0sControllerConfig{
+			Spec: bootstrapv1.K0sControllerConfigSpec{
+				K0sConfigSpec: &bootstrapv1.K0sConfigSpec{
+					Tunneling: bootstrapv1.TunnelingSpec{
+						Enabled:        true,
+						ServerAddress:  "1.2.3.4",
+						ServerNodePort: 31700,
+						Mode:           "tunnel",
+						Limits: &bootstrapv1.TunnelingLimitsSpec{
+							BandwidthLimit: "1MB",
+							PoolCount:      5,
+							Compression:    true,
+						},
+						ExtraPorts: []bootstrapv1.TunnelingPort{
+							{Name: "konnectivity", Address: "10.0.0.10", Port: 8132, NodePort: 31132},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	r := &ControlPlaneController{
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
+	}
+	var files []cloudinit.File
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		files, err = r.genTunnelingFiles(ctx, scope)
+		assert.NoError(c, err)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	require.Len(t, files, 1)
+	require.Contains(t, files[0].Content, "token = token\n    pool_count = 5\n")
+	require.Contains(t, files[0].Content, "remote_port = 6443\n    bandwidth_limit = 1MB\n    use_compression = true\n")
+	require.Contains(t, files[0].Content, "remote_port = 31132\n    bandwidth_limit = 1MB\n    use_compression = true\n")
+}
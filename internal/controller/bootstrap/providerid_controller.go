@@ -16,7 +16,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
 	k0smoutil "github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 )
 
 type ProviderIDController struct {
@@ -49,6 +53,19 @@ func (p *ProviderIDController) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
+	// Skip the machines whose bootstrap config is out of the objects watched by this instance, see --watch-filter
+	configKey := client.ObjectKey{Namespace: machine.Namespace, Name: machine.Spec.Bootstrap.ConfigRef.Name}
+	var config client.Object = &bootstrapv1.K0sWorkerConfig{}
+	if machine.Spec.Bootstrap.ConfigRef.Kind == "K0sControllerConfig" {
+		config = &bootstrapv1.K0sControllerConfig{}
+	}
+	if err := p.Get(ctx, configKey, config); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("can't get bootstrap config %s: %w", configKey, err)
+	}
+
 	if machine.Spec.ProviderID == nil || *machine.Spec.ProviderID == "" {
 		log.Info("waiting for providerID for the machine " + machine.Name)
 		return ctrl.Result{RequeueAfter: time.Second * 10}, nil
@@ -65,7 +82,7 @@ func (p *ProviderIDController) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	nodes, err := childClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("%s=%s", machineNameNodeLabel, machine.GetName()),
+		LabelSelector: fmt.Sprintf("%s=%s", k0smoutil.MachineNameNodeLabel, machine.GetName()),
 	})
 	if err != nil || len(nodes.Items) == 0 {
 		log.Info("waiting for node to be available for machine " + machine.Name)
@@ -73,6 +90,12 @@ func (p *ProviderIDController) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	node := nodes.Items[0]
+	if node.Spec.ProviderID != "" && node.Spec.ProviderID != *machine.Spec.ProviderID {
+		// The provider ID of a node is immutable, CAPI cannot match the node with the machine
+		log.Error(fmt.Errorf("node '%s' registered with providerID %s", node.Name, node.Spec.ProviderID),
+			"Node providerID does not match the machine providerID, make the kubelet register with the machine providerID", "machineProviderID", *machine.Spec.ProviderID)
+		return ctrl.Result{}, nil
+	}
 	if node.Spec.ProviderID == "" {
 		node.Spec.ProviderID = *machine.Spec.ProviderID
 		err = retry.OnError(retry.DefaultBackoff, func(err error) bool {
@@ -100,7 +123,11 @@ func (p *ProviderIDController) SetupWithManager(mgr ctrl.Manager) error {
 		return nil
 	}
 
+	if err := health.AddChecks(mgr, "providerid", &clusterv1.Machine{}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1.Machine{}).
-		Complete(p)
+		Complete(metrics.Instrument("providerid", mgr.GetClient(), func() client.Object { return &clusterv1.Machine{} }, nil, tracing.Instrument("providerid", health.Instrument("providerid", p))))
 }
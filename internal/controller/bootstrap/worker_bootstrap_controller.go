@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	bsutil "sigs.k8s.io/cluster-api/bootstrap/util"
@@ -48,13 +50,14 @@ import (
 	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
 	"github.com/k0sproject/k0smotron/internal/cloudinit"
 	"github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 	kutil "github.com/k0sproject/k0smotron/internal/util"
 )
 
 const (
 	defaultK0sSuffix = "k0s.0"
-
-	machineNameNodeLabel = "k0smotron.io/machine-name"
 )
 
 type Controller struct {
@@ -63,6 +66,7 @@ type Controller struct {
 	Scheme              *runtime.Scheme
 	ClientSet           *kubernetes.Clientset
 	RESTConfig          *rest.Config
+	Recorder            record.EventRecorder
 	// workloadClusterClient is used during testing to inject a fake client
 	workloadClusterClient client.Client
 }
@@ -152,6 +156,12 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.
 	}
 
 	if config.Status.Ready {
+		// The bootstrap data secret restored from a backup is owned by the config again, see util.RestoredAnnotation
+		if config.Status.DataSecretName != nil {
+			if err := util.ReadoptSecrets(ctx, r.SecretCachingClient, config, bootstrapv1.GroupVersion.WithKind("K0sWorkerConfig"), *config.Status.DataSecretName); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
 		// Bootstrapdata field is ready to be consumed, skipping the generation of the bootstrap data secret
 		log.Info("Bootstrapdata already created, reconciled succesfully")
 		return ctrl.Result{}, nil
@@ -197,19 +207,21 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.
 	bootstrapData, err := r.generateBootstrapDataForWorker(ctx, log, scope)
 	if err != nil {
 		conditions.MarkFalse(config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityError, err.Error())
+		r.Recorder.Eventf(config, corev1.EventTypeWarning, "DataSecretGenerationFailed", "Failed to generate the bootstrap data: %s", err)
 		return ctrl.Result{}, err
 	}
 
 	// Create the secret containing the bootstrap data
 	bootstrapSecret := createBootstrapSecret(scope, bootstrapData)
 
-	if err := r.Client.Patch(ctx, bootstrapSecret, client.Apply, &client.PatchOptions{FieldManager: "k0s-bootstrap"}); err != nil {
+	if err := util.Apply(ctx, r.Client, bootstrapSecret); err != nil {
 		log.Error(err, "Failed to patch bootstrap secret")
 		conditions.MarkFalse(config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityError, err.Error())
 		return ctrl.Result{}, err
 	}
 	conditions.MarkTrue(config, bootstrapv1.DataSecretAvailableCondition)
 	log.Info("Bootstrap secret created", "secret", bootstrapSecret.Name)
+	r.Recorder.Eventf(config, corev1.EventTypeNormal, "DataSecretCreated", "Created the bootstrap data secret %s", bootstrapSecret.Name)
 
 	// Set the status to ready
 	scope.Config.Status.Ready = true
@@ -308,6 +320,7 @@ func (r *Controller) getK0sToken(ctx context.Context, scope *Scope) (string, err
 	}); err != nil {
 		return "", fmt.Errorf("failed to create token secret: %w", err)
 	}
+	r.Recorder.Eventf(scope.Config, corev1.EventTypeNormal, "JoinTokenIssued", "Issued the worker join token %s in cluster %s", tokenID, scope.Cluster.Name)
 
 	certificates := secret.NewCertificatesForWorker("")
 	if err := certificates.LookupCached(ctx, scope.secretCachingClient, scope.client, capiutil.ObjectKey(scope.Cluster)); err != nil {
@@ -318,7 +331,7 @@ func (r *Controller) getK0sToken(ctx context.Context, scope *Scope) (string, err
 		return "", errors.New("failed to get CA certificate key pair")
 	}
 
-	joinToken, err := kutil.CreateK0sJoinToken(ca.KeyPair.Cert, token, fmt.Sprintf("https://%s:%d", scope.Cluster.Spec.ControlPlaneEndpoint.Host, scope.Cluster.Spec.ControlPlaneEndpoint.Port), "kubelet-bootstrap")
+	joinToken, err := kutil.CreateK0sJoinToken(ca.KeyPair.Cert, token, util.EndpointURL(scope.Cluster.Spec.ControlPlaneEndpoint.Host, strconv.Itoa(int(scope.Cluster.Spec.ControlPlaneEndpoint.Port))), "kubelet-bootstrap")
 	if err != nil {
 		return "", fmt.Errorf("failed to create join token: %w", err)
 	}
@@ -422,7 +435,13 @@ func createInstallCmd(scope *Scope) string {
 }
 
 func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	metrics.RegisterObjects("K0sWorkerConfig", mgr.GetClient(), func() client.ObjectList { return &bootstrapv1.K0sWorkerConfigList{} }, nil)
+
+	if err := health.AddChecks(mgr, "k0sworkerconfig", &bootstrapv1.K0sWorkerConfig{}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&bootstrapv1.K0sWorkerConfig{}).
-		Complete(r)
+		Complete(metrics.Instrument("k0sworkerconfig", mgr.GetClient(), func() client.Object { return &bootstrapv1.K0sWorkerConfig{} }, nil, tracing.Instrument("k0sworkerconfig", health.Instrument("k0sworkerconfig", r))))
 }
@@ -30,6 +30,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	kubeadmbootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
@@ -236,7 +237,8 @@ func TestReconcileNoK0sWorkerConfig(t *testing.T) {
 	}(cluster, ns)
 
 	r := &Controller{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	nonExistingK0sWorkerConfig := client.ObjectKey{
@@ -266,7 +268,8 @@ func TestReconcileReturnErrorWhenWorkerConfigOwnerRefIsMissing(t *testing.T) {
 	}(k0sWorkerConfig, ns)
 
 	r := &Controller{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: util.ObjectKey(k0sWorkerConfig)})
@@ -299,7 +302,8 @@ func TestReconcileReturnErrorWhenWorkerConfigOwnerIsNotFound(t *testing.T) {
 	}(k0sWorkerConfig, ns)
 
 	r := &Controller{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: util.ObjectKey(k0sWorkerConfig)})
@@ -344,7 +348,8 @@ func TestReconcileReturnErrorWhenClusterIsNotFound(t *testing.T) {
 	}(k0sWorkerConfig, machineForWorkerConfig, ns)
 
 	r := &Controller{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	// Cluster is not created yet.
@@ -402,7 +407,8 @@ func TestReconcilePausedCluster(t *testing.T) {
 	}(k0sWorkerConfig, cluster, machineForWorkerConfig, ns)
 
 	r := &Controller{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: util.ObjectKey(k0sWorkerConfig)})
@@ -458,7 +464,8 @@ func TestReconcilePausedK0sWorkerConfig(t *testing.T) {
 	}(k0sWorkerConfig, cluster, machineForWorkerConfig, ns)
 
 	r := &Controller{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 
 	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: util.ObjectKey(k0sWorkerConfig)})
@@ -515,7 +522,8 @@ func TestReconcileBootstrapDataAlreadyCreated(t *testing.T) {
 	}(k0sWorkerConfig, cluster, machineForWorkerConfig, ns)
 
 	r := &Controller{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 	require.EventuallyWithT(t, func(c *assert.CollectT) {
 		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: util.ObjectKey(k0sWorkerConfig)})
@@ -572,7 +580,8 @@ func TestReconcileControlPlaneNotReady(t *testing.T) {
 	}(k0sWorkerConfig, cluster, machineForWorkerConfig, ns)
 
 	r := &Controller{
-		Client: testEnv,
+		Recorder: record.NewFakeRecorder(100),
+		Client:   testEnv,
 	}
 	require.EventuallyWithT(t, func(c *assert.CollectT) {
 		// Cluster.Spec.ControlPlaneEndpoint is not initialize by infra provider
@@ -661,6 +670,7 @@ func TestReconcileGenerateBootstrapData(t *testing.T) {
 
 	workloadClient, _ := fakeremote.NewClusterClient(ctx, "", testEnv, types.NamespacedName{})
 	r := &Controller{
+		Recorder:              record.NewFakeRecorder(100),
 		Client:                testEnv,
 		workloadClusterClient: workloadClient,
 		SecretCachingClient:   secretCachingClient,
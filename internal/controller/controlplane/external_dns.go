@@ -0,0 +1,139 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
+)
+
+var dnsEndpointGVK = schema.GroupVersionKind{Group: "externaldns.k8s.io", Version: "v1alpha1", Kind: "DNSEndpoint"}
+
+// reconcileExternalDNS keeps the DNSEndpoint of the control plane pointing at the addresses of its machines. The
+// DNSEndpoint is named after the K0sControlPlane and is deleted when external-dns is disabled, the ExternalDNSReady
+// condition recording that it may exist. It is left as is while no machine has an address, so the record doesn't
+// disappear while the machines are replaced.
+func (c *K0sController) reconcileExternalDNS(ctx context.Context, kcp *cpv1beta1.K0sControlPlane) error {
+	dnsEndpoint := &unstructured.Unstructured{}
+	dnsEndpoint.SetGroupVersionKind(dnsEndpointGVK)
+	dnsEndpoint.SetName(kcp.Name)
+	dnsEndpoint.SetNamespace(kcp.Namespace)
+
+	if kcp.Spec.ExternalDNS == nil {
+		if !conditions.Has(kcp, cpv1beta1.ExternalDNSReadyCondition) {
+			return nil
+		}
+		err := c.Client.Delete(ctx, dnsEndpoint)
+		if err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("error deleting DNSEndpoint: %w", err)
+		}
+		conditions.Delete(kcp, cpv1beta1.ExternalDNSReadyCondition)
+		return nil
+	}
+
+	machines, err := util.GetControlPlaneMachines(ctx, c.Client, kcp, collections.ActiveMachines)
+	if err != nil {
+		return fmt.Errorf("error collecting machines: %w", err)
+	}
+	endpoints := dnsEndpoints(kcp.Spec.ExternalDNS, machines)
+	if len(endpoints) == 0 {
+		log.FromContext(ctx).Info("No controller machine address to publish yet", "addressType", kcp.Spec.ExternalDNS.AddressType)
+		if !conditions.Has(kcp, cpv1beta1.ExternalDNSReadyCondition) {
+			conditions.MarkFalse(kcp, cpv1beta1.ExternalDNSReadyCondition, "WaitingForMachineAddresses", clusterv1.ConditionSeverityInfo, "No controller machine has a %s address yet", kcp.Spec.ExternalDNS.AddressType)
+		}
+		return nil
+	}
+
+	if err := unstructured.SetNestedSlice(dnsEndpoint.Object, endpoints, "spec", "endpoints"); err != nil {
+		return err
+	}
+	if err := ctrl.SetControllerReference(kcp, dnsEndpoint, c.Client.Scheme()); err != nil {
+		return err
+	}
+	if err := util.Apply(ctx, c.Client, dnsEndpoint); err != nil {
+		conditions.MarkFalse(kcp, cpv1beta1.ExternalDNSReadyCondition, "DNSEndpointApplyFailed", clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		return fmt.Errorf("error applying DNSEndpoint, is the external-dns CRD installed?: %w", err)
+	}
+	conditions.MarkTrue(kcp, cpv1beta1.ExternalDNSReadyCondition)
+	return nil
+}
+
+// dnsEndpoints returns the endpoints of the DNSEndpoint of the control plane: an A record for the IPv4 addresses of the
+// machines and an AAAA record for the IPv6 ones. The addresses are sorted so the endpoints are stable.
+func dnsEndpoints(externalDNS *cpv1beta1.ExternalDNS, machines collections.Machines) []interface{} {
+	addressType := externalDNS.AddressType
+	if addressType == "" {
+		addressType = clusterv1.MachineExternalIP
+	}
+
+	var v4, v6 []string
+	seen := map[string]bool{}
+	for _, machine := range machines {
+		for _, address := range machine.Status.Addresses {
+			ip := net.ParseIP(address.Address)
+			if address.Type != addressType || ip == nil || seen[address.Address] {
+				continue
+			}
+			seen[address.Address] = true
+			if ip.To4() != nil {
+				v4 = append(v4, address.Address)
+			} else {
+				v6 = append(v6, address.Address)
+			}
+		}
+	}
+
+	var endpoints []interface{}
+	for _, record := range []struct {
+		recordType string
+		addresses  []string
+	}{{"A", v4}, {"AAAA", v6}} {
+		if len(record.addresses) == 0 {
+			continue
+		}
+		sort.Strings(record.addresses)
+		targets := make([]interface{}, len(record.addresses))
+		for i, address := range record.addresses {
+			targets[i] = address
+		}
+		endpoint := map[string]interface{}{
+			"dnsName":    externalDNS.Hostname,
+			"recordType": record.recordType,
+			"targets":    targets,
+		}
+		if externalDNS.TTL > 0 {
+			endpoint["recordTTL"] = externalDNS.TTL
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
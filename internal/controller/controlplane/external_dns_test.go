@@ -0,0 +1,135 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+)
+
+func TestDNSEndpoints(t *testing.T) {
+	machine := func(name string, addresses ...clusterv1.MachineAddress) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     clusterv1.MachineStatus{Addresses: addresses},
+		}
+	}
+	machines := collections.FromMachines(
+		machine("cp-1",
+			clusterv1.MachineAddress{Type: clusterv1.MachineExternalIP, Address: "192.0.2.2"},
+			clusterv1.MachineAddress{Type: clusterv1.MachineInternalIP, Address: "10.0.0.2"},
+			clusterv1.MachineAddress{Type: clusterv1.MachineExternalIP, Address: "2001:db8::2"},
+		),
+		machine("cp-0",
+			clusterv1.MachineAddress{Type: clusterv1.MachineExternalIP, Address: "192.0.2.1"},
+			clusterv1.MachineAddress{Type: clusterv1.MachineExternalDNS, Address: "cp-0.example.com"},
+		),
+		machine("cp-2"),
+	)
+
+	endpoints := dnsEndpoints(&cpv1beta1.ExternalDNS{Hostname: "api.example.com", TTL: 60}, machines)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"dnsName":    "api.example.com",
+			"recordType": "A",
+			"targets":    []interface{}{"192.0.2.1", "192.0.2.2"},
+			"recordTTL":  int64(60),
+		},
+		map[string]interface{}{
+			"dnsName":    "api.example.com",
+			"recordType": "AAAA",
+			"targets":    []interface{}{"2001:db8::2"},
+			"recordTTL":  int64(60),
+		},
+	}, endpoints)
+
+	endpoints = dnsEndpoints(&cpv1beta1.ExternalDNS{Hostname: "api.example.com", AddressType: clusterv1.MachineInternalIP}, machines)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"dnsName":    "api.example.com",
+			"recordType": "A",
+			"targets":    []interface{}{"10.0.0.2"},
+		},
+	}, endpoints)
+
+	assert.Empty(t, dnsEndpoints(&cpv1beta1.ExternalDNS{Hostname: "api.example.com"}, collections.FromMachines(machine("cp-2"))))
+}
+
+func TestReconcileExternalDNSDeletesOnlyPublishedEndpoints(t *testing.T) {
+	var deleted int
+	c := &K0sController{Client: fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Delete: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deleted++
+			return client.Delete(ctx, obj, opts...)
+		},
+	}).Build()}
+	kcp := &cpv1beta1.K0sControlPlane{ObjectMeta: metav1.ObjectMeta{Name: "kcp", Namespace: "default"}}
+
+	// Without the external-dns CRD nor a DNSEndpoint published, nothing is deleted
+	require.NoError(t, c.reconcileExternalDNS(context.Background(), kcp))
+	assert.Zero(t, deleted)
+
+	conditions.MarkTrue(kcp, cpv1beta1.ExternalDNSReadyCondition)
+	require.NoError(t, c.reconcileExternalDNS(context.Background(), kcp))
+	assert.Equal(t, 1, deleted)
+	assert.False(t, conditions.Has(kcp, cpv1beta1.ExternalDNSReadyCondition))
+
+	require.NoError(t, c.reconcileExternalDNS(context.Background(), kcp))
+	assert.Equal(t, 1, deleted)
+}
+
+func TestMachineK0sConfigSpecExternalDNSHostname(t *testing.T) {
+	kcp := &cpv1beta1.K0sControlPlane{
+		Spec: cpv1beta1.K0sControlPlaneSpec{
+			K0sConfigSpec: bootstrapv1.K0sConfigSpec{
+				K0s: &unstructured.Unstructured{Object: map[string]interface{}{
+					"spec": map[string]interface{}{"api": map[string]interface{}{"sans": []interface{}{"10.0.0.1"}}},
+				}},
+			},
+			ExternalDNS: &cpv1beta1.ExternalDNS{Hostname: "api.example.com"},
+		},
+	}
+
+	k0sConfigSpec, err := machineK0sConfigSpec(kcp)
+	require.NoError(t, err)
+	sans, _, _ := unstructured.NestedStringSlice(k0sConfigSpec.K0s.Object, "spec", "api", "sans")
+	assert.Equal(t, []string{"10.0.0.1", "api.example.com"}, sans)
+
+	// The hostname is not written to the spec, so it is dropped from the machines once external-dns is disabled
+	sans, _, _ = unstructured.NestedStringSlice(kcp.Spec.K0sConfigSpec.K0s.Object, "spec", "api", "sans")
+	assert.Equal(t, []string{"10.0.0.1"}, sans)
+
+	kcp.Spec.ExternalDNS = nil
+	k0sConfigSpec, err = machineK0sConfigSpec(kcp)
+	require.NoError(t, err)
+	sans, _, _ = unstructured.NestedStringSlice(k0sConfigSpec.K0s.Object, "spec", "api", "sans")
+	assert.Equal(t, []string{"10.0.0.1"}, sans)
+}
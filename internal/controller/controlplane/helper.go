@@ -24,8 +24,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/imdario/mergo"
 	"github.com/k0sproject/version"
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -36,11 +36,13 @@ import (
 	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/util/collections"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
 	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 )
 
 const (
@@ -54,16 +56,17 @@ func (c *K0sController) createMachine(ctx context.Context, name string, cluster
 	}
 	_ = ctrl.SetControllerReference(kcp, machine, c.Client.Scheme())
 
-	err = c.Client.Patch(ctx, machine, client.Apply, &client.PatchOptions{
-		FieldManager: "k0smotron",
-	})
+	err = util.Apply(ctx, c.Client, machine)
 	if err != nil {
 		return machine, err
 	}
 
 	// Remove the annotation tracking that a remediation is in progress.
 	// A remediation is completed when the replacement machine has been created above.
-	delete(kcp.Annotations, cpv1beta1.RemediationInProgressAnnotation)
+	if _, ok := kcp.Annotations[cpv1beta1.RemediationInProgressAnnotation]; ok {
+		delete(kcp.Annotations, cpv1beta1.RemediationInProgressAnnotation)
+		kcp.Status.Operations = kapi.CompleteOperation(kcp.Status.Operations, kapi.OperationRemediation, kapi.OperationSucceeded, "")
+	}
 
 	return machine, nil
 }
@@ -159,15 +162,14 @@ func (c *K0sController) getInfraMachines(ctx context.Context, machines collectio
 func (c *K0sController) getBootstrapConfigs(ctx context.Context, machines collections.Machines) (map[string]bootstrapv1.K0sControllerConfig, error) {
 	result := map[string]bootstrapv1.K0sControllerConfig{}
 	for _, m := range machines {
-		var b bootstrapv1.K0sControllerConfig
-		err := c.Client.Get(ctx, client.ObjectKey{Namespace: m.Namespace, Name: m.Name}, &b)
+		b, err := util.GetControllerConfigForMachine(ctx, c.Client, m)
 		if err != nil {
-			if apierrors.IsNotFound(err) {
-				continue
-			}
 			return nil, fmt.Errorf("failed to retrieve bootstrap data for machine object %s: %w", m.Name, err)
 		}
-		result[m.Name] = b
+		if b == nil {
+			continue
+		}
+		result[m.Name] = *b
 	}
 	return result, nil
 }
@@ -178,52 +180,10 @@ func (c *K0sController) createMachineFromTemplate(ctx context.Context, name stri
 		return nil, err
 	}
 
-	existingInfraMachine := &unstructured.Unstructured{}
-	existingInfraMachine.SetAPIVersion(infraMachine.GetAPIVersion())
-	existingInfraMachine.SetKind(infraMachine.GetKind())
-	err = c.Get(ctx, client.ObjectKey{Namespace: infraMachine.GetNamespace(), Name: infraMachine.GetName()}, existingInfraMachine)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			if err = c.Client.Patch(ctx, infraMachine, client.Apply, &client.PatchOptions{
-				FieldManager: "k0smotron",
-			}); err != nil {
-				return nil, fmt.Errorf("error apply patching: %w", err)
-			}
-			return infraMachine, nil
-		}
-
-		return nil, fmt.Errorf("error getting machine implementation: %w", err)
-	}
-
-	err = mergo.Merge(existingInfraMachine, infraMachine, mergo.WithSliceDeepCopy)
-	if err != nil {
-		return nil, err
-	}
-
-	spec, _, _ := unstructured.NestedMap(existingInfraMachine.Object, "spec")
-	patch := unstructured.Unstructured{Object: map[string]interface{}{
-		"spec": spec,
-	}}
-	data, err := patch.MarshalJSON()
-	if err != nil {
-		return nil, err
+	if err = util.Apply(ctx, c.Client, infraMachine); err != nil {
+		return nil, fmt.Errorf("error apply patching: %w", err)
 	}
 
-	pluralName := ""
-	resList, _ := c.ClientSet.Discovery().ServerResourcesForGroupVersion(existingInfraMachine.GetAPIVersion())
-	for _, apiRes := range resList.APIResources {
-		if apiRes.Kind == existingInfraMachine.GetKind() && !strings.Contains(apiRes.Name, "/") {
-			pluralName = apiRes.Name
-			break
-		}
-	}
-	req := c.ClientSet.RESTClient().Patch(types.MergePatchType).
-		Body(data).
-		AbsPath("apis", infraMachine.GetAPIVersion(), "namespaces", infraMachine.GetNamespace(), pluralName, infraMachine.GetName())
-	_, err = req.DoRaw(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("error patching: %w", err)
-	}
 	return infraMachine, nil
 }
 
@@ -233,10 +193,16 @@ func (c *K0sController) generateMachineFromTemplate(ctx context.Context, name st
 		return nil, err
 	}
 
-	_ = ctrl.SetControllerReference(cluster, infraMachineTemplate, c.Client.Scheme())
-	err = c.Client.Patch(ctx, infraMachineTemplate, client.Merge, &client.PatchOptions{FieldManager: "k0smotron"})
-	if err != nil {
-		return nil, err
+	// Only the owner reference is applied, the rest of the template is owned by whoever created it.
+	if err := ctrl.SetControllerReference(cluster, infraMachineTemplate, c.Client.Scheme()); err == nil {
+		templateOwner := &unstructured.Unstructured{}
+		templateOwner.SetGroupVersionKind(infraMachineTemplate.GroupVersionKind())
+		templateOwner.SetName(infraMachineTemplate.GetName())
+		templateOwner.SetNamespace(infraMachineTemplate.GetNamespace())
+		templateOwner.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(cluster, clusterv1.GroupVersion.WithKind("Cluster"))})
+		if err := util.Apply(ctx, c.Client, templateOwner); err != nil {
+			return nil, err
+		}
 	}
 
 	template, found, err := unstructured.NestedMap(infraMachineTemplate.UnstructuredContent(), "spec", "template")
@@ -265,6 +231,15 @@ func (c *K0sController) generateMachineFromTemplate(ctx context.Context, name st
 
 	infraMachine.SetLabels(controlPlaneCommonLabelsForCluster(kcp, cluster.GetName()))
 
+	// The K0sControlPlane owns the infrastructure machine until the Machine controller takes it over, so a machine
+	// whose Machine failed to be created is garbage collected along with the control plane.
+	infraMachine.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: cpv1beta1.GroupVersion.String(),
+		Kind:       "K0sControlPlane",
+		Name:       kcp.Name,
+		UID:        kcp.UID,
+	}})
+
 	infraMachine.SetAPIVersion(infraMachineTemplate.GetAPIVersion())
 	infraMachine.SetKind(strings.TrimSuffix(infraMachineTemplate.GetKind(), clusterv1.TemplateSuffix))
 
@@ -292,9 +267,12 @@ func (c *K0sController) hasControllerConfigChanged(bootstrapConfigs map[string]b
 		return false
 	}
 
-	kcpK0sConfigSpecCopy := kcp.Spec.K0sConfigSpec.DeepCopy()
+	kcpK0sConfigSpecCopy, err := machineK0sConfigSpec(kcp)
+	if err != nil {
+		// The bootstrap configs can't be created from an invalid k0s config either
+		return false
+	}
 	bootstrapConfigCopy := bootstrapConfig.DeepCopy()
-	kcpK0sConfigSpecCopy.Args = uniqueArgs(kcpK0sConfigSpecCopy.Args)
 
 	// remove data that should not be taken into account to check if the configuration has changed.
 	normalizeK0sConfigSpec(kcp, bootstrapConfigCopy)
@@ -392,10 +370,24 @@ func (c *K0sController) markChildControlNodeToLeave(ctx context.Context, name st
 
 	logger := log.FromContext(ctx).WithValues("controlNode", name)
 
+	// The member is marked only once, so the marked-to-leave-at annotation records when it was first asked to leave
+	// instead of changing on every reconciliation.
+	var etcdMember unstructured.Unstructured
 	err := clientset.RESTClient().
+		Get().
+		AbsPath("/apis/etcd.k0sproject.io/v1beta1/etcdmembers/" + name).
+		Do(ctx).
+		Into(&etcdMember)
+	if err == nil {
+		if leave, _, _ := unstructured.NestedBool(etcdMember.Object, "spec", "leave"); leave {
+			return nil
+		}
+	}
+
+	err = clientset.RESTClient().
 		Patch(types.MergePatchType).
 		AbsPath("/apis/etcd.k0sproject.io/v1beta1/etcdmembers/" + name).
-		Body([]byte(`{"spec":{"leave":true}, "metadata": {"annotations": {"k0smotron.io/marked-to-leave-at": "` + time.Now().String() + `"}}}`)).
+		Body([]byte(`{"spec":{"leave":true}, "metadata": {"annotations": {"k0smotron.io/marked-to-leave-at": "` + time.Now().UTC().Format(time.RFC3339) + `"}}}`)).
 		Do(ctx).
 		Error()
 	if err != nil {
@@ -415,12 +407,12 @@ func (c *K0sController) markChildControlNodeToLeave(ctx context.Context, name st
 	return nil
 }
 
-func (c *K0sController) deleteOldControlNodes(ctx context.Context, cluster *clusterv1.Cluster) error {
+func (c *K0sController) deleteOldControlNodes(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
 	kubeClient, err := c.getKubeClient(ctx, cluster)
 	if err != nil {
 		return fmt.Errorf("error getting kube client: %w", err)
 	}
-	machines, err := collections.GetFilteredMachinesForCluster(ctx, c, cluster, collections.ControlPlaneMachines(cluster.Name))
+	machines, err := util.GetControlPlaneMachines(ctx, c, kcp)
 	if err != nil {
 		return fmt.Errorf("error getting all machines: %w", err)
 	}
@@ -470,13 +462,16 @@ func (c *K0sController) deleteControlNode(ctx context.Context, name string, clie
 	return nil
 }
 
-func (c *K0sController) createAutopilotPlan(ctx context.Context, kcp *cpv1beta1.K0sControlPlane, cluster *clusterv1.Cluster, clientset *kubernetes.Clientset) error {
+func (c *K0sController) createAutopilotPlan(ctx context.Context, kcp *cpv1beta1.K0sControlPlane, cluster *clusterv1.Cluster, clientset *kubernetes.Clientset) (err error) {
 	if clientset == nil {
 		return nil
 	}
 
+	ctx, span := tracing.Start(ctx, "createAutopilotPlan", tracing.Cluster(cluster.Name), attribute.String("k0s.version", kcp.Spec.Version))
+	defer func() { tracing.End(span, err) }()
+
 	var existingPlan unstructured.Unstructured
-	err := clientset.RESTClient().Get().AbsPath("/apis/autopilot.k0sproject.io/v1beta2/plans/autopilot").Do(ctx).Into(&existingPlan)
+	err = clientset.RESTClient().Get().AbsPath("/apis/autopilot.k0sproject.io/v1beta2/plans/autopilot").Do(ctx).Into(&existingPlan)
 	if err != nil && !apierrors.IsNotFound(err) {
 		return fmt.Errorf("error getting autopilot plan: %w", err)
 	}
@@ -519,7 +514,7 @@ func (c *K0sController) createAutopilotPlan(ctx context.Context, kcp *cpv1beta1.
 		return fmt.Errorf("error deleting autopilot plan: %w", err)
 	}
 
-	machines, err := collections.GetFilteredMachinesForCluster(ctx, c, cluster, collections.ControlPlaneMachines(cluster.Name), collections.ActiveMachines)
+	machines, err := util.GetControlPlaneMachines(ctx, c, kcp, collections.ActiveMachines)
 	if err != nil {
 		return fmt.Errorf("error getting control plane machines: %w", err)
 	}
@@ -533,7 +528,10 @@ func (c *K0sController) createAutopilotPlan(ctx context.Context, kcp *cpv1beta1.
 		armDownloadURL = kcp.Spec.K0sConfigSpec.DownloadURL
 	}
 
-	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+	timestamp := autopilotPlanTimestamp(kcp)
+	// The machines are not ordered, the plan of the same upgrade must be the same whenever it is created
+	nodes := machines.Names()
+	sort.Strings(nodes)
 	plan := []byte(`
 	{
 		"apiVersion": "autopilot.k0sproject.io/v1beta2",
@@ -562,7 +560,7 @@ func (c *K0sController) createAutopilotPlan(ctx context.Context, kcp *cpv1beta1.
 						"controllers": {
 							"discovery": {
 							    "static": {
-									"nodes": ["` + strings.Join(machines.Names(), `","`) + `"]
+									"nodes": ["` + strings.Join(nodes, `","`) + `"]
 								}
 							}
 						}
@@ -579,6 +577,16 @@ func (c *K0sController) createAutopilotPlan(ctx context.Context, kcp *cpv1beta1.
 		Error()
 }
 
+// autopilotPlanTimestamp returns the timestamp of the autopilot plan, which is the start time of the upgrade of the
+// control plane, so the plan id stays the same when the plan is created again for the same upgrade.
+func autopilotPlanTimestamp(kcp *cpv1beta1.K0sControlPlane) string {
+	startTime := time.Now()
+	if op := kapi.LastOperation(kcp.Status.Operations, kapi.OperationUpgrade); op != nil && op.Target == kcp.Spec.Version {
+		startTime = op.StartTime.Time
+	}
+	return fmt.Sprintf("%d", startTime.Unix())
+}
+
 // minVersion returns the minimum version from a list of machines
 func minVersion(machines collections.Machines) (string, error) {
 	if machines == nil || machines.Len() == 0 {
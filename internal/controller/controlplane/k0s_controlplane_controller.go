@@ -20,7 +20,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -38,6 +40,7 @@ import (
 	"k8s.io/apiserver/pkg/storage/names"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	kubeadmbootstrapv1 "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
@@ -45,17 +48,25 @@ import (
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/certs"
 	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/failuredomains"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
 	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/keystore"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/runtimehooks"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 	kutil "github.com/k0sproject/k0smotron/internal/util"
 )
 
@@ -71,6 +82,8 @@ var (
 	FRPConfigMapNameTemplate  = "%s-frps-config"
 	FRPDeploymentNameTemplate = "%s-frps"
 	FRPServiceNameTemplate    = "%s-frps"
+	FRPServerTLSNameTemplate  = "%s-frps-tls"
+	FRPClientTLSNameTemplate  = "%s-frpc-tls"
 )
 
 type K0sController struct {
@@ -78,6 +91,18 @@ type K0sController struct {
 	SecretCachingClient client.Client
 	ClientSet           *kubernetes.Clientset
 	RESTConfig          *rest.Config
+	// KeyStore keeps the private keys of the cluster CAs out of the certificate secrets. The keys are kept in the
+	// secrets if nil.
+	KeyStore keystore.Store
+	// RuntimeHooks calls the Cluster API Runtime SDK lifecycle hooks around the upgrades of the control plane. The
+	// hooks are not called if nil.
+	RuntimeHooks *runtimehooks.Caller
+	// CrossNamespaceMachineTemplates allows the K0sControlPlanes to reference an infrastructure machine template of
+	// another namespace, if the template allows it with the AllowedNamespacesAnnotation.
+	CrossNamespaceMachineTemplates bool
+	// WatchSecrets watches the metadata of the Secrets labelled with a cluster name. It requires the manager cache
+	// to be limited to these Secrets.
+	WatchSecrets bool
 	// workloadClusterKubeClient is used during testing to inject a fake client
 	workloadClusterKubeClient *kubernetes.Clientset
 }
@@ -85,8 +110,13 @@ type K0sController struct {
 // +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=k0scontrolplanes/status,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=k0scontrolplanes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=*,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=runtime.cluster.x-k8s.io,resources=extensionconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=k0smotron.io,resources=providerconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=externaldns.k8s.io,resources=dnsendpoints,verbs=get;list;watch;create;update;patch;delete
 
 func (c *K0sController) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
 	log := log.FromContext(ctx).WithValues("controlplane", req.NamespacedName)
@@ -115,6 +145,14 @@ func (c *K0sController) Reconcile(ctx context.Context, req ctrl.Request) (res ct
 	if err != nil {
 		return ctrl.Result{}, err
 	}
+	// The tunneling settings of automatically enabled tunneling are not patched
+	tunneling := kcp.Spec.K0sConfigSpec.Tunneling.DeepCopy()
+	// Nor is the tunneling server address of the ProviderConfig, it is resolved on each reconciliation so that its
+	// changes reach the existing control planes
+	serverAddressFromProviderConfig, err := c.applyProviderConfigServerAddress(ctx, kcp)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 
 	log.Info("Reconciling K0sControlPlane", "version", kcp.Spec.Version)
 
@@ -170,11 +208,18 @@ func (c *K0sController) Reconcile(ctx context.Context, req ctrl.Request) (res ct
 				}
 			}
 
+			setReadyCondition(kcp)
+
 			if errors.Is(err, ErrNotReady) || reflect.DeepEqual(existingStatus, kcp.Status) {
 				return
 			}
 		}
 
+		if !tunneling.Enabled {
+			kcp.Spec.K0sConfigSpec.Tunneling = *tunneling
+		} else if serverAddressFromProviderConfig {
+			kcp.Spec.K0sConfigSpec.Tunneling.ServerAddress = tunneling.ServerAddress
+		}
 		derr = kcpPatchHelper.Patch(ctx, kcp)
 		if derr != nil {
 			log.Error(derr, "Failed to patch status")
@@ -205,6 +250,11 @@ func (c *K0sController) Reconcile(ctx context.Context, req ctrl.Request) (res ct
 
 	log = log.WithValues("cluster", cluster.Name)
 
+	if err := c.readoptRestoredObjects(ctx, cluster, kcp); err != nil {
+		log.Error(err, "Failed to readopt restored objects")
+		return ctrl.Result{}, err
+	}
+
 	if err := c.ensureCertificates(ctx, cluster, kcp); err != nil {
 		log.Error(err, "Failed to ensure certificates")
 		return ctrl.Result{}, err
@@ -220,11 +270,21 @@ func (c *K0sController) Reconcile(ctx context.Context, req ctrl.Request) (res ct
 		return ctrl.Result{}, err
 	}
 
+	if err := c.reconcileExternalDNS(ctx, kcp); err != nil {
+		log.Error(err, "Failed to reconcile external-dns record")
+		return ctrl.Result{}, err
+	}
+
 	err = c.reconcile(ctx, cluster, kcp)
 	if err != nil {
 		if errors.Is(err, ErrNotReady) {
 			return ctrl.Result{RequeueAfter: 10 * time.Second, Requeue: true}, nil
 		}
+		var hookErr *errLifecycleHookBlocking
+		if errors.As(err, &hookErr) {
+			log.Info("Machines reconciliation blocked by a lifecycle hook", "hook", hookErr.hook, "retryAfter", hookErr.retryAfter)
+			return ctrl.Result{RequeueAfter: hookErr.retryAfter}, nil
+		}
 		return res, err
 	}
 
@@ -260,10 +320,15 @@ func (c *K0sController) reconcileKubeconfig(ctx context.Context, cluster *cluste
 		}
 	}()
 
-	workloadClusterKubeconfigSecret, err := secret.GetFromNamespacedName(ctx, c.SecretCachingClient, capiutil.ObjectKey(cluster), secret.Kubeconfig)
+	// The kubeconfig secrets are read bypassing the cache, so they don't need to be cached, see --secret-cache.
+	workloadClusterKubeconfigSecret, err := secret.GetFromNamespacedName(ctx, c.Client, capiutil.ObjectKey(cluster), secret.Kubeconfig)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			return kubeconfig.CreateSecret(ctx, c.SecretCachingClient, cluster)
+			cfg, err := c.generateKubeconfig(ctx, capiutil.ObjectKey(cluster), fmt.Sprintf("https://%s", cluster.Spec.ControlPlaneEndpoint.String()))
+			if err != nil {
+				return err
+			}
+			return c.createKubeconfigSecret(ctx, cfg, cluster, secret.Name(cluster.Name, secret.Kubeconfig))
 		}
 
 		return err
@@ -271,61 +336,189 @@ func (c *K0sController) reconcileKubeconfig(ctx context.Context, cluster *cluste
 	kubeconfigSecrets = append(kubeconfigSecrets, workloadClusterKubeconfigSecret)
 
 	if kcp.Spec.K0sConfigSpec.Tunneling.Enabled {
-		clusterKey := client.ObjectKey{
-			Name:      cluster.GetName(),
-			Namespace: cluster.GetNamespace(),
+		tunnelingKubeconfig, err := c.reconcileTunnelingKubeconfig(ctx, cluster, kcp)
+		if err != nil {
+			return err
+		}
+		if tunnelingKubeconfig != nil {
+			kubeconfigSecrets = append(kubeconfigSecrets, tunnelingKubeconfig)
 		}
+	}
 
-		if kcp.Spec.K0sConfigSpec.Tunneling.Mode == "proxy" {
+	return nil
+}
 
-			secretName := secret.Name(cluster.Name+"-proxied", secret.Kubeconfig)
+// reconcileTunnelingKubeconfig creates the kubeconfig secret to access the cluster through the tunnel. The secret is
+// regenerated when the tunneling settings it was generated from change, and the secret of the other tunneling mode is
+// removed. It returns the existing secret, or nil if the secret was just created.
+func (c *K0sController) reconcileTunnelingKubeconfig(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) (*corev1.Secret, error) {
+	logger := log.FromContext(ctx, "cluster", cluster.Name, "kcp", kcp.Name)
 
-			proxiedKubeconfig := &corev1.Secret{}
-			err := c.SecretCachingClient.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: secretName}, proxiedKubeconfig)
+	clusterKey := client.ObjectKey{
+		Name:      cluster.GetName(),
+		Namespace: cluster.GetNamespace(),
+	}
+	tunneling := kcp.Spec.K0sConfigSpec.Tunneling
+
+	secretName := secret.Name(cluster.Name+"-tunneled", secret.Kubeconfig)
+	staleSecretName := secret.Name(cluster.Name+"-proxied", secret.Kubeconfig)
+	tunnelingPort := strconv.Itoa(int(tunneling.TunnelingNodePort))
+	endpoint := util.EndpointURL(tunneling.ServerAddress, tunnelingPort)
+	if tunneling.SNIHostname != "" {
+		endpoint = util.EndpointURL(tunneling.SNIHostname, tunnelingPort)
+	}
+	var proxyURL string
+	if proxiedTunneling(tunneling) {
+		secretName, staleSecretName = staleSecretName, secretName
+		endpoint = fmt.Sprintf("https://%s", cluster.Spec.ControlPlaneEndpoint.String())
+		proxyURL = "http://" + net.JoinHostPort(util.SANHost(tunneling.ServerAddress), tunnelingPort)
+		// The konnectivity agents forward the traffic to the kubernetes service, and the konnectivity server
+		// only accepts TLS connections from clients with a certificate signed by the cluster CA.
+		if tunneling.Provider == bootstrapv1.TunnelingProviderKonnectivity {
+			serviceIP, err := util.KubernetesServiceIP(cluster)
 			if err != nil {
-				if apierrors.IsNotFound(err) {
-					kc, err := c.generateKubeconfig(ctx, clusterKey, fmt.Sprintf("https://%s", cluster.Spec.ControlPlaneEndpoint.String()))
-					if err != nil {
-						return err
-					}
+				return nil, err
+			}
+			endpoint = util.EndpointURL(serviceIP, "443")
+			proxyURL = util.EndpointURL(tunneling.ServerAddress, tunnelingPort)
+		}
+	}
 
-					for cn := range kc.Clusters {
-						kc.Clusters[cn].ProxyURL = fmt.Sprintf("http://%s:%d", kcp.Spec.K0sConfigSpec.Tunneling.ServerAddress, kcp.Spec.K0sConfigSpec.Tunneling.TunnelingNodePort)
-					}
+	// The kubeconfig of the previous tunneling mode points to an endpoint which is gone.
+	staleSecret := &corev1.Secret{}
+	err := c.Client.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: staleSecretName}, staleSecret)
+	if err == nil {
+		logger.Info("Deleting kubeconfig secret of the previous tunneling mode", "Secret", staleSecretName)
+		if err := c.Client.Delete(ctx, staleSecret); err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
 
-					err = c.createKubeconfigSecret(ctx, kc, cluster, secretName)
-					if err != nil {
-						return err
-					}
-				}
-				return err
-			}
-			kubeconfigSecrets = append(kubeconfigSecrets, proxiedKubeconfig)
+	tunnelingEndpoint := endpoint
+	if proxyURL != "" {
+		tunnelingEndpoint = fmt.Sprintf("%s via %s", endpoint, proxyURL)
+	}
 
-		} else {
-			secretName := secret.Name(cluster.Name+"-tunneled", secret.Kubeconfig)
+	existingSecret := &corev1.Secret{}
+	err = c.Client.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: secretName}, existingSecret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	found := err == nil
+	if found {
+		existingConfig, err := clientcmd.Load(existingSecret.Data[secret.KubeconfigDataName])
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig secret %s: %w", secretName, err)
+		}
+		if kc, ok := existingConfig.Clusters[cluster.Name]; ok && kc.Server == endpoint && kc.ProxyURL == proxyURL {
+			kcp.Status.TunnelingEndpoint = tunnelingEndpoint
+			return existingSecret, nil
+		}
+	}
 
-			tunneledKubeconfig := &corev1.Secret{}
-			err := c.SecretCachingClient.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: secretName}, tunneledKubeconfig)
-			if err != nil {
-				if apierrors.IsNotFound(err) {
-					kc, err := c.generateKubeconfig(ctx, clusterKey, fmt.Sprintf("https://%s:%d", kcp.Spec.K0sConfigSpec.Tunneling.ServerAddress, kcp.Spec.K0sConfigSpec.Tunneling.TunnelingNodePort))
-					if err != nil {
-						return err
-					}
+	kc, err := c.generateKubeconfig(ctx, clusterKey, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	for cn := range kc.Clusters {
+		kc.Clusters[cn].ProxyURL = proxyURL
+	}
 
-					err = c.createKubeconfigSecret(ctx, kc, cluster, secretName)
-					if err != nil {
-						return err
-					}
-				}
-				return err
-			}
-			kubeconfigSecrets = append(kubeconfigSecrets, tunneledKubeconfig)
+	if !found {
+		err = c.createKubeconfigSecret(ctx, kc, cluster, secretName)
+	} else {
+		logger.Info("Tunneling settings changed, regenerating kubeconfig secret", "Secret", secretName, "endpoint", tunnelingEndpoint)
+		err = c.updateKubeconfigSecret(ctx, kc, existingSecret)
+	}
+	if err != nil {
+		return nil, err
+	}
+	kcp.Status.TunnelingEndpoint = tunnelingEndpoint
+
+	return nil, nil
+}
+
+// unroutableControlPlaneEndpoint returns why the control plane endpoint of the cluster is not routable, or an empty
+// string if it is. Hostnames are assumed to resolve to a routable address.
+func unroutableControlPlaneEndpoint(cluster *clusterv1.Cluster, routableNetworks []string) (string, error) {
+	host := util.SANHost(cluster.Spec.ControlPlaneEndpoint.Host)
+	if host == "" {
+		// The infrastructure provider sets the endpoint before marking the infrastructure ready.
+		if cluster.Status.InfrastructureReady {
+			return "control plane endpoint is not set", nil
 		}
+		return "", nil
 	}
 
-	return nil
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", nil
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return fmt.Sprintf("control plane endpoint %s is not routable", host), nil
+	}
+	if !ip.IsPrivate() || len(routableNetworks) == 0 {
+		return "", nil
+	}
+	for _, cidr := range routableNetworks {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return "", fmt.Errorf("invalid routable network %s: %w", cidr, err)
+		}
+		if network.Contains(ip) {
+			return "", nil
+		}
+	}
+	return fmt.Sprintf("control plane endpoint %s is outside of the routable networks", host), nil
+}
+
+// autoEnableTunneling enables tunneling for the reconciliation if AutoEnable is set and the control plane endpoint
+// is not routable. Once enabled, tunneling stays enabled, as the machines are bootstrapped with the tunneling client.
+// Only the in-memory spec is changed, the TunnelingAutoEnabled condition records that tunneling is enabled.
+func autoEnableTunneling(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) (bool, error) {
+	tunneling := &kcp.Spec.K0sConfigSpec.Tunneling
+	if !tunneling.AutoEnable {
+		return false, nil
+	}
+
+	if !conditions.IsTrue(kcp, cpv1beta1.TunnelingAutoEnabledCondition) {
+		reason, err := unroutableControlPlaneEndpoint(cluster, tunneling.RoutableNetworks)
+		if err != nil {
+			return false, fmt.Errorf("error checking control plane endpoint: %w", err)
+		}
+		if reason == "" {
+			return false, nil
+		}
+
+		log.FromContext(ctx).Info("Enabling tunneling", "reason", reason)
+		conditions.Set(kcp, &clusterv1.Condition{
+			Type:     cpv1beta1.TunnelingAutoEnabledCondition,
+			Status:   corev1.ConditionTrue,
+			Severity: clusterv1.ConditionSeverityNone,
+			Reason:   "ControlPlaneEndpointNotRoutable",
+			Message:  reason,
+		})
+	}
+
+	tunneling.Enabled = true
+	if tunneling.Mode == "" {
+		tunneling.Mode = "tunnel"
+	}
+	return true, nil
+}
+
+// proxiedTunneling returns whether the API server is exposed through a proxy rather than through a plain tunnel.
+func proxiedTunneling(tunneling bootstrapv1.TunnelingSpec) bool {
+	switch tunneling.Provider {
+	case bootstrapv1.TunnelingProviderKonnectivity:
+		return true
+	case bootstrapv1.TunnelingProviderWireGuard:
+		return false
+	default:
+		return tunneling.Mode == "proxy"
+	}
 }
 
 func (c *K0sController) reconcile(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
@@ -335,6 +528,10 @@ func (c *K0sController) reconcile(ctx context.Context, cluster *clusterv1.Cluste
 		return err
 	}
 
+	if cluster.Spec.ControlPlaneEndpoint.IsZero() {
+		return c.reconcileFirstMachine(ctx, cluster, kcp)
+	}
+
 	err = c.reconcileKubeconfig(ctx, cluster, kcp)
 	if err != nil {
 		return fmt.Errorf("error reconciling kubeconfig secret: %w", err)
@@ -356,7 +553,7 @@ func (c *K0sController) reconcile(ctx context.Context, cluster *clusterv1.Cluste
 func (c *K0sController) reconcileMachines(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
 	logger := log.FromContext(ctx, "cluster", cluster.Name, "kcp", kcp.Name)
 
-	allMachines, err := collections.GetFilteredMachinesForCluster(ctx, c, cluster, collections.ControlPlaneMachines(cluster.Name))
+	allMachines, err := util.GetControlPlaneMachines(ctx, c, kcp)
 	if err != nil {
 		return fmt.Errorf("error collecting machines: %w", err)
 	}
@@ -387,6 +584,17 @@ func (c *K0sController) reconcileMachines(ctx context.Context, cluster *clusterv
 		return fmt.Errorf("error getting bootstrap configs: %w", err)
 	}
 
+	// The first machine is created without its bootstrap config while the control plane endpoint is not set, see
+	// reconcileFirstMachine.
+	for _, m := range activeMachines {
+		if _, found := bootstrapConfigs[m.Name]; found || m.Spec.Bootstrap.DataSecretName != nil {
+			continue
+		}
+		if err := c.createBootstrapConfig(ctx, m.Name, cluster, kcp, m, cluster.Name); err != nil {
+			return fmt.Errorf("error creating bootstrap config: %w", err)
+		}
+	}
+
 	currentVersion, err := minVersion(activeMachines)
 	if err != nil {
 		return fmt.Errorf("error getting current cluster version from machines: %w", err)
@@ -427,8 +635,28 @@ func (c *K0sController) reconcileMachines(ctx context.Context, cluster *clusterv
 	}
 	log.Log.Info("Collected machines", "count", activeMachines.Len(), "desired", kcp.Spec.Replicas, "updating", clusterIsUpdating, "deleting", len(machineNamesToDelete), "desiredMachines", desiredMachineNames)
 
+	if clusterIsUpdating {
+		if !upgradeApproved(kcp) {
+			return fmt.Errorf("upgrade to %s awaiting approval: %w", kcp.Spec.Version, ErrNotReady)
+		}
+		if err := c.beforeClusterUpgrade(ctx, cluster, kcp, currentVersion); err != nil {
+			return err
+		}
+	} else {
+		conditions.Delete(kcp, cpv1beta1.UpgradeAwaitingApprovalCondition)
+	}
+
+	_, remediating := kcp.Annotations[cpv1beta1.RemediationInProgressAnnotation]
+	recordScaleOperation(kcp, activeMachines.Len(), !clusterIsUpdating && !remediating && len(machineNamesToDelete) == 0)
+	if clusterIsUpdating {
+		kcp.Status.Operations = kapi.StartOperation(kcp.Status.Operations, kapi.OperationUpgrade, k0sControlPlaneOperationActor, kcp.Spec.Version,
+			fmt.Sprintf("Upgrading from %s to %s with the %s strategy", currentVersion, kcp.Spec.Version, kcp.Spec.UpdateStrategy))
+	} else {
+		kcp.Status.Operations = kapi.CompleteOperation(kcp.Status.Operations, kapi.OperationUpgrade, kapi.OperationSucceeded, "")
+	}
+
 	go func() {
-		err = c.deleteOldControlNodes(ctx, cluster)
+		err = c.deleteOldControlNodes(ctx, cluster, kcp)
 		if err != nil {
 			logger.Error(err, "Error deleting old control nodes")
 		}
@@ -441,7 +669,9 @@ func (c *K0sController) reconcileMachines(ctx context.Context, cluster *clusterv
 			if kcp.Spec.K0sConfigSpec.Args != nil {
 				for _, arg := range kcp.Spec.K0sConfigSpec.Args {
 					if arg == "--single" {
-						return fmt.Errorf("UpdateRecreate strategy is not allowed when the cluster is running in single mode")
+						err := fmt.Errorf("UpdateRecreate strategy is not allowed when the cluster is running in single mode")
+						kcp.Status.Operations = kapi.CompleteOperation(kcp.Status.Operations, kapi.OperationUpgrade, kapi.OperationFailed, err.Error())
+						return err
 					}
 				}
 			}
@@ -508,22 +738,9 @@ func (c *K0sController) reconcileMachines(ctx context.Context, cluster *clusterv
 			}
 		}
 
-		infraMachine, err := c.createMachineFromTemplate(ctx, name, cluster, kcp)
-		if err != nil {
-			return fmt.Errorf("error creating machine from template: %w", err)
-		}
-
-		infraRef := corev1.ObjectReference{
-			APIVersion: infraMachine.GetAPIVersion(),
-			Kind:       infraMachine.GetKind(),
-			Name:       infraMachine.GetName(),
-			Namespace:  kcp.Namespace,
-		}
-
-		selectedFailureDomain := failuredomains.PickFewest(ctx, cluster.Status.FailureDomains.FilterControlPlane(), activeMachines)
-		machine, err := c.createMachine(ctx, name, cluster, kcp, infraRef, selectedFailureDomain)
+		machine, err := c.createControlPlaneMachine(ctx, name, cluster, kcp, activeMachines)
 		if err != nil {
-			return fmt.Errorf("error creating machine: %w", err)
+			return err
 		}
 		activeMachines[machine.Name] = machine
 		desiredMachineNames[machine.Name] = true
@@ -538,9 +755,55 @@ func (c *K0sController) reconcileMachines(ctx context.Context, cluster *clusterv
 		return ErrNewMachinesNotReady
 	}
 
+	if !clusterIsUpdating && len(machineNamesToDelete) == 0 {
+		return c.afterControlPlaneUpgrade(ctx, cluster, kcp)
+	}
+
 	return nil
 }
 
+// createControlPlaneMachine creates a control plane machine and its infrastructure machine, the bootstrap config is
+// created separately.
+func (c *K0sController) createControlPlaneMachine(ctx context.Context, name string, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane, activeMachines collections.Machines) (*clusterv1.Machine, error) {
+	infraMachine, err := c.createMachineFromTemplate(ctx, name, cluster, kcp)
+	if err != nil {
+		return nil, fmt.Errorf("error creating machine from template: %w", err)
+	}
+
+	infraRef := corev1.ObjectReference{
+		APIVersion: infraMachine.GetAPIVersion(),
+		Kind:       infraMachine.GetKind(),
+		Name:       infraMachine.GetName(),
+		Namespace:  kcp.Namespace,
+	}
+
+	selectedFailureDomain := failuredomains.PickFewest(ctx, cluster.Status.FailureDomains.FilterControlPlane(), activeMachines)
+	machine, err := c.createMachine(ctx, name, cluster, kcp, infraRef, selectedFailureDomain)
+	if err != nil {
+		return nil, fmt.Errorf("error creating machine: %w", err)
+	}
+	return machine, nil
+}
+
+// reconcileFirstMachine creates the first control plane machine while the control plane endpoint is not set. Some
+// infrastructure providers only set the endpoint once the first machine exists, e.g. a RemoteCluster with the
+// FirstController endpoint management mode. The bootstrap config of the machine depends on the endpoint, so it is only
+// created once the endpoint is set, as are the kubeconfig and the other machines.
+func (c *K0sController) reconcileFirstMachine(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
+	machines, err := util.GetControlPlaneMachines(ctx, c, kcp)
+	if err != nil {
+		return fmt.Errorf("error collecting machines: %w", err)
+	}
+	if machines.Len() == 0 && kcp.Spec.Replicas > 0 {
+		name := names.SimpleNameGenerator.GenerateName(fmt.Sprintf("%s-", kcp.Name))
+		if _, err := c.createControlPlaneMachine(ctx, name, cluster, kcp, machines); err != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Created the first control plane machine before the control plane endpoint is set", "machine", name)
+	}
+	return fmt.Errorf("control plane endpoint is not set: %w", ErrNotReady)
+}
+
 func (c *K0sController) runMachineDeletionSequence(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane, machine *clusterv1.Machine) error {
 	err := c.deleteK0sNodeResources(ctx, cluster, kcp, machine)
 	if err != nil {
@@ -588,11 +851,29 @@ func (c *K0sController) deleteK0sNodeResources(ctx context.Context, cluster *clu
 	return nil
 }
 
-func (c *K0sController) createBootstrapConfig(ctx context.Context, name string, _ *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane, machine *clusterv1.Machine, clusterName string) error {
-
+// machineK0sConfigSpec returns the k0s config spec of the controller machines: the spec of the K0sControlPlane along
+// with the settings rendered from its other fields, which are not written to its spec.
+func machineK0sConfigSpec(kcp *cpv1beta1.K0sControlPlane) (*bootstrapv1.K0sConfigSpec, error) {
 	k0sConfigSpec := kcp.Spec.K0sConfigSpec.DeepCopy()
 	k0sConfigSpec.Args = uniqueArgs(k0sConfigSpec.Args)
 
+	if kcp.Spec.ExternalDNS != nil {
+		var err error
+		k0sConfigSpec.K0s, err = addAPISANs(k0sConfigSpec.K0s, kcp.Spec.ExternalDNS.Hostname)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return k0sConfigSpec, nil
+}
+
+func (c *K0sController) createBootstrapConfig(ctx context.Context, name string, _ *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane, machine *clusterv1.Machine, clusterName string) error {
+
+	k0sConfigSpec, err := machineK0sConfigSpec(kcp)
+	if err != nil {
+		return err
+	}
+
 	controllerConfig := bootstrapv1.K0sControllerConfig{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "bootstrap.cluster.x-k8s.io/v1beta1",
@@ -604,8 +885,8 @@ func (c *K0sController) createBootstrapConfig(ctx context.Context, name string,
 			Labels:      controlPlaneCommonLabelsForCluster(kcp, clusterName),
 			Annotations: kcp.Spec.MachineTemplate.ObjectMeta.Annotations,
 			OwnerReferences: []metav1.OwnerReference{{
-				APIVersion:         machine.APIVersion,
-				Kind:               machine.Kind,
+				APIVersion:         clusterv1.GroupVersion.String(),
+				Kind:               "Machine",
 				Name:               machine.GetName(),
 				UID:                machine.GetUID(),
 				BlockOwnerDeletion: ptr.To(true),
@@ -618,9 +899,7 @@ func (c *K0sController) createBootstrapConfig(ctx context.Context, name string,
 		},
 	}
 
-	if err := c.Client.Patch(ctx, &controllerConfig, client.Apply, &client.PatchOptions{
-		FieldManager: "k0smotron",
-	}); err != nil {
+	if err := util.Apply(ctx, c.Client, &controllerConfig); err != nil {
 		return fmt.Errorf("error patching K0sControllerConfig: %w", err)
 	}
 
@@ -652,11 +931,32 @@ func (c *K0sController) checkMachineIsReady(ctx context.Context, machineName str
 	return nil
 }
 
-func (c *K0sController) ensureCertificates(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
+func (c *K0sController) ensureCertificates(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) (err error) {
+	ctx, span := tracing.Start(ctx, "ensureCertificates", tracing.Cluster(cluster.Name))
+	defer func() { tracing.End(span, err) }()
+
 	certificates := secret.NewCertificatesForInitialControlPlane(&kubeadmbootstrapv1.ClusterConfiguration{
 		CertificatesDir: "/var/lib/k0s/pki",
 	})
-	return certificates.LookupOrGenerateCached(ctx, c.SecretCachingClient, c.Client, capiutil.ObjectKey(cluster), *metav1.NewControllerRef(kcp, cpv1beta1.GroupVersion.WithKind("K0sControlPlane")))
+	if kcp.Status.Initialized {
+		// The nodes of a running cluster would not trust a new CA, a deleted certificate is never regenerated
+		err = certificates.LookupCached(ctx, c.SecretCachingClient, c.Client, capiutil.ObjectKey(cluster))
+		if err != nil {
+			return err
+		}
+		for _, cert := range certificates {
+			if cert.KeyPair == nil {
+				return fmt.Errorf("certificate secret %s of the initialized cluster not found, it must be restored from a backup", secret.Name(cluster.Name, cert.Purpose))
+			}
+		}
+	} else {
+		err = certificates.LookupOrGenerateCached(ctx, c.SecretCachingClient, c.Client, capiutil.ObjectKey(cluster), *metav1.NewControllerRef(kcp, cpv1beta1.GroupVersion.WithKind("K0sControlPlane")))
+	}
+	if err != nil || c.KeyStore == nil {
+		return err
+	}
+	// The keys are moved out of the secrets once generated, read uncached so a key is never left behind
+	return keystore.Offload(ctx, c.Client, c.KeyStore, capiutil.ObjectKey(cluster))
 }
 
 func (c *K0sController) reconcileConfig(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
@@ -669,12 +969,12 @@ func (c *K0sController) reconcileConfig(ctx context.Context, cluster *clusterv1.
 		// Set the external address if NLLB is not enabled
 		// Otherwise, just add the external address to the SANs to allow the clients to connect using LB address
 		if !(found && nllbEnabled) {
-			err = unstructured.SetNestedField(kcp.Spec.K0sConfigSpec.K0s.Object, cluster.Spec.ControlPlaneEndpoint.Host, "spec", "api", "externalAddress")
+			err = unstructured.SetNestedField(kcp.Spec.K0sConfigSpec.K0s.Object, util.SANHost(cluster.Spec.ControlPlaneEndpoint.Host), "spec", "api", "externalAddress")
 			if err != nil {
 				return fmt.Errorf("error setting control plane endpoint: %v", err)
 			}
 		} else {
-			sans := []string{cluster.Spec.ControlPlaneEndpoint.Host}
+			sans := []string{util.SANHost(cluster.Spec.ControlPlaneEndpoint.Host)}
 			existingSANs, sansFound, err := unstructured.NestedStringSlice(kcp.Spec.K0sConfigSpec.K0s.Object, "spec", "api", "sans")
 			if err == nil && sansFound {
 				sans = util.AddToExistingSans(existingSANs, sans)
@@ -690,7 +990,10 @@ func (c *K0sController) reconcileConfig(ctx context.Context, cluster *clusterv1.
 			if err != nil {
 				return fmt.Errorf("error getting sans from config: %v", err)
 			}
-			sans = util.AddToExistingSans(sans, []string{kcp.Spec.K0sConfigSpec.Tunneling.ServerAddress})
+			sans = util.AddToExistingSans(sans, []string{util.SANHost(kcp.Spec.K0sConfigSpec.Tunneling.ServerAddress)})
+			if hostname := kcp.Spec.K0sConfigSpec.Tunneling.SNIHostname; hostname != "" {
+				sans = util.AddToExistingSans(sans, []string{hostname})
+			}
 			err = unstructured.SetNestedStringSlice(kcp.Spec.K0sConfigSpec.K0s.Object, sans, "spec", "api", "sans")
 			if err != nil {
 				return fmt.Errorf("error setting sans to the config: %v", err)
@@ -708,8 +1011,36 @@ func (c *K0sController) reconcileConfig(ctx context.Context, cluster *clusterv1.
 	return nil
 }
 
+// applyProviderConfigServerAddress sets the tunneling server address of the ProviderConfig of the namespace on the
+// control plane, unless it sets its own. It returns whether it did.
+func (c *K0sController) applyProviderConfigServerAddress(ctx context.Context, kcp *cpv1beta1.K0sControlPlane) (bool, error) {
+	if kcp.Spec.K0sConfigSpec.Tunneling.ServerAddress != "" {
+		return false, nil
+	}
+	pc, err := util.GetProviderConfig(ctx, c.Client, kcp.Namespace)
+	if err != nil {
+		return false, fmt.Errorf("error getting provider config: %w", err)
+	}
+	if pc == nil || pc.Spec.Tunneling == nil || pc.Spec.Tunneling.ServerAddress == "" {
+		return false, nil
+	}
+	kcp.Spec.K0sConfigSpec.Tunneling.ServerAddress = pc.Spec.Tunneling.ServerAddress
+	return true, nil
+}
+
 func (c *K0sController) reconcileTunneling(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
 	if !kcp.Spec.K0sConfigSpec.Tunneling.Enabled {
+		enabled, err := autoEnableTunneling(ctx, cluster, kcp)
+		if err != nil || !enabled {
+			return err
+		}
+	}
+
+	if kcp.Spec.K0sConfigSpec.Tunneling.ServerRef != nil {
+		if err := c.reconcileTunnelServerRef(ctx, cluster, kcp); err != nil {
+			return fmt.Errorf("error reconciling tunneling server reference: %w", err)
+		}
+		// The shared tunneling server is deployed by the TunnelServer controller.
 		return nil
 	}
 
@@ -721,11 +1052,32 @@ func (c *K0sController) reconcileTunneling(ctx context.Context, cluster *cluster
 		kcp.Spec.K0sConfigSpec.Tunneling.ServerAddress = ip
 	}
 
+	switch kcp.Spec.K0sConfigSpec.Tunneling.Provider {
+	case bootstrapv1.TunnelingProviderKonnectivity:
+		return c.reconcileKonnectivityTunneling(ctx, cluster, kcp)
+	case bootstrapv1.TunnelingProviderWireGuard:
+		return c.reconcileWireGuardTunneling(ctx, cluster, kcp)
+	}
+
 	frpToken, err := c.createFRPToken(ctx, cluster, kcp)
 	if err != nil {
 		return fmt.Errorf("error creating FRP token secret: %w", err)
 	}
 
+	var frpsTLSSecretName, frpsTLSConfig string
+	if tlsSpec := kcp.Spec.K0sConfigSpec.Tunneling.TLS; tlsSpec != nil && tlsSpec.Enabled {
+		frpsTLSSecretName, frpsTLSConfig, err = c.reconcileFRPTLS(ctx, cluster, kcp)
+		if err != nil {
+			return fmt.Errorf("error reconciling FRP TLS: %w", err)
+		}
+	}
+
+	// frps accepts at most max_pool_count pooled connections per client, 5 by default.
+	var frpsLimitsConfig string
+	if limits := kcp.Spec.K0sConfigSpec.Tunneling.Limits; limits != nil && limits.PoolCount > 0 {
+		frpsLimitsConfig = fmt.Sprintf("max_pool_count = %d\n", limits.PoolCount)
+	}
+
 	var frpsConfig string
 	if kcp.Spec.K0sConfigSpec.Tunneling.Mode == "proxy" {
 		frpsConfig = `
@@ -734,14 +1086,14 @@ bind_port = 7000
 tcpmux_httpconnect_port = 6443
 authentication_method = token
 token = ` + frpToken + `
-`
+` + frpsTLSConfig + frpsLimitsConfig
 	} else {
 		frpsConfig = `
 [common]
 bind_port = 7000
 authentication_method = token
 token = ` + frpToken + `
-`
+` + frpsTLSConfig + frpsLimitsConfig
 	}
 
 	frpsCMName := fmt.Sprintf(FRPConfigMapNameTemplate, kcp.GetName())
@@ -760,7 +1112,7 @@ token = ` + frpToken + `
 	}
 
 	_ = ctrl.SetControllerReference(kcp, &cm, c.Client.Scheme())
-	err = c.Client.Patch(ctx, &cm, client.Apply, &client.PatchOptions{FieldManager: "k0s-bootstrap"})
+	err = util.Apply(ctx, c.Client, &cm)
 	if err != nil {
 		return fmt.Errorf("error creating ConfigMap: %w", err)
 	}
@@ -775,6 +1127,8 @@ token = ` + frpToken + `
 			Namespace: kcp.GetNamespace(),
 		},
 		Spec: appsv1.DeploymentSpec{
+			// The frpc clients register their proxies with a single frps, so it can't be scaled out
+			Replicas: ptr.To(int32(1)),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
 					"k0smotron_cluster": kcp.GetName(),
@@ -789,6 +1143,7 @@ token = ` + frpToken + `
 					},
 				},
 				Spec: corev1.PodSpec{
+					SecurityContext: util.RestrictedPodSecurityContext(util.NonRootUID),
 					Volumes: []corev1.Volume{{
 						Name: frpsCMName,
 						VolumeSource: corev1.VolumeSource{
@@ -805,8 +1160,9 @@ token = ` + frpToken + `
 					}},
 					Containers: []corev1.Container{{
 						Name:            "frps",
-						Image:           "snowdreamtech/frps:0.51.3",
-						ImagePullPolicy: corev1.PullIfNotPresent,
+						Image:           kcp.Spec.K0sConfigSpec.Tunneling.GetFRPServerImage(),
+						ImagePullPolicy: kcp.Spec.K0sConfigSpec.Tunneling.GetImagePullPolicy(),
+						SecurityContext: util.RestrictedSecurityContext(),
 						Ports: []corev1.ContainerPort{
 							{
 								Name:          "api",
@@ -828,8 +1184,34 @@ token = ` + frpToken + `
 				}},
 		},
 	}
+	if frpsTLSSecretName != "" {
+		podSpec := &frpsDeployment.Spec.Template.Spec
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "frps-tls",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: frpsTLSSecretName,
+				},
+			},
+		})
+		podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      "frps-tls",
+			MountPath: "/etc/frp/tls",
+			ReadOnly:  true,
+		})
+	}
+	// frps listens for the extra ports on their NodePort, as requested by frpc.
+	for _, p := range kcp.Spec.K0sConfigSpec.Tunneling.ExtraPorts {
+		container := &frpsDeployment.Spec.Template.Spec.Containers[0]
+		container.Ports = append(container.Ports, corev1.ContainerPort{
+			Name:          p.Name,
+			Protocol:      corev1.ProtocolTCP,
+			ContainerPort: p.NodePort,
+		})
+	}
+	applyTunnelingDeploymentSpec(&frpsDeployment, kcp.Spec.K0sConfigSpec.Tunneling.ServerDeployment)
 	_ = ctrl.SetControllerReference(kcp, &frpsDeployment, c.Client.Scheme())
-	err = c.Client.Patch(ctx, &frpsDeployment, client.Apply, &client.PatchOptions{FieldManager: "k0s-bootstrap"})
+	err = util.Apply(ctx, c.Client, &frpsDeployment)
 	if err != nil {
 		return fmt.Errorf("error creating Deployment: %w", err)
 	}
@@ -864,8 +1246,17 @@ token = ` + frpToken + `
 			Type: corev1.ServiceTypeNodePort,
 		},
 	}
+	for _, p := range kcp.Spec.K0sConfigSpec.Tunneling.ExtraPorts {
+		frpsService.Spec.Ports = append(frpsService.Spec.Ports, corev1.ServicePort{
+			Name:       p.Name,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       p.NodePort,
+			TargetPort: intstr.FromInt32(p.NodePort),
+			NodePort:   p.NodePort,
+		})
+	}
 	_ = ctrl.SetControllerReference(kcp, &frpsService, c.Client.Scheme())
-	err = c.Client.Patch(ctx, &frpsService, client.Apply, &client.PatchOptions{FieldManager: "k0s-bootstrap"})
+	err = util.Apply(ctx, c.Client, &frpsService)
 	if err != nil {
 		return fmt.Errorf("error creating Service: %w", err)
 	}
@@ -873,6 +1264,20 @@ token = ` + frpToken + `
 	return nil
 }
 
+// applyTunnelingDeploymentSpec sets the configured scheduling constraints and resources of the first container on a
+// tunneling deployment. The replicas are set by the providers, as only konnectivity can run more than one.
+func applyTunnelingDeploymentSpec(deployment *appsv1.Deployment, spec *bootstrapv1.TunnelingDeploymentSpec) {
+	if spec == nil {
+		return
+	}
+
+	podSpec := &deployment.Spec.Template.Spec
+	podSpec.NodeSelector = spec.NodeSelector
+	podSpec.Tolerations = spec.Tolerations
+	podSpec.Affinity = spec.Affinity
+	podSpec.Containers[0].Resources = spec.Resources
+}
+
 func (c *K0sController) reconcileDelete(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
@@ -884,7 +1289,11 @@ func (c *K0sController) reconcileDelete(ctx context.Context, cluster *clusterv1.
 	cpMachines := allMachines.Filter(collections.ControlPlaneMachines(cluster.Name))
 
 	if len(cpMachines) == 0 {
-		// No machines left, we can finally delete the K0sControlPlane by removing the finalizer.
+		// No machines left, we can finally delete the K0sControlPlane by removing the finalizer. The deletion of the
+		// keys is best-effort, the key store being unavailable must not block the deletion of the cluster.
+		if err := keystore.DeleteKeys(ctx, c.KeyStore, capiutil.ObjectKey(cluster)); err != nil {
+			logger.Error(err, "Failed to delete the CA keys from the key store, they must be deleted manually")
+		}
 		controllerutil.RemoveFinalizer(kcp, cpv1beta1.K0sControlPlaneFinalizer)
 		return ctrl.Result{}, nil
 	}
@@ -945,6 +1354,19 @@ func (c *K0sController) detectNodeIP(ctx context.Context, _ *cpv1beta1.K0sContro
 }
 
 func (c *K0sController) createFRPToken(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) (string, error) {
+	if ref := kcp.Spec.K0sConfigSpec.Tunneling.TokenSecretRef; ref != nil {
+		var tokenSecret corev1.Secret
+		err := c.SecretCachingClient.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: kcp.Namespace}, &tokenSecret)
+		if err != nil {
+			return "", fmt.Errorf("failed to get FRP token secret %s: %w", ref.Name, err)
+		}
+		frpToken, ok := tokenSecret.Data[ref.Key]
+		if !ok || len(frpToken) == 0 {
+			return "", fmt.Errorf("FRP token secret %s has no value for key %s", ref.Name, ref.Key)
+		}
+		return string(frpToken), nil
+	}
+
 	secretName := fmt.Sprintf(FRPTokenNameTemplate, cluster.Name)
 
 	var existingSecret corev1.Secret
@@ -956,13 +1378,18 @@ func (c *K0sController) createFRPToken(ctx context.Context, cluster *clusterv1.C
 	}
 
 	frpToken := uuid.New().String()
+	return frpToken, c.applyFRPTokenSecret(ctx, cluster, kcp, frpToken)
+}
+
+// applyFRPTokenSecret writes the token the tunneling clients of the cluster authenticate with.
+func (c *K0sController) applyFRPTokenSecret(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane, frpToken string) error {
 	frpSecret := &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
 			Kind:       "Secret",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      secretName,
+			Name:      fmt.Sprintf(FRPTokenNameTemplate, cluster.Name),
 			Namespace: cluster.Namespace,
 			Labels: map[string]string{
 				clusterv1.ClusterNameLabel: cluster.Name,
@@ -976,16 +1403,119 @@ func (c *K0sController) createFRPToken(ctx context.Context, cluster *clusterv1.C
 
 	_ = ctrl.SetControllerReference(kcp, frpSecret, c.Client.Scheme())
 
-	return frpToken, c.Client.Patch(ctx, frpSecret, client.Apply, &client.PatchOptions{
-		FieldManager: "k0smotron",
-	})
+	var existingSecret corev1.Secret
+	err := c.SecretCachingClient.Get(ctx, client.ObjectKeyFromObject(frpSecret), &existingSecret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	err = util.Apply(ctx, c.Client, frpSecret)
+	if err != nil {
+		return err
+	}
+
+	if existingToken := existingSecret.Data["value"]; len(existingToken) > 0 && string(existingToken) != frpToken {
+		kcp.Status.Operations = kapi.RecordOperation(kcp.Status.Operations, kapi.OperationTokenRotation, k0sControlPlaneOperationActor,
+			tokenFingerprint(frpToken), kapi.OperationSucceeded, fmt.Sprintf("Rotated the tunneling token in the secret %s", frpSecret.Name))
+	}
+
+	return nil
+}
+
+// reconcileFRPTLS requests the tunneling certificates from cert-manager if an issuer is configured and returns
+// the name of the secret with the server certificate along with the TLS part of the frps configuration.
+func (c *K0sController) reconcileFRPTLS(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) (string, string, error) {
+	tlsSpec := kcp.Spec.K0sConfigSpec.Tunneling.TLS
+
+	serverSecretName := tlsSpec.ServerCertSecretName
+	if serverSecretName == "" {
+		serverSecretName = fmt.Sprintf(FRPServerTLSNameTemplate, cluster.Name)
+	}
+
+	if tlsSpec.IssuerRef != nil {
+		clientSecretName := tlsSpec.ClientCertSecretName
+		if clientSecretName == "" {
+			clientSecretName = fmt.Sprintf(FRPClientTLSNameTemplate, cluster.Name)
+		}
+		err := c.createFRPCertificate(ctx, kcp, serverSecretName, "frps", "server auth", util.SANHost(kcp.Spec.K0sConfigSpec.Tunneling.ServerAddress))
+		if err != nil {
+			return "", "", fmt.Errorf("error creating tunneling server certificate: %w", err)
+		}
+		err = c.createFRPCertificate(ctx, kcp, clientSecretName, "frpc", "client auth", "")
+		if err != nil {
+			return "", "", fmt.Errorf("error creating tunneling client certificate: %w", err)
+		}
+	}
+
+	var serverSecret corev1.Secret
+	err := c.SecretCachingClient.Get(ctx, client.ObjectKey{Name: serverSecretName, Namespace: kcp.Namespace}, &serverSecret)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get tunneling server certificate secret %s: %w", serverSecretName, err)
+	}
+
+	tlsConfig := `tls_only = true
+tls_cert_file = /etc/frp/tls/tls.crt
+tls_key_file = /etc/frp/tls/tls.key
+`
+	// With a CA in the secret the server verifies the certificates of the clients.
+	if len(serverSecret.Data["ca.crt"]) > 0 {
+		tlsConfig += "tls_trusted_ca_file = /etc/frp/tls/ca.crt\n"
+	}
+
+	return serverSecretName, tlsConfig, nil
+}
+
+// createFRPCertificate creates a cert-manager Certificate for the tunneling server or clients.
+func (c *K0sController) createFRPCertificate(ctx context.Context, kcp *cpv1beta1.K0sControlPlane, secretName, commonName, usage, host string) error {
+	issuerRef := kcp.Spec.K0sConfigSpec.Tunneling.TLS.IssuerRef
+	spec := map[string]interface{}{
+		"secretName": secretName,
+		"commonName": commonName,
+		"usages":     []interface{}{"digital signature", "key encipherment", usage},
+		"issuerRef": map[string]interface{}{
+			"name":  issuerRef.Name,
+			"kind":  issuerRef.Kind,
+			"group": issuerRef.Group,
+		},
+	}
+	if host != "" {
+		if net.ParseIP(host) != nil {
+			spec["ipAddresses"] = []interface{}{host}
+		} else {
+			spec["dnsNames"] = []interface{}{host}
+		}
+	}
+
+	cert := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"spec":       spec,
+	}}
+	cert.SetName(secretName)
+	cert.SetNamespace(kcp.Namespace)
+
+	_ = ctrl.SetControllerReference(kcp, cert, c.Client.Scheme())
+	return util.Apply(ctx, c.Client, cert)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (c *K0sController) SetupWithManager(mgr ctrl.Manager) error {
 	// Check if the cluster.x-k8s.io API is available and if not, don't try to watch for Machine objects
-	return ctrl.NewControllerManagedBy(mgr).
+	metrics.RegisterObjects("K0sControlPlane", mgr.GetClient(), func() client.ObjectList { return &cpv1beta1.K0sControlPlaneList{} }, nil)
+
+	if err := health.AddChecks(mgr, "k0scontrolplane", &cpv1beta1.K0sControlPlane{}); err != nil {
+		return err
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&cpv1beta1.K0sControlPlane{}).
 		Owns(&clusterv1.Machine{}).
-		Complete(c)
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		// Watching the metadata is enough to notice the changes, and keeps the data of the config maps and secrets out of the cache.
+		Owns(&corev1.ConfigMap{}, builder.OnlyMetadata)
+	if c.WatchSecrets {
+		b = b.Owns(&corev1.Secret{}, builder.OnlyMetadata)
+	}
+	return b.Complete(metrics.Instrument("k0scontrolplane", mgr.GetClient(), func() client.Object { return &cpv1beta1.K0sControlPlane{} }, nil, tracing.Instrument("k0scontrolplane", health.Instrument("k0scontrolplane", c))))
 }
@@ -34,7 +34,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -57,10 +60,12 @@ import (
 	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	autopilot "github.com/k0sproject/k0s/pkg/apis/autopilot/v1beta2"
 	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
 	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
 	kubeadmConfig "sigs.k8s.io/cluster-api/bootstrap/kubeadm/api/v1beta1"
 )
 
@@ -146,6 +151,66 @@ func TestK0sConfigEnrichment(t *testing.T) {
 				},
 			}},
 		},
+		{
+			cluster: &clusterv1.Cluster{
+				Spec: clusterv1.ClusterSpec{
+					ClusterNetwork: &clusterv1.ClusterNetwork{
+						ServiceDomain: "example.internal",
+					},
+				},
+			},
+			kcp: &cpv1beta1.K0sControlPlane{
+				Spec: cpv1beta1.K0sControlPlaneSpec{
+					K0sConfigSpec: bootstrapv1.K0sConfigSpec{
+						K0s: &unstructured.Unstructured{Object: map[string]interface{}{
+							"spec": map[string]interface{}{
+								"api": map[string]interface{}{"sans": []interface{}{"my.san.address"}},
+							},
+						}},
+					},
+				},
+			},
+			want: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "k0s.k0sproject.io/v1beta1",
+				"kind":       "ClusterConfig",
+				"spec": map[string]interface{}{
+					"api": map[string]interface{}{
+						"sans": []interface{}{"kubernetes.default.svc.example.internal", "my.san.address"},
+					},
+					"network": map[string]interface{}{"clusterDomain": "example.internal"},
+				},
+			}},
+		},
+		{
+			cluster: &clusterv1.Cluster{
+				Spec: clusterv1.ClusterSpec{
+					ClusterNetwork: &clusterv1.ClusterNetwork{
+						Services: &clusterv1.NetworkRanges{
+							CIDRBlocks: []string{"fd01::/108", "10.96.0.0/12"},
+						},
+						Pods: &clusterv1.NetworkRanges{
+							CIDRBlocks: []string{"10.244.0.0/16", "fd00::/108"},
+						},
+					},
+				},
+			},
+			kcp: &cpv1beta1.K0sControlPlane{},
+			want: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "k0s.k0sproject.io/v1beta1",
+				"kind":       "ClusterConfig",
+				"spec": map[string]interface{}{
+					"network": map[string]interface{}{
+						"serviceCIDR": "10.96.0.0/12",
+						"podCIDR":     "10.244.0.0/16",
+						"dualStack": map[string]interface{}{
+							"enabled":         true,
+							"IPv6podCIDR":     "fd00::/108",
+							"IPv6serviceCIDR": "fd01::/108",
+						},
+					},
+				},
+			}},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -305,6 +370,155 @@ func TestReconcileTunneling(t *testing.T) {
 	require.True(t, metav1.IsControlledBy(frpService, kcp))
 }
 
+func TestReconcileTunnelingWithTokenSecretRef(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-reconcile-tunneling-token-ref")
+	require.NoError(t, err)
+
+	node := createNode()
+	require.NoError(t, testEnv.Create(ctx, node))
+
+	cluster, kcp, _ := createClusterWithControlPlane(ns.Name)
+	require.NoError(t, testEnv.Create(ctx, cluster))
+
+	tokenSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "external-frp-token",
+			Namespace: ns.Name,
+		},
+		Data: map[string][]byte{
+			"token": []byte("shared-token"),
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, tokenSecret))
+
+	kcp.Spec.K0sConfigSpec = bootstrapv1.K0sConfigSpec{
+		Tunneling: bootstrapv1.TunnelingSpec{
+			Enabled: true,
+			TokenSecretRef: &bootstrapv1.ContentSourceRef{
+				Name: tokenSecret.Name,
+				Key:  "token",
+			},
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, kcp))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(kcp, cluster, tokenSecret, ns)
+
+	clientSet, err := kubernetes.NewForConfig(testEnv.Config)
+	require.NoError(t, err)
+
+	r := &K0sController{
+		Client:              testEnv,
+		ClientSet:           clientSet,
+		SecretCachingClient: secretCachingClient,
+	}
+	require.Eventually(t, func() bool {
+		return r.reconcileTunneling(ctx, cluster, kcp) == nil
+	}, 10*time.Second, 100*time.Millisecond)
+
+	_, err = clientSet.CoreV1().Secrets(ns.Name).Get(ctx, fmt.Sprintf(FRPTokenNameTemplate, cluster.Name), metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	frpCM, err := clientSet.CoreV1().ConfigMaps(ns.Name).Get(ctx, fmt.Sprintf(FRPConfigMapNameTemplate, kcp.GetName()), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Contains(t, frpCM.Data["frps.ini"], "token = shared-token")
+}
+
+func TestReconcileTunnelingWithTLS(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-reconcile-tunneling-tls")
+	require.NoError(t, err)
+
+	node := createNode()
+	require.NoError(t, testEnv.Create(ctx, node))
+
+	cluster, kcp, _ := createClusterWithControlPlane(ns.Name)
+	require.NoError(t, testEnv.Create(ctx, cluster))
+
+	tlsSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(FRPServerTLSNameTemplate, cluster.Name),
+			Namespace: ns.Name,
+		},
+		Data: map[string][]byte{
+			"tls.crt": []byte("cert"),
+			"tls.key": []byte("key"),
+			"ca.crt":  []byte("ca"),
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, tlsSecret))
+
+	kcp.Spec.K0sConfigSpec = bootstrapv1.K0sConfigSpec{
+		Tunneling: bootstrapv1.TunnelingSpec{
+			Enabled: true,
+			TLS: &bootstrapv1.TunnelingTLSSpec{
+				Enabled: true,
+			},
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, kcp))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(kcp, cluster, tlsSecret, ns)
+
+	clientSet, err := kubernetes.NewForConfig(testEnv.Config)
+	require.NoError(t, err)
+
+	r := &K0sController{
+		Client:              testEnv,
+		ClientSet:           clientSet,
+		SecretCachingClient: secretCachingClient,
+	}
+	require.Eventually(t, func() bool {
+		return r.reconcileTunneling(ctx, cluster, kcp) == nil
+	}, 10*time.Second, 100*time.Millisecond)
+
+	frpCM, err := clientSet.CoreV1().ConfigMaps(ns.Name).Get(ctx, fmt.Sprintf(FRPConfigMapNameTemplate, kcp.GetName()), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Contains(t, frpCM.Data["frps.ini"], "tls_only = true")
+	require.Contains(t, frpCM.Data["frps.ini"], "tls_trusted_ca_file = /etc/frp/tls/ca.crt")
+
+	frpDeploy, err := clientSet.AppsV1().Deployments(ns.Name).Get(ctx, fmt.Sprintf(FRPDeploymentNameTemplate, kcp.GetName()), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, frpDeploy.Spec.Template.Spec.Volumes, 2)
+	require.Equal(t, tlsSecret.Name, frpDeploy.Spec.Template.Spec.Volumes[1].Secret.SecretName)
+}
+
+func TestApplyTunnelingDeploymentSpec(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "frps"}, {Name: "sidecar"}},
+				},
+			},
+		},
+	}
+
+	applyTunnelingDeploymentSpec(deployment, nil)
+	require.Nil(t, deployment.Spec.Replicas)
+
+	applyTunnelingDeploymentSpec(deployment, &bootstrapv1.TunnelingDeploymentSpec{
+		Replicas:     ptr.To(int32(2)),
+		NodeSelector: map[string]string{"node-role.kubernetes.io/egress": ""},
+		Tolerations: []corev1.Toleration{{
+			Key:      "egress",
+			Operator: corev1.TolerationOpExists,
+		}},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+		},
+	})
+	// The replicas are set by the tunneling providers
+	require.Nil(t, deployment.Spec.Replicas)
+	require.Equal(t, map[string]string{"node-role.kubernetes.io/egress": ""}, deployment.Spec.Template.Spec.NodeSelector)
+	require.Len(t, deployment.Spec.Template.Spec.Tolerations, 1)
+	require.Equal(t, "128Mi", deployment.Spec.Template.Spec.Containers[0].Resources.Limits.Memory().String())
+	require.Empty(t, deployment.Spec.Template.Spec.Containers[1].Resources.Limits)
+}
+
 func TestReconcileKubeconfigEmptyAPIEndpoints(t *testing.T) {
 	ns, err := testEnv.CreateNamespace(ctx, "test-reconcile-kubeconfig-empty-api-endpoints")
 	require.NoError(t, err)
@@ -479,6 +693,148 @@ func TestReconcileKubeconfigTunnelingModeProxy(t *testing.T) {
 	}, 10*time.Second, 100*time.Millisecond)
 }
 
+func TestReconcileKubeconfigTunnelingProviderKonnectivity(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-reconcile-kubeconfig-tunneling-konnectivity")
+	require.NoError(t, err)
+
+	cluster, kcp, _ := createClusterWithControlPlane(ns.Name)
+	require.NoError(t, testEnv.Create(ctx, cluster))
+
+	kcp.Spec.K0sConfigSpec.Tunneling = bootstrapv1.TunnelingSpec{
+		Enabled:           true,
+		Provider:          bootstrapv1.TunnelingProviderKonnectivity,
+		ServerAddress:     "test.com",
+		TunnelingNodePort: 9999,
+	}
+
+	require.NoError(t, testEnv.Create(ctx, kcp))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(kcp, cluster, ns)
+
+	kubeconfigSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secret.Name(cluster.Name, secret.Kubeconfig),
+			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabel: cluster.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{},
+		},
+		Data: map[string][]byte{
+			secret.KubeconfigDataName: {},
+		},
+	}
+	require.NoError(t, testEnv.Create(ctx, kubeconfigSecret))
+
+	clusterCerts := secret.NewCertificatesForInitialControlPlane(&kubeadmConfig.ClusterConfiguration{})
+	require.NoError(t, clusterCerts.Generate())
+	caCert := clusterCerts.GetByPurpose(secret.ClusterCA)
+	caCertSecret := caCert.AsSecret(
+		client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name},
+		*metav1.NewControllerRef(kcp, cpv1beta1.GroupVersion.WithKind("K0sControlPlane")),
+	)
+	require.NoError(t, testEnv.Create(ctx, caCertSecret))
+
+	r := &K0sController{
+		Client:              testEnv,
+		SecretCachingClient: secretCachingClient,
+	}
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		err = r.reconcileKubeconfig(ctx, cluster, kcp)
+		assert.Error(c, err)
+
+		secretKey := client.ObjectKey{
+			Namespace: cluster.Namespace,
+			Name:      secret.Name(cluster.Name+"-proxied", secret.Kubeconfig),
+		}
+
+		kubeconfigProxiedSecret := &corev1.Secret{}
+		assert.NoError(c, testEnv.Get(ctx, secretKey, kubeconfigProxiedSecret))
+
+		kubeconfigProxiedSecretCrt, _ := runtime.Decode(clientcmdlatest.Codec, kubeconfigProxiedSecret.Data["value"])
+		for _, v := range kubeconfigProxiedSecretCrt.(*api.Config).Clusters {
+			assert.Equal(c, "https://10.96.0.1:443", v.Server)
+			assert.Equal(c, "https://test.com:9999", v.ProxyURL)
+		}
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestReconcileTunnelingKubeconfigSettingsChange(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-reconcile-tunneling-kubeconfig-settings-change")
+	require.NoError(t, err)
+
+	cluster, kcp, _ := createClusterWithControlPlane(ns.Name)
+	require.NoError(t, testEnv.Create(ctx, cluster))
+
+	kcp.Spec.K0sConfigSpec.Tunneling = bootstrapv1.TunnelingSpec{
+		Enabled:           true,
+		Mode:              "tunnel",
+		ServerAddress:     "test.com",
+		TunnelingNodePort: 9999,
+	}
+
+	require.NoError(t, testEnv.Create(ctx, kcp))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(kcp, cluster, ns)
+
+	clusterCerts := secret.NewCertificatesForInitialControlPlane(&kubeadmConfig.ClusterConfiguration{})
+	require.NoError(t, clusterCerts.Generate())
+	caCert := clusterCerts.GetByPurpose(secret.ClusterCA)
+	caCertSecret := caCert.AsSecret(
+		client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name},
+		*metav1.NewControllerRef(kcp, cpv1beta1.GroupVersion.WithKind("K0sControlPlane")),
+	)
+	require.NoError(t, testEnv.Create(ctx, caCertSecret))
+
+	r := &K0sController{
+		Client:              testEnv,
+		SecretCachingClient: secretCachingClient,
+	}
+
+	tunneledKey := client.ObjectKey{Namespace: cluster.Namespace, Name: secret.Name(cluster.Name+"-tunneled", secret.Kubeconfig)}
+	proxiedKey := client.ObjectKey{Namespace: cluster.Namespace, Name: secret.Name(cluster.Name+"-proxied", secret.Kubeconfig)}
+	assertKubeconfig := func(c *assert.CollectT, key client.ObjectKey, server, proxyURL string) {
+		kubeconfigSecret := &corev1.Secret{}
+		if !assert.NoError(c, testEnv.Get(ctx, key, kubeconfigSecret)) {
+			return
+		}
+		kc, err := clientcmd.Load(kubeconfigSecret.Data[secret.KubeconfigDataName])
+		if !assert.NoError(c, err) {
+			return
+		}
+		for _, v := range kc.Clusters {
+			assert.Equal(c, server, v.Server)
+			assert.Equal(c, proxyURL, v.ProxyURL)
+		}
+	}
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		_, _ = r.reconcileTunnelingKubeconfig(ctx, cluster, kcp)
+		assertKubeconfig(c, tunneledKey, "https://test.com:9999", "")
+	}, 10*time.Second, 100*time.Millisecond)
+
+	kcp.Spec.K0sConfigSpec.Tunneling.TunnelingNodePort = 8888
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		_, err := r.reconcileTunnelingKubeconfig(ctx, cluster, kcp)
+		assert.NoError(c, err)
+		assertKubeconfig(c, tunneledKey, "https://test.com:8888", "")
+		assert.Equal(c, "https://test.com:8888", kcp.Status.TunnelingEndpoint)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	kcp.Spec.K0sConfigSpec.Tunneling.Mode = "proxy"
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		_, _ = r.reconcileTunnelingKubeconfig(ctx, cluster, kcp)
+		assertKubeconfig(c, proxiedKey, fmt.Sprintf("https://%s", cluster.Spec.ControlPlaneEndpoint.String()), "http://test.com:8888")
+		err := testEnv.Get(ctx, tunneledKey, &corev1.Secret{})
+		assert.True(c, apierrors.IsNotFound(err))
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
 func TestReconcileKubeconfigTunnelingModeTunnel(t *testing.T) {
 	ns, err := testEnv.CreateNamespace(ctx, "test-reconcile-kubeconfig-tunneling-mode-tunnel")
 	require.NoError(t, err)
@@ -977,6 +1333,64 @@ func TestReconcileMachinesScaleUp(t *testing.T) {
 	}
 }
 
+func TestReconcileFirstMachineWithoutControlPlaneEndpoint(t *testing.T) {
+	ns, err := testEnv.CreateNamespace(ctx, "test-reconcile-first-machine-without-endpoint")
+	require.NoError(t, err)
+
+	cluster, kcp, gmt := createClusterWithControlPlane(ns.Name)
+	// The endpoint is only set once the first controller machine exists, e.g. by a RemoteCluster with the
+	// FirstController endpoint management mode.
+	cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{}
+	require.NoError(t, testEnv.Create(ctx, cluster))
+	require.NoError(t, testEnv.Create(ctx, gmt))
+
+	kcp.Spec.Replicas = 3
+	require.NoError(t, testEnv.Create(ctx, kcp))
+
+	defer func(do ...client.Object) {
+		require.NoError(t, testEnv.Cleanup(ctx, do...))
+	}(kcp, gmt, cluster, ns)
+
+	r := &K0sController{
+		Client: testEnv,
+	}
+
+	// Only the first machine is created, without its bootstrap config nor the kubeconfig.
+	require.ErrorIs(t, r.reconcile(ctx, cluster, kcp), ErrNotReady)
+	var machines collections.Machines
+	require.Eventually(t, func() bool {
+		machines, err = collections.GetFilteredMachinesForCluster(ctx, testEnv, cluster, collections.ControlPlaneMachines(cluster.Name), collections.ActiveMachines)
+		return err == nil && machines.Len() == 1
+	}, 5*time.Second, 100*time.Millisecond)
+	firstMachine := machines.Oldest()
+
+	require.ErrorIs(t, r.reconcile(ctx, cluster, kcp), ErrNotReady)
+	machines, err = collections.GetFilteredMachinesForCluster(ctx, testEnv, cluster, collections.ControlPlaneMachines(cluster.Name), collections.ActiveMachines)
+	require.NoError(t, err)
+	require.Len(t, machines, 1)
+
+	configs, err := r.getBootstrapConfigs(ctx, machines)
+	require.NoError(t, err)
+	require.Empty(t, configs)
+
+	kubeconfigSecret := &corev1.Secret{}
+	err = testEnv.Get(ctx, client.ObjectKey{Namespace: ns.Name, Name: secret.Name(cluster.Name, secret.Kubeconfig)}, kubeconfigSecret)
+	require.True(t, apierrors.IsNotFound(err))
+
+	// Once the endpoint is set, the bootstrap config of the first machine is created.
+	cluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "test.endpoint", Port: 6443}
+	kcp.Spec.Replicas = 1
+	require.NoError(t, r.reconcileMachines(ctx, cluster, kcp))
+	require.Eventually(t, func() bool {
+		configs, err := r.getBootstrapConfigs(ctx, machines)
+		if err != nil {
+			return false
+		}
+		config, found := configs[firstMachine.Name]
+		return found && metav1.IsControlledBy(&config, firstMachine)
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
 func TestReconcileMachinesScaleDown(t *testing.T) {
 	ns, err := testEnv.CreateNamespace(ctx, "test-reconcile-machines-scale-down")
 	require.NoError(t, err)
@@ -1802,3 +2216,201 @@ func normalizeUnstructured(obj *unstructured.Unstructured) *unstructured.Unstruc
 
 	return normalized
 }
+
+func TestUnroutableControlPlaneEndpoint(t *testing.T) {
+	tests := []struct {
+		name             string
+		endpoint         string
+		infraReady       bool
+		routableNetworks []string
+		unroutable       bool
+	}{
+		{name: "not set, infrastructure not ready", endpoint: "", unroutable: false},
+		{name: "not set, infrastructure ready", endpoint: "", infraReady: true, unroutable: true},
+		{name: "hostname", endpoint: "cp.example.com", unroutable: false},
+		{name: "loopback", endpoint: "127.0.0.1", unroutable: true},
+		{name: "link-local", endpoint: "169.254.0.10", unroutable: true},
+		{name: "public", endpoint: "8.8.8.8", routableNetworks: []string{"10.0.0.0/8"}, unroutable: false},
+		{name: "private without routable networks", endpoint: "192.168.1.10", unroutable: false},
+		{name: "private inside routable networks", endpoint: "10.1.2.3", routableNetworks: []string{"10.0.0.0/8"}, unroutable: false},
+		{name: "private outside routable networks", endpoint: "192.168.1.10", routableNetworks: []string{"10.0.0.0/8"}, unroutable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &clusterv1.Cluster{
+				Spec: clusterv1.ClusterSpec{
+					ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: tt.endpoint, Port: 6443},
+				},
+				Status: clusterv1.ClusterStatus{InfrastructureReady: tt.infraReady},
+			}
+			reason, err := unroutableControlPlaneEndpoint(cluster, tt.routableNetworks)
+			require.NoError(t, err)
+			require.Equal(t, tt.unroutable, reason != "", reason)
+		})
+	}
+
+	_, err := unroutableControlPlaneEndpoint(&clusterv1.Cluster{
+		Spec: clusterv1.ClusterSpec{ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: "10.1.2.3"}},
+	}, []string{"invalid"})
+	require.Error(t, err)
+}
+
+func TestAutopilotPlanTimestamp(t *testing.T) {
+	started := metav1.NewTime(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	kcp := &cpv1beta1.K0sControlPlane{
+		Spec: cpv1beta1.K0sControlPlaneSpec{Version: "v1.31.1+k0s.0"},
+		Status: cpv1beta1.K0sControlPlaneStatus{
+			Operations: []kapi.Operation{{Type: kapi.OperationUpgrade, Target: "v1.31.1+k0s.0", Outcome: kapi.OperationInProgress, StartTime: started}},
+		},
+	}
+	require.Equal(t, fmt.Sprintf("%d", started.Unix()), autopilotPlanTimestamp(kcp))
+
+	// Without an upgrade operation for the version, the plan is stamped with the current time
+	kcp.Spec.Version = "v1.32.0+k0s.0"
+	require.NotEqual(t, fmt.Sprintf("%d", started.Unix()), autopilotPlanTimestamp(kcp))
+}
+
+func TestAutoEnableTunneling(t *testing.T) {
+	unroutable := &clusterv1.Cluster{
+		Spec: clusterv1.ClusterSpec{ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: "127.0.0.1", Port: 6443}},
+	}
+	routable := &clusterv1.Cluster{
+		Spec: clusterv1.ClusterSpec{ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: "8.8.8.8", Port: 6443}},
+	}
+
+	// Disabled by default
+	kcp := &cpv1beta1.K0sControlPlane{}
+	enabled, err := autoEnableTunneling(ctx, unroutable, kcp)
+	require.NoError(t, err)
+	require.False(t, enabled)
+	require.False(t, kcp.Spec.K0sConfigSpec.Tunneling.Enabled)
+
+	kcp.Spec.K0sConfigSpec.Tunneling.AutoEnable = true
+	enabled, err = autoEnableTunneling(ctx, routable, kcp)
+	require.NoError(t, err)
+	require.False(t, enabled)
+	require.False(t, conditions.Has(kcp, cpv1beta1.TunnelingAutoEnabledCondition))
+
+	enabled, err = autoEnableTunneling(ctx, unroutable, kcp)
+	require.NoError(t, err)
+	require.True(t, enabled)
+	require.True(t, kcp.Spec.K0sConfigSpec.Tunneling.Enabled)
+	require.Equal(t, "tunnel", kcp.Spec.K0sConfigSpec.Tunneling.Mode)
+	require.True(t, conditions.IsTrue(kcp, cpv1beta1.TunnelingAutoEnabledCondition))
+
+	// The next reconciliations start from the spec left as is, tunneling stays enabled once the condition is set
+	kcp.Spec.K0sConfigSpec.Tunneling = bootstrapv1.TunnelingSpec{AutoEnable: true}
+	enabled, err = autoEnableTunneling(ctx, routable, kcp)
+	require.NoError(t, err)
+	require.True(t, enabled)
+	require.True(t, kcp.Spec.K0sConfigSpec.Tunneling.Enabled)
+}
+
+func TestUpgradeApproved(t *testing.T) {
+	kcp := &cpv1beta1.K0sControlPlane{Spec: cpv1beta1.K0sControlPlaneSpec{Version: "v1.31.2+k0s.0"}}
+	require.True(t, upgradeApproved(kcp))
+
+	kcp.Annotations = map[string]string{
+		cpv1beta1.UpgradeApprovalRequiredAnnotation: "true",
+		cpv1beta1.UpgradeApprovedVersionAnnotation:  "v1.31.1+k0s.0",
+	}
+	require.False(t, upgradeApproved(kcp))
+	require.True(t, conditions.IsTrue(kcp, cpv1beta1.UpgradeAwaitingApprovalCondition))
+
+	kcp.Annotations[cpv1beta1.UpgradeApprovedVersionAnnotation] = "v1.31.2+k0s.0"
+	require.True(t, upgradeApproved(kcp))
+	require.False(t, conditions.Has(kcp, cpv1beta1.UpgradeAwaitingApprovalCondition))
+}
+
+func TestControlPlaneCommonLabelsForClusterWatchFilter(t *testing.T) {
+	kcp := &cpv1beta1.K0sControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "test",
+			Labels: map[string]string{clusterv1.WatchLabel: "shard-a", "team": "a"},
+		},
+		Spec: cpv1beta1.K0sControlPlaneSpec{
+			MachineTemplate: &cpv1beta1.K0sControlPlaneMachineTemplate{
+				ObjectMeta: clusterv1.ObjectMeta{Labels: map[string]string{"role": "controller"}},
+			},
+		},
+	}
+
+	// Only the watch filter label of the control plane is propagated to the generated objects
+	require.Equal(t, map[string]string{
+		clusterv1.WatchLabel:                   "shard-a",
+		"role":                                 "controller",
+		clusterv1.ClusterNameLabel:             "test-cluster",
+		clusterv1.MachineControlPlaneLabel:     "true",
+		clusterv1.MachineControlPlaneNameLabel: "test",
+	}, controlPlaneCommonLabelsForCluster(kcp, "test-cluster"))
+}
+
+func TestSetReadyCondition(t *testing.T) {
+	kcp := &cpv1beta1.K0sControlPlane{}
+	conditions.MarkTrue(kcp, cpv1beta1.ControlPlaneReadyCondition)
+	conditions.MarkTrue(kcp, cpv1beta1.MachinesReadyCondition)
+	// Informational conditions are not summarized
+	conditions.MarkFalse(kcp, cpv1beta1.TunnelingAutoEnabledCondition, "EndpointNotRoutable", clusterv1.ConditionSeverityInfo, "")
+	setReadyCondition(kcp)
+	require.True(t, conditions.IsTrue(kcp, clusterv1.ReadyCondition))
+
+	conditions.MarkFalse(kcp, cpv1beta1.MachinesReadyCondition, "MachineNotReady", clusterv1.ConditionSeverityWarning, "")
+	setReadyCondition(kcp)
+	require.True(t, conditions.IsFalse(kcp, clusterv1.ReadyCondition))
+	require.Equal(t, "MachineNotReady", conditions.GetReason(kcp, clusterv1.ReadyCondition))
+
+	conditions.MarkTrue(kcp, cpv1beta1.MachinesReadyCondition)
+	conditions.MarkTrueWithNegativePolarity(kcp, cpv1beta1.UpgradeAwaitingApprovalCondition, "VersionNotApproved", clusterv1.ConditionSeverityInfo, "")
+	setReadyCondition(kcp)
+	require.True(t, conditions.IsFalse(kcp, clusterv1.ReadyCondition))
+	require.Equal(t, "VersionNotApproved", conditions.GetReason(kcp, clusterv1.ReadyCondition))
+
+	conditions.Delete(kcp, cpv1beta1.UpgradeAwaitingApprovalCondition)
+	setReadyCondition(kcp)
+	require.True(t, conditions.IsTrue(kcp, clusterv1.ReadyCondition))
+}
+
+func TestK0sControlPlaneControllerWithoutSecretWatch(t *testing.T) {
+	recorder := startInformerRecordingManager(t, func(mgr ctrl.Manager) error {
+		return (&K0sController{Client: mgr.GetClient(), SecretCachingClient: mgr.GetClient()}).SetupWithManager(mgr)
+	})
+
+	require.Eventually(t, func() bool {
+		return recorder.started("K0sControlPlane") && recorder.started("Machine") && recorder.started("ConfigMap")
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.False(t, recorder.started("Secret"))
+}
+
+func TestApplyProviderConfigServerAddress(t *testing.T) {
+	pc := &kapi.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: kapi.DefaultProviderConfigName, Namespace: "team-a"},
+		Spec:       kapi.ProviderConfigSpec{Tunneling: &kapi.ProviderConfigTunneling{ServerAddress: "frps.team-a.example.com"}},
+	}
+	providerConfigScheme := runtime.NewScheme()
+	require.NoError(t, kapi.AddToScheme(providerConfigScheme))
+	c := &K0sController{Client: fake.NewClientBuilder().WithScheme(providerConfigScheme).WithObjects(pc).Build()}
+	kcp := func(namespace, address string) *cpv1beta1.K0sControlPlane {
+		return &cpv1beta1.K0sControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "kcp", Namespace: namespace},
+			Spec: cpv1beta1.K0sControlPlaneSpec{K0sConfigSpec: bootstrapv1.K0sConfigSpec{
+				Tunneling: bootstrapv1.TunnelingSpec{Enabled: true, ServerAddress: address},
+			}},
+		}
+	}
+
+	own := kcp("team-a", "10.0.0.1")
+	applied, err := c.applyProviderConfigServerAddress(ctx, own)
+	require.NoError(t, err)
+	assert.False(t, applied)
+	assert.Equal(t, "10.0.0.1", own.Spec.K0sConfigSpec.Tunneling.ServerAddress)
+
+	defaulted := kcp("team-a", "")
+	applied, err = c.applyProviderConfigServerAddress(ctx, defaulted)
+	require.NoError(t, err)
+	assert.True(t, applied)
+	assert.Equal(t, "frps.team-a.example.com", defaulted.Spec.K0sConfigSpec.Tunneling.ServerAddress)
+
+	applied, err = c.applyProviderConfigServerAddress(ctx, kcp("team-b", ""))
+	require.NoError(t, err)
+	assert.False(t, applied)
+}
@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
 	"github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
 )
 
@@ -36,7 +37,11 @@ import (
 //
 // NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
 // as this struct is used only for temporary operations and does not need to be deeply copied.
-type K0sControlPlaneValidator struct{}
+type K0sControlPlaneValidator struct {
+	// CrossNamespaceMachineTemplates allows the infrastructure machine template to be in another namespace than the
+	// K0sControlPlane.
+	CrossNamespaceMachineTemplates bool
+}
 
 var _ webhook.CustomValidator = &K0sControlPlaneValidator{}
 
@@ -57,6 +62,9 @@ func (v *K0sControlPlaneValidator) ValidateCreate(_ context.Context, obj runtime
 	}
 
 	warnings := v.validateVersionSuffix(kcp.Spec.Version)
+	if err := v.denyCrossNamespaceMachineTemplate(kcp); err != nil {
+		return warnings, err
+	}
 	return warnings, validateK0sControlPlane(kcp)
 }
 
@@ -73,6 +81,15 @@ func (v *K0sControlPlaneValidator) ValidateUpdate(_ context.Context, oldObj, new
 
 	warnings := v.validateVersionSuffix(newKCP.Spec.Version)
 
+	// The namespace of the reference was ignored before the cross-namespace machine templates were supported, so
+	// existing objects are only checked when the reference changes.
+	if oldKCP.Spec.MachineTemplate == nil || newKCP.Spec.MachineTemplate == nil ||
+		oldKCP.Spec.MachineTemplate.InfrastructureRef != newKCP.Spec.MachineTemplate.InfrastructureRef {
+		if err := v.denyCrossNamespaceMachineTemplate(newKCP); err != nil {
+			return warnings, err
+		}
+	}
+
 	if oldKCP.Spec.Version != newKCP.Spec.Version {
 		oldV, err := version.NewVersion(oldKCP.Spec.Version)
 		if err != nil {
@@ -102,11 +119,63 @@ func validateK0sControlPlane(kcp *v1beta1.K0sControlPlane) error {
 		return err
 	}
 
-	// nolint:revive
 	if err := denyRecreateOnSingleClusters(kcp); err != nil {
 		return err
 	}
 
+	// nolint:revive
+	if err := validateTunneling(&kcp.Spec.K0sConfigSpec.Tunneling); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateTunneling denies the tunneling settings the tunneling providers cannot be deployed with.
+func validateTunneling(tunneling *bootstrapv1.TunnelingSpec) error {
+	if !tunneling.Enabled {
+		return nil
+	}
+
+	if d := tunneling.ServerDeployment; d != nil && d.Replicas != nil && *d.Replicas > 1 &&
+		tunneling.Provider != bootstrapv1.TunnelingProviderKonnectivity {
+		return fmt.Errorf("spec.k0sConfigSpec.tunneling.serverDeployment.replicas must be 1, only the konnectivity provider runs more than one tunneling server")
+	}
+
+	if len(tunneling.ExtraPorts) > 0 {
+		if tunneling.ServerRef != nil {
+			return fmt.Errorf("spec.k0sConfigSpec.tunneling.extraPorts is not supported with spec.k0sConfigSpec.tunneling.serverRef")
+		}
+		// The extra ports are published next to the api and tunnel ports of the frps service
+		names := map[string]bool{"api": true, "tunnel": true}
+		for _, p := range tunneling.ExtraPorts {
+			if names[p.Name] {
+				return fmt.Errorf("spec.k0sConfigSpec.tunneling.extraPorts: duplicate or reserved port name %s", p.Name)
+			}
+			names[p.Name] = true
+		}
+	}
+
+	if tunneling.Provider == bootstrapv1.TunnelingProviderWireGuard {
+		if _, _, err := tunneling.GetWireGuardPeerAddresses(); err != nil {
+			return fmt.Errorf("spec.k0sConfigSpec.tunneling.wireGuard.network: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// denyCrossNamespaceMachineTemplate denies the references to an infrastructure machine template of another namespace
+// unless they are enabled.
+func (v *K0sControlPlaneValidator) denyCrossNamespaceMachineTemplate(kcp *v1beta1.K0sControlPlane) error {
+	if v.CrossNamespaceMachineTemplates || kcp.Spec.MachineTemplate == nil {
+		return nil
+	}
+	ref := kcp.Spec.MachineTemplate.InfrastructureRef
+	if ref.Namespace != "" && ref.Namespace != kcp.Namespace {
+		return fmt.Errorf("spec.machineTemplate.infrastructureRef.namespace %s must be the namespace of the K0sControlPlane, "+
+			"cross-namespace machine templates are disabled", ref.Namespace)
+	}
 	return nil
 }
 
@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+)
+
+func TestCrossNamespaceMachineTemplateValidation(t *testing.T) {
+	kcp := func(templateNamespace string) *cpv1beta1.K0sControlPlane {
+		return &cpv1beta1.K0sControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "kcp", Namespace: "team-a"},
+			Spec: cpv1beta1.K0sControlPlaneSpec{
+				Version: "v1.31.2+k0s.0",
+				MachineTemplate: &cpv1beta1.K0sControlPlaneMachineTemplate{
+					InfrastructureRef: corev1.ObjectReference{
+						APIVersion: "infrastructure.cluster.x-k8s.io/v1beta1",
+						Kind:       "DockerMachineTemplate",
+						Name:       "hardened",
+						Namespace:  templateNamespace,
+					},
+				},
+			},
+		}
+	}
+
+	disabled := &K0sControlPlaneValidator{}
+	for _, namespace := range []string{"", "team-a"} {
+		_, err := disabled.ValidateCreate(context.Background(), kcp(namespace))
+		require.NoError(t, err, namespace)
+	}
+	_, err := disabled.ValidateCreate(context.Background(), kcp("templates"))
+	require.Error(t, err)
+
+	// Existing objects are only denied when their reference changes.
+	_, err = disabled.ValidateUpdate(context.Background(), kcp("templates"), kcp("templates"))
+	require.NoError(t, err)
+	_, err = disabled.ValidateUpdate(context.Background(), kcp("team-a"), kcp("templates"))
+	require.Error(t, err)
+
+	enabled := &K0sControlPlaneValidator{CrossNamespaceMachineTemplates: true}
+	_, err = enabled.ValidateCreate(context.Background(), kcp("templates"))
+	require.NoError(t, err)
+}
+
+func TestNamespaceAllowed(t *testing.T) {
+	template := func(allowed string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			cpv1beta1.AllowedNamespacesAnnotation: allowed,
+		}}}
+	}
+
+	assert.True(t, namespaceAllowed(template("team-a"), "team-a"))
+	assert.True(t, namespaceAllowed(template("team-b, team-a"), "team-a"))
+	assert.True(t, namespaceAllowed(template("*"), "team-a"))
+	assert.False(t, namespaceAllowed(template("team-b"), "team-a"))
+	assert.False(t, namespaceAllowed(template(""), "team-a"))
+	assert.False(t, namespaceAllowed(&corev1.ConfigMap{}, "team-a"))
+}
+
+func TestValidateTunneling(t *testing.T) {
+	wireGuard := func(network string) *bootstrapv1.TunnelingSpec {
+		return &bootstrapv1.TunnelingSpec{
+			Enabled:   true,
+			Provider:  bootstrapv1.TunnelingProviderWireGuard,
+			WireGuard: &bootstrapv1.TunnelingWireGuardSpec{Network: network},
+		}
+	}
+
+	require.NoError(t, validateTunneling(&bootstrapv1.TunnelingSpec{}))
+	require.NoError(t, validateTunneling(wireGuard("")))
+	require.NoError(t, validateTunneling(wireGuard("192.168.77.0/29")))
+	require.Error(t, validateTunneling(wireGuard("192.168.77.0/31")))
+	require.Error(t, validateTunneling(wireGuard("192.168.77.0")))
+
+	replicas := func(provider bootstrapv1.TunnelingProvider, replicas int32) *bootstrapv1.TunnelingSpec {
+		return &bootstrapv1.TunnelingSpec{
+			Enabled:          true,
+			Provider:         provider,
+			ServerDeployment: &bootstrapv1.TunnelingDeploymentSpec{Replicas: ptr.To(replicas)},
+		}
+	}
+	require.NoError(t, validateTunneling(replicas(bootstrapv1.TunnelingProviderFRP, 1)))
+	require.NoError(t, validateTunneling(replicas(bootstrapv1.TunnelingProviderKonnectivity, 3)))
+	require.Error(t, validateTunneling(replicas(bootstrapv1.TunnelingProviderFRP, 2)))
+	require.Error(t, validateTunneling(replicas("", 2)))
+	require.Error(t, validateTunneling(replicas(bootstrapv1.TunnelingProviderWireGuard, 2)))
+
+	extraPorts := func(serverRef *bootstrapv1.TunnelServerReference, names ...string) *bootstrapv1.TunnelingSpec {
+		tunneling := &bootstrapv1.TunnelingSpec{Enabled: true, ServerRef: serverRef}
+		for i, name := range names {
+			tunneling.ExtraPorts = append(tunneling.ExtraPorts, bootstrapv1.TunnelingPort{Name: name, Port: 8132, NodePort: 31132 + int32(i)})
+		}
+		return tunneling
+	}
+	require.NoError(t, validateTunneling(extraPorts(nil, "konnectivity", "metrics")))
+	require.NoError(t, validateTunneling(extraPorts(&bootstrapv1.TunnelServerReference{Name: "shared"})))
+	require.Error(t, validateTunneling(extraPorts(&bootstrapv1.TunnelServerReference{Name: "shared"}, "konnectivity")))
+	require.Error(t, validateTunneling(extraPorts(nil, "konnectivity", "konnectivity")))
+	require.Error(t, validateTunneling(extraPorts(nil, "tunnel")))
+}
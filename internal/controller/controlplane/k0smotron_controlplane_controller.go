@@ -55,6 +55,9 @@ import (
 	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
 	"github.com/k0sproject/k0smotron/internal/controller/util"
 	"github.com/k0sproject/k0smotron/internal/exec"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 	"github.com/k0sproject/version"
 )
 
@@ -164,6 +167,13 @@ func (c *K0smotronController) Reconcile(ctx context.Context, req ctrl.Request) (
 			}
 		}
 
+		// Summarize the conditions in the Ready condition reported by clusterctl describe
+		conditions.SetSummary(kcp,
+			conditions.WithConditions(
+				cpv1beta1.ControlPlaneReadyCondition,
+			),
+		)
+
 		derr = kcpPatchHelper.Patch(ctx, kcp)
 		if derr != nil {
 			log.Error(derr, "Failed to patch K0smotronControlPlane")
@@ -252,6 +262,12 @@ func (c *K0smotronController) waitExternalAddress(ctx context.Context, cluster *
 				return false, err
 			}
 			log.Info("Found infrastructure cluster")
+
+			// Only the endpoint is applied, the rest of the infrastructure cluster is owned by its provider.
+			infraCluster = &unstructured.Unstructured{}
+			infraCluster.SetGroupVersionKind(cluster.Spec.InfrastructureRef.GroupVersionKind())
+			infraCluster.SetNamespace(cluster.Namespace)
+			infraCluster.SetName(cluster.Spec.InfrastructureRef.Name)
 			newEndpoint := map[string]interface{}{
 				"host": host,
 				"port": int64(port),
@@ -261,7 +277,7 @@ func (c *K0smotronController) waitExternalAddress(ctx context.Context, cluster *
 				log.Error(err, "Failed to set controlPlaneEndpoint in infrastructure cluster")
 				return false, err
 			}
-			if err := c.Client.Update(ctx, infraCluster); err != nil {
+			if err := util.Apply(ctx, c.Client, infraCluster); err != nil {
 				log.Error(err, "Failed to update infrastructure cluster")
 				return false, err
 			}
@@ -321,9 +337,7 @@ func (c *K0smotronController) reconcile(ctx context.Context, cluster *clusterv1.
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      cluster.Name,
 			Namespace: cluster.Namespace,
-			Labels: map[string]string{
-				clusterv1.ClusterNameLabel: cluster.Name,
-			},
+			Labels:    k0smotronClusterLabels(kcp, cluster.Name),
 			OwnerReferences: []metav1.OwnerReference{
 				{
 					APIVersion: cpv1beta1.GroupVersion.String(),
@@ -339,7 +353,7 @@ func (c *K0smotronController) reconcile(ctx context.Context, cluster *clusterv1.
 	var foundCluster kapi.Cluster
 	err = c.Client.Get(ctx, types.NamespacedName{Name: desiredK0smotronCluster.Name, Namespace: desiredK0smotronCluster.Namespace}, &foundCluster)
 	if err != nil && apierrors.IsNotFound(err) {
-		if err := c.Client.Create(ctx, &desiredK0smotronCluster); err != nil {
+		if err := util.Apply(ctx, c.Client, &desiredK0smotronCluster); err != nil {
 			return ctrl.Result{}, false, err
 		}
 
@@ -356,15 +370,7 @@ func (c *K0smotronController) reconcile(ctx context.Context, cluster *clusterv1.
 		return ctrl.Result{}, false, fmt.Errorf("error comparing cluster spec between k0smotron.Cluster and k0smotronControlPlane: %w", err)
 	}
 	if !isClusterSpecSynced {
-		patchHelper, err := patch.NewHelper(&foundCluster, c.Client)
-		if err != nil {
-			return ctrl.Result{}, false, err
-		}
-
-		// Modidy current Cluster specification with the desired one.
-		foundCluster.Spec = kcp.Spec
-
-		return ctrl.Result{}, false, patchHelper.Patch(ctx, &foundCluster)
+		return ctrl.Result{}, false, util.Apply(ctx, c.Client, &desiredK0smotronCluster)
 	}
 
 	return ctrl.Result{}, foundCluster.Status.Ready, nil
@@ -399,7 +405,10 @@ func isClusterSpecSynced(kmcSpec, kcpSpec kapi.ClusterSpec) (bool, error) {
 	return reflect.DeepEqual(kmcSpec, *overridenKmcSpec), nil
 }
 
-func ensureCertificates(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0smotronControlPlane, scope *kmcScope) error {
+func ensureCertificates(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0smotronControlPlane, scope *kmcScope) (err error) {
+	ctx, span := tracing.Start(ctx, "ensureCertificates", tracing.Cluster(cluster.Name))
+	defer func() { tracing.End(span, err) }()
+
 	certificates := secret.NewCertificatesForInitialControlPlane(&bootstrapv1.ClusterConfiguration{})
 	return certificates.LookupOrGenerateCached(ctx, scope.secretCachingClient, scope.client, capiutil.ObjectKey(cluster), *metav1.NewControllerRef(kcp, cpv1beta1.GroupVersion.WithKind("K0smotronControlPlane")))
 }
@@ -518,6 +527,9 @@ func (c *K0smotronController) computeStatus(ctx context.Context, cluster *cluste
 // computeAvailability checks if the control plane is ready by connecting to the API server
 // and checking if the control plane is initialized
 func (c *K0smotronController) computeAvailability(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0smotronControlPlane) {
+	ctx, span := tracing.Start(ctx, "computeAvailability", tracing.Cluster(cluster.Name))
+	defer span.End()
+
 	logger := log.FromContext(ctx).WithValues("cluster", cluster.Name)
 	// Check if the control plane is ready by connecting to the API server
 	// and checking if the control plane is initialized
@@ -527,7 +539,7 @@ func (c *K0smotronController) computeAvailability(ctx context.Context, cluster *
 	client, err := remote.NewClusterClient(ctx, "k0smotron", c.Client, capiutil.ObjectKey(cluster))
 	if err != nil {
 		logger.Info("Failed to create cluster client", "error", err)
-		conditions.MarkFalse(kcp, cpv1beta1.ControlPlaneReadyCondition, "ClusterClientCreationFailed", clusterv1.ConditionSeverityWarning, "Failed to create cluster client: %v", err)
+		conditions.MarkFalse(kcp, cpv1beta1.ControlPlaneReadyCondition, cpv1beta1.ClusterClientCreationFailedReason, clusterv1.ConditionSeverityWarning, "Failed to create cluster client: %v", err)
 		return
 	}
 
@@ -543,7 +555,7 @@ func (c *K0smotronController) computeAvailability(ctx context.Context, cluster *
 	err = client.Get(pingCtx, nsKey, ns)
 	if err != nil {
 		logger.Info("Failed to get workload cluster namespace", "error", err)
-		conditions.MarkFalse(kcp, cpv1beta1.ControlPlaneReadyCondition, "KubeSystemNamespaceNotAccessible", clusterv1.ConditionSeverityWarning, "Failed to get kube-system namespace: %v", err)
+		conditions.MarkFalse(kcp, cpv1beta1.ControlPlaneReadyCondition, cpv1beta1.KubeSystemNamespaceNotAccessibleReason, clusterv1.ConditionSeverityWarning, "Failed to get kube-system namespace: %v", err)
 		return
 	}
 
@@ -686,8 +698,14 @@ func (c *K0smotronController) getKmcScope(ctx context.Context, kcp *cpv1beta1.K0
 
 // SetupWithManager sets up the controller with the Manager.
 func (c *K0smotronController) SetupWithManager(mgr ctrl.Manager) error {
+	metrics.RegisterObjects("K0smotronControlPlane", mgr.GetClient(), func() client.ObjectList { return &cpv1beta1.K0smotronControlPlaneList{} }, nil)
+
+	if err := health.AddChecks(mgr, "k0smotroncontrolplane", &cpv1beta1.K0smotronControlPlane{}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&cpv1beta1.K0smotronControlPlane{}).
 		Owns(&kapi.Cluster{}, builder.MatchEveryOwner).
-		Complete(c)
+		Complete(metrics.Instrument("k0smotroncontrolplane", mgr.GetClient(), func() client.Object { return &cpv1beta1.K0smotronControlPlane{} }, nil, tracing.Instrument("k0smotroncontrolplane", health.Instrument("k0smotroncontrolplane", c))))
 }
@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/runtimehooks"
+)
+
+// errLifecycleHookBlocking is returned while a lifecycle hook holds back the reconciliation of the machines.
+type errLifecycleHookBlocking struct {
+	hook       string
+	retryAfter time.Duration
+}
+
+func (e *errLifecycleHookBlocking) Error() string {
+	return fmt.Sprintf("waiting for the %s hook, retrying in %s", e.hook, e.retryAfter)
+}
+
+// lifecycleHooksEnabled returns whether the K0sControlPlane controller calls the lifecycle hooks of the cluster. The
+// hooks of the clusters with a managed topology are called by the Cluster API topology controller, which only updates
+// the version of the control plane once the BeforeClusterUpgrade hook allows it.
+func (c *K0sController) lifecycleHooksEnabled(cluster *clusterv1.Cluster) bool {
+	return c.RuntimeHooks != nil && cluster.Spec.Topology == nil
+}
+
+// beforeClusterUpgrade calls the BeforeClusterUpgrade hook before the machines of the control plane are upgraded. Once
+// the hook allows the upgrade, the AfterControlPlaneUpgrade hook is marked as pending, which also keeps the
+// BeforeClusterUpgrade hook from being called again for the same upgrade.
+func (c *K0sController) beforeClusterUpgrade(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane, currentVersion string) error {
+	if !c.lifecycleHooksEnabled(cluster) || runtimehooks.IsPending(kcp, runtimehooks.AfterControlPlaneUpgrade) {
+		return nil
+	}
+
+	resp, err := c.RuntimeHooks.BeforeClusterUpgrade(ctx, cluster, kubernetesVersion(currentVersion), kubernetesVersion(kcp.Spec.Version))
+	if err != nil {
+		return fmt.Errorf("error calling the %s hook: %w", runtimehooks.BeforeClusterUpgrade, err)
+	}
+	if resp.RetryAfterSeconds > 0 {
+		return lifecycleHookBlocking(kcp, runtimehooks.BeforeClusterUpgrade, resp.RetryAfterSeconds, resp.Message)
+	}
+	conditions.Delete(kcp, cpv1beta1.LifecycleHookBlockingCondition)
+
+	log.FromContext(ctx).Info("Upgrade allowed by the lifecycle hooks", "hook", runtimehooks.BeforeClusterUpgrade)
+	return runtimehooks.MarkPending(ctx, c.Client, kcp, runtimehooks.AfterControlPlaneUpgrade)
+}
+
+// upgradeApproved returns whether the machines of the control plane may be upgraded to its version. The upgrades of
+// the control planes annotated with UpgradeApprovalRequiredAnnotation wait for their version to be approved.
+func upgradeApproved(kcp *cpv1beta1.K0sControlPlane) bool {
+	if _, required := kcp.Annotations[cpv1beta1.UpgradeApprovalRequiredAnnotation]; !required ||
+		kcp.Annotations[cpv1beta1.UpgradeApprovedVersionAnnotation] == kcp.Spec.Version {
+		conditions.Delete(kcp, cpv1beta1.UpgradeAwaitingApprovalCondition)
+		return true
+	}
+
+	conditions.MarkTrueWithNegativePolarity(kcp, cpv1beta1.UpgradeAwaitingApprovalCondition, "VersionNotApproved",
+		clusterv1.ConditionSeverityInfo, "Upgrade to %s awaiting approval", kcp.Spec.Version)
+	return false
+}
+
+// afterControlPlaneUpgrade calls the pending AfterControlPlaneUpgrade hook once all the machines of the control plane
+// run the desired version. The hook is called until it does not ask to be retried.
+func (c *K0sController) afterControlPlaneUpgrade(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
+	if !c.lifecycleHooksEnabled(cluster) || !runtimehooks.IsPending(kcp, runtimehooks.AfterControlPlaneUpgrade) {
+		return nil
+	}
+
+	resp, err := c.RuntimeHooks.AfterControlPlaneUpgrade(ctx, cluster, kubernetesVersion(kcp.Spec.Version))
+	if err != nil {
+		return fmt.Errorf("error calling the %s hook: %w", runtimehooks.AfterControlPlaneUpgrade, err)
+	}
+	if resp.RetryAfterSeconds > 0 {
+		return lifecycleHookBlocking(kcp, runtimehooks.AfterControlPlaneUpgrade, resp.RetryAfterSeconds, resp.Message)
+	}
+	conditions.Delete(kcp, cpv1beta1.LifecycleHookBlockingCondition)
+
+	return runtimehooks.MarkDone(ctx, c.Client, kcp, runtimehooks.AfterControlPlaneUpgrade)
+}
+
+func lifecycleHookBlocking(kcp *cpv1beta1.K0sControlPlane, hook string, retryAfterSeconds int32, message string) error {
+	conditions.MarkTrueWithNegativePolarity(kcp, cpv1beta1.LifecycleHookBlockingCondition, hook,
+		clusterv1.ConditionSeverityInfo, "%s", message)
+	return &errLifecycleHookBlocking{hook: hook, retryAfter: time.Duration(retryAfterSeconds) * time.Second}
+}
+
+// kubernetesVersion returns the Kubernetes version of a k0s version, e.g. v1.30.3 for v1.30.3+k0s.0.
+func kubernetesVersion(k0sVersion string) string {
+	v, _, _ := strings.Cut(k0sVersion, "+")
+	return "v" + strings.TrimPrefix(v, "v")
+}
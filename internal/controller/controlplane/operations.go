@@ -0,0 +1,59 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+)
+
+// k0sControlPlaneOperationActor is the actor of the operations recorded in the status of the K0sControlPlane.
+const k0sControlPlaneOperationActor = "k0s-controlplane-controller"
+
+// recordScaleOperation starts a scale operation when the desired number of replicas differs from the target of the
+// last one, or from the observed replicas if no scale operation was recorded yet. The operation succeeds once the
+// control plane settles, i.e. no machine is being upgraded, replaced or remediated, on the desired number of machines.
+func recordScaleOperation(kcp *cpv1beta1.K0sControlPlane, activeMachines int, settled bool) {
+	target := strconv.Itoa(int(kcp.Spec.Replicas))
+
+	var from string
+	if last := kapi.LastOperation(kcp.Status.Operations, kapi.OperationScale); last != nil {
+		from = last.Target
+	} else if settled {
+		// Without history, only trust the observed replicas while no machine is being replaced.
+		from = strconv.Itoa(int(kcp.Status.Replicas))
+	}
+	if from != "" && from != target {
+		kcp.Status.Operations = kapi.StartOperation(kcp.Status.Operations, kapi.OperationScale, k0sControlPlaneOperationActor, target,
+			fmt.Sprintf("Scaling from %s to %s replicas", from, target))
+	}
+
+	if settled && activeMachines == int(kcp.Spec.Replicas) {
+		kcp.Status.Operations = kapi.CompleteOperation(kcp.Status.Operations, kapi.OperationScale, kapi.OperationSucceeded, "")
+	}
+}
+
+// tokenFingerprint identifies a token in the operation history without revealing it.
+func tokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -21,6 +21,8 @@ import (
 	"fmt"
 
 	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
 	"github.com/pkg/errors"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -34,7 +36,7 @@ import (
 func (c *K0sController) reconcileUnhealthyMachines(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) (retErr error) {
 	log := ctrl.LoggerFrom(ctx)
 
-	machines, err := collections.GetFilteredMachinesForCluster(ctx, c, cluster, collections.ControlPlaneMachines(cluster.Name))
+	machines, err := util.GetControlPlaneMachines(ctx, c, kcp)
 	if err != nil {
 		return fmt.Errorf("failed to filter machines for control plane: %w", err)
 	}
@@ -111,9 +113,13 @@ func (c *K0sController) reconcileUnhealthyMachines(ctx context.Context, cluster
 
 	if err := c.runMachineDeletionSequence(ctx, cluster, kcp, machineToBeRemediated); err != nil {
 		conditions.MarkFalse(machineToBeRemediated, clusterv1.MachineOwnerRemediatedCondition, clusterv1.RemediationFailedReason, clusterv1.ConditionSeverityError, err.Error())
+		kcp.Status.Operations = kapi.RecordOperation(kcp.Status.Operations, kapi.OperationRemediation, k0sControlPlaneOperationActor,
+			machineToBeRemediated.Name, kapi.OperationFailed, fmt.Sprintf("Failed to delete unhealthy machine %s: %s", machineToBeRemediated.Name, err))
 		return errors.Wrapf(err, "failed to delete unhealthy machine %s", machineToBeRemediated.Name)
 	}
 	log.Info("Remediated unhealthy machine, another new machine should take its place soon.")
+	kcp.Status.Operations = kapi.StartOperation(kcp.Status.Operations, kapi.OperationRemediation, k0sControlPlaneOperationActor,
+		machineToBeRemediated.Name, fmt.Sprintf("Deleted unhealthy machine %s, waiting for its replacement", machineToBeRemediated.Name))
 
 	// Mark controlplane to track that remediation is in progress and do not proceed until machine is gone.
 	// This annotation is removed when new controlplane creates a new machine.
@@ -0,0 +1,64 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"fmt"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/labels/format"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
+)
+
+// restoredCertificates are the certificates generated for the K0sControlPlane, readopted after a restore.
+var restoredCertificates = []secret.Purpose{secret.ClusterCA, secret.EtcdCA, secret.FrontProxyCA, secret.ServiceAccount}
+
+// readoptRestoredObjects points the machines and the certificates of the control plane to the K0sControlPlane again
+// after they were restored from a backup. It must run before the machines are reconciled, otherwise the machines
+// restored without owner are not found and new ones are created.
+func (c *K0sController) readoptRestoredObjects(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
+	gvk := cpv1beta1.GroupVersion.WithKind("K0sControlPlane")
+
+	names := make([]string, 0, len(restoredCertificates))
+	for _, purpose := range restoredCertificates {
+		names = append(names, secret.Name(cluster.Name, purpose))
+	}
+	if err := util.ReadoptSecrets(ctx, c.SecretCachingClient, kcp, gvk, names...); err != nil {
+		return err
+	}
+
+	machines := &clusterv1.MachineList{}
+	err := c.Client.List(ctx, machines, client.InNamespace(kcp.Namespace), client.MatchingLabels{
+		clusterv1.ClusterNameLabel:             cluster.Name,
+		clusterv1.MachineControlPlaneNameLabel: format.MustFormatValue(kcp.Name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list control plane machines: %w", err)
+	}
+	for i := range machines.Items {
+		if err := util.ReadoptObject(ctx, c.Client, &machines.Items[i], kcp, gvk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
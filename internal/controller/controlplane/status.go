@@ -40,6 +40,8 @@ import (
 	autopilot "github.com/k0sproject/k0s/pkg/apis/autopilot/v1beta2"
 	"github.com/k0sproject/k0s/pkg/autopilot/controller/plans/core"
 	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	k0smoutil "github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 	"github.com/k0sproject/version"
 )
 
@@ -66,6 +68,12 @@ func (c *K0sController) updateStatus(ctx context.Context, kcp *cpv1beta1.K0sCont
 
 	kcp.Status.Selector = collections.ControlPlaneSelectorForCluster(cluster.Name).String()
 
+	machines, err := k0smoutil.GetControlPlaneMachines(ctx, c.Client, kcp)
+	if err != nil {
+		return fmt.Errorf("failed to get machines: %w", err)
+	}
+	conditions.SetAggregate(kcp, cpv1beta1.MachinesReadyCondition, machines.ConditionGetters(), conditions.AddSourceRef())
+
 	sc, err := c.newReplicasStatusComputer(ctx, cluster, kcp)
 	if err != nil {
 		return err
@@ -77,6 +85,24 @@ func (c *K0sController) updateStatus(ctx context.Context, kcp *cpv1beta1.K0sCont
 	return sc.compute(kcp)
 }
 
+// setReadyCondition summarizes the conditions of the control plane in the Ready condition reported by clusterctl
+// describe. The upgrades held back by a lifecycle hook or waiting for their approval are True while they wait.
+func setReadyCondition(kcp *cpv1beta1.K0sControlPlane) {
+	conditions.SetSummary(kcp,
+		conditions.WithConditions(
+			cpv1beta1.ControlPlaneReadyCondition,
+			cpv1beta1.MachinesReadyCondition,
+			cpv1beta1.ExternalDNSReadyCondition,
+			cpv1beta1.LifecycleHookBlockingCondition,
+			cpv1beta1.UpgradeAwaitingApprovalCondition,
+		),
+		conditions.WithNegativePolarityConditions(
+			cpv1beta1.LifecycleHookBlockingCondition,
+			cpv1beta1.UpgradeAwaitingApprovalCondition,
+		),
+	)
+}
+
 func (c *K0sController) newReplicasStatusComputer(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) (replicaStatusComputer, error) {
 	logger := log.FromContext(ctx)
 
@@ -95,7 +121,7 @@ func (c *K0sController) newReplicasStatusComputer(ctx context.Context, cluster *
 				// on the status of the Machines associated to the controlplane instead of the Plan status since
 				// it does not exist. At this point it is safe to calculate the state via the Machines because the
 				// initial state of the Machine describes the initial state of the controlplane.
-				return newMachineStatusComputer(ctx, c.Client, cluster)
+				return newMachineStatusComputer(ctx, c.Client, kcp)
 			}
 
 			return nil, err
@@ -108,7 +134,7 @@ func (c *K0sController) newReplicasStatusComputer(ctx context.Context, cluster *
 
 		return &planStatus{plan}, nil
 	case cpv1beta1.UpdateRecreate:
-		return newMachineStatusComputer(ctx, c.Client, cluster)
+		return newMachineStatusComputer(ctx, c.Client, kcp)
 	default:
 		return nil, errors.New("upgrade strategy not found")
 	}
@@ -182,8 +208,8 @@ type machineStatus struct {
 	machines collections.Machines
 }
 
-func newMachineStatusComputer(ctx context.Context, c client.Client, cluster *clusterv1.Cluster) (replicaStatusComputer, error) {
-	machines, err := collections.GetFilteredMachinesForCluster(ctx, c, cluster, collections.ControlPlaneMachines(cluster.Name), collections.ActiveMachines)
+func newMachineStatusComputer(ctx context.Context, c client.Client, kcp *cpv1beta1.K0sControlPlane) (replicaStatusComputer, error) {
+	machines, err := k0smoutil.GetControlPlaneMachines(ctx, c, kcp, collections.ActiveMachines)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get machines: %w", err)
 	}
@@ -297,6 +323,9 @@ func versionMatches(machine *clusterv1.Machine, ver string) bool {
 }
 
 func (c *K0sController) computeAvailability(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane, logger logr.Logger) {
+	ctx, span := tracing.Start(ctx, "computeAvailability", tracing.Cluster(cluster.Name))
+	defer span.End()
+
 	kcp.Status.Ready = false
 	logger.Info("Computed status", "status", kcp.Status)
 	// Check if the control plane is ready by connecting to the API server
@@ -307,7 +336,7 @@ func (c *K0sController) computeAvailability(ctx context.Context, cluster *cluste
 	if err != nil {
 		logger.Info("Failed to create cluster client", "error", err)
 		// Set a condition for this so we can determine later if we should requeue the reconciliation
-		conditions.MarkFalse(kcp, cpv1beta1.ControlPlaneReadyCondition, "Unable to connect to the workload cluster API", clusterv1.ConditionSeverityWarning, "Failed to create cluster client: %v", err)
+		conditions.MarkFalse(kcp, cpv1beta1.ControlPlaneReadyCondition, cpv1beta1.ClusterClientCreationFailedReason, clusterv1.ConditionSeverityWarning, "Failed to create cluster client: %v", err)
 		return
 	}
 	pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -321,7 +350,7 @@ func (c *K0sController) computeAvailability(ctx context.Context, cluster *cluste
 	}
 	err = client.Get(pingCtx, nsKey, ns)
 	if err != nil {
-		conditions.MarkFalse(kcp, cpv1beta1.ControlPlaneReadyCondition, "Unable to connect to the workload cluster API", clusterv1.ConditionSeverityWarning, "Failed to get namespace: %v", err)
+		conditions.MarkFalse(kcp, cpv1beta1.ControlPlaneReadyCondition, cpv1beta1.KubeSystemNamespaceNotAccessibleReason, clusterv1.ConditionSeverityWarning, "Failed to get namespace: %v", err)
 		return
 	}
 	logger.Info("Successfully pinged the workload cluster API")
@@ -122,6 +122,7 @@ func TestNewReplicaStatusComputer(t *testing.T) {
 					clusterv1.ClusterNameLabel:         cluster.Name,
 					clusterv1.MachineControlPlaneLabel: "",
 				},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(kcp, cpv1beta1.GroupVersion.WithKind("K0sControlPlane"))},
 			},
 			Spec: clusterv1.MachineSpec{
 				ClusterName: cluster.Name,
@@ -190,6 +191,7 @@ func TestNewReplicaStatusComputer(t *testing.T) {
 					clusterv1.ClusterNameLabel:         cluster.Name,
 					clusterv1.MachineControlPlaneLabel: "",
 				},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(kcp, cpv1beta1.GroupVersion.WithKind("K0sControlPlane"))},
 			},
 			Spec: clusterv1.MachineSpec{
 				ClusterName: cluster.Name,
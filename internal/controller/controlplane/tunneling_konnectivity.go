@@ -0,0 +1,280 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capiutil "sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/certs"
+	"sigs.k8s.io/cluster-api/util/secret"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/keystore"
+)
+
+var (
+	KonnectivityTunnelCertsNameTemplate = "%s-konnectivity-tunnel"
+	KonnectivityServerNameTemplate      = "%s-konnectivity-server"
+)
+
+const (
+	konnectivityServerImage      = "registry.k8s.io/kas-network-proxy/proxy-server:v0.30.3"
+	konnectivityServerPort       = 8443
+	konnectivityAgentPort        = 8132
+	konnectivityAdminPort        = 8133
+	konnectivityHealthPort       = 8134
+	konnectivityCertsMountPath   = "/etc/konnectivity"
+	konnectivityServerCommonName = "k0smotron-konnectivity-server"
+	konnectivityAgentCommonName  = "k0smotron-konnectivity-agent"
+)
+
+// reconcileKonnectivityTunneling deploys a konnectivity server in http-connect mode, to which the konnectivity agents
+// of the child cluster connect. Both the agents and the clients of the proxy must present a certificate signed by the
+// cluster CA, so the regular admin kubeconfig can be used through the proxy.
+func (c *K0sController) reconcileKonnectivityTunneling(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
+	certsSecretName, err := c.createKonnectivityTunnelCerts(ctx, cluster, kcp)
+	if err != nil {
+		return fmt.Errorf("error creating konnectivity certificates: %w", err)
+	}
+
+	labels := map[string]string{
+		"k0smotron_cluster": kcp.GetName(),
+		"app":               "konnectivity-server",
+	}
+	serverCount := int32(1)
+	if d := kcp.Spec.K0sConfigSpec.Tunneling.ServerDeployment; d != nil && d.Replicas != nil {
+		serverCount = *d.Replicas
+	}
+	certFile := func(name string) string {
+		return konnectivityCertsMountPath + "/" + name
+	}
+
+	deployment := appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(KonnectivityServerNameTemplate, kcp.GetName()),
+			Namespace: kcp.GetNamespace(),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &serverCount,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					SecurityContext: util.RestrictedPodSecurityContext(util.NonRootUID),
+					Volumes: []corev1.Volume{{
+						Name: "konnectivity-certs",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName: certsSecretName,
+							},
+						},
+					}},
+					Containers: []corev1.Container{{
+						Name:            "konnectivity-server",
+						Image:           kcp.Spec.K0sConfigSpec.Tunneling.GetImage(konnectivityServerImage),
+						ImagePullPolicy: kcp.Spec.K0sConfigSpec.Tunneling.GetImagePullPolicy(),
+						Command:         []string{"/proxy-server"},
+						SecurityContext: util.RestrictedSecurityContext(),
+						Args: []string{
+							"--mode=http-connect",
+							fmt.Sprintf("--server-count=%d", serverCount),
+							fmt.Sprintf("--server-port=%d", konnectivityServerPort),
+							fmt.Sprintf("--agent-port=%d", konnectivityAgentPort),
+							fmt.Sprintf("--admin-port=%d", konnectivityAdminPort),
+							fmt.Sprintf("--health-port=%d", konnectivityHealthPort),
+							"--server-cert=" + certFile("server.crt"),
+							"--server-key=" + certFile("server.key"),
+							"--server-ca-cert=" + certFile("ca.crt"),
+							"--cluster-cert=" + certFile("server.crt"),
+							"--cluster-key=" + certFile("server.key"),
+							"--cluster-ca-cert=" + certFile("ca.crt"),
+						},
+						Ports: []corev1.ContainerPort{
+							{
+								Name:          "agent",
+								Protocol:      corev1.ProtocolTCP,
+								ContainerPort: konnectivityAgentPort,
+							},
+							{
+								Name:          "tunnel",
+								Protocol:      corev1.ProtocolTCP,
+								ContainerPort: konnectivityServerPort,
+							},
+						},
+						LivenessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{
+									Path: "/healthz",
+									Port: intstr.FromInt(konnectivityHealthPort),
+								},
+							},
+						},
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "konnectivity-certs",
+							MountPath: konnectivityCertsMountPath,
+							ReadOnly:  true,
+						}},
+					}},
+				},
+			},
+		},
+	}
+	applyTunnelingDeploymentSpec(&deployment, kcp.Spec.K0sConfigSpec.Tunneling.ServerDeployment)
+	_ = ctrl.SetControllerReference(kcp, &deployment, c.Client.Scheme())
+	err = util.Apply(ctx, c.Client, &deployment)
+	if err != nil {
+		return fmt.Errorf("error creating Deployment: %w", err)
+	}
+
+	service := corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(KonnectivityServerNameTemplate, kcp.GetName()),
+			Namespace: kcp.GetNamespace(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{{
+				Name:       "agent",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       konnectivityAgentPort,
+				TargetPort: intstr.FromInt(konnectivityAgentPort),
+				NodePort:   kcp.Spec.K0sConfigSpec.Tunneling.ServerNodePort,
+			}, {
+				Name:       "tunnel",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       konnectivityServerPort,
+				TargetPort: intstr.FromInt(konnectivityServerPort),
+				NodePort:   kcp.Spec.K0sConfigSpec.Tunneling.TunnelingNodePort,
+			}},
+			Type: corev1.ServiceTypeNodePort,
+		},
+	}
+	_ = ctrl.SetControllerReference(kcp, &service, c.Client.Scheme())
+	err = util.Apply(ctx, c.Client, &service)
+	if err != nil {
+		return fmt.Errorf("error creating Service: %w", err)
+	}
+
+	return nil
+}
+
+// createKonnectivityTunnelCerts creates the secret holding the cluster CA certificate along with the certificates of
+// the konnectivity server and agents, signed by the cluster CA.
+func (c *K0sController) createKonnectivityTunnelCerts(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) (string, error) {
+	secretName := fmt.Sprintf(KonnectivityTunnelCertsNameTemplate, cluster.Name)
+
+	var existingSecret corev1.Secret
+	err := c.SecretCachingClient.Get(ctx, client.ObjectKey{Name: secretName, Namespace: cluster.Namespace}, &existingSecret)
+	if err == nil {
+		return secretName, nil
+	} else if !apierrors.IsNotFound(err) {
+		return "", err
+	}
+
+	clusterCA, err := secret.GetFromNamespacedName(ctx, c.SecretCachingClient, capiutil.ObjectKey(cluster), secret.ClusterCA)
+	if err != nil {
+		return "", fmt.Errorf("failed to get cluster CA: %w", err)
+	}
+	caCert, err := certs.DecodeCertPEM(clusterCA.Data[secret.TLSCrtDataName])
+	if err != nil || caCert == nil {
+		return "", fmt.Errorf("failed to decode CA cert: %w", err)
+	}
+	caKeyPEM, err := keystore.Key(ctx, c.KeyStore, capiutil.ObjectKey(cluster), clusterCA, secret.ClusterCA)
+	if err != nil {
+		return "", err
+	}
+	caKey, err := certs.DecodePrivateKeyPEM(caKeyPEM)
+	if err != nil || caKey == nil {
+		return "", fmt.Errorf("failed to decode CA key: %w", err)
+	}
+
+	serverConfig := &certs.Config{
+		CommonName: konnectivityServerCommonName,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	serverAddress := util.SANHost(kcp.Spec.K0sConfigSpec.Tunneling.ServerAddress)
+	if ip := net.ParseIP(serverAddress); ip != nil {
+		serverConfig.AltNames.IPs = []net.IP{ip}
+	} else {
+		serverConfig.AltNames.DNSNames = []string{serverAddress}
+	}
+	agentConfig := &certs.Config{
+		CommonName: konnectivityAgentCommonName,
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	data := map[string][]byte{
+		"ca.crt": clusterCA.Data[secret.TLSCrtDataName],
+	}
+	for name, cfg := range map[string]*certs.Config{"server": serverConfig, "agent": agentConfig} {
+		key, err := certs.NewPrivateKey()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate %s key: %w", name, err)
+		}
+		cert, err := cfg.NewSignedCert(key, caCert, caKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to sign %s certificate: %w", name, err)
+		}
+		data[name+".crt"] = certs.EncodeCertPEM(cert)
+		data[name+".key"] = certs.EncodePrivateKeyPEM(key)
+	}
+
+	certsSecret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabel: cluster.Name,
+			},
+		},
+		Data: data,
+		Type: clusterv1.ClusterSecretType,
+	}
+
+	_ = ctrl.SetControllerReference(kcp, certsSecret, c.Client.Scheme())
+
+	return secretName, util.Apply(ctx, c.Client, certsSecret)
+}
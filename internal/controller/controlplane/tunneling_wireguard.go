@@ -0,0 +1,298 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
+)
+
+var (
+	WireGuardKeysNameTemplate         = "%s-wireguard-keys"
+	WireGuardServerConfigNameTemplate = "%s-wireguard-config"
+	WireGuardServerNameTemplate       = "%s-wireguard"
+)
+
+const (
+	wireGuardPort = 51820
+	// The client pod forwards the tunneled API server traffic from this port to the kubernetes service.
+	wireGuardAPIPort = 6443
+)
+
+// reconcileWireGuardTunneling deploys the WireGuard peer of the management cluster. The pod forwards the connections
+// to the tunneling port through the WireGuard tunnel to the peer running in the child cluster.
+func (c *K0sController) reconcileWireGuardTunneling(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
+	tunneling := &kcp.Spec.K0sConfigSpec.Tunneling
+	serverAddr, clientAddr, err := tunneling.GetWireGuardPeerAddresses()
+	if err != nil {
+		return err
+	}
+
+	keys, err := c.createWireGuardKeys(ctx, cluster, kcp)
+	if err != nil {
+		return fmt.Errorf("error creating WireGuard keys: %w", err)
+	}
+
+	wgConfig := fmt.Sprintf(`[Interface]
+Address = %s
+ListenPort = %d
+PrivateKey = %s
+
+[Peer]
+PublicKey = %s
+PresharedKey = %s
+AllowedIPs = %s
+`, serverAddr, wireGuardPort, keys["server.key"], keys["client.pub"], keys["preshared.key"], netip.PrefixFrom(clientAddr.Addr(), clientAddr.Addr().BitLen()))
+
+	configSecretName := fmt.Sprintf(WireGuardServerConfigNameTemplate, kcp.GetName())
+	configSecret := corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configSecretName,
+			Namespace: kcp.GetNamespace(),
+		},
+		StringData: map[string]string{
+			"wg0.conf": wgConfig,
+		},
+	}
+	_ = ctrl.SetControllerReference(kcp, &configSecret, c.Client.Scheme())
+	err = util.Apply(ctx, c.Client, &configSecret)
+	if err != nil {
+		return fmt.Errorf("error creating WireGuard config secret: %w", err)
+	}
+
+	labels := map[string]string{
+		"k0smotron_cluster": kcp.GetName(),
+		"app":               "wireguard",
+	}
+	deployment := appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(WireGuardServerNameTemplate, kcp.GetName()),
+			Namespace: kcp.GetNamespace(),
+		},
+		Spec: appsv1.DeploymentSpec{
+			// The peer of the child cluster has a single endpoint, so the tunnel can't be scaled out
+			Replicas: ptr.To(int32(1)),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+					},
+					Volumes: []corev1.Volume{{
+						Name: "wireguard-config",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName: configSecretName,
+							},
+						},
+					}},
+					Containers: []corev1.Container{
+						{
+							Name:            "wireguard",
+							Image:           tunneling.GetWireGuardImage(),
+							ImagePullPolicy: tunneling.GetImagePullPolicy(),
+							SecurityContext: &corev1.SecurityContext{
+								Capabilities: &corev1.Capabilities{
+									Add: []corev1.Capability{"NET_ADMIN"},
+								},
+							},
+							Ports: []corev1.ContainerPort{{
+								Name:          "wireguard",
+								Protocol:      corev1.ProtocolUDP,
+								ContainerPort: wireGuardPort,
+							}},
+							VolumeMounts: []corev1.VolumeMount{{
+								Name:      "wireguard-config",
+								MountPath: "/config/wg_confs",
+								ReadOnly:  true,
+							}},
+						},
+						{
+							Name:            "forwarder",
+							Image:           tunneling.GetForwarderImage(),
+							ImagePullPolicy: tunneling.GetImagePullPolicy(),
+							SecurityContext: forwarderSecurityContext(),
+							Args: []string{
+								socatListenAddress(tunneling.ServerAddress, wireGuardAPIPort),
+								"TCP:" + net.JoinHostPort(clientAddr.Addr().String(), strconv.Itoa(wireGuardAPIPort)),
+							},
+							Ports: []corev1.ContainerPort{{
+								Name:          "tunnel",
+								Protocol:      corev1.ProtocolTCP,
+								ContainerPort: wireGuardAPIPort,
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+	applyTunnelingDeploymentSpec(&deployment, tunneling.ServerDeployment)
+	_ = ctrl.SetControllerReference(kcp, &deployment, c.Client.Scheme())
+	err = util.Apply(ctx, c.Client, &deployment)
+	if err != nil {
+		return fmt.Errorf("error creating Deployment: %w", err)
+	}
+
+	service := corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(WireGuardServerNameTemplate, kcp.GetName()),
+			Namespace: kcp.GetNamespace(),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{{
+				Name:       "wireguard",
+				Protocol:   corev1.ProtocolUDP,
+				Port:       wireGuardPort,
+				TargetPort: intstr.FromInt(wireGuardPort),
+				NodePort:   tunneling.ServerNodePort,
+			}, {
+				Name:       "tunnel",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       wireGuardAPIPort,
+				TargetPort: intstr.FromInt(wireGuardAPIPort),
+				NodePort:   tunneling.TunnelingNodePort,
+			}},
+			Type: corev1.ServiceTypeNodePort,
+		},
+	}
+	_ = ctrl.SetControllerReference(kcp, &service, c.Client.Scheme())
+	err = util.Apply(ctx, c.Client, &service)
+	if err != nil {
+		return fmt.Errorf("error creating Service: %w", err)
+	}
+
+	return nil
+}
+
+// createWireGuardKeys creates the secret with the key pairs of both peers and their preshared key, unless it exists.
+func (c *K0sController) createWireGuardKeys(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) (map[string]string, error) {
+	secretName := fmt.Sprintf(WireGuardKeysNameTemplate, cluster.Name)
+
+	var existingSecret corev1.Secret
+	err := c.SecretCachingClient.Get(ctx, client.ObjectKey{Name: secretName, Namespace: cluster.Namespace}, &existingSecret)
+	if err == nil {
+		keys := make(map[string]string, len(existingSecret.Data))
+		for k, v := range existingSecret.Data {
+			keys[k] = string(v)
+		}
+		return keys, nil
+	} else if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	keys, err := generateWireGuardKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	keysSecret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabel: cluster.Name,
+			},
+		},
+		StringData: keys,
+		Type:       clusterv1.ClusterSecretType,
+	}
+
+	_ = ctrl.SetControllerReference(kcp, keysSecret, c.Client.Scheme())
+
+	return keys, util.Apply(ctx, c.Client, keysSecret)
+}
+
+// socatListenAddress returns the socat address listening on the port, on IPv6 when the server is reached over IPv6,
+// as socat only listens on IPv4 by default.
+func socatListenAddress(serverAddress string, port int) string {
+	if util.IsIPv6(serverAddress) {
+		return fmt.Sprintf("TCP6-LISTEN:%d,fork,reuseaddr,ipv6only=0", port)
+	}
+	return fmt.Sprintf("TCP-LISTEN:%d,fork,reuseaddr", port)
+}
+
+// forwarderSecurityContext runs the forwarder as non-root, as only the WireGuard container needs privileges.
+func forwarderSecurityContext() *corev1.SecurityContext {
+	sc := util.RestrictedSecurityContext()
+	sc.RunAsNonRoot = ptr.To(true)
+	sc.RunAsUser = ptr.To(util.NonRootUID)
+	sc.RunAsGroup = ptr.To(util.NonRootUID)
+	return sc
+}
+
+// generateWireGuardKeys generates the base64 encoded key pairs of the server and client peers and a preshared key.
+func generateWireGuardKeys() (map[string]string, error) {
+	keys := map[string]string{}
+	for _, peer := range []string{"server", "client"} {
+		key, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %s key: %w", peer, err)
+		}
+		keys[peer+".key"] = base64.StdEncoding.EncodeToString(key.Bytes())
+		keys[peer+".pub"] = base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())
+	}
+
+	psk := make([]byte, 32)
+	if _, err := rand.Read(psk); err != nil {
+		return nil, fmt.Errorf("failed to generate preshared key: %w", err)
+	}
+	keys["preshared.key"] = base64.StdEncoding.EncodeToString(psk)
+
+	return keys, nil
+}
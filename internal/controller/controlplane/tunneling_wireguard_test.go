@@ -0,0 +1,59 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"crypto/ecdh"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
+)
+
+func TestGenerateWireGuardKeys(t *testing.T) {
+	keys, err := generateWireGuardKeys()
+	require.NoError(t, err)
+
+	for _, peer := range []string{"server", "client"} {
+		priv, err := base64.StdEncoding.DecodeString(keys[peer+".key"])
+		require.NoError(t, err)
+		key, err := ecdh.X25519().NewPrivateKey(priv)
+		require.NoError(t, err)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()), keys[peer+".pub"])
+	}
+	assert.NotEqual(t, keys["server.key"], keys["client.key"])
+
+	psk, err := base64.StdEncoding.DecodeString(keys["preshared.key"])
+	require.NoError(t, err)
+	assert.Len(t, psk, 32)
+}
+
+func TestProxiedTunneling(t *testing.T) {
+	assert.False(t, proxiedTunneling(bootstrapv1.TunnelingSpec{Mode: "tunnel"}))
+	assert.True(t, proxiedTunneling(bootstrapv1.TunnelingSpec{Mode: "proxy"}))
+	assert.True(t, proxiedTunneling(bootstrapv1.TunnelingSpec{Mode: "tunnel", Provider: bootstrapv1.TunnelingProviderKonnectivity}))
+	assert.False(t, proxiedTunneling(bootstrapv1.TunnelingSpec{Mode: "proxy", Provider: bootstrapv1.TunnelingProviderWireGuard}))
+}
+
+func TestListenOnIPv6(t *testing.T) {
+	assert.Equal(t, "TCP-LISTEN:6443,fork,reuseaddr", socatListenAddress("10.0.0.1", 6443))
+	assert.Equal(t, "TCP-LISTEN:6443,fork,reuseaddr", socatListenAddress("frps.example.com", 6443))
+	assert.Equal(t, "TCP6-LISTEN:6443,fork,reuseaddr,ipv6only=0", socatListenAddress("fd00::1", 6443))
+}
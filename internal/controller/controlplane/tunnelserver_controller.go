@@ -0,0 +1,450 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/tracing"
+)
+
+var (
+	TunnelServerNameTemplate       = "%s-tunnel-server"
+	TunnelServerConfigNameTemplate = "%s-tunnel-server-config"
+	TunnelServerTokenNameTemplate  = "%s-tunnel-server-token"
+)
+
+// tunnelServerSNIPort is the port frps routes the API server connections by SNI hostname on.
+const tunnelServerSNIPort = 8443
+
+// TunnelServerController deploys the frp server of TunnelServers and releases the ports allocated to deleted clusters.
+type TunnelServerController struct {
+	client.Client
+	SecretCachingClient client.Client
+	// WatchSecrets watches the metadata of the Secrets labelled with a cluster name. It requires the manager cache
+	// to be limited to these Secrets.
+	WatchSecrets bool
+}
+
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=tunnelservers,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=tunnelservers/status,verbs=get;list;watch;update;patch
+
+func (c *TunnelServerController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithValues("tunnelserver", req.NamespacedName)
+
+	ts := &cpv1beta1.TunnelServer{}
+	if err := c.Get(ctx, req.NamespacedName, ts); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if !ts.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	token, err := c.ensureToken(ctx, ts)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("error creating tunneling server token: %w", err)
+	}
+
+	// Release the ports of the clusters which no longer exist.
+	var allocations []cpv1beta1.TunnelServerAllocation
+	for _, a := range ts.Status.Allocations {
+		namespace, name, _ := strings.Cut(a.Cluster, "/")
+		err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &clusterv1.Cluster{})
+		if apierrors.IsNotFound(err) {
+			log.Info("Releasing the port of a deleted cluster", "cluster", a.Cluster, "port", a.Port)
+			continue
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+		allocations = append(allocations, a)
+	}
+	ts.Status.Allocations = allocations
+	ts.Status.AllocatedPorts = int32(len(allocations))
+
+	if err := c.deployServer(ctx, ts, token); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	ts.Status.Ready = true
+	if err := c.Status().Update(ctx, ts); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
+	}
+
+	// Clusters are not watched, so check for deleted clusters periodically.
+	if len(allocations) > 0 {
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// ensureToken returns the token of the tunneling server, generating it if no secret is referenced.
+func (c *TunnelServerController) ensureToken(ctx context.Context, ts *cpv1beta1.TunnelServer) (string, error) {
+	if ts.Spec.TokenSecretRef != nil {
+		return tunnelServerToken(ctx, c.SecretCachingClient, ts)
+	}
+
+	secretName := fmt.Sprintf(TunnelServerTokenNameTemplate, ts.Name)
+	var existingSecret corev1.Secret
+	err := c.SecretCachingClient.Get(ctx, client.ObjectKey{Name: secretName, Namespace: ts.Namespace}, &existingSecret)
+	if err == nil {
+		return string(existingSecret.Data["value"]), nil
+	} else if !apierrors.IsNotFound(err) {
+		return "", err
+	}
+
+	token := uuid.New().String()
+	tokenSecret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: ts.Namespace,
+		},
+		Data: map[string][]byte{
+			"value": []byte(token),
+		},
+	}
+	_ = ctrl.SetControllerReference(ts, tokenSecret, c.Scheme())
+
+	return token, util.Apply(ctx, c, tokenSecret)
+}
+
+// deployServer deploys frps, publishing a NodePort for each allocated port.
+func (c *TunnelServerController) deployServer(ctx context.Context, ts *cpv1beta1.TunnelServer, token string) error {
+	frpsConfig := fmt.Sprintf(`
+[common]
+bind_port = 7000
+tcpmux_httpconnect_port = 6443
+allow_ports = %d-%d
+authentication_method = token
+token = %s
+`, ts.Spec.PortRange.Start, ts.Spec.PortRange.End, token)
+	if ts.Spec.SNI != nil {
+		frpsConfig += fmt.Sprintf("vhost_https_port = %d\n", tunnelServerSNIPort)
+	}
+
+	configName := fmt.Sprintf(TunnelServerConfigNameTemplate, ts.Name)
+	cm := corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configName,
+			Namespace: ts.Namespace,
+		},
+		Data: map[string]string{
+			"frps.ini": frpsConfig,
+		},
+	}
+	_ = ctrl.SetControllerReference(ts, &cm, c.Scheme())
+	err := util.Apply(ctx, c, &cm)
+	if err != nil {
+		return fmt.Errorf("error creating ConfigMap: %w", err)
+	}
+
+	images := bootstrapv1.TunnelingSpec{Images: ts.Spec.Images}
+	labels := map[string]string{
+		"k0smotron_tunnel_server": ts.Name,
+		"app":                     "frps",
+	}
+	deployment := appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(TunnelServerNameTemplate, ts.Name),
+			Namespace: ts.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			// The frpc clients register their proxies with a single frps, so it can't be scaled out
+			Replicas: ptr.To(int32(1)),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					SecurityContext: util.RestrictedPodSecurityContext(util.NonRootUID),
+					Volumes: []corev1.Volume{{
+						Name: configName,
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{
+									Name: configName,
+								},
+								Items: []corev1.KeyToPath{{
+									Key:  "frps.ini",
+									Path: "frps.ini",
+								}},
+							},
+						},
+					}},
+					Containers: []corev1.Container{{
+						Name:            "frps",
+						Image:           images.GetFRPServerImage(),
+						ImagePullPolicy: images.GetImagePullPolicy(),
+						SecurityContext: util.RestrictedSecurityContext(),
+						Ports: []corev1.ContainerPort{
+							{
+								Name:          "api",
+								Protocol:      corev1.ProtocolTCP,
+								ContainerPort: 7000,
+							},
+							{
+								Name:          "proxy",
+								Protocol:      corev1.ProtocolTCP,
+								ContainerPort: 6443,
+							},
+						},
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      configName,
+							MountPath: "/etc/frp/frps.ini",
+							SubPath:   "frps.ini",
+						}},
+					}},
+				},
+			},
+		},
+	}
+	if ts.Spec.SNI != nil {
+		container := &deployment.Spec.Template.Spec.Containers[0]
+		container.Ports = append(container.Ports, corev1.ContainerPort{
+			Name:          "sni",
+			Protocol:      corev1.ProtocolTCP,
+			ContainerPort: tunnelServerSNIPort,
+		})
+	}
+	applyTunnelingDeploymentSpec(&deployment, ts.Spec.Deployment)
+	_ = ctrl.SetControllerReference(ts, &deployment, c.Scheme())
+	err = util.Apply(ctx, c, &deployment)
+	if err != nil {
+		return fmt.Errorf("error creating Deployment: %w", err)
+	}
+
+	ports := []corev1.ServicePort{{
+		Name:       "api",
+		Protocol:   corev1.ProtocolTCP,
+		Port:       7000,
+		TargetPort: intstr.FromInt(7000),
+		NodePort:   ts.Spec.ServerNodePort,
+	}, {
+		Name:       "proxy",
+		Protocol:   corev1.ProtocolTCP,
+		Port:       6443,
+		TargetPort: intstr.FromInt(6443),
+		NodePort:   ts.Spec.ProxyNodePort,
+	}}
+	if ts.Spec.SNI != nil {
+		ports = append(ports, corev1.ServicePort{
+			Name:       "sni",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       tunnelServerSNIPort,
+			TargetPort: intstr.FromInt(tunnelServerSNIPort),
+			NodePort:   ts.Spec.SNI.NodePort,
+		})
+	}
+	for _, a := range ts.Status.Allocations {
+		ports = append(ports, corev1.ServicePort{
+			Name:       fmt.Sprintf("tunnel-%d", a.Port),
+			Protocol:   corev1.ProtocolTCP,
+			Port:       a.Port,
+			TargetPort: intstr.FromInt32(a.Port),
+			NodePort:   a.Port,
+		})
+	}
+	service := corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(TunnelServerNameTemplate, ts.Name),
+			Namespace: ts.Namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports:    ports,
+			Type:     corev1.ServiceTypeNodePort,
+		},
+	}
+	_ = ctrl.SetControllerReference(ts, &service, c.Scheme())
+	err = util.Apply(ctx, c, &service)
+	if err != nil {
+		return fmt.Errorf("error creating Service: %w", err)
+	}
+
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (c *TunnelServerController) SetupWithManager(mgr ctrl.Manager) error {
+	metrics.RegisterObjects("TunnelServer", mgr.GetClient(), func() client.ObjectList { return &cpv1beta1.TunnelServerList{} }, nil)
+
+	if err := health.AddChecks(mgr, "tunnelserver", &cpv1beta1.TunnelServer{}); err != nil {
+		return err
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&cpv1beta1.TunnelServer{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.ConfigMap{}, builder.OnlyMetadata)
+	if c.WatchSecrets {
+		b = b.Owns(&corev1.Secret{}, builder.OnlyMetadata)
+	}
+	return b.Complete(metrics.Instrument("tunnelserver", mgr.GetClient(), func() client.Object { return &cpv1beta1.TunnelServer{} }, nil, tracing.Instrument("tunnelserver", health.Instrument("tunnelserver", c))))
+}
+
+// tunnelServerToken returns the token of the tunneling server.
+func tunnelServerToken(ctx context.Context, c client.Client, ts *cpv1beta1.TunnelServer) (string, error) {
+	secretName, secretKey := fmt.Sprintf(TunnelServerTokenNameTemplate, ts.Name), "value"
+	if ref := ts.Spec.TokenSecretRef; ref != nil {
+		secretName, secretKey = ref.Name, ref.Key
+	}
+
+	var tokenSecret corev1.Secret
+	err := c.Get(ctx, client.ObjectKey{Name: secretName, Namespace: ts.Namespace}, &tokenSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tunneling server token secret %s: %w", secretName, err)
+	}
+	token := tokenSecret.Data[secretKey]
+	if len(token) == 0 {
+		return "", fmt.Errorf("tunneling server token secret %s has no value for key %s", secretName, secretKey)
+	}
+	return string(token), nil
+}
+
+// allocateTunnelServerPort returns the port allocated to the cluster, allocating the first free port of the range if
+// the cluster has none yet. It returns whether the status of the TunnelServer was changed, and 0 if the range is full.
+func allocateTunnelServerPort(ts *cpv1beta1.TunnelServer, cluster string) (int32, bool) {
+	used := make(map[int32]bool, len(ts.Status.Allocations))
+	for _, a := range ts.Status.Allocations {
+		if a.Cluster == cluster {
+			return a.Port, false
+		}
+		used[a.Port] = true
+	}
+
+	for port := ts.Spec.PortRange.Start; port <= ts.Spec.PortRange.End; port++ {
+		if !used[port] {
+			ts.Status.Allocations = append(ts.Status.Allocations, cpv1beta1.TunnelServerAllocation{
+				Cluster: cluster,
+				Port:    port,
+			})
+			ts.Status.AllocatedPorts = int32(len(ts.Status.Allocations))
+			return port, true
+		}
+	}
+	return 0, false
+}
+
+// checkTunnelServerNamespace returns an error if the TunnelServer does not allow the control planes of the namespace to
+// use it.
+func (c *K0sController) checkTunnelServerNamespace(ctx context.Context, ts *cpv1beta1.TunnelServer, namespace string) error {
+	if ts.Namespace == namespace || ts.Spec.NamespaceSelector == nil {
+		return nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(ts.Spec.NamespaceSelector)
+	if err != nil {
+		return fmt.Errorf("invalid namespace selector of TunnelServer %s/%s: %w", ts.Namespace, ts.Name, err)
+	}
+	ns := &corev1.Namespace{}
+	if err := c.Client.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	if !selector.Matches(labels.Set(ns.Labels)) {
+		return fmt.Errorf("TunnelServer %s/%s does not allow control planes of namespace %s", ts.Namespace, ts.Name, namespace)
+	}
+	return nil
+}
+
+// reconcileTunnelServerRef configures the tunneling of the control plane to use a shared TunnelServer instead of
+// deploying a dedicated tunneling server. In tunnel mode, the cluster is either routed by its SNI hostname or
+// allocated a port.
+func (c *K0sController) reconcileTunnelServerRef(ctx context.Context, cluster *clusterv1.Cluster, kcp *cpv1beta1.K0sControlPlane) error {
+	tunneling := &kcp.Spec.K0sConfigSpec.Tunneling
+	key := client.ObjectKey{Name: tunneling.ServerRef.Name, Namespace: tunneling.ServerRef.Namespace}
+	if key.Namespace == "" {
+		key.Namespace = kcp.Namespace
+	}
+
+	ts := &cpv1beta1.TunnelServer{}
+	if err := c.Client.Get(ctx, key, ts); err != nil {
+		return fmt.Errorf("failed to get TunnelServer %s: %w", key, err)
+	}
+	if err := c.checkTunnelServerNamespace(ctx, ts, kcp.Namespace); err != nil {
+		return err
+	}
+	token, err := tunnelServerToken(ctx, c.SecretCachingClient, ts)
+	if err != nil {
+		return err
+	}
+
+	tunneling.ServerAddress = ts.Spec.ServerAddress
+	tunneling.ServerNodePort = ts.Spec.ServerNodePort
+	tunneling.SNIHostname = ""
+	if tunneling.Mode == "proxy" {
+		tunneling.TunnelingNodePort = ts.Spec.ProxyNodePort
+	} else if ts.Spec.SNI != nil {
+		tunneling.SNIHostname = fmt.Sprintf("%s-%s.%s", cluster.Name, cluster.Namespace, ts.Spec.SNI.Domain)
+		tunneling.TunnelingNodePort = ts.Spec.SNI.NodePort
+	} else {
+		port, allocated := allocateTunnelServerPort(ts, client.ObjectKeyFromObject(cluster).String())
+		if port == 0 {
+			return fmt.Errorf("no free port left in the range of TunnelServer %s", key)
+		}
+		if allocated {
+			if err := c.Client.Status().Update(ctx, ts); err != nil {
+				return fmt.Errorf("failed to allocate a port of TunnelServer %s: %w", key, err)
+			}
+		}
+		tunneling.TunnelingNodePort = port
+	}
+
+	return c.applyFRPTokenSecret(ctx, cluster, kcp, token)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplane
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+)
+
+func TestAllocateTunnelServerPort(t *testing.T) {
+	ts := &cpv1beta1.TunnelServer{
+		Spec: cpv1beta1.TunnelServerSpec{
+			PortRange: cpv1beta1.TunnelServerPortRange{Start: 31800, End: 31802},
+		},
+		Status: cpv1beta1.TunnelServerStatus{
+			Allocations: []cpv1beta1.TunnelServerAllocation{
+				{Cluster: "default/a", Port: 31801},
+			},
+		},
+	}
+
+	port, allocated := allocateTunnelServerPort(ts, "default/a")
+	assert.Equal(t, int32(31801), port)
+	assert.False(t, allocated)
+
+	port, allocated = allocateTunnelServerPort(ts, "default/b")
+	assert.Equal(t, int32(31800), port)
+	assert.True(t, allocated)
+
+	port, allocated = allocateTunnelServerPort(ts, "default/c")
+	assert.Equal(t, int32(31802), port)
+	assert.True(t, allocated)
+	assert.Equal(t, int32(3), ts.Status.AllocatedPorts)
+
+	port, allocated = allocateTunnelServerPort(ts, "default/d")
+	assert.Equal(t, int32(0), port)
+	assert.False(t, allocated)
+	assert.Len(t, ts.Status.Allocations, 3)
+}
+
+func TestCheckTunnelServerNamespace(t *testing.T) {
+	c := &K0sController{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tenant": "a"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"tenant": "b"}}},
+	).Build()}
+	ts := &cpv1beta1.TunnelServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "tunneling"},
+	}
+
+	// All namespaces are allowed without a selector
+	assert.NoError(t, c.checkTunnelServerNamespace(context.Background(), ts, "team-b"))
+
+	ts.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}}
+	assert.NoError(t, c.checkTunnelServerNamespace(context.Background(), ts, "team-a"))
+	assert.NoError(t, c.checkTunnelServerNamespace(context.Background(), ts, "tunneling"))
+	assert.ErrorContains(t, c.checkTunnelServerNamespace(context.Background(), ts, "team-b"), "does not allow")
+}
+
+// informerRecordingCache records the kinds of the informers the controllers start.
+type informerRecordingCache struct {
+	cache.Cache
+	mu    sync.Mutex
+	kinds map[string]bool
+}
+
+func (c *informerRecordingCache) GetInformer(ctx context.Context, obj client.Object, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	if gvk, err := apiutil.GVKForObject(obj, scheme.Scheme); err == nil {
+		c.mu.Lock()
+		c.kinds[gvk.Kind] = true
+		c.mu.Unlock()
+	}
+	return c.Cache.GetInformer(ctx, obj, opts...)
+}
+
+func (c *informerRecordingCache) started(kind string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.kinds[kind]
+}
+
+// startInformerRecordingManager starts a manager with the controllers added by setup, and records the kinds of the
+// informers they start.
+func startInformerRecordingManager(t *testing.T, setup func(mgr ctrl.Manager) error) *informerRecordingCache {
+	recorder := &informerRecordingCache{kinds: map[string]bool{}}
+	mgr, err := ctrl.NewManager(testEnv.Config, manager.Options{
+		Scheme:                 scheme.Scheme,
+		Metrics:                metricsserver.Options{BindAddress: "0"},
+		HealthProbeBindAddress: "0",
+		NewCache: func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+			c, err := cache.New(config, opts)
+			recorder.Cache = c
+			return recorder, err
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, setup(mgr))
+
+	mgrCtx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+	go func() {
+		_ = mgr.Start(mgrCtx)
+	}()
+	return recorder
+}
+
+func TestTunnelServerControllerWithoutSecretWatch(t *testing.T) {
+	recorder := startInformerRecordingManager(t, func(mgr ctrl.Manager) error {
+		return (&TunnelServerController{Client: mgr.GetClient(), SecretCachingClient: mgr.GetClient()}).SetupWithManager(mgr)
+	})
+
+	require.Eventually(t, func() bool {
+		return recorder.started("TunnelServer") && recorder.started("ConfigMap")
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.False(t, recorder.started("Secret"))
+}
+
+func TestTunnelServerControllerWithSecretWatch(t *testing.T) {
+	recorder := startInformerRecordingManager(t, func(mgr ctrl.Manager) error {
+		return (&TunnelServerController{Client: mgr.GetClient(), SecretCachingClient: mgr.GetClient(), WatchSecrets: true}).SetupWithManager(mgr)
+	})
+
+	require.Eventually(t, func() bool {
+		return recorder.started("Secret")
+	}, 10*time.Second, 100*time.Millisecond)
+}
@@ -3,6 +3,7 @@ package controlplane
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/imdario/mergo"
 	v1 "k8s.io/api/core/v1"
@@ -22,6 +23,7 @@ import (
 
 	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
 	k0smoutil "github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/keystore"
 )
 
 func (c *K0sController) getMachineTemplate(ctx context.Context, kcp *cpv1beta1.K0sControlPlane) (*unstructured.Unstructured, error) {
@@ -32,15 +34,35 @@ func (c *K0sController) getMachineTemplate(ctx context.Context, kcp *cpv1beta1.K
 	infraMachineTemplate.SetKind(infRef.Kind)
 	infraMachineTemplate.SetName(infRef.Name)
 
-	key := client.ObjectKey{Name: infRef.Name, Namespace: kcp.Namespace}
+	namespace := kcp.Namespace
+	if c.CrossNamespaceMachineTemplates && infRef.Namespace != "" {
+		namespace = infRef.Namespace
+	}
+	key := client.ObjectKey{Name: infRef.Name, Namespace: namespace}
 
 	err := c.Get(ctx, key, infraMachineTemplate)
 	if err != nil {
 		return nil, err
 	}
+	if namespace != kcp.Namespace && !namespaceAllowed(infraMachineTemplate, kcp.Namespace) {
+		return nil, fmt.Errorf("%s %s does not allow namespace %s to reference it, see the %s annotation",
+			infRef.Kind, key, kcp.Namespace, cpv1beta1.AllowedNamespacesAnnotation)
+	}
 	return infraMachineTemplate, nil
 }
 
+// namespaceAllowed returns whether the AllowedNamespacesAnnotation of a machine template allows a K0sControlPlane of
+// the given namespace to reference it.
+func namespaceAllowed(template client.Object, namespace string) bool {
+	for _, allowed := range strings.Split(template.GetAnnotations()[cpv1beta1.AllowedNamespacesAnnotation], ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*" || allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *K0sController) generateKubeconfig(ctx context.Context, clusterKey client.ObjectKey, endpoint string) (*api.Config, error) {
 	clusterCA, err := secret.GetFromNamespacedName(ctx, c.SecretCachingClient, clusterKey, secret.ClusterCA)
 	if err != nil {
@@ -57,7 +79,11 @@ func (c *K0sController) generateKubeconfig(ctx context.Context, clusterKey clien
 		return nil, fmt.Errorf("certificate not found in config: %w", err)
 	}
 
-	key, err := certs.DecodePrivateKeyPEM(clusterCA.Data[secret.TLSKeyDataName])
+	keyPEM, err := keystore.Key(ctx, c.KeyStore, clusterKey, clusterCA, secret.ClusterCA)
+	if err != nil {
+		return nil, err
+	}
+	key, err := certs.DecodePrivateKeyPEM(keyPEM)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode private key: %w", err)
 	} else if key == nil {
@@ -89,8 +115,20 @@ func (c *K0sController) createKubeconfigSecret(ctx context.Context, cfg *api.Con
 
 	kcSecret := kubeconfig.GenerateSecretWithOwner(clusterName, cfgBytes, owner)
 	kcSecret.Name = secretName
+	kcSecret.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+
+	return k0smoutil.Apply(ctx, c, kcSecret)
+}
+
+// updateKubeconfigSecret replaces the kubeconfig of an existing kubeconfig secret.
+func (c *K0sController) updateKubeconfigSecret(ctx context.Context, cfg *api.Config, kubeconfigSecret *v1.Secret) error {
+	cfgBytes, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return fmt.Errorf("failed to serialize config to yaml: %w", err)
+	}
+	kubeconfigSecret.Data[secret.KubeconfigDataName] = cfgBytes
 
-	return c.Create(ctx, kcSecret)
+	return c.applyKubeconfigSecretData(ctx, kubeconfigSecret)
 }
 
 func (c *K0sController) regenerateKubeconfigSecret(ctx context.Context, kubeconfigSecret *v1.Secret, clusterName string) error {
@@ -129,7 +167,24 @@ func (c *K0sController) regenerateKubeconfigSecret(ctx context.Context, kubeconf
 	}
 	kubeconfigSecret.Data[secret.KubeconfigDataName] = out
 
-	return c.Update(ctx, kubeconfigSecret)
+	return c.applyKubeconfigSecretData(ctx, kubeconfigSecret)
+}
+
+// applyKubeconfigSecretData applies the kubeconfig of an existing kubeconfig secret, leaving the rest of the secret
+// to its other field managers.
+func (c *K0sController) applyKubeconfigSecretData(ctx context.Context, kubeconfigSecret *v1.Secret) error {
+	kcSecret := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kubeconfigSecret.Name,
+			Namespace: kubeconfigSecret.Namespace,
+		},
+		Data: map[string][]byte{
+			secret.KubeconfigDataName: kubeconfigSecret.Data[secret.KubeconfigDataName],
+		},
+	}
+
+	return k0smoutil.Apply(ctx, c, kcSecret)
 }
 
 func (c *K0sController) getKubeClient(ctx context.Context, cluster *clusterv1.Cluster) (*kubernetes.Clientset, error) {
@@ -137,7 +192,7 @@ func (c *K0sController) getKubeClient(ctx context.Context, cluster *clusterv1.Cl
 		return c.workloadClusterKubeClient, nil
 	}
 
-	return k0smoutil.GetKubeClient(ctx, c.SecretCachingClient, cluster)
+	return k0smoutil.GetKubeClient(ctx, c.Client, cluster)
 }
 
 func enrichK0sConfigWithClusterData(cluster *clusterv1.Cluster, k0sConfig *unstructured.Unstructured) (*unstructured.Unstructured, error) {
@@ -146,11 +201,30 @@ func enrichK0sConfigWithClusterData(cluster *clusterv1.Cluster, k0sConfig *unstr
 	}
 
 	clusterNetworkValues := make(map[string]interface{})
-	if cluster.Spec.ClusterNetwork.Pods != nil {
-		clusterNetworkValues["podCIDR"] = cluster.Spec.ClusterNetwork.Pods.String()
+	dualStackValues := make(map[string]interface{})
+	podCIDR, podIPv6CIDR, err := k0smoutil.SplitDualStackCIDRs(cluster.Spec.ClusterNetwork.Pods)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pods network: %w", err)
 	}
-	if cluster.Spec.ClusterNetwork.Services != nil {
-		clusterNetworkValues["serviceCIDR"] = cluster.Spec.ClusterNetwork.Services.String()
+	if podCIDR != "" {
+		clusterNetworkValues["podCIDR"] = podCIDR
+	}
+	if podIPv6CIDR != "" {
+		dualStackValues["IPv6podCIDR"] = podIPv6CIDR
+	}
+	serviceCIDR, serviceIPv6CIDR, err := k0smoutil.SplitDualStackCIDRs(cluster.Spec.ClusterNetwork.Services)
+	if err != nil {
+		return nil, fmt.Errorf("invalid services network: %w", err)
+	}
+	if serviceCIDR != "" {
+		clusterNetworkValues["serviceCIDR"] = serviceCIDR
+	}
+	if serviceIPv6CIDR != "" {
+		dualStackValues["IPv6serviceCIDR"] = serviceIPv6CIDR
+	}
+	if len(dualStackValues) > 0 {
+		dualStackValues["enabled"] = true
+		clusterNetworkValues["dualStack"] = dualStackValues
 	}
 	if cluster.Spec.ClusterNetwork.ServiceDomain != "" {
 		clusterNetworkValues["clusterDomain"] = cluster.Spec.ClusterNetwork.ServiceDomain
@@ -168,8 +242,46 @@ func enrichK0sConfigWithClusterData(cluster *clusterv1.Cluster, k0sConfig *unstr
 		},
 	}
 
-	err := mergo.Merge(&k0sConfig.Object, clusterValues)
-	return k0sConfig, err
+	if err := mergo.Merge(&k0sConfig.Object, clusterValues); err != nil {
+		return nil, err
+	}
+
+	// The API server certificate must be valid for the kubernetes service FQDN in the cluster domain, which is not
+	// merged above as the SANs of the config are kept.
+	return addAPISANs(k0sConfig, k0smoutil.ClusterDomainSANs(cluster.Spec.ClusterNetwork.ServiceDomain)...)
+}
+
+// addAPISANs adds SANs to the API server certificate SANs of the k0s config, keeping the existing ones.
+func addAPISANs(k0sConfig *unstructured.Unstructured, sans ...string) (*unstructured.Unstructured, error) {
+	if len(sans) == 0 {
+		return k0sConfig, nil
+	}
+	if k0sConfig == nil {
+		k0sConfig = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "k0s.k0sproject.io/v1beta1",
+			"kind":       "ClusterConfig",
+		}}
+	}
+	existingSANs, _, err := unstructured.NestedStringSlice(k0sConfig.Object, "spec", "api", "sans")
+	if err != nil {
+		return nil, fmt.Errorf("invalid api sans: %w", err)
+	}
+	sans = k0smoutil.AddToExistingSans(existingSANs, sans)
+	if err := unstructured.SetNestedStringSlice(k0sConfig.Object, sans, "spec", "api", "sans"); err != nil {
+		return nil, err
+	}
+	return k0sConfig, nil
+}
+
+// k0smotronClusterLabels returns the labels of the k0smotron Cluster of a K0smotronControlPlane. The labels of the
+// K0smotronControlPlane are propagated, so the Cluster matches the same --watch-filter.
+func k0smotronClusterLabels(kcp *cpv1beta1.K0smotronControlPlane, clusterName string) map[string]string {
+	labels := map[string]string{}
+	for k, v := range kcp.Labels {
+		labels[k] = v
+	}
+	labels[clusterv1.ClusterNameLabel] = clusterName
+	return labels
 }
 
 func controlPlaneCommonLabelsForCluster(kcp *cpv1beta1.K0sControlPlane, clusterName string) map[string]string {
@@ -181,6 +293,11 @@ func controlPlaneCommonLabelsForCluster(kcp *cpv1beta1.K0sControlPlane, clusterN
 		labels[k] = v
 	}
 
+	// The generated objects are reconciled by the instances watching the same objects as the control plane.
+	if watchFilter, ok := kcp.Labels[clusterv1.WatchLabel]; ok {
+		labels[clusterv1.WatchLabel] = watchFilter
+	}
+
 	// Always force these labels over the ones coming from the spec.
 	labels[clusterv1.ClusterNameLabel] = clusterName
 	labels[clusterv1.MachineControlPlaneLabel] = "true"
@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	rig "github.com/k0sproject/rig/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capiutil "sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	k0smoutil "github.com/k0sproject/k0smotron/internal/controller/util"
+)
+
+// verifyAdoption checks k0s is running on the adopted machine with the role of the Machine
+// and returns the name of the node of the machine.
+func (p *SSHProvisioner) verifyAdoption(ctx context.Context, rigClient *rig.Client) (string, error) {
+	log := log.FromContext(ctx).WithValues("remotemachine", p.machine.Name)
+
+	if p.mode != ModeNonK0s {
+		status, err := rigClient.ExecOutput("k0s status")
+		if err != nil {
+			return "", fmt.Errorf("k0s is not running on the machine: %w", err)
+		}
+		role, err := parseK0sRole(status)
+		if err != nil {
+			return "", err
+		}
+		if !roleMatchesMode(role, p.mode) {
+			return "", fmt.Errorf("k0s runs with the %s role, which does not match the Machine", role)
+		}
+		log.Info("verified adopted machine", "role", role)
+	}
+
+	nodeName := p.machine.Spec.Adopt.NodeName
+	if nodeName == "" {
+		hostname, err := rigClient.ExecOutput("hostname")
+		if err != nil {
+			return "", fmt.Errorf("failed to get the hostname: %w", err)
+		}
+		nodeName = strings.ToLower(strings.TrimSpace(hostname))
+	}
+	return nodeName, nil
+}
+
+// parseK0sRole returns the role from the output of k0s status.
+func parseK0sRole(status string) (string, error) {
+	for _, line := range strings.Split(status, "\n") {
+		if role, ok := strings.CutPrefix(strings.TrimSpace(line), "Role:"); ok {
+			return strings.TrimSpace(role), nil
+		}
+	}
+	return "", fmt.Errorf("unexpected k0s status output: %q", status)
+}
+
+// roleMatchesMode returns true if a k0s node of the role can be the node of a Machine of the mode.
+func roleMatchesMode(role string, mode RemoteMachineMode) bool {
+	switch mode {
+	case ModeController:
+		return strings.HasPrefix(role, "controller") || role == "single"
+	case ModeWorker:
+		return role == "worker"
+	default:
+		return true
+	}
+}
+
+// labelAdoptedNode labels the node of an adopted machine with the name of its Machine, so that the node
+// gets matched with the Machine.
+func (r *RemoteMachineController) labelAdoptedNode(ctx context.Context, machine *clusterv1.Machine, nodeName string) error {
+	kc, err := r.workloadClient(ctx, machine)
+	if err != nil {
+		return err
+	}
+
+	patch := fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, k0smoutil.MachineNameNodeLabel, machine.Name)
+	if _, err := kc.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to label node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+// workloadClient returns a client for the workload cluster of the machine.
+func (r *RemoteMachineController) workloadClient(ctx context.Context, machine *clusterv1.Machine) (*kubernetes.Clientset, error) {
+	cluster, err := capiutil.GetClusterFromMetadata(ctx, r.Client, machine.ObjectMeta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	kc, err := k0smoutil.GetKubeClient(ctx, r.Client, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workload cluster client: %w", err)
+	}
+	return kc, nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseK0sRole(t *testing.T) {
+	status := `Version: v1.30.2+k0s.0
+Process ID: 1234
+Role: controller+worker
+Workloads: true
+SingleNode: false
+`
+	role, err := parseK0sRole(status)
+	require.NoError(t, err)
+	assert.Equal(t, "controller+worker", role)
+
+	_, err = parseK0sRole("Error: failed to get status")
+	assert.Error(t, err)
+}
+
+func TestRoleMatchesMode(t *testing.T) {
+	assert.True(t, roleMatchesMode("controller", ModeController))
+	assert.True(t, roleMatchesMode("controller+worker", ModeController))
+	assert.True(t, roleMatchesMode("single", ModeController))
+	assert.False(t, roleMatchesMode("worker", ModeController))
+	assert.True(t, roleMatchesMode("worker", ModeWorker))
+	assert.False(t, roleMatchesMode("controller", ModeWorker))
+	assert.True(t, roleMatchesMode("worker", ModeNonK0s))
+}
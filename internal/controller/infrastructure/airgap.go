@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/k0sproject/rig/v2/remotefs"
+
+	api "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+const (
+	airgapK0sBinaryPath = "/usr/local/bin/k0s"
+	airgapImagesDir     = "/var/lib/k0s/images"
+)
+
+// airgapArtifact is a file uploaded from the k0smotron manager to the machine.
+type airgapArtifact struct {
+	src  string
+	dst  string
+	perm fs.FileMode
+}
+
+// airgapArtifacts returns the artifacts to upload to a machine of the given architecture.
+func airgapArtifacts(dir string, airgap *api.AirgapArtifacts, arch string) ([]airgapArtifact, error) {
+	if airgap == nil {
+		return nil, nil
+	}
+	if dir == "" {
+		return nil, errors.New("airgap artifacts directory is not configured on the k0smotron manager")
+	}
+
+	var artifacts []airgapArtifact
+	for _, a := range []struct {
+		name    string
+		dstFunc func(src string) string
+		perm    fs.FileMode
+	}{
+		{airgap.K0sBinary, func(string) string { return airgapK0sBinaryPath }, 0755},
+		{airgap.ImageBundle, func(src string) string { return path.Join(airgapImagesDir, filepath.Base(src)) }, 0644},
+	} {
+		if a.name == "" {
+			continue
+		}
+		name := strings.ReplaceAll(a.name, archPlaceholder, arch)
+		if !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("airgap artifact path %s must be relative to the airgap artifacts directory", a.name)
+		}
+		src := filepath.Join(dir, name)
+		artifacts = append(artifacts, airgapArtifact{src: src, dst: a.dstFunc(src), perm: a.perm})
+	}
+	return artifacts, nil
+}
+
+// uploadArtifact uploads the artifact to the machine, unless an identical file is there already.
+func uploadArtifact(fsys remotefs.FS, a airgapArtifact) error {
+	if err := fsys.MkdirAll(path.Dir(a.dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if fsys.FileExist(a.dst) {
+		localSum, err := fileSha256(a.src)
+		if err != nil {
+			return err
+		}
+		if remoteSum, err := fsys.Sha256(a.dst); err == nil && remoteSum == localSum {
+			return nil
+		}
+	}
+
+	if err := remotefs.Upload(fsys, a.src, a.dst); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", a.src, err)
+	}
+	if err := fsys.Chmod(a.dst, a.perm); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", a.dst, err)
+	}
+	return nil
+}
+
+func fileSha256(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
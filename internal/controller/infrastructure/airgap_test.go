@@ -0,0 +1,61 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+func TestAirgapArtifacts(t *testing.T) {
+	artifacts, err := airgapArtifacts("/artifacts", &api.AirgapArtifacts{
+		K0sBinary:   "v1.30.2+k0s.0/k0s-${K0S_ARCH}",
+		ImageBundle: "v1.30.2+k0s.0/bundle-${K0S_ARCH}.tar",
+	}, "arm64")
+	require.NoError(t, err)
+	assert.Equal(t, []airgapArtifact{
+		{src: "/artifacts/v1.30.2+k0s.0/k0s-arm64", dst: "/usr/local/bin/k0s", perm: 0755},
+		{src: "/artifacts/v1.30.2+k0s.0/bundle-arm64.tar", dst: "/var/lib/k0s/images/bundle-arm64.tar", perm: 0644},
+	}, artifacts)
+
+	artifacts, err = airgapArtifacts("", nil, "amd64")
+	assert.NoError(t, err)
+	assert.Empty(t, artifacts)
+
+	_, err = airgapArtifacts("", &api.AirgapArtifacts{K0sBinary: "k0s"}, "amd64")
+	assert.Error(t, err)
+
+	_, err = airgapArtifacts("/artifacts", &api.AirgapArtifacts{K0sBinary: "../etc/shadow"}, "amd64")
+	assert.Error(t, err)
+	_, err = airgapArtifacts("/artifacts", &api.AirgapArtifacts{ImageBundle: "/etc/shadow"}, "amd64")
+	assert.Error(t, err)
+}
+
+func TestFileSha256(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "k0s")
+	require.NoError(t, os.WriteFile(name, []byte("foo"), 0644))
+
+	sum, err := fileSha256(name)
+	require.NoError(t, err)
+	assert.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", sum)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+// bmcRemediationTimeout is how long the BMC has to carry out the remediation of a machine.
+const bmcRemediationTimeout = 2 * time.Minute
+
+// powerController controls the power and the boot of a machine through its BMC.
+type powerController interface {
+	// PowerCycle power-cycles the machine, or powers it on if it is off.
+	PowerCycle(ctx context.Context) error
+	// SetNetworkBootOnce makes the machine boot from the network on its next boot.
+	SetNetworkBootOnce(ctx context.Context) error
+}
+
+func newPowerController(bmc *infrastructure.BMC, username, password string) (powerController, error) {
+	switch bmc.Protocol {
+	case infrastructure.BMCProtocolRedfish:
+		return newRedfishClient(bmc.Address, bmc.SystemID, username, password, bmc.InsecureSkipVerify)
+	default:
+		return nil, fmt.Errorf("unsupported bmc protocol %q", bmc.Protocol)
+	}
+}
+
+// remediate runs the remediation action on the machine.
+func remediate(ctx context.Context, pc powerController, action infrastructure.RemediationAction) error {
+	if action == infrastructure.RemediationActionReimage {
+		if err := pc.SetNetworkBootOnce(ctx); err != nil {
+			return err
+		}
+	}
+	return pc.PowerCycle(ctx)
+}
+
+// machineRemediated returns true if the Machine is deleted because a MachineHealthCheck found it unhealthy.
+func machineRemediated(machine *clusterv1.Machine) bool {
+	return conditions.IsFalse(machine, clusterv1.MachineHealthCheckSucceededCondition)
+}
+
+// needsBMCRemediation returns whether the remediation action must be run through the BMC of a deleted machine.
+// It is skipped once it succeeded, so that retrying the deletion does not power-cycle the machine again.
+func needsBMCRemediation(rm *infrastructure.RemoteMachine, machine *clusterv1.Machine) bool {
+	return rm.Spec.BMC != nil && machineRemediated(machine) && !conditions.IsTrue(rm, infrastructure.RemoteMachineRemediatedCondition)
+}
+
+// remediateWithBMC runs the remediation action of the machine through its BMC.
+func (r *RemoteMachineController) remediateWithBMC(ctx context.Context, rm *infrastructure.RemoteMachine) error {
+	bmc := rm.Spec.BMC
+	namespace := rm.Namespace
+	if usesPool(rm) {
+		namespace = poolNamespace(rm)
+	}
+	secret := &v1.Secret{}
+	key := client.ObjectKey{
+		Namespace: namespace,
+		Name:      bmc.CredentialsRef.Name,
+	}
+	if err := r.Get(ctx, key, secret); err != nil {
+		return fmt.Errorf("failed to get bmc credentials: %w", err)
+	}
+
+	pc, err := newPowerController(bmc, string(secret.Data["username"]), string(secret.Data["password"]))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, bmcRemediationTimeout)
+	defer cancel()
+	return remediate(ctx, pc, bmc.RemediationAction)
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+func TestNeedsBMCRemediation(t *testing.T) {
+	unhealthy := &clusterv1.Machine{}
+	conditions.MarkFalse(unhealthy, clusterv1.MachineHealthCheckSucceededCondition, clusterv1.MachineHasFailureReason, clusterv1.ConditionSeverityWarning, "")
+	healthy := &clusterv1.Machine{}
+	conditions.MarkTrue(healthy, clusterv1.MachineHealthCheckSucceededCondition)
+
+	rm := &infrastructure.RemoteMachine{}
+	assert.False(t, needsBMCRemediation(rm, unhealthy))
+
+	rm.Spec.BMC = &infrastructure.BMC{Protocol: infrastructure.BMCProtocolRedfish}
+	assert.True(t, needsBMCRemediation(rm, unhealthy))
+	assert.False(t, needsBMCRemediation(rm, healthy))
+
+	// A failed remediation is retried with the deletion
+	conditions.MarkFalse(rm, infrastructure.RemoteMachineRemediatedCondition, infrastructure.RemoteMachineRemediationFailedReason, clusterv1.ConditionSeverityWarning, "")
+	assert.True(t, needsBMCRemediation(rm, unhealthy))
+
+	conditions.MarkTrue(rm, infrastructure.RemoteMachineRemediatedCondition)
+	assert.False(t, needsBMCRemediation(rm, unhealthy))
+}
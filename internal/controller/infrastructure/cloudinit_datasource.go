@@ -0,0 +1,116 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+const nocloudSeedDir = "/var/lib/cloud/seed/nocloud"
+
+// mergedCloudConfig is a cloud-config part merged by cloud-init into the previous parts of the user-data,
+// as set by MergeHow, instead of replacing their keys.
+type mergedCloudConfig struct {
+	MergeHow            string `yaml:"merge_how"`
+	cloudinit.CloudInit `yaml:",inline"`
+}
+
+// nocloudSeed returns the files and commands to write a NoCloud seed holding the bootstrap data
+// and to run all the cloud-init stages against it.
+func nocloudSeed(rm *infrastructure.RemoteMachine, bootstrapData []byte) *cloudinit.CloudInit {
+	return &cloudinit.CloudInit{
+		Files: []cloudinit.File{
+			{Path: nocloudSeedDir + "/user-data", Content: string(bootstrapData), Permissions: "0600"},
+			{Path: nocloudSeedDir + "/meta-data", Content: string(nocloudMetaData(rm)), Permissions: "0644"},
+		},
+		RunCmds: []string{
+			"cloud-init clean --logs",
+			"cloud-init init --local",
+			"cloud-init init",
+			"cloud-init modules --mode=config",
+			"cloud-init modules --mode=final",
+		},
+	}
+}
+
+// nocloudMetaData returns the NoCloud meta-data of the machine. The instance ID is unique per RemoteMachine
+// so that cloud-init treats a machine reused from a pool as a new instance.
+func nocloudMetaData(rm *infrastructure.RemoteMachine) []byte {
+	return []byte(fmt.Sprintf("instance-id: %s\nlocal-hostname: %s\n", rm.UID, rm.Name))
+}
+
+// nocloudUserData returns the bootstrap data as a multipart user-data, along with the cloud-config parts
+// merged into it by cloud-init: the files and commands of preBootstrap run before the ones of the bootstrap data,
+// the ones of postBootstrap after, and cloud-init reports the end of the boot to phoneHomeURL.
+func nocloudUserData(bootstrapData []byte, preBootstrap, postBootstrap *cloudinit.CloudInit, phoneHomeURL string) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\nMIME-Version: 1.0\n\n", mw.Boundary())
+
+	contentType := "text/cloud-config"
+	if strings.HasPrefix(string(bootstrapData), "## template: jinja") {
+		contentType = "text/jinja2"
+	}
+
+	type part struct {
+		contentType string
+		content     []byte
+	}
+	parts := []part{{contentType, bootstrapData}}
+	for _, merge := range []struct {
+		ci       *cloudinit.CloudInit
+		mergeHow string
+	}{
+		{preBootstrap, "dict(no_replace,recurse_list)+list(prepend)+str()"},
+		{postBootstrap, "dict(no_replace,recurse_list)+list(append)+str()"},
+	} {
+		if len(merge.ci.Files) == 0 && len(merge.ci.RunCmds) == 0 {
+			continue
+		}
+		content, err := yaml.Marshal(mergedCloudConfig{MergeHow: merge.mergeHow, CloudInit: *merge.ci})
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part{"text/cloud-config", append([]byte("#cloud-config\n"), content...)})
+	}
+	parts = append(parts, part{"text/cloud-config", []byte(fmt.Sprintf("#cloud-config\nphone_home:\n  url: %q\n  post: [instance_id]\n  tries: 10\n", phoneHomeURL))})
+
+	for _, part := range parts {
+		w, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
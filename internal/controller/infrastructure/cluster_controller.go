@@ -18,23 +18,48 @@ package infrastructure
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capiutil "sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+	k0smoutil "github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 )
 
 type ClusterController struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// loadBalancerConfigs holds the HAProxy configuration last pushed to the load balancer host of each RemoteCluster,
+	// so the host is only connected to over SSH when the configuration changes.
+	loadBalancerConfigs sync.Map
 }
 
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=remoteclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=remoteclusters/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;machines,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
 
 func (r *ClusterController) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
 	log := log.FromContext(ctx).WithValues("remotecluster", req.NamespacedName)
@@ -44,24 +69,315 @@ func (r *ClusterController) Reconcile(ctx context.Context, req ctrl.Request) (re
 	if err := r.Get(ctx, req.NamespacedName, c); err != nil {
 		if apierrors.IsNotFound(err) {
 			log.Info("RemoteCluster not found, ignoring since object must be deleted")
+			r.loadBalancerConfigs.Delete(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		log.Error(err, "Failed to get RemoteCluster")
 		return ctrl.Result{}, err
 	}
 
-	// Nothing really to do, except put the cluster in a ready state
+	if c.Spec.EndpointManagement != nil {
+		if c.Spec.ControlPlaneEndpoint.IsZero() {
+			endpoint, err := r.managedEndpoint(ctx, c)
+			if err != nil {
+				log.Error(err, "Failed to determine control plane endpoint")
+				conditions.MarkFalse(c, infrastructure.RemoteClusterEndpointAvailableCondition, infrastructure.RemoteClusterEndpointInvalidReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+				return ctrl.Result{}, kerrors.NewAggregate([]error{err, r.updateStatus(ctx, c)})
+			}
+			if endpoint.IsZero() {
+				log.Info("Control plane endpoint not discovered yet")
+				conditions.MarkFalse(c, infrastructure.RemoteClusterEndpointAvailableCondition, infrastructure.RemoteClusterEndpointPendingReason, clusterv1.ConditionSeverityInfo, "")
+				res = ctrl.Result{RequeueAfter: 10 * time.Second}
+			} else {
+				log.Info("Setting control plane endpoint", "endpoint", endpoint.String())
+				c.Spec.ControlPlaneEndpoint = endpoint
+				if err := r.Update(ctx, c); err != nil {
+					log.Error(err, "Failed to update RemoteCluster")
+					return ctrl.Result{}, err
+				}
+			}
+		}
+		if !c.Spec.ControlPlaneEndpoint.IsZero() {
+			conditions.MarkTrue(c, infrastructure.RemoteClusterEndpointAvailableCondition)
+		}
+	}
+
+	var lbErr error
+	if em := c.Spec.EndpointManagement; em != nil && em.Mode == infrastructure.EndpointManagementModeLoadBalancer {
+		// The load balancer backends follow the control plane machines, a failure must not block their provisioning
+		if lbErr = r.reconcileLoadBalancer(ctx, c); lbErr != nil {
+			log.Error(lbErr, "Failed to configure the load balancer")
+			conditions.MarkFalse(c, infrastructure.RemoteClusterLoadBalancerReadyCondition, infrastructure.RemoteClusterLoadBalancerConfigurationFailedReason, clusterv1.ConditionSeverityWarning, "%s", lbErr.Error())
+		} else {
+			conditions.MarkTrue(c, infrastructure.RemoteClusterLoadBalancerReadyCondition)
+		}
+	}
+
+	// The cluster is always ready as the machines must be provisioned before the endpoint can be discovered
 	c.Status.Ready = true
-	if err := r.Status().Update(ctx, c); err != nil {
-		log.Error(err, "Failed to update RemoteCluster status")
+	if err := r.updateStatus(ctx, c); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	return res, lbErr
+}
+
+// updateStatus summarizes the conditions of the RemoteCluster in its Ready condition, so clusterctl describe reports
+// the state of the endpoint management, and updates its status.
+func (r *ClusterController) updateStatus(ctx context.Context, c *infrastructure.RemoteCluster) error {
+	conditions.SetSummary(c,
+		conditions.WithConditions(
+			infrastructure.RemoteClusterEndpointAvailableCondition,
+			infrastructure.RemoteClusterLoadBalancerReadyCondition,
+		),
+	)
+	if err := r.Status().Update(ctx, c); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update RemoteCluster status")
+		return err
+	}
+	return nil
+}
+
+// managedEndpoint returns the control plane endpoint according to the endpoint management mode.
+// An empty endpoint is returned if it cannot be determined yet.
+func (r *ClusterController) managedEndpoint(ctx context.Context, c *infrastructure.RemoteCluster) (clusterv1.APIEndpoint, error) {
+	em := c.Spec.EndpointManagement
+	port := em.Port
+	if port == 0 {
+		port = 6443
+	}
+
+	switch em.Mode {
+	case infrastructure.EndpointManagementModeVIP:
+		if em.VIP == nil || em.VIP.Address == "" {
+			return clusterv1.APIEndpoint{}, fmt.Errorf("vip address is required when endpoint management mode is %s", em.Mode)
+		}
+		return clusterv1.APIEndpoint{Host: em.VIP.Address, Port: port}, nil
+	case infrastructure.EndpointManagementModeLoadBalancer:
+		if em.LoadBalancer == nil || em.LoadBalancer.Host.Address == "" {
+			return clusterv1.APIEndpoint{}, fmt.Errorf("load balancer host is required when endpoint management mode is %s", em.Mode)
+		}
+		return clusterv1.APIEndpoint{Host: em.LoadBalancer.Host.Address, Port: port}, nil
+	case infrastructure.EndpointManagementModeFirstController:
+		cluster, err := capiutil.GetOwnerCluster(ctx, r.Client, c.ObjectMeta)
+		if err != nil || cluster == nil {
+			return clusterv1.APIEndpoint{}, err
+		}
+		address, err := r.firstControllerAddress(ctx, cluster)
+		if err != nil || address == "" {
+			return clusterv1.APIEndpoint{}, err
+		}
+		return clusterv1.APIEndpoint{Host: address, Port: port}, nil
+	default:
+		return clusterv1.APIEndpoint{}, fmt.Errorf("unknown endpoint management mode %q", em.Mode)
+	}
+}
+
+// firstControllerAddress returns the address of the oldest control plane RemoteMachine with an address.
+// The address is known before the machine is provisioned, either from the spec or from the pool reservation.
+func (r *ClusterController) firstControllerAddress(ctx context.Context, cluster *clusterv1.Cluster) (string, error) {
+	addresses, err := r.controllerAddresses(ctx, cluster)
+	if err != nil || len(addresses) == 0 {
+		return "", err
+	}
+	return addresses[0], nil
+}
+
+// controllerAddresses returns the addresses of the control plane RemoteMachines, oldest first.
+func (r *ClusterController) controllerAddresses(ctx context.Context, cluster *clusterv1.Cluster) ([]string, error) {
+	machines, err := collections.GetFilteredMachinesForCluster(ctx, r.Client, cluster, collections.ControlPlaneMachines(cluster.Name), collections.ActiveMachines)
+	if err != nil {
+		return nil, fmt.Errorf("error collecting machines: %w", err)
+	}
+
+	var addresses []string
+	for _, m := range machines.SortedByCreationTimestamp() {
+		ref := m.Spec.InfrastructureRef
+		if ref.Kind != "RemoteMachine" {
+			continue
+		}
+		rm := &infrastructure.RemoteMachine{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: m.Namespace, Name: ref.Name}, rm); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if address := machineAddress(rm); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+
+	return addresses, nil
+}
+
+// reconcileLoadBalancer pushes the HAProxy configuration balancing the control plane traffic to the load balancer host,
+// and publishes the kubeconfig of the cluster with the endpoints. The host is only connected to when the configuration
+// differs from the one last pushed by this instance, and the configuration is only rewritten, and HAProxy reloaded,
+// when it differs from the one on the host.
+func (r *ClusterController) reconcileLoadBalancer(ctx context.Context, c *infrastructure.RemoteCluster) error {
+	log := log.FromContext(ctx).WithValues("remotecluster", client.ObjectKeyFromObject(c))
+	em := c.Spec.EndpointManagement
+	if em.LoadBalancer == nil {
+		return fmt.Errorf("load balancer host is required when endpoint management mode is %s", em.Mode)
+	}
+
+	cluster, err := capiutil.GetOwnerCluster(ctx, r.Client, c.ObjectMeta)
+	if err != nil {
+		return err
+	}
+	endpoints := em.LoadBalancer.Endpoints
+	if len(endpoints) == 0 {
+		if cluster == nil {
+			return nil
+		}
+		endpoints, err = r.controllerAddresses(ctx, cluster)
+		if err != nil {
+			return err
+		}
+	}
+
+	haproxy, err := haproxyCloudInit(em, endpoints)
+	if err != nil {
+		return err
+	}
+
+	key := client.ObjectKeyFromObject(c)
+	if pushed, ok := r.loadBalancerConfigs.Load(key); !ok || pushed != haproxy.Files[0].Content {
+		if err := r.pushLoadBalancerConfig(ctx, log, c, haproxy, endpoints); err != nil {
+			return err
+		}
+		r.loadBalancerConfigs.Store(key, haproxy.Files[0].Content)
+	}
+	c.Status.LoadBalancerEndpoints = endpoints
+
+	if cluster == nil {
+		return nil
+	}
+	return r.reconcileEndpointsKubeconfig(ctx, c, cluster, endpoints)
+}
+
+// pushLoadBalancerConfig writes the HAProxy configuration to the load balancer host and reloads HAProxy, unless the
+// host is already up to date.
+func (r *ClusterController) pushLoadBalancerConfig(ctx context.Context, log logr.Logger, c *infrastructure.RemoteCluster, haproxy *cloudinit.CloudInit, endpoints []string) error {
+	p, err := r.loadBalancerProvisioner(ctx, log, c)
+	if err != nil {
+		return err
+	}
+	rigClient, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer rigClient.Disconnect()
+
+	if filesUpToDate(rigClient.Sudo().FS(), haproxy.Files) {
+		return nil
+	}
+	log.Info("Updating load balancer configuration", "endpoints", endpoints)
+	if err := p.uploadFiles(rigClient, haproxy.Files, func() {}); err != nil {
+		return err
+	}
+	return p.runCommands(ctx, log, rigClient, haproxy.RunCmds, func() {})
+}
+
+// reconcileEndpointsKubeconfig publishes the kubeconfig of the cluster with a context per load balanced endpoint in the
+// <cluster>-kubeconfig-endpoints secret, once the control plane provider generated the kubeconfig of the cluster.
+func (r *ClusterController) reconcileEndpointsKubeconfig(ctx context.Context, c *infrastructure.RemoteCluster, cluster *clusterv1.Cluster, endpoints []string) error {
+	kubeconfigSecret := &v1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: secret.Name(cluster.Name, secret.Kubeconfig)}, kubeconfigSecret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+
+	port := c.Spec.EndpointManagement.Port
+	if port == 0 {
+		port = 6443
+	}
+	kubeconfig, err := endpointsKubeconfig(kubeconfigSecret.Data[secret.KubeconfigDataName], endpoints, port)
+	if err != nil {
+		return err
+	}
+
+	endpointsSecret := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(endpointsKubeconfigSecretNameTemplate, cluster.Name),
+			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabel: cluster.Name,
+			},
+		},
+		Data: map[string][]byte{secret.KubeconfigDataName: kubeconfig},
+		Type: clusterv1.ClusterSecretType,
+	}
+	if err := ctrl.SetControllerReference(c, endpointsSecret, r.Scheme); err != nil {
+		return err
+	}
+	return k0smoutil.Apply(ctx, r.Client, endpointsSecret)
+}
+
+// loadBalancerProvisioner returns an SSH provisioner connecting to the load balancer host.
+func (r *ClusterController) loadBalancerProvisioner(ctx context.Context, log logr.Logger, c *infrastructure.RemoteCluster) (*SSHProvisioner, error) {
+	host := c.Spec.EndpointManagement.LoadBalancer.Host
+
+	secret := &v1.Secret{}
+	key := client.ObjectKey{Namespace: c.Namespace, Name: host.SSHKeyRef.Name}
+	if err := r.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("failed to get ssh key: %w", err)
+	}
+	sshProxy, err := getSSHProxyDialer(ctx, r.Client, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SSHProvisioner{
+		cloudInit:        &cloudinit.CloudInit{},
+		sshKey:           secret.Data["value"],
+		sshCertificate:   secret.Data[sshCertificateSecretKey],
+		sshKeyPassphrase: secret.Data[sshPassphraseSecretKey],
+		sshProxy:         sshProxy,
+		sshConnection:    resolveSSHConnection(c.Spec.SSHConnection, nil),
+		machine: &infrastructure.RemoteMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: c.Name, Namespace: c.Namespace},
+			Spec: infrastructure.RemoteMachineSpec{
+				Address:   host.Address,
+				Port:      host.Port,
+				User:      host.User,
+				UseSudo:   host.UseSudo,
+				SSHKeyRef: host.SSHKeyRef,
+			},
+		},
+		log: log,
+	}, nil
+}
+
+// remoteClusterForMachine maps a control plane Machine to the RemoteCluster of its Cluster.
+func (r *ClusterController) remoteClusterForMachine(ctx context.Context, o client.Object) []reconcile.Request {
+	if _, ok := o.GetLabels()[clusterv1.MachineControlPlaneLabel]; !ok {
+		return nil
+	}
+	cluster, err := capiutil.GetClusterFromMetadata(ctx, r.Client, metav1.ObjectMeta{Namespace: o.GetNamespace(), Labels: o.GetLabels()})
+	if err != nil || cluster.Spec.InfrastructureRef == nil || cluster.Spec.InfrastructureRef.Kind != "RemoteCluster" {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Spec.InfrastructureRef.Name}}}
 }
 
 func (r *ClusterController) SetupWithManager(mgr ctrl.Manager) error {
+	metrics.RegisterObjects("RemoteCluster", mgr.GetClient(), func() client.ObjectList { return &infrastructure.RemoteClusterList{} }, nil)
+
+	if err := health.AddChecks(mgr, "remotecluster", &infrastructure.RemoteCluster{}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&infrastructure.RemoteCluster{}).
-		Complete(r)
+		Watches(&clusterv1.Machine{}, handler.EnqueueRequestsFromMapFunc(r.remoteClusterForMachine)).
+		Complete(metrics.Instrument("remotecluster", mgr.GetClient(), func() client.Object { return &infrastructure.RemoteCluster{} }, nil, tracing.Instrument("remotecluster", health.Instrument("remotecluster", r))))
 }
@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/util/v1beta2conditions"
+)
+
+func TestRemoteClusterConditions(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrastructure.AddToScheme(scheme))
+
+	rc := &infrastructure.RemoteCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: infrastructure.RemoteClusterSpec{
+			EndpointManagement: &infrastructure.EndpointManagement{Mode: infrastructure.EndpointManagementModeVIP},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(rc).WithStatusSubresource(rc).Build()
+	r := &ClusterController{Client: c, Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(rc)}
+
+	// A VIP mode without address cannot provide an endpoint
+	_, err := r.Reconcile(ctx, req)
+	require.Error(t, err)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, rc))
+	assert.True(t, conditions.IsFalse(rc, clusterv1.ReadyCondition))
+	assert.Equal(t, infrastructure.RemoteClusterEndpointInvalidReason, conditions.GetReason(rc, infrastructure.RemoteClusterEndpointAvailableCondition))
+	assert.Equal(t, metav1.ConditionFalse, v1beta2conditions.Get(rc.GetV1Beta2Conditions(), v1beta2conditions.ReadyCondition).Status)
+
+	rc.Spec.EndpointManagement.VIP = &infrastructure.VIPSpec{Address: "10.0.0.10"}
+	require.NoError(t, c.Update(ctx, rc))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, rc))
+	assert.Equal(t, "10.0.0.10", rc.Spec.ControlPlaneEndpoint.Host)
+	assert.True(t, rc.Status.Ready)
+	assert.True(t, conditions.IsTrue(rc, clusterv1.ReadyCondition))
+	assert.True(t, conditions.IsTrue(rc, infrastructure.RemoteClusterEndpointAvailableCondition))
+}
+
+func TestRemoteClusterLoadBalancerConfigCache(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrastructure.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	em := &infrastructure.EndpointManagement{
+		Mode: infrastructure.EndpointManagementModeLoadBalancer,
+		Port: 6443,
+		LoadBalancer: &infrastructure.LoadBalancerSpec{
+			Host:      infrastructure.LoadBalancerHost{Address: "10.0.0.100", SSHKeyRef: infrastructure.SecretRef{Name: "lb-ssh-key"}},
+			Endpoints: []string{"10.0.0.1", "10.0.0.2"},
+		},
+	}
+	rc := &infrastructure.RemoteCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec:       infrastructure.RemoteClusterSpec{EndpointManagement: em},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(rc).WithStatusSubresource(rc).Build()
+	r := &ClusterController{Client: c, Scheme: scheme}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(rc)}
+
+	// The load balancer host is connected to, which fails without its SSH key
+	_, err := r.Reconcile(ctx, req)
+	require.ErrorContains(t, err, "ssh key")
+	require.NoError(t, c.Get(ctx, req.NamespacedName, rc))
+	assert.True(t, conditions.IsFalse(rc, infrastructure.RemoteClusterLoadBalancerReadyCondition))
+
+	// The host is not connected to again once the same configuration was pushed
+	haproxy, err := haproxyCloudInit(em, em.LoadBalancer.Endpoints)
+	require.NoError(t, err)
+	r.loadBalancerConfigs.Store(req.NamespacedName, haproxy.Files[0].Content)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, rc))
+	assert.True(t, conditions.IsTrue(rc, infrastructure.RemoteClusterLoadBalancerReadyCondition))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, rc.Status.LoadBalancerEndpoints)
+
+	// A configuration change connects to the host again
+	rc.Spec.EndpointManagement.LoadBalancer.Endpoints = []string{"10.0.0.1"}
+	require.NoError(t, c.Update(ctx, rc))
+	_, err = r.Reconcile(ctx, req)
+	require.ErrorContains(t, err, "ssh key")
+}
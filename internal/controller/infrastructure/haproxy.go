@@ -0,0 +1,141 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"text/template"
+
+	"k8s.io/client-go/tools/clientcmd"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+const haproxyConfigPath = "/etc/haproxy/haproxy.cfg"
+
+// endpointsKubeconfigSecretNameTemplate is the name of the secret holding the kubeconfig with the load balanced endpoints.
+const endpointsKubeconfigSecretNameTemplate = "%s-kubeconfig-endpoints"
+
+// The k0s API and konnectivity servers track the Kubernetes API server of the same node, so a node
+// failing the readiness check is taken out of all the backends.
+var haproxyConfigTemplate = template.Must(template.New("haproxy.cfg").Parse(`# Managed by k0smotron
+defaults
+  mode tcp
+  timeout connect 10s
+  timeout client 1h
+  timeout server 1h
+  default-server inter 5s fall 2 rise 2
+
+frontend kube_api
+  bind :{{ .Port }}
+  default_backend kube_api
+
+backend kube_api
+  balance roundrobin
+  option httpchk GET /readyz
+  http-check expect status 200
+{{- range $i, $e := .Endpoints }}
+  server controller-{{ $i }} {{ $e }}:{{ $.Port }} check check-ssl verify none
+{{- end }}
+
+frontend k0s_api
+  bind :9443
+  default_backend k0s_api
+
+backend k0s_api
+  balance roundrobin
+{{- range $i, $e := .Endpoints }}
+  server controller-{{ $i }} {{ $e }}:9443 track kube_api/controller-{{ $i }}
+{{- end }}
+
+frontend konnectivity
+  bind :8132
+  default_backend konnectivity
+
+backend konnectivity
+  balance roundrobin
+{{- range $i, $e := .Endpoints }}
+  server controller-{{ $i }} {{ $e }}:8132 track kube_api/controller-{{ $i }}
+{{- end }}
+`))
+
+const reloadHAProxyCommand = `haproxy -c -q -f ` + haproxyConfigPath + ` && (` +
+	`(command -v systemctl > /dev/null 2>&1 && systemctl enable haproxy && systemctl reload-or-restart haproxy) || ` + // systemd
+	`(command -v rc-service > /dev/null 2>&1 && rc-update add haproxy && rc-service haproxy restart) || ` + // OpenRC
+	`(command -v service > /dev/null 2>&1 && service haproxy restart) || ` + // SysV
+	`(echo "haproxy could not be reloaded"; false))`
+
+// haproxyCloudInit returns the HAProxy configuration file balancing the control plane traffic over the endpoints
+// and the command to reload HAProxy.
+func haproxyCloudInit(em *infrastructure.EndpointManagement, endpoints []string) (*cloudinit.CloudInit, error) {
+	port := em.Port
+	if port == 0 {
+		port = 6443
+	}
+
+	var b bytes.Buffer
+	err := haproxyConfigTemplate.Execute(&b, map[string]interface{}{
+		"Endpoints": endpoints,
+		"Port":      port,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render haproxy config: %w", err)
+	}
+
+	return &cloudinit.CloudInit{
+		Files: []cloudinit.File{{
+			Path:        haproxyConfigPath,
+			Content:     b.String(),
+			Permissions: "0644",
+		}},
+		RunCmds: []string{reloadHAProxyCommand},
+	}, nil
+}
+
+// endpointsKubeconfig returns the kubeconfig with a cluster and a context per endpoint, named after the current context
+// with the index of the endpoint, in the round-robin order of the load balancer. The current context still goes
+// through the load balancer, the other contexts reach the control plane when the load balancer host is down.
+func endpointsKubeconfig(kubeconfig []byte, endpoints []string, port int32) ([]byte, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	current, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig has no current context")
+	}
+	cluster, ok := config.Clusters[current.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig has no cluster %q", current.Cluster)
+	}
+
+	for i, endpoint := range endpoints {
+		name := fmt.Sprintf("%s-%d", config.CurrentContext, i)
+		endpointCluster := cluster.DeepCopy()
+		endpointCluster.Server = "https://" + net.JoinHostPort(endpoint, strconv.Itoa(int(port)))
+		config.Clusters[name] = endpointCluster
+		endpointContext := current.DeepCopy()
+		endpointContext.Cluster = name
+		config.Contexts[name] = endpointContext
+	}
+	return clientcmd.Write(*config)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+func TestHAProxyCloudInit(t *testing.T) {
+	ci, err := haproxyCloudInit(&infrastructure.EndpointManagement{
+		Mode: infrastructure.EndpointManagementModeLoadBalancer,
+	}, []string{"10.0.0.1", "10.0.0.2"})
+	require.NoError(t, err)
+	require.Len(t, ci.Files, 1)
+	assert.Equal(t, haproxyConfigPath, ci.Files[0].Path)
+	assert.Contains(t, ci.Files[0].Content, "bind :6443")
+	assert.Contains(t, ci.Files[0].Content, "server controller-0 10.0.0.1:6443 check check-ssl verify none")
+	assert.Contains(t, ci.Files[0].Content, "server controller-1 10.0.0.2:6443 check check-ssl verify none")
+	assert.Contains(t, ci.Files[0].Content, "server controller-1 10.0.0.2:9443 track kube_api/controller-1")
+	assert.Contains(t, ci.Files[0].Content, "server controller-0 10.0.0.1:8132 track kube_api/controller-0")
+	assert.Equal(t, []string{reloadHAProxyCommand}, ci.RunCmds)
+
+	ci, err = haproxyCloudInit(&infrastructure.EndpointManagement{
+		Mode: infrastructure.EndpointManagementModeLoadBalancer,
+		Port: 443,
+	}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, ci.Files[0].Content, "bind :443")
+	assert.NotContains(t, ci.Files[0].Content, "server controller")
+}
+
+func TestEndpointsKubeconfig(t *testing.T) {
+	kubeconfig := []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://lb.example.com:6443
+    certificate-authority-data: Y2E=
+contexts:
+- name: test-admin@test
+  context:
+    cluster: test
+    user: test-admin
+current-context: test-admin@test
+users:
+- name: test-admin
+  user:
+    client-certificate-data: Y2VydA==
+    client-key-data: a2V5
+`)
+
+	out, err := endpointsKubeconfig(kubeconfig, []string{"10.0.0.1", "fd00::2"}, 6443)
+	require.NoError(t, err)
+	config, err := clientcmd.Load(out)
+	require.NoError(t, err)
+
+	// The current context still goes through the load balancer
+	assert.Equal(t, "test-admin@test", config.CurrentContext)
+	assert.Equal(t, "https://lb.example.com:6443", config.Clusters["test"].Server)
+	assert.Equal(t, "https://10.0.0.1:6443", config.Clusters["test-admin@test-0"].Server)
+	assert.Equal(t, "https://[fd00::2]:6443", config.Clusters["test-admin@test-1"].Server)
+	assert.Equal(t, []byte("ca"), config.Clusters["test-admin@test-1"].CertificateAuthorityData)
+	assert.Equal(t, "test-admin@test-1", config.Contexts["test-admin@test-1"].Cluster)
+	assert.Equal(t, "test-admin", config.Contexts["test-admin@test-1"].AuthInfo)
+
+	_, err = endpointsKubeconfig([]byte("apiVersion: v1\nkind: Config\n"), []string{"10.0.0.1"}, 6443)
+	assert.Error(t, err)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/k0sproject/k0smotron/internal/cloudinit"
+	k0smoutil "github.com/k0sproject/k0smotron/internal/controller/util"
 
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
@@ -21,11 +22,6 @@ import (
 	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
 )
 
-var patchOpts []client.PatchOption = []client.PatchOption{
-	client.FieldOwner("k0smotron-operator"),
-	client.ForceOwnership,
-}
-
 type JobProvisioner struct {
 	client    client.Client
 	clientSet *kubernetes.Clientset
@@ -88,7 +84,7 @@ func (p *JobProvisioner) Provision(ctx context.Context) error {
 
 	job.Spec.Template.Spec.Containers[0].Args = []string{"/var/lib/bootstrap-data/k0smotron-entrypoint.sh"}
 
-	if err := p.client.Patch(context.Background(), secret, client.Apply, patchOpts...); err != nil {
+	if err := k0smoutil.Apply(context.Background(), p.client, secret); err != nil {
 		return fmt.Errorf("failed to create a secret: %w", err)
 	}
 
@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+const keepalivedConfigPath = "/etc/keepalived/keepalived.conf"
+
+var keepalivedConfigTemplate = template.Must(template.New("keepalived.conf").Parse(`# Managed by k0smotron
+vrrp_script k0s_api {
+  script "/bin/sh -c 'command -v curl > /dev/null && curl -ksf https://localhost:{{ .Port }}/readyz > /dev/null'"
+  interval 5
+  fall 2
+  rise 2
+}
+
+vrrp_instance k0s_api_vip {
+  state BACKUP
+  interface {{ .Interface }}
+  virtual_router_id {{ .VirtualRouterID }}
+  priority 100
+  advert_int 1
+  virtual_ipaddress {
+    {{ .Address }}
+  }
+  track_script {
+    k0s_api
+  }
+}
+`))
+
+const restartKeepalivedCommand = `(command -v systemctl > /dev/null 2>&1 && systemctl enable --now keepalived && systemctl restart keepalived) || ` + // systemd
+	`(command -v rc-service > /dev/null 2>&1 && rc-update add keepalived && rc-service keepalived restart) || ` + // OpenRC
+	`(command -v service > /dev/null 2>&1 && service keepalived restart) || ` + // SysV
+	`(echo "keepalived could not be restarted"; false)`
+
+// keepalivedCloudInit returns the keepalived configuration file and the commands to (re)start keepalived
+// so that the control plane machine competes for the VIP of the endpoint.
+func keepalivedCloudInit(em *infrastructure.EndpointManagement) (*cloudinit.CloudInit, error) {
+	if em.VIP == nil || em.VIP.Address == "" || em.VIP.Interface == "" {
+		return nil, fmt.Errorf("vip address and interface are required when endpoint management mode is %s", em.Mode)
+	}
+
+	port := em.Port
+	if port == 0 {
+		port = 6443
+	}
+	routerID := em.VIP.VirtualRouterID
+	if routerID == 0 {
+		routerID = 51
+	}
+
+	var b bytes.Buffer
+	err := keepalivedConfigTemplate.Execute(&b, map[string]interface{}{
+		"Address":         em.VIP.Address,
+		"Interface":       em.VIP.Interface,
+		"VirtualRouterID": routerID,
+		"Port":            port,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render keepalived config: %w", err)
+	}
+
+	return &cloudinit.CloudInit{
+		Files: []cloudinit.File{{
+			Path:        keepalivedConfigPath,
+			Content:     b.String(),
+			Permissions: "0644",
+		}},
+		RunCmds: []string{restartKeepalivedCommand},
+	}, nil
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+func TestKeepalivedCloudInit(t *testing.T) {
+	ci, err := keepalivedCloudInit(&infrastructure.EndpointManagement{
+		Mode: infrastructure.EndpointManagementModeVIP,
+		VIP: &infrastructure.VIPSpec{
+			Address:   "192.168.1.100",
+			Interface: "eth0",
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, ci.Files, 1)
+	assert.Equal(t, keepalivedConfigPath, ci.Files[0].Path)
+	assert.Contains(t, ci.Files[0].Content, "interface eth0")
+	assert.Contains(t, ci.Files[0].Content, "virtual_router_id 51")
+	assert.Contains(t, ci.Files[0].Content, "192.168.1.100")
+	assert.Contains(t, ci.Files[0].Content, "https://localhost:6443/readyz")
+	assert.Equal(t, []string{restartKeepalivedCommand}, ci.RunCmds)
+
+	_, err = keepalivedCloudInit(&infrastructure.EndpointManagement{Mode: infrastructure.EndpointManagementModeVIP})
+	assert.Error(t, err)
+}
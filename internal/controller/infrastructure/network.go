@@ -0,0 +1,89 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/k0sproject/rig/v2/remotefs"
+	"github.com/k0sproject/rig/v2/sh"
+
+	api "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+const (
+	netplanConfigPath            = "/etc/netplan/90-k0smotron.yaml"
+	networkManagerConnectionsDir = "/etc/NetworkManager/system-connections"
+)
+
+// networkCloudInit returns the files of the network configuration and the command applying it.
+func networkCloudInit(network *api.NetworkConfig) (*cloudinit.CloudInit, error) {
+	ci := &cloudinit.CloudInit{}
+	switch {
+	case network == nil:
+	case network.Netplan != "" && len(network.NetworkManager) > 0:
+		return nil, errors.New("invalid network configuration: only one of netplan and networkManager can be set")
+	case network.Netplan != "":
+		ci.Files = append(ci.Files, cloudinit.File{Path: netplanConfigPath, Content: network.Netplan, Permissions: "0600"})
+		ci.RunCmds = append(ci.RunCmds, "netplan apply")
+	case len(network.NetworkManager) > 0:
+		cmds := []string{"nmcli connection reload"}
+		for _, conn := range network.NetworkManager {
+			ci.Files = append(ci.Files, cloudinit.File{
+				Path:        path.Join(networkManagerConnectionsDir, conn.Name+".nmconnection"),
+				Content:     conn.Content,
+				Permissions: "0600",
+			})
+			cmds = append(cmds, "nmcli connection up "+sh.Quote(conn.Name))
+		}
+		ci.RunCmds = append(ci.RunCmds, strings.Join(cmds, " && "))
+	default:
+		return nil, errors.New("invalid network configuration: one of netplan and networkManager must be set")
+	}
+	return ci, nil
+}
+
+// detach returns a command running cmd in the background after a short delay, so that the SSH session
+// running it returns before the network configuration changes.
+func detach(cmd string) string {
+	return fmt.Sprintf("nohup sh -c %s > /dev/null 2>&1 &", sh.Quote("sleep 2; "+cmd))
+}
+
+// filesUpToDate returns true if all the files are on the machine with the same content.
+func filesUpToDate(fsys remotefs.FS, files []cloudinit.File) bool {
+	for _, file := range files {
+		content, err := fsys.ReadFile(file.Path)
+		if err != nil || !bytes.Equal(content, []byte(file.Content)) {
+			return false
+		}
+	}
+	return true
+}
+
+// machineAddress returns the address the machine is reachable at.
+func machineAddress(rm *api.RemoteMachine) string {
+	if rm.Status.Address != "" {
+		return rm.Status.Address
+	}
+	return rm.Spec.Address
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+func TestNetworkCloudInit(t *testing.T) {
+	ci, err := networkCloudInit(nil)
+	require.NoError(t, err)
+	assert.Empty(t, ci.Files)
+	assert.Empty(t, ci.RunCmds)
+
+	ci, err = networkCloudInit(&api.NetworkConfig{Netplan: "network:\n  version: 2\n"})
+	require.NoError(t, err)
+	assert.Equal(t, &cloudinit.CloudInit{
+		Files:   []cloudinit.File{{Path: "/etc/netplan/90-k0smotron.yaml", Content: "network:\n  version: 2\n", Permissions: "0600"}},
+		RunCmds: []string{"netplan apply"},
+	}, ci)
+
+	ci, err = networkCloudInit(&api.NetworkConfig{NetworkManager: []api.NetworkManagerConnection{
+		{Name: "bond0", Content: "[connection]\nid=bond0\n"},
+		{Name: "bond0.100", Content: "[connection]\nid=bond0.100\n"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, &cloudinit.CloudInit{
+		Files: []cloudinit.File{
+			{Path: "/etc/NetworkManager/system-connections/bond0.nmconnection", Content: "[connection]\nid=bond0\n", Permissions: "0600"},
+			{Path: "/etc/NetworkManager/system-connections/bond0.100.nmconnection", Content: "[connection]\nid=bond0.100\n", Permissions: "0600"},
+		},
+		RunCmds: []string{"nmcli connection reload && nmcli connection up bond0 && nmcli connection up bond0.100"},
+	}, ci)
+
+	_, err = networkCloudInit(&api.NetworkConfig{Address: "10.0.0.10"})
+	assert.Error(t, err)
+	_, err = networkCloudInit(&api.NetworkConfig{Netplan: "network: {}", NetworkManager: []api.NetworkManagerConnection{{Name: "eth0"}}})
+	assert.Error(t, err)
+}
+
+func TestDetach(t *testing.T) {
+	assert.Equal(t, "nohup sh -c 'sleep 2; netplan apply' > /dev/null 2>&1 &", detach("netplan apply"))
+}
+
+func TestMachineAddress(t *testing.T) {
+	rm := &api.RemoteMachine{Spec: api.RemoteMachineSpec{Address: "192.168.1.23"}}
+	assert.Equal(t, "192.168.1.23", machineAddress(rm))
+
+	rm.Status.Address = "10.0.0.10"
+	assert.Equal(t, "10.0.0.10", machineAddress(rm))
+}
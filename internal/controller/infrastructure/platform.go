@@ -0,0 +1,101 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+const (
+	// archPlaceholder is replaced with the architecture of the machine in the bootstrap commands,
+	// e.g. in a download URL like https://example.com/k0s-v1.30.0+k0s.0-${K0S_ARCH}.
+	archPlaceholder = "${K0S_ARCH}"
+	// osPlaceholder is replaced with the operating system of the machine in the bootstrap commands.
+	osPlaceholder = "${K0S_OS}"
+
+	// platformDetectionScript sets the K0S_ARCH and K0S_OS variables in the pull bootstrap script.
+	platformDetectionScript = `case "$(uname -m)" in
+  x86_64|amd64) K0S_ARCH=amd64 ;;
+  aarch64|arm64) K0S_ARCH=arm64 ;;
+  armv7*|armv8l|armhf|arm) K0S_ARCH=arm ;;
+  *) echo "unsupported architecture $(uname -m)" >&2; exit 1 ;;
+esac
+K0S_OS=$(uname -s | tr '[:upper:]' '[:lower:]')
+if [ "$K0S_OS" != linux ]; then echo "unsupported operating system $K0S_OS" >&2; exit 1; fi
+`
+)
+
+// errUnsupportedPlatform is returned when k0s has no binaries for the operating system or the architecture of the machine.
+var errUnsupportedPlatform = errors.New("unsupported platform")
+
+// parsePlatform parses the output of `uname -s -m` and returns the operating system and the architecture
+// in the naming of the k0s binaries.
+func parsePlatform(uname string) (string, string, error) {
+	fields := strings.Fields(uname)
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("unexpected uname output: %q", uname)
+	}
+
+	osName := strings.ToLower(fields[0])
+	if osName != "linux" {
+		return "", "", fmt.Errorf("%w: operating system %s is not supported by k0s", errUnsupportedPlatform, fields[0])
+	}
+
+	var arch string
+	switch m := fields[1]; {
+	case m == "x86_64" || m == "amd64":
+		arch = "amd64"
+	case m == "aarch64" || m == "arm64":
+		arch = "arm64"
+	case strings.HasPrefix(m, "armv7") || m == "armv8l" || m == "armhf" || m == "arm":
+		arch = "arm"
+	default:
+		return "", "", fmt.Errorf("%w: architecture %s is not supported by k0s", errUnsupportedPlatform, m)
+	}
+
+	return osName, arch, nil
+}
+
+// placeholderReplacer returns a replacer of the platform and provider ID placeholders.
+func placeholderReplacer(osName, arch, providerID string) *strings.Replacer {
+	return strings.NewReplacer(archPlaceholder, arch, osPlaceholder, osName, providerIDPlaceholder, providerID)
+}
+
+// substitutePlaceholders returns a copy of ci with the placeholders of the commands replaced.
+func substitutePlaceholders(ci *cloudinit.CloudInit, r *strings.Replacer) *cloudinit.CloudInit {
+	out := *ci
+	out.RunCmds = make([]string, len(ci.RunCmds))
+	for i, cmd := range ci.RunCmds {
+		out.RunCmds[i] = r.Replace(cmd)
+	}
+	return &out
+}
+
+// usesPlatformPlaceholders returns true if any of the commands of ci refers to the platform placeholders.
+func usesPlatformPlaceholders(ci *cloudinit.CloudInit) bool {
+	for _, cmd := range ci.RunCmds {
+		if strings.Contains(cmd, archPlaceholder) || strings.Contains(cmd, osPlaceholder) {
+			return true
+		}
+	}
+	return false
+}
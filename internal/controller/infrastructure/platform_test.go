@@ -0,0 +1,62 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+func TestParsePlatform(t *testing.T) {
+	for uname, arch := range map[string]string{
+		"Linux x86_64\n": "amd64",
+		"Linux aarch64":  "arm64",
+		"Linux armv7l":   "arm",
+	} {
+		osName, a, err := parsePlatform(uname)
+		require.NoError(t, err, uname)
+		assert.Equal(t, "linux", osName)
+		assert.Equal(t, arch, a)
+	}
+
+	_, _, err := parsePlatform("Linux riscv64")
+	assert.ErrorIs(t, err, errUnsupportedPlatform)
+	_, _, err = parsePlatform("Darwin arm64")
+	assert.ErrorIs(t, err, errUnsupportedPlatform)
+	_, _, err = parsePlatform("")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, errUnsupportedPlatform)
+}
+
+func TestSubstitutePlatform(t *testing.T) {
+	ci := &cloudinit.CloudInit{
+		Files:   []cloudinit.File{{Path: "/etc/k0s/k0s.yaml", Content: "${K0S_ARCH}"}},
+		RunCmds: []string{"curl -sSfL https://example.com/${K0S_OS}/k0s-${K0S_ARCH} -o /usr/local/bin/k0s", "k0s start"},
+	}
+	assert.True(t, usesPlatformPlaceholders(ci))
+
+	out := substitutePlaceholders(ci, placeholderReplacer("linux", "arm64", "remote-machine://1.2.3.4:22"))
+	assert.Equal(t, []string{"curl -sSfL https://example.com/linux/k0s-arm64 -o /usr/local/bin/k0s", "k0s start"}, out.RunCmds)
+	assert.Equal(t, ci.Files, out.Files)
+	assert.False(t, usesPlatformPlaceholders(out))
+	// The original is left untouched
+	assert.Equal(t, "curl -sSfL https://example.com/${K0S_OS}/k0s-${K0S_ARCH} -o /usr/local/bin/k0s", ci.RunCmds[0])
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+// The states of the pooled machines reported in the k0smotron_pool_machines gauge.
+const (
+	pooledMachineStateAvailable        = "available"
+	pooledMachineStateReserved         = "reserved"
+	pooledMachineStateUnhealthy        = "unhealthy"
+	pooledMachineStateDecommissioned   = "decommissioned"
+	pooledMachineStateAwaitingApproval = "awaitingApproval"
+)
+
+var (
+	poolMachinesDesc = prometheus.NewDesc(
+		"k0smotron_pool_machines",
+		"Number of PooledRemoteMachines per namespace, pool and state.",
+		[]string{"namespace", "pool", "state"}, nil,
+	)
+
+	poolCapacity = &poolCapacityCollector{}
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(poolCapacity)
+}
+
+// poolCapacityCollector computes the capacity of the pools from the cached PooledRemoteMachines when the metrics are
+// collected, once per pool, instead of storing it in the status of every machine of the pool on each change.
+type poolCapacityCollector struct {
+	mu     sync.Mutex
+	reader client.Reader
+}
+
+func (p *poolCapacityCollector) setReader(reader client.Reader) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reader = reader
+}
+
+func (p *poolCapacityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolMachinesDesc
+}
+
+func (p *poolCapacityCollector) Collect(ch chan<- prometheus.Metric) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.reader == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pooledMachines := &infrastructure.PooledRemoteMachineList{}
+	if err := p.reader.List(ctx, pooledMachines); err != nil {
+		return
+	}
+
+	type key struct{ namespace, pool, state string }
+	counts := map[key]int{}
+	for i := range pooledMachines.Items {
+		pm := &pooledMachines.Items[i]
+		counts[key{pm.Namespace, pm.Spec.Pool, pooledMachineState(pm)}]++
+	}
+	for k, count := range counts {
+		ch <- prometheus.MustNewConstMetric(poolMachinesDesc, prometheus.GaugeValue, float64(count), k.namespace, k.pool, k.state)
+	}
+}
+
+// pooledMachineState returns the state of the pooled machine counted in the capacity of its pool.
+func pooledMachineState(pm *infrastructure.PooledRemoteMachine) string {
+	switch {
+	case pm.Status.Reserved:
+		return pooledMachineStateReserved
+	case pm.Status.ReleaseState == infrastructure.PooledMachineDecommissioned:
+		return pooledMachineStateDecommissioned
+	case pm.Status.ReleaseState == infrastructure.PooledMachineAwaitingApproval:
+		return pooledMachineStateAwaitingApproval
+	case conditions.IsFalse(pm, infrastructure.PooledMachineHealthyCondition):
+		return pooledMachineStateUnhealthy
+	default:
+		return pooledMachineStateAvailable
+	}
+}
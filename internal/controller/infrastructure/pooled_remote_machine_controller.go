@@ -0,0 +1,363 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	k0smoutil "github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/tracing"
+)
+
+const defaultPooledMachineProbeInterval = 5 * time.Minute
+
+// PooledRemoteMachineController probes the free machines of a pool and reclaims the machines
+// whose RemoteMachine is gone. The capacity of the pools is reported in the k0smotron_pool_machines metric.
+type PooledRemoteMachineController struct {
+	client.Client
+	// APIReader is used to read the PooledRemoteMachine bypassing the cache before reclaiming it,
+	// so a machine just reserved by another RemoteMachine is never reset.
+	APIReader client.Reader
+	Scheme    *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=pooledremotemachines,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=pooledremotemachines/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=remotemachines,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+func (r *PooledRemoteMachineController) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	log := log.FromContext(ctx).WithValues("pooledremotemachine", req.NamespacedName)
+
+	pm := &infrastructure.PooledRemoteMachine{}
+	if err := r.Get(ctx, req.NamespacedName, pm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !pm.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	if pm.Status.Reserved {
+		reclaimed, err := r.reclaimIfOrphaned(ctx, log, pm)
+		if err != nil || reclaimed {
+			// The reclaimed machine is reconciled again to be probed and counted as free
+			return ctrl.Result{}, err
+		}
+	}
+
+	patchHelper, err := patch.NewHelper(pm, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		if perr := patchHelper.Patch(ctx, pm); perr != nil {
+			log.Error(perr, "Failed to update PooledRemoteMachine status")
+			if err == nil {
+				err = perr
+			}
+		}
+	}()
+
+	if pm.Status.Reserved {
+		// Reserved machines are managed by their RemoteMachine, no probing needed
+		return ctrl.Result{}, nil
+	}
+
+	if pm.Status.ReleaseState == infrastructure.PooledMachineAwaitingApproval {
+		if _, ok := pm.Annotations[infrastructure.PooledMachineApproveReuseAnnotation]; !ok {
+			return ctrl.Result{}, nil
+		}
+		log.Info("Reuse of the pooled machine approved, returning machine to the pool")
+		delete(pm.Annotations, infrastructure.PooledMachineApproveReuseAnnotation)
+		pm.Status.ReleaseState = ""
+		// Make sure the machine is probed again before it is handed out
+		pm.Status.LastProbe = nil
+	}
+	if pm.Status.ReleaseState != "" {
+		// Decommissioned machines wait to be removed from the pool
+		return ctrl.Result{}, nil
+	}
+
+	if pm.Spec.HealthCheck != nil {
+		keyName, err := pooledMachineSSHKeyName(ctx, r.Client, pm)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		// Machines without a key cannot be reached, they are probed once a key is set for their pool
+		if keyName != "" {
+			return r.probeIfDue(ctx, log, pm)
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reclaimIfOrphaned returns the machine to the pool if the RemoteMachine holding the reservation
+// does not exist anymore. The machine is reset over SSH before it is released.
+func (r *PooledRemoteMachineController) reclaimIfOrphaned(ctx context.Context, log logr.Logger, pm *infrastructure.PooledRemoteMachine) (bool, error) {
+	key := types.NamespacedName{Namespace: pm.Status.MachineRef.Namespace, Name: pm.Status.MachineRef.Name}
+	if key.Namespace == "" {
+		key.Namespace = pm.Namespace
+	}
+	err := r.Get(ctx, key, &infrastructure.RemoteMachine{})
+	if err == nil {
+		return false, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get remote machine %s: %w", key, err)
+	}
+
+	// The cached reservation may be outdated, e.g. the RemoteMachine returned the machine to the
+	// pool during its deletion and another RemoteMachine reserved it since.
+	current := &infrastructure.PooledRemoteMachine{}
+	if err := r.APIReader.Get(ctx, client.ObjectKeyFromObject(pm), current); err != nil {
+		return false, fmt.Errorf("failed to get pooled machine: %w", err)
+	}
+	if !current.Status.Reserved || current.Status.MachineRef != pm.Status.MachineRef {
+		return false, nil
+	}
+
+	log.Info("RemoteMachine holding the reservation not found, returning machine to the pool", "remotemachine", key)
+	p, err := r.sshProvisioner(ctx, log, current)
+	if err != nil {
+		return false, err
+	}
+	if err := p.Reset(ctx); err != nil {
+		return false, fmt.Errorf("failed to reset pooled machine: %w", err)
+	}
+
+	current.Status.Reserved = false
+	current.Status.MachineRef = infrastructure.RemoteMachineRef{}
+	current.Status.ReleaseState = releaseState(current)
+	// Make sure the machine is probed again before it is handed out
+	current.Status.LastProbe = nil
+	if err := r.Status().Update(ctx, current); err != nil {
+		return false, fmt.Errorf("failed to update pooled machine status: %w", err)
+	}
+
+	return true, nil
+}
+
+// releaseState returns the state the pooled machine is left in once released, according to its reuse policy.
+// An empty state returns the machine to the pool.
+func releaseState(pm *infrastructure.PooledRemoteMachine) infrastructure.PooledMachineReleaseState {
+	switch pm.Spec.ReusePolicy {
+	case infrastructure.PooledMachineReusePolicyDecommission:
+		return infrastructure.PooledMachineDecommissioned
+	case infrastructure.PooledMachineReusePolicyManualApproval:
+		return infrastructure.PooledMachineAwaitingApproval
+	default:
+		return ""
+	}
+}
+
+// probeIfDue runs the health probe if the configured interval has passed since the last probe.
+func (r *PooledRemoteMachineController) probeIfDue(ctx context.Context, log logr.Logger, pm *infrastructure.PooledRemoteMachine) (ctrl.Result, error) {
+	hc := pm.Spec.HealthCheck
+	interval := hc.Interval.Duration
+	if interval <= 0 {
+		interval = defaultPooledMachineProbeInterval
+	}
+
+	if pm.Status.LastProbe != nil {
+		if next := time.Until(pm.Status.LastProbe.Time.Add(interval)); next > 0 {
+			return ctrl.Result{RequeueAfter: next}, nil
+		}
+	}
+
+	p, err := r.sshProvisioner(ctx, log, pm)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	diskPath := hc.DiskPath
+	if diskPath == "" {
+		diskPath = "/"
+	}
+	now := metav1.Now()
+	result, err := p.Probe(ctx, diskPath)
+	if err != nil {
+		log.Info("Pooled machine health probe failed", "error", err.Error())
+		pm.Status.LastProbe = &infrastructure.PooledMachineProbeResult{Time: now}
+		conditions.MarkFalse(pm, infrastructure.PooledMachineHealthyCondition, infrastructure.PooledMachineUnreachableReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		return ctrl.Result{RequeueAfter: interval}, nil
+	}
+
+	pm.Status.LastProbe = &infrastructure.PooledMachineProbeResult{
+		Time:            now,
+		UptimeSeconds:   result.uptimeSeconds,
+		FreeDiskSpaceMB: result.freeDiskSpaceMB,
+	}
+	if msg := checkProbeResult(hc, result); msg != "" {
+		conditions.MarkFalse(pm, infrastructure.PooledMachineHealthyCondition, infrastructure.PooledMachineProbeFailedReason, clusterv1.ConditionSeverityWarning, "%s", msg)
+	} else {
+		conditions.MarkTrue(pm, infrastructure.PooledMachineHealthyCondition)
+	}
+
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// checkProbeResult returns a message describing why the probe result does not satisfy the health check,
+// or an empty string if the machine is healthy.
+func checkProbeResult(hc *infrastructure.PooledMachineHealthCheck, result probeResult) string {
+	if hc.MinFreeDiskSpaceMB > 0 && result.freeDiskSpaceMB < hc.MinFreeDiskSpaceMB {
+		return fmt.Sprintf("free disk space %dMB is below the required %dMB", result.freeDiskSpaceMB, hc.MinFreeDiskSpaceMB)
+	}
+	if hc.MinUptime.Duration > 0 && time.Duration(result.uptimeSeconds)*time.Second < hc.MinUptime.Duration {
+		return fmt.Sprintf("uptime %ds is below the required %s", result.uptimeSeconds, hc.MinUptime.Duration)
+	}
+	return ""
+}
+
+func (r *PooledRemoteMachineController) sshProvisioner(ctx context.Context, log logr.Logger, pm *infrastructure.PooledRemoteMachine) (*SSHProvisioner, error) {
+	keyName, err := pooledMachineSSHKeyName(ctx, r.Client, pm)
+	if err != nil {
+		return nil, err
+	}
+	if keyName == "" {
+		return nil, fmt.Errorf("pooled machine %s has no ssh key", pm.Name)
+	}
+	secret := &v1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: pm.Namespace, Name: keyName}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get ssh key: %w", err)
+	}
+
+	return &SSHProvisioner{
+		cloudInit:        &cloudinit.CloudInit{},
+		sshKey:           secret.Data["value"],
+		sshCertificate:   secret.Data[sshCertificateSecretKey],
+		sshKeyPassphrase: secret.Data[sshPassphraseSecretKey],
+		sshConnection:    resolveSSHConnection(nil, nil),
+		machine: &infrastructure.RemoteMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: pm.Name, Namespace: pm.Namespace},
+			Spec: infrastructure.RemoteMachineSpec{
+				Pool:                  pm.Spec.Pool,
+				Address:               pm.Spec.Machine.Address,
+				Port:                  pm.Spec.Machine.Port,
+				User:                  pm.Spec.Machine.User,
+				UseSudo:               pm.Spec.Machine.UseSudo,
+				CustomCleanUpCommands: pm.Spec.Machine.CustomCleanUpCommands,
+				SSHKeyRef:             infrastructure.SecretRef{Name: keyName},
+			},
+		},
+		log: log,
+	}, nil
+}
+
+// pooledMachineSSHKeyName returns the name of the SSH key Secret of the pooled machine, in the namespace of the machine.
+func pooledMachineSSHKeyName(ctx context.Context, c client.Reader, pm *infrastructure.PooledRemoteMachine) (string, error) {
+	if pm.Spec.Machine.SSHKeyRef.Name != "" {
+		return pm.Spec.Machine.SSHKeyRef.Name, nil
+	}
+	pc, err := k0smoutil.GetProviderConfig(ctx, c, pm.Namespace)
+	if err != nil {
+		return "", fmt.Errorf("error getting provider config: %w", err)
+	}
+	return pooledMachineKeyName(pm, pc), nil
+}
+
+// pooledMachineKeyName returns the key of the pooled machine if it sets one, the key of its pool in the given
+// ProviderConfig of its namespace otherwise. It's empty if neither is set.
+func pooledMachineKeyName(pm *infrastructure.PooledRemoteMachine, pc *kapi.ProviderConfig) string {
+	if pm.Spec.Machine.SSHKeyRef.Name != "" {
+		return pm.Spec.Machine.SSHKeyRef.Name
+	}
+	if pc == nil {
+		return ""
+	}
+	for _, pool := range pc.Spec.Pools {
+		if pool.Name == pm.Spec.Pool {
+			return pool.SSHKeyRef
+		}
+	}
+	return ""
+}
+
+// providerConfigPooledMachines enqueues the pooled machines of the namespace of the ProviderConfig, which sets the
+// keys of their pools, so that they're probed once their pool gets a key.
+func (r *PooledRemoteMachineController) providerConfigPooledMachines(ctx context.Context, o client.Object) []reconcile.Request {
+	if o.GetName() != kapi.DefaultProviderConfigName {
+		return nil
+	}
+	pooledMachines := &infrastructure.PooledRemoteMachineList{}
+	if err := r.List(ctx, pooledMachines, client.InNamespace(o.GetNamespace())); err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(pooledMachines.Items))
+	for _, pm := range pooledMachines.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&pm)})
+	}
+	return requests
+}
+
+// reservedBy enqueues the machine reserved by the given RemoteMachine, so it's reclaimed once the RemoteMachine is gone.
+// The machine may be in the namespace of a central pool, so they're looked up in all the namespaces.
+func (r *PooledRemoteMachineController) reservedBy(ctx context.Context, o client.Object) []reconcile.Request {
+	pooledMachines := &infrastructure.PooledRemoteMachineList{}
+	if err := r.List(ctx, pooledMachines, client.MatchingFields{k0smoutil.PooledMachineReservationField: client.ObjectKeyFromObject(o).String()}); err != nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(pooledMachines.Items))
+	for _, pm := range pooledMachines.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&pm)})
+	}
+	return requests
+}
+
+func (r *PooledRemoteMachineController) SetupWithManager(mgr ctrl.Manager) error {
+	poolCapacity.setReader(mgr.GetClient())
+	metrics.RegisterObjects("PooledRemoteMachine", mgr.GetClient(), func() client.ObjectList { return &infrastructure.PooledRemoteMachineList{} }, nil)
+
+	if err := health.AddChecks(mgr, "pooledremotemachine", &infrastructure.PooledRemoteMachine{}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrastructure.PooledRemoteMachine{}).
+		Watches(&infrastructure.RemoteMachine{}, handler.EnqueueRequestsFromMapFunc(r.reservedBy)).
+		Watches(&kapi.ProviderConfig{}, handler.EnqueueRequestsFromMapFunc(r.providerConfigPooledMachines)).
+		Complete(metrics.Instrument("pooledremotemachine", mgr.GetClient(), func() client.Object { return &infrastructure.PooledRemoteMachine{} }, nil, tracing.Instrument("pooledremotemachine", health.Instrument("pooledremotemachine", r))))
+}
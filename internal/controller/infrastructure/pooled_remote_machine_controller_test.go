@@ -0,0 +1,165 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	k0smoutil "github.com/k0sproject/k0smotron/internal/controller/util"
+)
+
+func TestParseProbeOutput(t *testing.T) {
+	res, err := parseProbeOutput("3725.91 14421.35\n", "/dev/sda1 40188 9036 29087 24% /\n")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3725), res.uptimeSeconds)
+	assert.Equal(t, int64(29087), res.freeDiskSpaceMB)
+
+	_, err = parseProbeOutput("", "/dev/sda1 40188 9036 29087 24% /")
+	assert.Error(t, err)
+
+	_, err = parseProbeOutput("3725.91 14421.35", "df: /foo: No such file or directory")
+	assert.Error(t, err)
+}
+
+func TestCheckProbeResult(t *testing.T) {
+	hc := &infrastructure.PooledMachineHealthCheck{
+		MinFreeDiskSpaceMB: 1024,
+		MinUptime:          metav1.Duration{Duration: 10 * time.Minute},
+	}
+
+	assert.Empty(t, checkProbeResult(hc, probeResult{uptimeSeconds: 3600, freeDiskSpaceMB: 2048}))
+	assert.Contains(t, checkProbeResult(hc, probeResult{uptimeSeconds: 3600, freeDiskSpaceMB: 512}), "free disk space")
+	assert.Contains(t, checkProbeResult(hc, probeResult{uptimeSeconds: 60, freeDiskSpaceMB: 2048}), "uptime")
+	assert.Empty(t, checkProbeResult(&infrastructure.PooledMachineHealthCheck{}, probeResult{}))
+}
+
+func TestPooledMachineState(t *testing.T) {
+	machine := func(reserved bool, releaseState infrastructure.PooledMachineReleaseState, healthy corev1.ConditionStatus) *infrastructure.PooledRemoteMachine {
+		pm := &infrastructure.PooledRemoteMachine{
+			Status: infrastructure.PooledRemoteMachineStatus{Reserved: reserved, ReleaseState: releaseState},
+		}
+		if healthy != "" {
+			pm.Status.Conditions = clusterv1.Conditions{{Type: infrastructure.PooledMachineHealthyCondition, Status: healthy}}
+		}
+		return pm
+	}
+
+	assert.Equal(t, pooledMachineStateAvailable, pooledMachineState(machine(false, "", "")))
+	assert.Equal(t, pooledMachineStateAvailable, pooledMachineState(machine(false, "", corev1.ConditionTrue)))
+	assert.Equal(t, pooledMachineStateUnhealthy, pooledMachineState(machine(false, "", corev1.ConditionFalse)))
+	assert.Equal(t, pooledMachineStateReserved, pooledMachineState(machine(true, "", corev1.ConditionFalse)))
+	assert.Equal(t, pooledMachineStateDecommissioned, pooledMachineState(machine(false, infrastructure.PooledMachineDecommissioned, "")))
+	assert.Equal(t, pooledMachineStateAwaitingApproval, pooledMachineState(machine(false, infrastructure.PooledMachineAwaitingApproval, "")))
+}
+
+func TestPoolCapacityCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrastructure.AddToScheme(scheme))
+	pm := func(name, namespace, pool string, reserved bool) *infrastructure.PooledRemoteMachine {
+		return &infrastructure.PooledRemoteMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       infrastructure.PooledRemoteMachineSpec{Pool: pool},
+			Status:     infrastructure.PooledRemoteMachineStatus{Reserved: reserved},
+		}
+	}
+	collector := &poolCapacityCollector{reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		pm("pm-0", "pool", "default", false),
+		pm("pm-1", "pool", "default", true),
+		pm("pm-2", "pool", "default", false),
+		pm("pm-3", "pool", "gpu", false),
+	).Build()}
+
+	expected := `
+# HELP k0smotron_pool_machines Number of PooledRemoteMachines per namespace, pool and state.
+# TYPE k0smotron_pool_machines gauge
+k0smotron_pool_machines{namespace="pool",pool="default",state="available"} 2
+k0smotron_pool_machines{namespace="pool",pool="default",state="reserved"} 1
+k0smotron_pool_machines{namespace="pool",pool="gpu",state="available"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+}
+
+func TestReleaseState(t *testing.T) {
+	pm := &infrastructure.PooledRemoteMachine{}
+	assert.Empty(t, releaseState(pm))
+
+	pm.Spec.ReusePolicy = infrastructure.PooledMachineReusePolicyWipeAndReturn
+	assert.Empty(t, releaseState(pm))
+
+	pm.Spec.ReusePolicy = infrastructure.PooledMachineReusePolicyDecommission
+	assert.Equal(t, infrastructure.PooledMachineDecommissioned, releaseState(pm))
+
+	pm.Spec.ReusePolicy = infrastructure.PooledMachineReusePolicyManualApproval
+	assert.Equal(t, infrastructure.PooledMachineAwaitingApproval, releaseState(pm))
+}
+
+func TestPooledMachineKeyName(t *testing.T) {
+	pc := &kapi.ProviderConfig{Spec: kapi.ProviderConfigSpec{Pools: []kapi.ProviderConfigPool{{Name: "workers", SSHKeyRef: "workers-key"}}}}
+	pm := &infrastructure.PooledRemoteMachine{Spec: infrastructure.PooledRemoteMachineSpec{Pool: "workers"}}
+
+	assert.Equal(t, "workers-key", pooledMachineKeyName(pm, pc))
+	assert.Empty(t, pooledMachineKeyName(pm, nil))
+
+	// The key of the machine overrides the key of its pool
+	pm.Spec.Machine.SSHKeyRef.Name = "pm-key"
+	assert.Equal(t, "pm-key", pooledMachineKeyName(pm, pc))
+
+	pm = &infrastructure.PooledRemoteMachine{Spec: infrastructure.PooledRemoteMachineSpec{Pool: "other"}}
+	assert.Empty(t, pooledMachineKeyName(pm, pc))
+}
+
+func TestReservedBy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrastructure.AddToScheme(scheme))
+
+	pm := func(name string, ref infrastructure.RemoteMachineRef) *infrastructure.PooledRemoteMachine {
+		return &infrastructure.PooledRemoteMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "pool"},
+			Status:     infrastructure.PooledRemoteMachineStatus{Reserved: true, MachineRef: ref},
+		}
+	}
+	r := &PooledRemoteMachineController{Client: fake.NewClientBuilder().WithScheme(scheme).
+		WithIndex(&infrastructure.PooledRemoteMachine{}, k0smoutil.PooledMachineReservationField, k0smoutil.PooledMachineReservationIndexFunc).
+		WithObjects(
+			pm("pm-a", infrastructure.RemoteMachineRef{Name: "rm", Namespace: "team-a"}),
+			pm("pm-b", infrastructure.RemoteMachineRef{Name: "rm", Namespace: "team-b"}),
+			pm("pm-c", infrastructure.RemoteMachineRef{Name: "other", Namespace: "team-a"}),
+		).Build()}
+
+	// The machine reserved from the central pool is enqueued, not the one reserved by the same name in another namespace
+	rm := &infrastructure.RemoteMachine{ObjectMeta: metav1.ObjectMeta{Name: "rm", Namespace: "team-a"}}
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "pool", Name: "pm-a"}}}, r.reservedBy(context.Background(), rm))
+
+	rm = &infrastructure.RemoteMachine{ObjectMeta: metav1.ObjectMeta{Name: "rm", Namespace: "pool"}}
+	assert.Empty(t, r.reservedBy(context.Background(), rm))
+}
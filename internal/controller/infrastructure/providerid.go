@@ -0,0 +1,77 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+const (
+	defaultProviderIDTemplate = "remote-machine://{{ .Address }}:{{ .Port }}"
+
+	// providerIDPlaceholder is replaced with the provider ID of the machine in the bootstrap commands,
+	// e.g. in --kubelet-extra-args=--provider-id=${K0S_PROVIDER_ID}.
+	providerIDPlaceholder = "${K0S_PROVIDER_ID}"
+)
+
+// providerIDData holds the fields available in the provider ID template.
+type providerIDData struct {
+	Name                string
+	Namespace           string
+	Address             string
+	Port                int
+	Labels              map[string]string
+	PooledMachineLabels map[string]string
+}
+
+// renderProviderID renders the provider ID of the machine with its provider ID template.
+func renderProviderID(rm *infrastructure.RemoteMachine, pooledMachineLabels map[string]string) (string, error) {
+	text := rm.Spec.ProviderIDTemplate
+	if text == "" {
+		text = defaultProviderIDTemplate
+	}
+
+	tmpl, err := template.New("providerID").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid provider ID template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, providerIDData{
+		Name:                rm.Name,
+		Namespace:           rm.Namespace,
+		Address:             rm.Spec.Address,
+		Port:                rm.Spec.Port,
+		Labels:              rm.Labels,
+		PooledMachineLabels: pooledMachineLabels,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render provider ID template: %w", err)
+	}
+
+	providerID := buf.String()
+	if scheme, id, ok := strings.Cut(providerID, "://"); !ok || scheme == "" || id == "" {
+		return "", fmt.Errorf("provider ID %q must have the form <scheme>://<id>", providerID)
+	}
+	return providerID, nil
+}
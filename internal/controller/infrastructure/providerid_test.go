@@ -0,0 +1,59 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+func TestRenderProviderID(t *testing.T) {
+	rm := &infrastructure.RemoteMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rm-0",
+			Namespace: "default",
+			Labels:    map[string]string{"datacenter": "fra1"},
+		},
+		Spec: infrastructure.RemoteMachineSpec{Address: "1.2.3.4", Port: 22},
+	}
+
+	providerID, err := renderProviderID(rm, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "remote-machine://1.2.3.4:22", providerID)
+
+	rm.Spec.ProviderIDTemplate = "metal://{{ .Labels.datacenter }}/{{ .PooledMachineLabels.rack }}/{{ .Name }}"
+	providerID, err = renderProviderID(rm, map[string]string{"rack": "r12"})
+	require.NoError(t, err)
+	assert.Equal(t, "metal://fra1/r12/rm-0", providerID)
+
+	// Missing labels are errors, rather than silently rendering <no value>
+	_, err = renderProviderID(rm, nil)
+	assert.Error(t, err)
+
+	rm.Spec.ProviderIDTemplate = "{{ .Name }}"
+	_, err = renderProviderID(rm, nil)
+	assert.ErrorContains(t, err, "<scheme>://<id>")
+
+	rm.Spec.ProviderIDTemplate = "metal://{{ .Name"
+	_, err = renderProviderID(rm, nil)
+	assert.ErrorContains(t, err, "invalid provider ID template")
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"fmt"
+	"strings"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+// provisionHooksDir is the directory the hook scripts are uploaded to on the machine.
+const provisionHooksDir = "/var/lib/k0smotron/hooks"
+
+// hookCloudInit returns the files and commands running the hooks of the given stage, in order.
+// Scripts are uploaded to the machine and run, commands are run as they are.
+func hookCloudInit(hooks []infrastructure.ProvisionHook, stage string) (*cloudinit.CloudInit, error) {
+	ci := &cloudinit.CloudInit{}
+	for i, hook := range hooks {
+		switch {
+		case hook.Command != "" && hook.Script != "":
+			return nil, fmt.Errorf("hook %s: only one of command and script can be set", hook.Name)
+		case hook.Command != "":
+			ci.RunCmds = append(ci.RunCmds, hook.Command)
+		case hook.Script != "":
+			if !strings.HasPrefix(hook.Script, "#!") {
+				return nil, fmt.Errorf("hook %s: script must start with a shebang line", hook.Name)
+			}
+			path := fmt.Sprintf("%s/%s-%02d-%s", provisionHooksDir, stage, i, hook.Name)
+			ci.Files = append(ci.Files, cloudinit.File{Path: path, Content: hook.Script, Permissions: "0700"})
+			ci.RunCmds = append(ci.RunCmds, path)
+		default:
+			return nil, fmt.Errorf("hook %s: one of command and script must be set", hook.Name)
+		}
+	}
+	return ci, nil
+}
+
+// provisionHooks returns the pre-bootstrap and post-bootstrap hooks of the machine.
+func provisionHooks(rm *infrastructure.RemoteMachine) (pre, post *cloudinit.CloudInit, err error) {
+	var hooks infrastructure.ProvisionHooks
+	if rm.Spec.ProvisionHooks != nil {
+		hooks = *rm.Spec.ProvisionHooks
+	}
+
+	pre, err = hookCloudInit(hooks.PreBootstrap, "pre")
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid provision hooks: %w", err)
+	}
+	post, err = hookCloudInit(hooks.PostBootstrap, "post")
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid provision hooks: %w", err)
+	}
+	return pre, post, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+func TestProvisionHooks(t *testing.T) {
+	rm := &infrastructure.RemoteMachine{
+		Spec: infrastructure.RemoteMachineSpec{
+			ProvisionHooks: &infrastructure.ProvisionHooks{
+				PreBootstrap: []infrastructure.ProvisionHook{
+					{Name: "update", Command: "apt-get update"},
+					{Name: "raid", Script: "#!/bin/sh\nmdadm --assemble --scan\n"},
+				},
+				PostBootstrap: []infrastructure.ProvisionHook{
+					{Name: "cmdb", Command: "curl -X POST https://cmdb.example.com/nodes"},
+				},
+			},
+		},
+	}
+
+	pre, post, err := provisionHooks(rm)
+	assert.NoError(t, err)
+	assert.Equal(t, &cloudinit.CloudInit{
+		Files: []cloudinit.File{
+			{Path: "/var/lib/k0smotron/hooks/pre-01-raid", Content: "#!/bin/sh\nmdadm --assemble --scan\n", Permissions: "0700"},
+		},
+		RunCmds: []string{"apt-get update", "/var/lib/k0smotron/hooks/pre-01-raid"},
+	}, pre)
+	assert.Equal(t, []string{"curl -X POST https://cmdb.example.com/nodes"}, post.RunCmds)
+	assert.Empty(t, post.Files)
+
+	pre, post, err = provisionHooks(&infrastructure.RemoteMachine{})
+	assert.NoError(t, err)
+	assert.Empty(t, pre.RunCmds)
+	assert.Empty(t, post.RunCmds)
+}
+
+func TestProvisionHooksInvalid(t *testing.T) {
+	for _, hook := range []infrastructure.ProvisionHook{
+		{Name: "empty"},
+		{Name: "both", Command: "true", Script: "#!/bin/sh\ntrue\n"},
+		{Name: "noshebang", Script: "true\n"},
+	} {
+		rm := &infrastructure.RemoteMachine{
+			Spec: infrastructure.RemoteMachineSpec{
+				ProvisionHooks: &infrastructure.ProvisionHooks{PostBootstrap: []infrastructure.ProvisionHook{hook}},
+			},
+		}
+		_, _, err := provisionHooks(rm)
+		assert.ErrorContains(t, err, "hook "+hook.Name)
+	}
+}
+
+func TestHooksResumeIndex(t *testing.T) {
+	hooks := []infrastructure.ProvisionHook{
+		{Name: "kernel-params", Command: "grubby --update-kernel=ALL --args=cgroup_no_v1=all", RebootAfter: true},
+		{Name: "update", Command: "apt-get upgrade -y", RebootAfter: true},
+		{Name: "cmdb", Command: "curl -X POST https://cmdb.example.com/nodes"},
+	}
+
+	assert.Equal(t, 0, hooksResumeIndex(hooks, nil))
+	assert.Equal(t, 1, hooksResumeIndex(hooks, []string{"kernel-params"}))
+	assert.Equal(t, 2, hooksResumeIndex(hooks, []string{"kernel-params", "update"}))
+	// Reboots after hooks of the other stage are ignored
+	assert.Equal(t, 0, hooksResumeIndex(hooks, []string{"cmdb", "other"}))
+}
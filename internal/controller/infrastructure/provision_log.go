@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+const (
+	// maxProvisionLogSteps is the maximum number of steps kept in the status of the RemoteMachine.
+	maxProvisionLogSteps = 100
+
+	exitStatusTimeout = "timeout"
+	exitStatusError   = "error"
+)
+
+// provisionLog records the commands run to provision a RemoteMachine. The commands and their output hold
+// secrets such as join tokens and certificates, so only the name of each step and its exit status are reported,
+// as an event and in the status of the machine.
+type provisionLog struct {
+	recorder      record.EventRecorder
+	remoteMachine *infrastructure.RemoteMachine
+	steps         []infrastructure.ProvisionStep
+}
+
+// commandExecuted records the exit status of a command.
+func (l *provisionLog) commandExecuted(cmd string, err error) {
+	step := infrastructure.ProvisionStep{
+		Name:       fmt.Sprintf("%d: %s", len(l.steps)+1, commandName(cmd)),
+		ExitStatus: exitStatus(err),
+		Time:       metav1.Now(),
+	}
+	l.steps = append(l.steps, step)
+
+	if l.recorder == nil {
+		return
+	}
+	if err != nil {
+		l.recorder.Eventf(l.remoteMachine, v1.EventTypeWarning, "CommandFailed", "Step %s failed with exit status %s", step.Name, step.ExitStatus)
+	} else {
+		l.recorder.Eventf(l.remoteMachine, v1.EventTypeNormal, "CommandExecuted", "Step %s succeeded", step.Name)
+	}
+}
+
+// save sets the steps of the provisioning attempt in the status of the machine, keeping the last ones if there
+// are too many.
+func (l *provisionLog) save() {
+	steps := l.steps
+	if len(steps) > maxProvisionLogSteps {
+		steps = steps[len(steps)-maxProvisionLogSteps:]
+	}
+	l.remoteMachine.Status.ProvisionLog = steps
+}
+
+// commandName returns the name of the program run by cmd, skipping the leading environment variable
+// assignments, which may hold secrets.
+func commandName(cmd string) string {
+	for _, field := range strings.Fields(cmd) {
+		if strings.Contains(field, "=") {
+			continue
+		}
+		return path.Base(field)
+	}
+	return "command"
+}
+
+// exitStatus returns the exit status of a command run over SSH. The error itself is not reported, as rig
+// includes the standard error output of the command in it.
+func exitStatus(err error) string {
+	var exitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		return "0"
+	case errors.As(err, &exitErr):
+		return strconv.Itoa(exitErr.ExitStatus())
+	case errors.Is(err, errCommandTimeout):
+		return exitStatusTimeout
+	default:
+		return exitStatusError
+	}
+}
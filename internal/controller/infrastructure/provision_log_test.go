@@ -0,0 +1,69 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+func TestProvisionLogCommandExecuted(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	rm := &infrastructure.RemoteMachine{ObjectMeta: metav1.ObjectMeta{Name: "rm-0", Namespace: "default"}}
+	l := &provisionLog{
+		recorder:      recorder,
+		remoteMachine: rm,
+	}
+
+	l.commandExecuted("/usr/local/bin/k0s install controller --token-file /etc/k0s.token", nil)
+	l.commandExecuted("K0S_TOKEN=secret-token k0s start", errors.New("process finished with error: secret-output"))
+	l.commandExecuted("sleep 3600", fmt.Errorf("%w after 1m0s: context deadline exceeded", errCommandTimeout))
+	l.save()
+
+	require.Len(t, rm.Status.ProvisionLog, 3)
+	assert.Equal(t, "1: k0s", rm.Status.ProvisionLog[0].Name)
+	assert.Equal(t, "0", rm.Status.ProvisionLog[0].ExitStatus)
+	assert.Equal(t, "2: k0s", rm.Status.ProvisionLog[1].Name)
+	assert.Equal(t, "error", rm.Status.ProvisionLog[1].ExitStatus)
+	assert.Equal(t, "3: sleep", rm.Status.ProvisionLog[2].Name)
+	assert.Equal(t, "timeout", rm.Status.ProvisionLog[2].ExitStatus)
+
+	require.Len(t, recorder.Events, 3)
+	assert.Equal(t, "Normal CommandExecuted Step 1: k0s succeeded", <-recorder.Events)
+	assert.Equal(t, "Warning CommandFailed Step 2: k0s failed with exit status error", <-recorder.Events)
+	assert.Equal(t, "Warning CommandFailed Step 3: sleep failed with exit status timeout", <-recorder.Events)
+}
+
+func TestProvisionLogKeepsLastSteps(t *testing.T) {
+	rm := &infrastructure.RemoteMachine{}
+	l := &provisionLog{remoteMachine: rm}
+	for i := 0; i < maxProvisionLogSteps+5; i++ {
+		l.commandExecuted("true", nil)
+	}
+	l.save()
+
+	require.Len(t, rm.Status.ProvisionLog, maxProvisionLogSteps)
+	assert.Equal(t, "6: true", rm.Status.ProvisionLog[0].Name)
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+// PullBootstrapServer serves the one-time bootstrap scripts of the RemoteMachines using pull bootstrap
+// over HTTPS. Requests are authenticated with the bearer token stored in the pull bootstrap secret.
+type PullBootstrapServer struct {
+	Client client.Client
+	// BindAddress is the address the server listens on.
+	BindAddress string
+	// CertDir is the directory holding the tls.crt and tls.key files of the server.
+	CertDir string
+}
+
+// Start runs the server until the context is cancelled. It implements manager.Runnable.
+func (s *PullBootstrapServer) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /bootstrap/{namespace}/{name}", s.serveScript)
+	mux.HandleFunc("POST /bootstrap/{namespace}/{name}/complete", s.complete)
+	// NoCloud datasource, cloud-init cannot send an Authorization header so the token is part of the path
+	mux.HandleFunc("GET /nocloud/{namespace}/{name}/{token}/meta-data", s.serveMetaData)
+	mux.HandleFunc("GET /nocloud/{namespace}/{name}/{token}/user-data", s.serveScript)
+	mux.HandleFunc("POST /nocloud/{namespace}/{name}/{token}/complete", s.complete)
+
+	srv := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.FromContext(ctx).Info("Starting pull bootstrap server", "address", s.BindAddress)
+	err := srv.ListenAndServeTLS(filepath.Join(s.CertDir, "tls.crt"), filepath.Join(s.CertDir, "tls.key"))
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// NeedLeaderElection returns false so that all the replicas of the manager serve the bootstrap scripts.
+func (s *PullBootstrapServer) NeedLeaderElection() bool {
+	return false
+}
+
+// serveMetaData returns the NoCloud meta-data of the machine.
+func (s *PullBootstrapServer) serveMetaData(w http.ResponseWriter, r *http.Request) {
+	_, secret, status := s.authenticate(r)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	_, _ = w.Write(secret.Data[pullBootstrapMetaDataKey])
+}
+
+// serveScript returns the bootstrap script, or the cloud-init user-data, of the machine. It can only be fetched once.
+func (s *PullBootstrapServer) serveScript(w http.ResponseWriter, r *http.Request) {
+	rm, secret, status := s.authenticate(r)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if _, ok := rm.Annotations[pullBootstrapStateAnnotation]; ok {
+		http.Error(w, "bootstrap script already fetched", http.StatusGone)
+		return
+	}
+
+	if err := s.setState(r.Context(), rm, pullBootstrapStateFetched); err != nil {
+		log.FromContext(r.Context()).Error(err, "Failed to mark the bootstrap script as fetched", "remotemachine", client.ObjectKeyFromObject(rm))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if userData, ok := secret.Data[pullBootstrapUserDataKey]; ok {
+		_, _ = w.Write(userData)
+		return
+	}
+	w.Header().Set("Content-Type", "text/x-shellscript")
+	_, _ = w.Write(secret.Data[pullBootstrapScriptKey])
+}
+
+// complete marks the bootstrap of the machine as completed.
+func (s *PullBootstrapServer) complete(w http.ResponseWriter, r *http.Request) {
+	rm, _, status := s.authenticate(r)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if rm.Annotations[pullBootstrapStateAnnotation] != pullBootstrapStateFetched {
+		http.Error(w, "bootstrap script not fetched", http.StatusConflict)
+		return
+	}
+
+	if err := s.setState(r.Context(), rm, pullBootstrapStateCompleted); err != nil {
+		log.FromContext(r.Context()).Error(err, "Failed to mark the bootstrap as completed", "remotemachine", client.ObjectKeyFromObject(rm))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authenticate returns the RemoteMachine and the pull bootstrap secret of the request if the token is valid.
+// The token is read from the path if present, from the bearer token otherwise.
+func (s *PullBootstrapServer) authenticate(r *http.Request) (*infrastructure.RemoteMachine, *v1.Secret, int) {
+	ctx := r.Context()
+	key := client.ObjectKey{Namespace: r.PathValue("namespace"), Name: r.PathValue("name")}
+
+	token := r.PathValue("token")
+	if token == "" {
+		token, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if token == "" {
+		return nil, nil, http.StatusUnauthorized
+	}
+
+	rm := &infrastructure.RemoteMachine{}
+	if err := s.Client.Get(ctx, key, rm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, http.StatusNotFound
+		}
+		return nil, nil, http.StatusInternalServerError
+	}
+	if rm.Spec.PullBootstrap == nil {
+		return nil, nil, http.StatusNotFound
+	}
+
+	secret := &v1.Secret{}
+	if err := s.Client.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: pullBootstrapSecretName(key.Name)}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, http.StatusNotFound
+		}
+		return nil, nil, http.StatusInternalServerError
+	}
+	if subtle.ConstantTimeCompare(secret.Data[pullBootstrapTokenKey], []byte(token)) != 1 {
+		return nil, nil, http.StatusUnauthorized
+	}
+	expiresAt, err := time.Parse(time.RFC3339, string(secret.Data[pullBootstrapExpiresAtKey]))
+	if err != nil || time.Now().After(expiresAt) {
+		return nil, nil, http.StatusUnauthorized
+	}
+
+	return rm, secret, http.StatusOK
+}
+
+// setState sets the pull bootstrap state annotation, failing if the RemoteMachine was changed in the meantime
+// so that a script is never handed out twice.
+func (s *PullBootstrapServer) setState(ctx context.Context, rm *infrastructure.RemoteMachine, state string) error {
+	patch := client.MergeFromWithOptions(rm.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if rm.Annotations == nil {
+		rm.Annotations = map[string]string{}
+	}
+	rm.Annotations[pullBootstrapStateAnnotation] = state
+	return s.Client.Patch(ctx, rm, patch)
+}
@@ -0,0 +1,324 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/k0sproject/rig/v2/sh"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+const (
+	// pullBootstrapStateAnnotation is set on the RemoteMachine by the pull bootstrap server
+	// when the machine fetches its bootstrap script and when it reports the bootstrap as completed.
+	pullBootstrapStateAnnotation = "remotemachine.k0smotron.io/pull-bootstrap-state"
+	pullBootstrapStateFetched    = "Fetched"
+	pullBootstrapStateCompleted  = "Completed"
+
+	pullBootstrapTokenKey     = "token"
+	pullBootstrapScriptKey    = "script"
+	pullBootstrapCommandKey   = "command"
+	pullBootstrapExpiresAtKey = "expiresAt"
+	pullBootstrapUserDataKey  = "user-data"
+	pullBootstrapMetaDataKey  = "meta-data"
+	pullBootstrapSeedURLKey   = "seedURL"
+
+	defaultPullBootstrapTokenTTL = 24 * time.Hour
+)
+
+// errBootstrapPending is returned while the machine has not pulled and completed its bootstrap yet.
+var errBootstrapPending = errors.New("waiting for the machine to pull the bootstrap script")
+
+// bootstrapPendingError wraps errBootstrapPending with the time left until the bootstrap token expires,
+// so that the RemoteMachine is reconciled again once the token expires even if the machine never calls back.
+type bootstrapPendingError struct {
+	requeueAfter time.Duration
+}
+
+func (e *bootstrapPendingError) Error() string {
+	return errBootstrapPending.Error()
+}
+
+func (e *bootstrapPendingError) Unwrap() error {
+	return errBootstrapPending
+}
+
+// PullProvisioner lets the machine fetch a one-time bootstrap script from the pull bootstrap server,
+// instead of connecting to the machine.
+type PullProvisioner struct {
+	client        client.Client
+	bootstrapData []byte
+	cloudInit     *cloudinit.CloudInit
+	remoteMachine *infrastructure.RemoteMachine
+	// serverURL is the URL the machines reach the pull bootstrap server at.
+	serverURL string
+	// providerID is the provider ID the machine gets once provisioned.
+	providerID string
+	// cloudInitDatasource serves the bootstrap data as a NoCloud datasource instead of a shell script.
+	cloudInitDatasource bool
+	reportPhase         func(phase infrastructure.RemoteMachinePhase)
+	log                 logr.Logger
+}
+
+// Provision creates the bootstrap secret for the machine and reports the progress of the bootstrap.
+// errBootstrapPending is returned until the machine reports the bootstrap as completed. If the token expires
+// before that, the bootstrap fails and the next attempt starts over with a new token.
+func (p *PullProvisioner) Provision(ctx context.Context) error {
+	switch p.remoteMachine.Annotations[pullBootstrapStateAnnotation] {
+	case pullBootstrapStateCompleted:
+		return nil
+	case pullBootstrapStateFetched:
+		p.setPhase(infrastructure.RemoteMachinePhaseRunningBootstrap)
+		secret, expiresAt, err := p.getSecret(ctx)
+		if err != nil {
+			return err
+		}
+		if secret == nil || time.Now().After(expiresAt) {
+			// The machine cannot report the completion with an expired token anymore
+			if err := p.rotateToken(ctx, secret); err != nil {
+				return err
+			}
+			return errors.New("the machine did not report the bootstrap as completed before the token expired")
+		}
+		return &bootstrapPendingError{requeueAfter: time.Until(expiresAt)}
+	}
+
+	if p.remoteMachine.Spec.Airgap != nil {
+		return errors.New("airgap artifacts can only be uploaded to machines provisioned over SSH")
+	}
+	if hooks := p.remoteMachine.Spec.ProvisionHooks; hooks != nil {
+		for _, hook := range append(hooks.PreBootstrap, hooks.PostBootstrap...) {
+			if hook.RebootAfter {
+				return fmt.Errorf("hook %s: rebooting after a hook is only supported for machines provisioned over SSH", hook.Name)
+			}
+		}
+	}
+
+	if p.serverURL == "" {
+		return errors.New("pull bootstrap server URL is not configured on the k0smotron manager")
+	}
+
+	secret, expiresAt, err := p.getSecret(ctx)
+	if err != nil {
+		return err
+	}
+	if secret != nil && time.Now().After(expiresAt) {
+		// Drop the expired token, a new one is created on the next attempt
+		if err := p.rotateToken(ctx, secret); err != nil {
+			return err
+		}
+		return errors.New("the bootstrap script was not fetched before the token expired")
+	}
+	if secret == nil {
+		if expiresAt, err = p.createSecret(ctx); err != nil {
+			return err
+		}
+		p.log.Info("Created pull bootstrap secret", "secret", pullBootstrapSecretName(p.remoteMachine.Name))
+	}
+
+	p.setPhase(infrastructure.RemoteMachinePhaseAwaitingPull)
+	return &bootstrapPendingError{requeueAfter: time.Until(expiresAt)}
+}
+
+// getSecret returns the pull bootstrap secret of the machine and the expiry of its token, or nil if there is none.
+// The expiry is zero if it cannot be parsed, so that the token is treated as expired.
+func (p *PullProvisioner) getSecret(ctx context.Context) (*v1.Secret, time.Time, error) {
+	secret := &v1.Secret{}
+	err := p.client.Get(ctx, client.ObjectKey{Namespace: p.remoteMachine.Namespace, Name: pullBootstrapSecretName(p.remoteMachine.Name)}, secret)
+	if apierrors.IsNotFound(err) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get pull bootstrap secret: %w", err)
+	}
+	expiresAt, _ := time.Parse(time.RFC3339, string(secret.Data[pullBootstrapExpiresAtKey]))
+	return secret, expiresAt, nil
+}
+
+// rotateToken deletes the pull bootstrap secret and resets the bootstrap state, so that the next attempt creates
+// a new token and the machine can fetch the bootstrap script again.
+// The state annotation is removed when the RemoteMachine is patched at the end of the reconcile.
+func (p *PullProvisioner) rotateToken(ctx context.Context, secret *v1.Secret) error {
+	if secret != nil {
+		if err := p.client.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete expired pull bootstrap secret: %w", err)
+		}
+	}
+	delete(p.remoteMachine.Annotations, pullBootstrapStateAnnotation)
+	return nil
+}
+
+// createSecret creates the pull bootstrap secret with a new token and returns the expiry of the token.
+func (p *PullProvisioner) createSecret(ctx context.Context) (time.Time, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return time.Time{}, fmt.Errorf("failed to generate bootstrap token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	ttl := p.remoteMachine.Spec.PullBootstrap.TokenTTL.Duration
+	if ttl == 0 {
+		ttl = defaultPullBootstrapTokenTTL
+	}
+
+	preHooks, postHooks, err := provisionHooks(p.remoteMachine)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// The network is configured before running the pre-bootstrap hooks
+	network, err := networkCloudInit(p.remoteMachine.Spec.Network)
+	if err != nil {
+		return time.Time{}, err
+	}
+	preHooks = &cloudinit.CloudInit{
+		Files:   append(network.Files, preHooks.Files...),
+		RunCmds: append(network.RunCmds, preHooks.RunCmds...),
+	}
+	// The platform placeholders are set by the script itself, the provider ID is known already
+	r := strings.NewReplacer(providerIDPlaceholder, p.providerID)
+	preHooks, postHooks = substitutePlaceholders(preHooks, r), substitutePlaceholders(postHooks, r)
+
+	var data map[string][]byte
+	if p.cloudInitDatasource {
+		url := nocloudURL(p.serverURL, p.remoteMachine, token)
+		bootstrapData := r.Replace(string(p.bootstrapData))
+		if usesPlatformPlaceholders(preHooks) || usesPlatformPlaceholders(p.cloudInit) || usesPlatformPlaceholders(postHooks) {
+			// The commands of all the parts are merged into a single runcmd script
+			preHooks.RunCmds = append([]string{platformDetectionScript}, preHooks.RunCmds...)
+		}
+		userData, err := nocloudUserData([]byte(bootstrapData), preHooks, postHooks, url+"complete")
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to generate cloud-init user-data: %w", err)
+		}
+		data = map[string][]byte{
+			pullBootstrapUserDataKey: userData,
+			pullBootstrapMetaDataKey: nocloudMetaData(p.remoteMachine),
+			pullBootstrapSeedURLKey:  []byte(fmt.Sprintf("ds=nocloud;s=%s", url)),
+		}
+	} else {
+		url := pullBootstrapURL(p.serverURL, p.remoteMachine)
+		script := pullBootstrapScript(preHooks, substitutePlaceholders(p.cloudInit, r), postHooks, url, token)
+		data = map[string][]byte{
+			pullBootstrapScriptKey:  script,
+			pullBootstrapCommandKey: []byte(fmt.Sprintf("curl -fsSL -H %s %s | sh", sh.Quote("Authorization: Bearer "+token), sh.Quote(url))),
+		}
+	}
+	data[pullBootstrapTokenKey] = []byte(token)
+	// The expiry is truncated to the precision of its format, so that it is the same as when read from the secret
+	expiresAt := time.Now().Add(ttl).UTC().Truncate(time.Second)
+	data[pullBootstrapExpiresAtKey] = []byte(expiresAt.Format(time.RFC3339))
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pullBootstrapSecretName(p.remoteMachine.Name),
+			Namespace: p.remoteMachine.Namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: infrastructure.GroupVersion.String(),
+				Kind:       "RemoteMachine",
+				Name:       p.remoteMachine.GetName(),
+				UID:        p.remoteMachine.GetUID(),
+			}},
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: data,
+	}
+
+	if err := p.client.Create(ctx, secret); err != nil {
+		return time.Time{}, fmt.Errorf("failed to create pull bootstrap secret: %w", err)
+	}
+	return expiresAt, nil
+}
+
+// Cleanup does nothing, as k0smotron cannot connect to the machine.
+func (p *PullProvisioner) Cleanup(_ context.Context, _ RemoteMachineMode) error {
+	return nil
+}
+
+func (p *PullProvisioner) setPhase(phase infrastructure.RemoteMachinePhase) {
+	if p.reportPhase != nil {
+		p.reportPhase(phase)
+	}
+}
+
+func pullBootstrapSecretName(remoteMachineName string) string {
+	return fmt.Sprintf("%s-pull-bootstrap", remoteMachineName)
+}
+
+func pullBootstrapURL(serverURL string, rm *infrastructure.RemoteMachine) string {
+	return fmt.Sprintf("%s/bootstrap/%s/%s", strings.TrimSuffix(serverURL, "/"), rm.Namespace, rm.Name)
+}
+
+// nocloudURL returns the NoCloud seed URL of the machine. cloud-init cannot send an Authorization header,
+// so the token is part of the URL.
+func nocloudURL(serverURL string, rm *infrastructure.RemoteMachine, token string) string {
+	return fmt.Sprintf("%s/nocloud/%s/%s/%s/", strings.TrimSuffix(serverURL, "/"), rm.Namespace, rm.Name, token)
+}
+
+// pullBootstrapScript renders the bootstrap data, preceded by the pre-bootstrap hooks and followed by the
+// post-bootstrap hooks, as a shell script. The platform placeholders are set as shell variables by the script.
+// Once the bootstrap succeeds, the script reports the completion to the pull bootstrap server.
+func pullBootstrapScript(preHooks, cloudInit, postHooks *cloudinit.CloudInit, url, token string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("#!/bin/sh\nset -e\n")
+	if usesPlatformPlaceholders(preHooks) || usesPlatformPlaceholders(cloudInit) || usesPlatformPlaceholders(postHooks) {
+		buf.WriteString(platformDetectionScript)
+	}
+
+	writeScriptSteps(&buf, preHooks)
+	writeScriptSteps(&buf, cloudInit)
+	buf.WriteString("test -f /run/cluster-api/bootstrap-success.complete\n")
+	writeScriptSteps(&buf, postHooks)
+
+	buf.WriteString(fmt.Sprintf("curl -fsSL -X POST -H %s %s\n", sh.Quote("Authorization: Bearer "+token), sh.Quote(url+"/complete")))
+
+	return buf.Bytes()
+}
+
+// writeScriptSteps writes the files and then runs the commands of ci in the script.
+func writeScriptSteps(buf *bytes.Buffer, ci *cloudinit.CloudInit) {
+	for _, file := range ci.Files {
+		perms := file.Permissions
+		if perms == "" {
+			perms = "0644"
+		}
+		buf.WriteString(fmt.Sprintf("mkdir -p %s\n", sh.Quote(filepath.Dir(file.Path))))
+		buf.WriteString(fmt.Sprintf("echo %s | base64 -d > %s\n", base64.StdEncoding.EncodeToString([]byte(file.Content)), sh.Quote(file.Path)))
+		buf.WriteString(fmt.Sprintf("chmod %s %s\n", perms, sh.Quote(file.Path)))
+	}
+
+	for _, cmd := range ci.RunCmds {
+		buf.WriteString(cmd + "\n")
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+func TestPullBootstrapScript(t *testing.T) {
+	rm := &infrastructure.RemoteMachine{ObjectMeta: metav1.ObjectMeta{Name: "rm-0", Namespace: "default"}}
+	url := pullBootstrapURL("https://k0smotron.example.com:9444/", rm)
+	assert.Equal(t, "https://k0smotron.example.com:9444/bootstrap/default/rm-0", url)
+
+	script := string(pullBootstrapScript(&cloudinit.CloudInit{
+		RunCmds: []string{"mdadm --assemble --scan"},
+	}, &cloudinit.CloudInit{
+		Files: []cloudinit.File{
+			{Path: "/etc/k0s/k0s.yaml", Content: "foo", Permissions: "0600"},
+		},
+		RunCmds: []string{"k0s install controller", "k0s start"},
+	}, &cloudinit.CloudInit{}, url, "secret-token"))
+
+	assert.Equal(t, `#!/bin/sh
+set -e
+mdadm --assemble --scan
+mkdir -p /etc/k0s
+echo Zm9v | base64 -d > /etc/k0s/k0s.yaml
+chmod 0600 /etc/k0s/k0s.yaml
+k0s install controller
+k0s start
+test -f /run/cluster-api/bootstrap-success.complete
+curl -fsSL -X POST -H 'Authorization: Bearer secret-token' https://k0smotron.example.com:9444/bootstrap/default/rm-0/complete
+`, script)
+}
+
+func TestNocloudUserData(t *testing.T) {
+	rm := &infrastructure.RemoteMachine{ObjectMeta: metav1.ObjectMeta{Name: "rm-0", Namespace: "default"}}
+	url := nocloudURL("https://k0smotron.example.com:9444", rm, "secret-token")
+	assert.Equal(t, "https://k0smotron.example.com:9444/nocloud/default/rm-0/secret-token/", url)
+
+	userData, err := nocloudUserData([]byte("## template: jinja\n#cloud-config\nruncmd:\n- k0s start\n"), &cloudinit.CloudInit{
+		Files:   []cloudinit.File{{Path: "/etc/netplan/99-k0smotron.yaml", Content: "network: {}", Permissions: "0600"}},
+		RunCmds: []string{"netplan apply"},
+	}, &cloudinit.CloudInit{}, url+"complete")
+	assert.NoError(t, err)
+	assert.Contains(t, string(userData), "Content-Type: multipart/mixed")
+	assert.Contains(t, string(userData), "Content-Type: text/jinja2")
+	assert.Contains(t, string(userData), "runcmd:\n- k0s start\n")
+	// The pre-bootstrap files and commands are merged before the ones of the bootstrap data
+	assert.Contains(t, string(userData), `#cloud-config
+merge_how: dict(no_replace,recurse_list)+list(prepend)+str()
+write_files:
+    - path: /etc/netplan/99-k0smotron.yaml
+      content: 'network: {}'
+      permissions: "0600"
+runcmd:
+    - netplan apply
+`)
+	// Empty parts are left out
+	assert.NotContains(t, string(userData), "list(append)")
+	assert.Contains(t, string(userData), `url: "https://k0smotron.example.com:9444/nocloud/default/rm-0/secret-token/complete"`)
+}
+
+func TestPullBootstrapScriptDetectsPlatform(t *testing.T) {
+	script := string(pullBootstrapScript(&cloudinit.CloudInit{}, &cloudinit.CloudInit{
+		RunCmds: []string{"curl -sSfL https://example.com/k0s-${K0S_ARCH} -o /usr/local/bin/k0s"},
+	}, &cloudinit.CloudInit{}, "https://k0smotron.example.com/bootstrap/default/rm-0", "secret-token"))
+
+	assert.Contains(t, script, "set -e\n"+platformDetectionScript+"curl -sSfL https://example.com/k0s-${K0S_ARCH}")
+}
+
+func TestPullProvisionerProvision(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	secretKey := client.ObjectKey{Namespace: "default", Name: pullBootstrapSecretName("rm-0")}
+	secret := func(expiresAt time.Time) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretKey.Name, Namespace: secretKey.Namespace},
+			Data: map[string][]byte{
+				pullBootstrapTokenKey:     []byte("token"),
+				pullBootstrapExpiresAtKey: []byte(expiresAt.UTC().Format(time.RFC3339)),
+			},
+		}
+	}
+	provisioner := func(state string, objects ...client.Object) *PullProvisioner {
+		rm := &infrastructure.RemoteMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "rm-0", Namespace: "default"},
+			Spec:       infrastructure.RemoteMachineSpec{PullBootstrap: &infrastructure.PullBootstrap{}},
+		}
+		if state != "" {
+			rm.Annotations = map[string]string{pullBootstrapStateAnnotation: state}
+		}
+		return &PullProvisioner{
+			client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			bootstrapData: []byte("#cloud-config"),
+			cloudInit:     &cloudinit.CloudInit{},
+			remoteMachine: rm,
+			serverURL:     "https://k0smotron.example.com:9444",
+			log:           logr.Discard(),
+		}
+	}
+
+	t.Run("creates the secret and waits until the token expires", func(t *testing.T) {
+		p := provisioner("")
+		err := p.Provision(context.Background())
+		var pending *bootstrapPendingError
+		require.ErrorAs(t, err, &pending)
+		assert.ErrorIs(t, err, errBootstrapPending)
+		assert.InDelta(t, defaultPullBootstrapTokenTTL, pending.requeueAfter, float64(time.Minute))
+		assert.NoError(t, p.client.Get(context.Background(), secretKey, &v1.Secret{}))
+	})
+
+	t.Run("waits for the completion until the token expires", func(t *testing.T) {
+		p := provisioner(pullBootstrapStateFetched, secret(time.Now().Add(time.Hour)))
+		err := p.Provision(context.Background())
+		var pending *bootstrapPendingError
+		require.ErrorAs(t, err, &pending)
+		assert.InDelta(t, time.Hour, pending.requeueAfter, float64(time.Minute))
+		assert.Equal(t, pullBootstrapStateFetched, p.remoteMachine.Annotations[pullBootstrapStateAnnotation])
+	})
+
+	t.Run("rotates the token when the completion is not reported in time", func(t *testing.T) {
+		p := provisioner(pullBootstrapStateFetched, secret(time.Now().Add(-time.Minute)))
+		err := p.Provision(context.Background())
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, errBootstrapPending)
+		assert.NotContains(t, p.remoteMachine.Annotations, pullBootstrapStateAnnotation)
+		assert.True(t, apierrors.IsNotFound(p.client.Get(context.Background(), secretKey, &v1.Secret{})))
+	})
+
+	t.Run("rotates the token when the script is not fetched in time", func(t *testing.T) {
+		p := provisioner("", secret(time.Now().Add(-time.Minute)))
+		err := p.Provision(context.Background())
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, errBootstrapPending)
+		assert.True(t, apierrors.IsNotFound(p.client.Get(context.Background(), secretKey, &v1.Secret{})))
+	})
+
+	t.Run("completed", func(t *testing.T) {
+		assert.NoError(t, provisioner(pullBootstrapStateCompleted).Provision(context.Background()))
+	})
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	redfishSystemsPath = "/redfish/v1/Systems"
+	redfishTimeout     = 30 * time.Second
+)
+
+// redfishClient power-cycles a machine through the Redfish API of its BMC.
+type redfishClient struct {
+	endpoint   string
+	systemID   string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func newRedfishClient(address, systemID, username, password string, insecureSkipVerify bool) (*redfishClient, error) {
+	u, err := url.Parse(address)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("invalid redfish address %q: expected https://<host>[:<port>]", address)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+	return &redfishClient{
+		endpoint: u.Scheme + "://" + u.Host,
+		systemID: systemID,
+		username: username,
+		password: password,
+		httpClient: &http.Client{
+			Transport: transport,
+			Timeout:   redfishTimeout,
+		},
+	}, nil
+}
+
+// redfishSystem is the subset of the Redfish ComputerSystem resource used by k0smotron.
+type redfishSystem struct {
+	PowerState string `json:"PowerState"`
+	Actions    struct {
+		Reset struct {
+			Target string `json:"target"`
+		} `json:"#ComputerSystem.Reset"`
+	} `json:"Actions"`
+}
+
+// PowerCycle power-cycles the machine, or powers it on if it is off.
+func (c *redfishClient) PowerCycle(ctx context.Context) error {
+	path, err := c.systemPath(ctx)
+	if err != nil {
+		return err
+	}
+	system := &redfishSystem{}
+	if err := c.do(ctx, http.MethodGet, path, nil, system); err != nil {
+		return fmt.Errorf("failed to get the redfish system: %w", err)
+	}
+
+	resetType := "ForceRestart"
+	if system.PowerState == "Off" {
+		resetType = "On"
+	}
+	target := system.Actions.Reset.Target
+	if target == "" {
+		target = path + "/Actions/ComputerSystem.Reset"
+	}
+	if err := c.do(ctx, http.MethodPost, target, map[string]string{"ResetType": resetType}, nil); err != nil {
+		return fmt.Errorf("failed to reset the redfish system: %w", err)
+	}
+	return nil
+}
+
+// SetNetworkBootOnce makes the machine boot from the network on its next boot.
+func (c *redfishClient) SetNetworkBootOnce(ctx context.Context) error {
+	path, err := c.systemPath(ctx)
+	if err != nil {
+		return err
+	}
+	boot := map[string]any{
+		"Boot": map[string]string{
+			"BootSourceOverrideEnabled": "Once",
+			"BootSourceOverrideTarget":  "Pxe",
+		},
+	}
+	if err := c.do(ctx, http.MethodPatch, path, boot, nil); err != nil {
+		return fmt.Errorf("failed to set the network boot: %w", err)
+	}
+	return nil
+}
+
+// systemPath returns the path of the system of the machine, the first system of the BMC if no ID is configured.
+func (c *redfishClient) systemPath(ctx context.Context) (string, error) {
+	if c.systemID != "" {
+		return redfishSystemsPath + "/" + c.systemID, nil
+	}
+
+	systems := &struct {
+		Members []struct {
+			ID string `json:"@odata.id"`
+		} `json:"Members"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, redfishSystemsPath, nil, systems); err != nil {
+		return "", fmt.Errorf("failed to list the redfish systems: %w", err)
+	}
+	if len(systems.Members) == 0 {
+		return "", fmt.Errorf("no redfish system found")
+	}
+	return strings.TrimSuffix(systems.Members[0].ID, "/"), nil
+}
+
+// do sends a request to the Redfish API, encoding body and decoding the response into out if they are not nil.
+func (c *redfishClient) do(ctx context.Context, method, path string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedfishClient(t *testing.T) {
+	var (
+		powerState = "On"
+		resets     []string
+		boot       map[string]map[string]string
+	)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /redfish/v1/Systems":
+			_, _ = w.Write([]byte(`{"Members":[{"@odata.id":"/redfish/v1/Systems/1"}]}`))
+		case "GET /redfish/v1/Systems/1":
+			_, _ = w.Write([]byte(`{"PowerState":"` + powerState + `","Actions":{"#ComputerSystem.Reset":{"target":"/redfish/v1/Systems/1/Actions/Reset"}}}`))
+		case "PATCH /redfish/v1/Systems/1":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&boot))
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /redfish/v1/Systems/1/Actions/Reset":
+			body := map[string]string{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			resets = append(resets, body["ResetType"])
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := newRedfishClient(srv.URL, "", "admin", "secret", true)
+	require.NoError(t, err)
+
+	require.NoError(t, c.PowerCycle(context.Background()))
+	powerState = "Off"
+	require.NoError(t, c.PowerCycle(context.Background()))
+	assert.Equal(t, []string{"ForceRestart", "On"}, resets)
+
+	require.NoError(t, c.SetNetworkBootOnce(context.Background()))
+	assert.Equal(t, map[string]string{"BootSourceOverrideEnabled": "Once", "BootSourceOverrideTarget": "Pxe"}, boot["Boot"])
+
+	c, err = newRedfishClient(srv.URL, "2", "admin", "secret", true)
+	require.NoError(t, err)
+	assert.ErrorContains(t, c.PowerCycle(context.Background()), "404")
+
+	c, err = newRedfishClient(srv.URL, "", "admin", "wrong", true)
+	require.NoError(t, err)
+	assert.ErrorContains(t, c.PowerCycle(context.Background()), "401")
+
+	_, err = newRedfishClient("bmc.example.com", "", "admin", "secret", false)
+	assert.Error(t, err)
+}
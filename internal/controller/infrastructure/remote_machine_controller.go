@@ -18,28 +18,47 @@ package infrastructure
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/k0sproject/k0smotron/internal/cloudinit"
+	"slices"
+	"time"
 
+	"golang.org/x/net/proxy"
 	"gopkg.in/yaml.v3"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 
 	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+	k0smoutil "github.com/k0sproject/k0smotron/internal/controller/util"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/util/retry"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	capiutil "sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// sshCertificateSecretKey is the key of the SSH key secret holding the optional OpenSSH user certificate.
+const sshCertificateSecretKey = "certificate"
+
+// sshPassphraseSecretKey is the key of the SSH key secret holding the passphrase of an encrypted private key.
+const sshPassphraseSecretKey = "passphrase"
+
 var ErrPooledMachineNotFound = fmt.Errorf("free pooled machine not found")
 
 type Provisioner interface {
@@ -53,6 +72,16 @@ type RemoteMachineController struct {
 	Scheme              *runtime.Scheme
 	ClientSet           *kubernetes.Clientset
 	RESTConfig          *rest.Config
+
+	// MaxConcurrentReconciles is the maximum number of RemoteMachines provisioned in parallel.
+	MaxConcurrentReconciles int
+	// PullBootstrapURL is the URL the machines using pull bootstrap reach the pull bootstrap server at.
+	PullBootstrapURL string
+	// AirgapArtifactsDir is the directory holding the artifacts uploaded to the RemoteMachines using airgap provisioning.
+	AirgapArtifactsDir string
+	// PoolNamespaces are the namespaces RemoteMachines of any namespace can claim PooledRemoteMachines from.
+	PoolNamespaces []string
+	Recorder       record.EventRecorder
 }
 
 type RemoteMachineMode int
@@ -73,7 +102,9 @@ const (
 // +kubebuilder:rbac:groups=exp.cluster.x-k8s.io,resources=machinepools;machinepools/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinepools;machinepools/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups="batch",resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=k0smotron.io,resources=providerconfigs,verbs=get;list;watch
 
 func (r *RemoteMachineController) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
 	log := log.FromContext(ctx).WithValues("remotemachine", req.NamespacedName)
@@ -118,32 +149,98 @@ func (r *RemoteMachineController) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
+	var (
+		providerID string
+		// pooledMachine is the machine reserved from the pool, if the RemoteMachine uses one
+		pooledMachine *infrastructure.PooledRemoteMachine
+	)
 	if rm.ObjectMeta.DeletionTimestamp.IsZero() {
 		defer func() {
 			// Always update the RemoteMachine status with the phase the state machine is in
+			conditions.SetSummary(rm,
+				conditions.WithConditions(
+					infrastructure.RemoteMachineProvisionedCondition,
+					infrastructure.RemoteMachineVerifiedCondition,
+				),
+			)
 			if err := rmPatchHelper.Patch(ctx, rm); err != nil {
 				log.Error(err, "Failed to update RemoteMachine status")
 			}
 		}()
 
-		if rm.Spec.Pool != "" {
-			err := r.reservePooledMachine(ctx, rm)
+		var pooledMachineLabels map[string]string
+		if usesPool(rm) {
+			if ns := poolNamespace(rm); ns != rm.Namespace && !slices.Contains(r.PoolNamespaces, ns) {
+				rm.Status.FailureReason = infrastructure.RemoteMachinePoolNamespaceNotAllowedReason
+				rm.Status.FailureMessage = fmt.Sprintf("Claiming pooled machines from namespace %s is not allowed", ns)
+				rm.Status.Ready = false
+				conditions.MarkFalse(rm, infrastructure.RemoteMachineProvisionedCondition, infrastructure.RemoteMachinePoolNamespaceNotAllowedReason, clusterv1.ConditionSeverityError, rm.Status.FailureMessage)
+				return ctrl.Result{}, nil
+			}
+			pooledMachine, err = r.reservePooledMachine(ctx, rm)
 			if err != nil {
 				log.Error(err, "Error reserving PooledMachine")
 				return ctrl.Result{Requeue: true}, err
 			}
+			pooledMachineLabels = pooledMachine.Labels
 		}
 
 		if rm.Spec.ProvisionJob == nil {
-			if rm.Spec.Address == "" || rm.Spec.SSHKeyRef.Name == "" {
-				rm.Status.FailureReason = "MissingFields"
+			var sshKeyName string
+			if rm.Spec.PullBootstrap == nil {
+				sshKeyName, err = r.sshKeyName(ctx, rm, pooledMachine)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			if rm.Spec.Address == "" || (rm.Spec.PullBootstrap == nil && sshKeyName == "") {
+				rm.Status.FailureReason = infrastructure.RemoteMachineMissingFieldsReason
 				rm.Status.FailureMessage = "If pool is empty, following fields are required: address, sshKeyRef"
 				rm.Status.Ready = false
+				conditions.MarkFalse(rm, infrastructure.RemoteMachineProvisionedCondition, infrastructure.RemoteMachineMissingFieldsReason, clusterv1.ConditionSeverityError, rm.Status.FailureMessage)
 				if err := rmPatchHelper.Patch(ctx, rm); err != nil {
 					log.Error(err, "Failed to update RemoteMachine status")
 				}
 				return ctrl.Result{Requeue: true}, nil
 			}
+			if rm.Spec.CloudInit != nil && rm.Spec.CloudInit.Method == infrastructure.CloudInitDeliveryURL && rm.Spec.PullBootstrap == nil {
+				rm.Status.FailureReason = infrastructure.RemoteMachineMissingFieldsReason
+				rm.Status.FailureMessage = "The URL cloud-init delivery method requires pullBootstrap to be set"
+				rm.Status.Ready = false
+				conditions.MarkFalse(rm, infrastructure.RemoteMachineProvisionedCondition, infrastructure.RemoteMachineMissingFieldsReason, clusterv1.ConditionSeverityError, rm.Status.FailureMessage)
+				return ctrl.Result{}, nil
+			}
+			if _, err := networkCloudInit(rm.Spec.Network); err != nil {
+				rm.Status.FailureReason = infrastructure.RemoteMachineNetworkConfigurationFailedReason
+				rm.Status.FailureMessage = err.Error()
+				rm.Status.Ready = false
+				conditions.MarkFalse(rm, infrastructure.RemoteMachineProvisionedCondition, infrastructure.RemoteMachineNetworkConfigurationFailedReason, clusterv1.ConditionSeverityError, rm.Status.FailureMessage)
+				return ctrl.Result{}, nil
+			}
+			if _, _, err := provisionHooks(rm); err != nil {
+				rm.Status.FailureReason = infrastructure.RemoteMachineHookFailedReason
+				rm.Status.FailureMessage = err.Error()
+				rm.Status.Ready = false
+				conditions.MarkFalse(rm, infrastructure.RemoteMachineProvisionedCondition, infrastructure.RemoteMachineHookFailedReason, clusterv1.ConditionSeverityError, rm.Status.FailureMessage)
+				return ctrl.Result{}, nil
+			}
+		}
+
+		if rm.Spec.Adopt != nil && (rm.Spec.PullBootstrap != nil || rm.Spec.ProvisionJob != nil) {
+			rm.Status.FailureReason = infrastructure.RemoteMachineAdoptionFailedReason
+			rm.Status.FailureMessage = "Adopting a machine requires SSH access, it cannot be combined with pullBootstrap or provisionJob"
+			rm.Status.Ready = false
+			conditions.MarkFalse(rm, infrastructure.RemoteMachineProvisionedCondition, infrastructure.RemoteMachineAdoptionFailedReason, clusterv1.ConditionSeverityError, rm.Status.FailureMessage)
+			return ctrl.Result{}, nil
+		}
+
+		providerID, err = renderProviderID(rm, pooledMachineLabels)
+		if err != nil {
+			rm.Status.FailureReason = infrastructure.RemoteMachineInvalidProviderIDReason
+			rm.Status.FailureMessage = err.Error()
+			rm.Status.Ready = false
+			conditions.MarkFalse(rm, infrastructure.RemoteMachineProvisionedCondition, infrastructure.RemoteMachineInvalidProviderIDReason, clusterv1.ConditionSeverityError, rm.Status.FailureMessage)
+			return ctrl.Result{}, nil
 		}
 
 		// Fetch the Cluster
@@ -168,6 +265,16 @@ func (r *RemoteMachineController) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 
 		if rm.Spec.ProviderID != "" {
+			if _, restored := rm.Annotations[k0smoutil.RestoredAnnotation]; restored {
+				// The status may be lost when the RemoteMachine is restored from a backup, the machine is provisioned already
+				if !rm.Status.Ready {
+					log.Info("Restoring the status of the provisioned RemoteMachine")
+					setRemoteMachinePhase(rm, infrastructure.RemoteMachinePhaseDone)
+					conditions.MarkTrue(rm, infrastructure.RemoteMachineProvisionedCondition)
+					rm.Status.Ready = true
+				}
+				delete(rm.Annotations, k0smoutil.RestoredAnnotation)
+			}
 			log.Info("RemoteMachine already has ProviderID, skipping reconciliation")
 			return ctrl.Result{}, nil
 		}
@@ -178,6 +285,19 @@ func (r *RemoteMachineController) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	if !rm.ObjectMeta.DeletionTimestamp.IsZero() && usesPool(rm) {
+		pooledMachine, err = r.reservedPooledMachine(ctx, rm)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if pooledMachine == nil {
+			// No machine was reserved, or it was returned to the pool already, there is nothing to clean up.
+			// A reservation missed by the cache is reclaimed by the pooled machine controller.
+			controllerutil.RemoveFinalizer(rm, RemoteMachineFinalizer)
+			return ctrl.Result{}, rmPatchHelper.Patch(ctx, rm)
+		}
+	}
+
 	// Fetch the bootstrap data
 	bootstrapData, err := r.getBootstrapData(ctx, machine)
 	if err != nil {
@@ -194,8 +314,25 @@ func (r *RemoteMachineController) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, fmt.Errorf("failed to parse bootstrap data: %w", err)
 	}
 
-	var p Provisioner
-	if rm.Spec.ProvisionJob != nil {
+	var (
+		p    Provisioner
+		plog *provisionLog
+	)
+	if rm.Spec.PullBootstrap != nil {
+		p = &PullProvisioner{
+			client:              r.Client,
+			bootstrapData:       bootstrapData,
+			cloudInit:           cloudInit,
+			remoteMachine:       rm,
+			serverURL:           r.PullBootstrapURL,
+			providerID:          providerID,
+			cloudInitDatasource: rm.Spec.CloudInit != nil && rm.Spec.CloudInit.Method == infrastructure.CloudInitDeliveryURL,
+			reportPhase: func(phase infrastructure.RemoteMachinePhase) {
+				setRemoteMachinePhase(rm, phase)
+			},
+			log: log,
+		}
+	} else if rm.Spec.ProvisionJob != nil {
 		p = &JobProvisioner{
 			bootstrapData: bootstrapData,
 			cloudInit:     cloudInit,
@@ -208,18 +345,99 @@ func (r *RemoteMachineController) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 	} else {
 		// Get the ssh key
-		sshKey, err := r.getSSHKey(ctx, rm)
+		sshKey, sshCertificate, sshKeyPassphrase, err := r.getSSHKey(ctx, rm, pooledMachine)
 		if err != nil {
 			log.Error(err, "Failed to get ssh key")
 			return ctrl.Result{Requeue: true}, err
 		}
 
+		rc, err := r.getRemoteCluster(ctx, machine)
+		if err != nil {
+			if rm.ObjectMeta.DeletionTimestamp.IsZero() {
+				log.Error(err, "Failed to get RemoteCluster")
+				return ctrl.Result{}, err
+			}
+			// The cluster may already be gone when the machine is deleted, the cleanup is attempted without it
+			log.Info("RemoteCluster not found, cleaning up the machine without its settings", "error", err.Error())
+			rc = nil
+		}
+
+		sshProxy, err := getSSHProxyDialer(ctx, r.Client, rc)
+		if err != nil {
+			log.Error(err, "Failed to configure SSH proxy")
+			return ctrl.Result{}, err
+		}
+		var clusterSSHConnection *infrastructure.SSHConnectionSettings
+		if rc != nil {
+			clusterSSHConnection = rc.Spec.SSHConnection
+		}
+
+		var generatedCloudInit *cloudinit.CloudInit
+		if mode == ModeController && rm.ObjectMeta.DeletionTimestamp.IsZero() && rc != nil {
+			// Push the keepalived configuration along with the bootstrap files if the cluster VIP is managed by k0smotron
+			if em := rc.Spec.EndpointManagement; em != nil && em.Mode == infrastructure.EndpointManagementModeVIP {
+				generatedCloudInit, err = keepalivedCloudInit(em)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+		}
+
+		plog = &provisionLog{
+			recorder:      r.Recorder,
+			remoteMachine: rm,
+		}
 		p = &SSHProvisioner{
-			bootstrapData: bootstrapData,
-			cloudInit:     cloudInit,
-			sshKey:        sshKey,
-			machine:       rm,
-			log:           log,
+			bootstrapData:      bootstrapData,
+			cloudInit:          cloudInit,
+			sshKey:             sshKey,
+			sshCertificate:     sshCertificate,
+			sshKeyPassphrase:   sshKeyPassphrase,
+			cloudInitSeed:      rm.Spec.CloudInit != nil,
+			generatedCloudInit: generatedCloudInit,
+			providerID:         providerID,
+			airgapDir:          r.AirgapArtifactsDir,
+			sshProxy:           sshProxy,
+			sshConnection:      resolveSSHConnection(clusterSSHConnection, rm.Spec.SSHConnection),
+			mode:               mode,
+			machine:            rm,
+			log:                log,
+			labelNode: func(nodeName string) error {
+				return r.labelAdoptedNode(ctx, machine, nodeName)
+			},
+			nodeReady: func() error {
+				return r.machineNodeReady(ctx, machine)
+			},
+			reportPhase: func(phase infrastructure.RemoteMachinePhase) {
+				setRemoteMachinePhase(rm, phase)
+				if err := rmPatchHelper.Patch(ctx, rm); err != nil {
+					log.Error(err, "Failed to update RemoteMachine provisioning phase")
+				}
+			},
+			reportProgress: func(completed, total int) {
+				rm.Status.Progress.CompletedSteps = int32(completed)
+				rm.Status.Progress.TotalSteps = int32(total)
+				if err := rmPatchHelper.Patch(ctx, rm); err != nil {
+					log.Error(err, "Failed to update RemoteMachine provisioning progress")
+				}
+			},
+			reportCommand: plog.commandExecuted,
+			reportAddress: func(address string) {
+				rm.Status.Address = address
+				if err := rmPatchHelper.Patch(ctx, rm); err != nil {
+					log.Error(err, "Failed to update RemoteMachine address")
+				}
+			},
+			reportReboot: func(hook string) {
+				rm.Status.RebootedAfterHooks = append(rm.Status.RebootedAfterHooks, hook)
+				if err := rmPatchHelper.Patch(ctx, rm); err != nil {
+					log.Error(err, "Failed to update RemoteMachine reboots")
+				}
+			},
+			reportPlatform: func(osName, arch string) {
+				rm.Status.OperatingSystem = osName
+				rm.Status.Architecture = arch
+			},
 		}
 	}
 
@@ -228,7 +446,23 @@ func (r *RemoteMachineController) Reconcile(ctx context.Context, req ctrl.Reques
 			if err := p.Cleanup(ctx, mode); err != nil {
 				log.Error(err, "Failed to cleanup RemoteMachine")
 			}
-			if rm.Spec.Pool != "" {
+			if needsBMCRemediation(rm, machine) {
+				// The machine may be hung, the cleanup above is best effort and the BMC gets it back to a known state
+				if err := r.remediateWithBMC(ctx, rm); err != nil {
+					log.Error(err, "Failed to remediate RemoteMachine through its BMC")
+					r.Recorder.Eventf(rm, v1.EventTypeWarning, "RemediationFailed", "Failed to remediate the machine through its BMC: %s", err)
+					conditions.MarkFalse(rm, infrastructure.RemoteMachineRemediatedCondition, infrastructure.RemoteMachineRemediationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err)
+				} else {
+					log.Info("Remediated RemoteMachine through its BMC", "action", rm.Spec.BMC.RemediationAction)
+					r.Recorder.Eventf(rm, v1.EventTypeNormal, "Remediated", "Remediated the machine through its BMC: %s", rm.Spec.BMC.RemediationAction)
+					conditions.MarkTrue(rm, infrastructure.RemoteMachineRemediatedCondition)
+				}
+				// Persist the outcome before anything below can fail, a retried deletion must not power-cycle the machine again
+				if err := rmPatchHelper.Patch(ctx, rm); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			if usesPool(rm) {
 				// Return the machine back to pool
 				if err := r.returnMachineToPool(ctx, rm); err != nil {
 					return ctrl.Result{}, err
@@ -250,13 +484,20 @@ func (r *RemoteMachineController) Reconcile(ctx context.Context, req ctrl.Reques
 		controllerutil.AddFinalizer(rm, RemoteMachineFinalizer)
 	}
 
+	if rm.Status.NextRetryTime != nil {
+		if wait := time.Until(rm.Status.NextRetryTime.Time); wait > 0 {
+			log.Info("Waiting before retrying provisioning", "retryCount", rm.Status.RetryCount, "after", wait)
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+	}
+
 	defer func() {
 		log.Info("Reconcile complete")
 		if err != nil {
 			rm.Status.FailureReason = "ProvisionFailed"
 			rm.Status.FailureMessage = err.Error()
 			rm.Status.Ready = false
-		} else {
+		} else if rm.Status.Phase == infrastructure.RemoteMachinePhaseDone {
 			rm.Status.FailureReason = ""
 			rm.Status.FailureMessage = ""
 			rm.Status.Ready = true
@@ -267,23 +508,120 @@ func (r *RemoteMachineController) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}()
 
-	err = p.Provision(ctx)
-	if err != nil {
-		log.Error(err, "Failed to provision RemoteMachine")
-		return ctrl.Result{}, err
+	// A provisioned machine is verified until k0s is up, without being provisioned again
+	if v, ok := p.(verifier); ok && rm.Status.Phase == infrastructure.RemoteMachinePhaseVerifying &&
+		conditions.IsTrue(rm, infrastructure.RemoteMachineProvisionedCondition) {
+		return r.verifyProvisioned(ctx, rm, machine, v, providerID)
+	}
+
+	startProvisioningProgress(rm)
+	if rm.Spec.ProvisionJob != nil {
+		// The job provisioner runs the whole bootstrap in a single job
+		setRemoteMachinePhase(rm, infrastructure.RemoteMachinePhaseRunningBootstrap)
 	}
+	conditions.MarkFalse(rm, infrastructure.RemoteMachineProvisionedCondition, infrastructure.RemoteMachineProvisioningReason, clusterv1.ConditionSeverityInfo, "")
 
-	rm.Spec.ProviderID = fmt.Sprintf("remote-machine://%s:%d", rm.Spec.Address, rm.Spec.Port)
+	r.Recorder.Eventf(rm, v1.EventTypeNormal, "ProvisioningStarted", "Provisioning attempt %d started", rm.Status.RetryCount+1)
+	provisionErr := p.Provision(ctx)
+	if plog != nil {
+		plog.save()
+	}
+	if provisionErr != nil {
+		if errors.Is(provisionErr, errBootstrapPending) {
+			// The pull bootstrap server annotates the RemoteMachine as the bootstrap goes on, which triggers a new reconcile.
+			// The machine is reconciled again when the token expires in case it never calls back.
+			log.Info("Waiting for the machine to pull and run the bootstrap script", "phase", rm.Status.Phase)
+			var pending *bootstrapPendingError
+			if errors.As(provisionErr, &pending) {
+				return ctrl.Result{RequeueAfter: pending.requeueAfter}, nil
+			}
+			return ctrl.Result{}, nil
+		}
+		log.Error(provisionErr, "Failed to provision RemoteMachine")
+		delay := provisionBackoff(rm.Status.RetryCount)
+		rm.Status.RetryCount++
+		rm.Status.NextRetryTime = &metav1.Time{Time: time.Now().Add(delay)}
+		reason, severity := failedPhaseReason(rm.Status.Phase), clusterv1.ConditionSeverityWarning
+		if errors.Is(provisionErr, errUnsupportedPlatform) {
+			reason, severity = infrastructure.RemoteMachineUnsupportedPlatformReason, clusterv1.ConditionSeverityError
+		}
+		conditions.MarkFalse(rm, infrastructure.RemoteMachineProvisionedCondition, reason, severity,
+			"Attempt %d failed: %s", rm.Status.RetryCount, provisionErr.Error())
+		r.Recorder.Eventf(rm, v1.EventTypeWarning, "ProvisioningFailed", "Attempt %d failed in phase %s, retrying in %s: %s",
+			rm.Status.RetryCount, rm.Status.Phase, delay, provisionErr)
+		rm.Status.FailureReason = "ProvisionFailed"
+		rm.Status.FailureMessage = provisionErr.Error()
+		rm.Status.Ready = false
+		// The error is surfaced in the status, the retry is driven by the backoff instead of the controller rate limiter
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	completed := metav1.Now()
+	rm.Status.Progress.CompletionTime = &completed
+	rm.Status.RetryCount = 0
+	rm.Status.NextRetryTime = nil
+	conditions.MarkTrue(rm, infrastructure.RemoteMachineProvisionedCondition)
+
+	if _, ok := p.(verifier); ok && mode != ModeNonK0s {
+		// k0s is just starting, the machine is verified in the next reconciles
+		setRemoteMachinePhase(rm, infrastructure.RemoteMachinePhaseVerifying)
+		conditions.MarkFalse(rm, infrastructure.RemoteMachineVerifiedCondition, infrastructure.RemoteMachineVerificationPendingReason, clusterv1.ConditionSeverityInfo, "")
+		return ctrl.Result{RequeueAfter: verificationInterval}, nil
+	}
+
+	return r.completeProvisioning(ctx, rm, machine, providerID)
+}
+
+// startProvisioningProgress resets the progress of the machine, as each provisioning attempt starts over from the
+// first step. A pull bootstrap spans several reconciles, so its progress is only started in the first one.
+func startProvisioningProgress(rm *infrastructure.RemoteMachine) {
+	if rm.Spec.PullBootstrap == nil || rm.Status.Progress == nil {
+		now := metav1.Now()
+		rm.Status.Progress = &infrastructure.ProvisioningProgress{StartTime: &now}
+	}
+}
+
+// verifyProvisioned verifies k0s runs on the provisioned machine. The machine is completed once verified,
+// otherwise the verification is retried.
+func (r *RemoteMachineController) verifyProvisioned(ctx context.Context, rm *infrastructure.RemoteMachine, machine *clusterv1.Machine, v verifier, providerID string) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithValues("remotemachine", rm.Name)
+
+	if err := v.Verify(ctx); err != nil {
+		reason := infrastructure.RemoteMachineConnectionFailedReason
+		switch {
+		case errors.Is(err, errK0sNotRunning):
+			reason = infrastructure.RemoteMachineK0sNotRunningReason
+		case errors.Is(err, errNodeNotReady):
+			reason = infrastructure.RemoteMachineNodeNotReadyReason
+		}
+		log.Info("RemoteMachine not verified yet", "reason", err.Error())
+		conditions.MarkFalse(rm, infrastructure.RemoteMachineVerifiedCondition, reason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		return ctrl.Result{RequeueAfter: verificationInterval}, nil
+	}
+
+	conditions.MarkTrue(rm, infrastructure.RemoteMachineVerifiedCondition)
+	return r.completeProvisioning(ctx, rm, machine, providerID)
+}
+
+// completeProvisioning marks the machine as done and reports its provider ID and address to the Machine.
+func (r *RemoteMachineController) completeProvisioning(ctx context.Context, rm *infrastructure.RemoteMachine, machine *clusterv1.Machine, providerID string) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithValues("remotemachine", rm.Name)
+
+	if rm.Status.Phase != infrastructure.RemoteMachinePhaseDone {
+		r.Recorder.Eventf(rm, v1.EventTypeNormal, "Provisioned", "Machine provisioned with provider ID %s", providerID)
+	}
+	setRemoteMachinePhase(rm, infrastructure.RemoteMachinePhaseDone)
+	rm.Spec.ProviderID = providerID
 
 	m := machine.DeepCopy()
 	m.Status.Addresses = []clusterv1.MachineAddress{
 		{
 			Type:    clusterv1.MachineExternalIP,
-			Address: rm.Spec.Address,
+			Address: machineAddress(rm),
 		},
 	}
 
-	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		return r.Status().Patch(ctx, m, client.MergeFrom(machine))
 	})
 	if err != nil {
@@ -294,31 +632,114 @@ func (r *RemoteMachineController) Reconcile(ctx context.Context, req ctrl.Reques
 	return ctrl.Result{}, nil
 }
 
-func (r *RemoteMachineController) reservePooledMachine(ctx context.Context, rm *infrastructure.RemoteMachine) error {
+const (
+	provisionBackoffBase = 10 * time.Second
+	provisionBackoffMax  = 10 * time.Minute
+)
+
+// provisionBackoff returns the delay before the next provisioning attempt, doubling with each failed attempt.
+func provisionBackoff(retryCount int32) time.Duration {
+	delay := provisionBackoffBase
+	for i := int32(0); i < retryCount && delay < provisionBackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > provisionBackoffMax {
+		delay = provisionBackoffMax
+	}
+	return delay
+}
+
+// setRemoteMachinePhase sets the provisioning phase of the machine and counts the phase change in the metrics.
+func setRemoteMachinePhase(rm *infrastructure.RemoteMachine, phase infrastructure.RemoteMachinePhase) {
+	if rm.Status.Phase == phase {
+		return
+	}
+	rm.Status.Phase = phase
+	metrics.ObserveProvisioningPhase("RemoteMachine", rm.Labels[clusterv1.ClusterNameLabel], string(phase))
+}
+
+// failedPhaseReason returns the Provisioned condition reason for a provisioning that failed in the given phase.
+func failedPhaseReason(phase infrastructure.RemoteMachinePhase) string {
+	switch phase {
+	case infrastructure.RemoteMachinePhaseConnecting:
+		return infrastructure.RemoteMachineConnectionFailedReason
+	case infrastructure.RemoteMachinePhaseUploading:
+		return infrastructure.RemoteMachineUploadFailedReason
+	case infrastructure.RemoteMachinePhaseRebooting:
+		return infrastructure.RemoteMachineRebootFailedReason
+	case infrastructure.RemoteMachinePhaseConfiguringNetwork:
+		return infrastructure.RemoteMachineNetworkConfigurationFailedReason
+	case infrastructure.RemoteMachinePhaseRunningPreBootstrapHooks, infrastructure.RemoteMachinePhaseRunningPostBootstrapHooks:
+		return infrastructure.RemoteMachineHookFailedReason
+	case infrastructure.RemoteMachinePhaseVerifying:
+		return infrastructure.RemoteMachineAdoptionFailedReason
+	default:
+		return infrastructure.RemoteMachineBootstrapFailedReason
+	}
+}
+
+// usesPool returns true if the machine is claimed from the PooledRemoteMachines.
+func usesPool(rm *infrastructure.RemoteMachine) bool {
+	return rm.Spec.Pool != "" || rm.Spec.PoolSelector != nil
+}
+
+// poolNamespace returns the namespace the machine claims pooled machines from.
+func poolNamespace(rm *infrastructure.RemoteMachine) string {
+	if rm.Spec.PoolNamespace != "" {
+		return rm.Spec.PoolNamespace
+	}
+	return rm.Namespace
+}
+
+// pooledMachineMatches returns true if the pooled machine belongs to the pool, if any, and matches the selector, if any.
+func pooledMachineMatches(pm *infrastructure.PooledRemoteMachine, pool string, selector labels.Selector) bool {
+	if pool != "" && pm.Spec.Pool != pool {
+		return false
+	}
+	return selector == nil || selector.Matches(labels.Set(pm.Labels))
+}
+
+func (r *RemoteMachineController) reservePooledMachine(ctx context.Context, rm *infrastructure.RemoteMachine) (*infrastructure.PooledRemoteMachine, error) {
 	pooledMachineList := &infrastructure.PooledRemoteMachineList{}
-	if err := r.Client.List(ctx, pooledMachineList, client.InNamespace(rm.Namespace)); err != nil {
-		return fmt.Errorf("failed to list pooled machines: %w", err)
+	if err := r.Client.List(ctx, pooledMachineList, client.InNamespace(poolNamespace(rm))); err != nil {
+		return nil, fmt.Errorf("failed to list pooled machines: %w", err)
+	}
+
+	// The pool keys are set in the ProviderConfig of the namespace of the pool
+	pc, err := k0smoutil.GetProviderConfig(ctx, r.Client, poolNamespace(rm))
+	if err != nil {
+		return nil, fmt.Errorf("error getting provider config: %w", err)
 	}
+	needsSSHKey := rm.Spec.PullBootstrap == nil && rm.Spec.ProvisionJob == nil
 
 	var (
 		firstFreePooledMachine *infrastructure.PooledRemoteMachine
 		foundPooledMachine     *infrastructure.PooledRemoteMachine
 	)
+	var selector labels.Selector
+	if rm.Spec.PoolSelector != nil {
+		selector, err = metav1.LabelSelectorAsSelector(rm.Spec.PoolSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool selector: %w", err)
+		}
+	}
+
 	for _, pm := range pooledMachineList.Items {
-		if pm.Spec.Pool == rm.Spec.Pool {
-			if pm.Status.Reserved && pm.Status.MachineRef.Name == rm.GetName() {
-				foundPooledMachine = &pm
-				break
-			}
+		if pm.Status.Reserved && pm.Status.MachineRef.Name == rm.GetName() && pm.Status.MachineRef.Namespace == rm.GetNamespace() {
+			foundPooledMachine = &pm
+			break
+		}
 
-			if !pm.Status.Reserved {
-				firstFreePooledMachine = &pm
-			}
+		// Skip the machines kept out of the pool by their reuse policy, the machines which failed the last health probe,
+		// and the machines without a key when the RemoteMachine is provisioned over SSH
+		if !pm.Status.Reserved && pm.Status.ReleaseState == "" && pooledMachineMatches(&pm, rm.Spec.Pool, selector) && !conditions.IsFalse(&pm, infrastructure.PooledMachineHealthyCondition) &&
+			(!needsSSHKey || pooledMachineKeyName(&pm, pc) != "") {
+			firstFreePooledMachine = &pm
 		}
 	}
 
 	if foundPooledMachine == nil && firstFreePooledMachine == nil {
-		return ErrPooledMachineNotFound
+		return nil, ErrPooledMachineNotFound
 	}
 
 	if foundPooledMachine == nil && firstFreePooledMachine != nil {
@@ -331,33 +752,36 @@ func (r *RemoteMachineController) reservePooledMachine(ctx context.Context, rm *
 
 		err := r.Status().Update(ctx, foundPooledMachine)
 		if err != nil {
-			return fmt.Errorf("failed to update pooled machine status: %w", err)
+			return nil, fmt.Errorf("failed to update pooled machine status: %w", err)
 		}
 	}
 
 	rm.Spec.Address = foundPooledMachine.Spec.Machine.Address
 	rm.Spec.Port = foundPooledMachine.Spec.Machine.Port
 	rm.Spec.User = foundPooledMachine.Spec.Machine.User
-	rm.Spec.SSHKeyRef = foundPooledMachine.Spec.Machine.SSHKeyRef
 	rm.Spec.UseSudo = foundPooledMachine.Spec.Machine.UseSudo
 	rm.Spec.CustomCleanUpCommands = foundPooledMachine.Spec.Machine.CustomCleanUpCommands
+	if foundPooledMachine.Spec.Machine.Network != nil {
+		rm.Spec.Network = foundPooledMachine.Spec.Machine.Network
+	}
+	// The BMC belongs to the pooled machine, whatever the RemoteMachine sets
+	rm.Spec.BMC = foundPooledMachine.Spec.Machine.BMC
 
-	return nil
+	return foundPooledMachine, nil
 }
 
 func (r *RemoteMachineController) returnMachineToPool(ctx context.Context, rm *infrastructure.RemoteMachine) error {
-	if rm.Spec.Pool == "" {
+	if !usesPool(rm) {
 		return nil
 	}
 
-	pool := rm.Spec.Pool
 	pooledMachines := &infrastructure.PooledRemoteMachineList{}
 	err := r.List(ctx, pooledMachines, &client.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to list pooled machines: %w", err)
 	}
 	if len(pooledMachines.Items) == 0 {
-		return fmt.Errorf("no pooled machines found for pool %s", pool)
+		return fmt.Errorf("no pooled machines found for pool %s", rm.Spec.Pool)
 	}
 
 	for _, pooledMachine := range pooledMachines.Items {
@@ -367,6 +791,7 @@ func (r *RemoteMachineController) returnMachineToPool(ctx context.Context, rm *i
 
 			pooledMachine.Status.Reserved = false
 			pooledMachine.Status.MachineRef = infrastructure.RemoteMachineRef{}
+			pooledMachine.Status.ReleaseState = releaseState(&pooledMachine)
 			if err := r.Status().Update(ctx, &pooledMachine); err != nil {
 				return fmt.Errorf("failed to update pooled machine: %w", err)
 			}
@@ -379,19 +804,115 @@ func (r *RemoteMachineController) returnMachineToPool(ctx context.Context, rm *i
 	return nil
 }
 
-func (r *RemoteMachineController) getSSHKey(ctx context.Context, rm *infrastructure.RemoteMachine) ([]byte, error) {
+// sshKeyName returns the name of the SSH key Secret of the machine. The pooled machines use the key of the pooled
+// machine they reserved, the other machines which set no key use the key of the ProviderConfig of their namespace.
+// It is resolved on each reconciliation, and never written to the spec, so that the changes of the ProviderConfig
+// reach the existing machines.
+func (r *RemoteMachineController) sshKeyName(ctx context.Context, rm *infrastructure.RemoteMachine, pm *infrastructure.PooledRemoteMachine) (string, error) {
+	if usesPool(rm) {
+		if pm == nil {
+			return "", nil
+		}
+		return pooledMachineSSHKeyName(ctx, r.Client, pm)
+	}
+	if rm.Spec.SSHKeyRef.Name != "" {
+		return rm.Spec.SSHKeyRef.Name, nil
+	}
+	pc, err := k0smoutil.GetProviderConfig(ctx, r.Client, rm.Namespace)
+	if err != nil {
+		return "", fmt.Errorf("error getting provider config: %w", err)
+	}
+	if pc == nil || pc.Spec.SSH == nil {
+		return "", nil
+	}
+	return pc.Spec.SSH.KeyRef, nil
+}
+
+// getSSHKey returns the SSH private key, the optional user certificate and the optional key passphrase of the machine.
+// The key of a pooled machine is in the namespace of the pool.
+func (r *RemoteMachineController) getSSHKey(ctx context.Context, rm *infrastructure.RemoteMachine, pm *infrastructure.PooledRemoteMachine) ([]byte, []byte, []byte, error) {
+	namespace := rm.Namespace
+	if usesPool(rm) {
+		namespace = poolNamespace(rm)
+	}
+	name, err := r.sshKeyName(ctx, rm, pm)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if name == "" {
+		return nil, nil, nil, fmt.Errorf("no ssh key set for remote machine %s", rm.Name)
+	}
 	secret := &v1.Secret{}
 	key := client.ObjectKey{
-		Namespace: rm.Namespace,
-		Name:      rm.Spec.SSHKeyRef.Name,
+		Namespace: namespace,
+		Name:      name,
 	}
 
 	if err := r.Client.Get(ctx, key, secret); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return secret.Data["value"], secret.Data[sshCertificateSecretKey], secret.Data[sshPassphraseSecretKey], nil
+}
+
+// reservedPooledMachine returns the pooled machine reserved by the RemoteMachine, or nil if there is none.
+func (r *RemoteMachineController) reservedPooledMachine(ctx context.Context, rm *infrastructure.RemoteMachine) (*infrastructure.PooledRemoteMachine, error) {
+	pooledMachines := &infrastructure.PooledRemoteMachineList{}
+	if err := r.List(ctx, pooledMachines, client.InNamespace(poolNamespace(rm)),
+		client.MatchingFields{k0smoutil.PooledMachineReservationField: client.ObjectKeyFromObject(rm).String()}); err != nil {
+		return nil, fmt.Errorf("failed to list pooled machines: %w", err)
+	}
+	if len(pooledMachines.Items) == 0 {
+		return nil, nil
+	}
+	return &pooledMachines.Items[0], nil
+}
+
+// getRemoteCluster returns the RemoteCluster the machine belongs to, or nil if the cluster uses another infrastructure.
+func (r *RemoteMachineController) getRemoteCluster(ctx context.Context, machine *clusterv1.Machine) (*infrastructure.RemoteCluster, error) {
+	cluster, err := capiutil.GetClusterFromMetadata(ctx, r.Client, machine.ObjectMeta)
+	if err != nil {
 		return nil, err
 	}
+	if cluster.Spec.InfrastructureRef == nil || cluster.Spec.InfrastructureRef.Kind != "RemoteCluster" {
+		return nil, nil
+	}
 
-	return secret.Data["value"], nil
+	rc := &infrastructure.RemoteCluster{}
+	key := client.ObjectKey{
+		Namespace: cluster.Namespace,
+		Name:      cluster.Spec.InfrastructureRef.Name,
+	}
+	if err := r.Get(ctx, key, rc); err != nil {
+		return nil, err
+	}
+
+	return rc, nil
+}
+
+// getSSHProxyDialer returns the dialer for the SSH proxy of the RemoteCluster, or nil if no proxy is set.
+func getSSHProxyDialer(ctx context.Context, c client.Client, rc *infrastructure.RemoteCluster) (proxy.ContextDialer, error) {
+	if rc == nil || rc.Spec.SSHProxy == nil {
+		return nil, nil
+	}
 
+	var creds *proxyCredentials
+	if rc.Spec.SSHProxy.CredentialsRef != nil {
+		secret := &v1.Secret{}
+		key := client.ObjectKey{
+			Namespace: rc.Namespace,
+			Name:      rc.Spec.SSHProxy.CredentialsRef.Name,
+		}
+		if err := c.Get(ctx, key, secret); err != nil {
+			return nil, fmt.Errorf("failed to get ssh proxy credentials: %w", err)
+		}
+		creds = &proxyCredentials{
+			username: string(secret.Data["username"]),
+			password: string(secret.Data["password"]),
+		}
+	}
+
+	return proxyDialer(rc.Spec.SSHProxy.URL, creds)
 }
 
 func (r *RemoteMachineController) getBootstrapData(ctx context.Context, machine *clusterv1.Machine) ([]byte, error) {
@@ -412,7 +933,14 @@ func (r *RemoteMachineController) getBootstrapData(ctx context.Context, machine
 }
 
 func (r *RemoteMachineController) SetupWithManager(mgr ctrl.Manager) error {
+	metrics.RegisterObjects("RemoteMachine", mgr.GetClient(), func() client.ObjectList { return &infrastructure.RemoteMachineList{} }, nil)
+
+	if err := health.AddChecks(mgr, "remotemachine", &infrastructure.RemoteMachine{}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&infrastructure.RemoteMachine{}).
-		Complete(r)
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		Complete(metrics.Instrument("remotemachine", mgr.GetClient(), func() client.Object { return &infrastructure.RemoteMachine{} }, nil, tracing.Instrument("remotemachine", health.Instrument("remotemachine", r))))
 }
@@ -0,0 +1,216 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	k0smoutil "github.com/k0sproject/k0smotron/internal/controller/util"
+)
+
+func TestProvisionBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, provisionBackoff(0))
+	assert.Equal(t, 20*time.Second, provisionBackoff(1))
+	assert.Equal(t, 80*time.Second, provisionBackoff(3))
+	assert.Equal(t, 10*time.Minute, provisionBackoff(10))
+	assert.Equal(t, 10*time.Minute, provisionBackoff(1000))
+}
+
+func TestFailedPhaseReason(t *testing.T) {
+	assert.Equal(t, infrastructure.RemoteMachineConnectionFailedReason, failedPhaseReason(infrastructure.RemoteMachinePhaseConnecting))
+	assert.Equal(t, infrastructure.RemoteMachineUploadFailedReason, failedPhaseReason(infrastructure.RemoteMachinePhaseUploading))
+	assert.Equal(t, infrastructure.RemoteMachineBootstrapFailedReason, failedPhaseReason(infrastructure.RemoteMachinePhaseRunningBootstrap))
+}
+
+func TestPooledMachineMatches(t *testing.T) {
+	pm := &infrastructure.PooledRemoteMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"rack": "r1", "arch": "arm64"},
+		},
+		Spec: infrastructure.PooledRemoteMachineSpec{Pool: "default"},
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchLabels: map[string]string{"rack": "r1"}})
+	assert.NoError(t, err)
+	otherSelector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "arch", Operator: metav1.LabelSelectorOpIn, Values: []string{"amd64"}}},
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, pooledMachineMatches(pm, "default", nil))
+	assert.False(t, pooledMachineMatches(pm, "other", nil))
+	assert.True(t, pooledMachineMatches(pm, "", selector))
+	assert.True(t, pooledMachineMatches(pm, "default", selector))
+	assert.False(t, pooledMachineMatches(pm, "other", selector))
+	assert.False(t, pooledMachineMatches(pm, "", otherSelector))
+}
+
+func TestUsesPool(t *testing.T) {
+	assert.False(t, usesPool(&infrastructure.RemoteMachine{}))
+	assert.True(t, usesPool(&infrastructure.RemoteMachine{Spec: infrastructure.RemoteMachineSpec{Pool: "default"}}))
+	assert.True(t, usesPool(&infrastructure.RemoteMachine{Spec: infrastructure.RemoteMachineSpec{PoolSelector: &metav1.LabelSelector{}}}))
+}
+
+func TestPoolNamespace(t *testing.T) {
+	rm := &infrastructure.RemoteMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "tenant-a"}, Spec: infrastructure.RemoteMachineSpec{Pool: "default"}}
+	assert.Equal(t, "tenant-a", poolNamespace(rm))
+
+	rm.Spec.PoolNamespace = "hardware"
+	assert.Equal(t, "hardware", poolNamespace(rm))
+}
+
+func TestStartProvisioningProgress(t *testing.T) {
+	rm := &infrastructure.RemoteMachine{}
+	startProvisioningProgress(rm)
+	require.NotNil(t, rm.Status.Progress)
+	require.NotNil(t, rm.Status.Progress.StartTime)
+
+	// Each SSH provisioning attempt starts over from the first step
+	rm.Status.Progress.CompletedSteps = 3
+	rm.Status.Progress.TotalSteps = 5
+	startProvisioningProgress(rm)
+	assert.Zero(t, rm.Status.Progress.CompletedSteps)
+	assert.Zero(t, rm.Status.Progress.TotalSteps)
+
+	// A pull bootstrap keeps its progress across the reconciles
+	rm.Spec.PullBootstrap = &infrastructure.PullBootstrap{}
+	rm.Status.Progress.CompletedSteps = 3
+	startTime := rm.Status.Progress.StartTime
+	startProvisioningProgress(rm)
+	assert.Equal(t, int32(3), rm.Status.Progress.CompletedSteps)
+	assert.Same(t, startTime, rm.Status.Progress.StartTime)
+
+	rm.Status.Progress = nil
+	startProvisioningProgress(rm)
+	require.NotNil(t, rm.Status.Progress)
+}
+
+func TestSSHKeyName(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kapi.AddToScheme(scheme))
+	pc := &kapi.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: kapi.DefaultProviderConfigName, Namespace: "team-a"},
+		Spec: kapi.ProviderConfigSpec{
+			SSH:   &kapi.ProviderConfigSSH{KeyRef: "team-a-key"},
+			Pools: []kapi.ProviderConfigPool{{Name: "workers", SSHKeyRef: "workers-key"}},
+		},
+	}
+	r := &RemoteMachineController{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(pc).Build()}
+	rm := func(namespace, key string) *infrastructure.RemoteMachine {
+		return &infrastructure.RemoteMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "rm", Namespace: namespace},
+			Spec:       infrastructure.RemoteMachineSpec{SSHKeyRef: infrastructure.SecretRef{Name: key}},
+		}
+	}
+
+	name, err := r.sshKeyName(context.Background(), rm("team-a", "own-key"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "own-key", name)
+
+	// The key of the ProviderConfig is used, but not written to the spec
+	machine := rm("team-a", "")
+	name, err = r.sshKeyName(context.Background(), machine, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "team-a-key", name)
+	assert.Empty(t, machine.Spec.SSHKeyRef.Name)
+
+	name, err = r.sshKeyName(context.Background(), rm("team-b", ""), nil)
+	require.NoError(t, err)
+	assert.Empty(t, name)
+
+	// The pooled machines use the key of the pooled machine, whatever the RemoteMachine sets
+	machine = rm("team-a", "own-key")
+	machine.Spec.Pool = "workers"
+	name, err = r.sshKeyName(context.Background(), machine, nil)
+	require.NoError(t, err)
+	assert.Empty(t, name)
+
+	pm := &infrastructure.PooledRemoteMachine{
+		ObjectMeta: metav1.ObjectMeta{Name: "pm", Namespace: "team-a"},
+		Spec:       infrastructure.PooledRemoteMachineSpec{Pool: "workers"},
+	}
+	name, err = r.sshKeyName(context.Background(), machine, pm)
+	require.NoError(t, err)
+	assert.Equal(t, "workers-key", name)
+
+	pm.Spec.Machine.SSHKeyRef.Name = "pm-key"
+	name, err = r.sshKeyName(context.Background(), machine, pm)
+	require.NoError(t, err)
+	assert.Equal(t, "pm-key", name)
+}
+
+func TestReservePooledMachine(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, infrastructure.AddToScheme(scheme))
+	require.NoError(t, kapi.AddToScheme(scheme))
+
+	pc := &kapi.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: kapi.DefaultProviderConfigName, Namespace: "pool"},
+		Spec:       kapi.ProviderConfigSpec{Pools: []kapi.ProviderConfigPool{{Name: "workers", SSHKeyRef: "workers-key"}}},
+	}
+	pm := func(name, pool string) *infrastructure.PooledRemoteMachine {
+		return &infrastructure.PooledRemoteMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "pool"},
+			Spec:       infrastructure.PooledRemoteMachineSpec{Pool: pool, Machine: infrastructure.PooledMachineSpec{Address: "1.2.3.4"}},
+		}
+	}
+	r := &RemoteMachineController{Client: fake.NewClientBuilder().WithScheme(scheme).
+		WithIndex(&infrastructure.PooledRemoteMachine{}, k0smoutil.PooledMachineReservationField, k0smoutil.PooledMachineReservationIndexFunc).
+		WithStatusSubresource(&infrastructure.PooledRemoteMachine{}).
+		WithObjects(pc, pm("worker-0", "workers"), pm("keyless-0", "keyless")).Build()}
+	rm := func(pool string) *infrastructure.RemoteMachine {
+		return &infrastructure.RemoteMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "rm", Namespace: "team-a"},
+			Spec:       infrastructure.RemoteMachineSpec{Pool: pool, PoolNamespace: "pool"},
+		}
+	}
+
+	// The machines without a key, of their own or of their pool, cannot be provisioned over SSH
+	_, err := r.reservePooledMachine(context.Background(), rm("keyless"))
+	assert.ErrorIs(t, err, ErrPooledMachineNotFound)
+
+	machine := rm("keyless")
+	machine.Spec.PullBootstrap = &infrastructure.PullBootstrap{}
+	reserved, err := r.reservePooledMachine(context.Background(), machine)
+	require.NoError(t, err)
+	assert.Equal(t, "keyless-0", reserved.Name)
+
+	// The machine of a central pool is reserved with the key of its pool, without writing it to the RemoteMachine
+	machine = rm("workers")
+	machine.Name = "rm-workers"
+	reserved, err = r.reservePooledMachine(context.Background(), machine)
+	require.NoError(t, err)
+	assert.Equal(t, "worker-0", reserved.Name)
+	assert.Empty(t, machine.Spec.SSHKeyRef.Name)
+
+	found, err := r.reservedPooledMachine(context.Background(), machine)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	name, err := r.sshKeyName(context.Background(), machine, found)
+	require.NoError(t, err)
+	assert.Equal(t, "workers-key", name)
+}
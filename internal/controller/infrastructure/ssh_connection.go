@@ -0,0 +1,213 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/k0sproject/rig/v2/homedir"
+	"github.com/k0sproject/rig/v2/protocol"
+	"github.com/k0sproject/rig/v2/protocol/ssh/hostkey"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+const (
+	defaultSSHConnectTimeout = 10 * time.Second
+	defaultSSHConnectRetries = 3
+)
+
+// sshConnectionConfig holds the tuning of the SSH connections to a machine.
+type sshConnectionConfig struct {
+	// connectTimeout bounds each connection attempt, connectRetries is the number of attempts after the first one.
+	connectTimeout time.Duration
+	connectRetries int
+	// keepAliveInterval is the interval of the keepalive requests, zero disables them.
+	keepAliveInterval time.Duration
+	// commandTimeout bounds each provisioning and cleanup command, zero means no limit.
+	commandTimeout time.Duration
+}
+
+// resolveSSHConnection returns the SSH connection tuning of a machine, each setting of the machine overriding
+// the setting of the cluster. Unset settings get their defaults.
+func resolveSSHConnection(cluster, machine *infrastructure.SSHConnectionSettings) sshConnectionConfig {
+	cfg := sshConnectionConfig{
+		connectTimeout: defaultSSHConnectTimeout,
+		connectRetries: defaultSSHConnectRetries,
+	}
+	for _, s := range []*infrastructure.SSHConnectionSettings{cluster, machine} {
+		if s == nil {
+			continue
+		}
+		if s.ConnectTimeout.Duration > 0 {
+			cfg.connectTimeout = s.ConnectTimeout.Duration
+		}
+		if s.ConnectRetries != nil {
+			cfg.connectRetries = int(*s.ConnectRetries)
+		}
+		if s.KeepAliveInterval.Duration > 0 {
+			cfg.keepAliveInterval = s.KeepAliveInterval.Duration
+		}
+		if s.CommandTimeout.Duration > 0 {
+			cfg.commandTimeout = s.CommandTimeout.Duration
+		}
+	}
+	return cfg
+}
+
+// sshConnection is an SSH connection to a machine, dialed directly or through a proxy. rig dials SSH
+// connections without a timeout and only through an SSH bastion, so the connection is implemented on top
+// of x/crypto/ssh.
+type sshConnection struct {
+	address   string
+	port      int
+	user      string
+	auth      []ssh.AuthMethod
+	dialer    proxy.ContextDialer
+	keepAlive time.Duration
+
+	client *ssh.Client
+}
+
+var _ protocol.Connection = &sshConnection{}
+
+// Connect dials the machine and opens the SSH connection, until the context is done.
+func (c *sshConnection) Connect(ctx context.Context) error {
+	hostKeyCallback, err := knownHostsCallback()
+	if err != nil {
+		return fmt.Errorf("%w: %w", protocol.ErrAbort, err)
+	}
+
+	dst := net.JoinHostPort(c.address, strconv.Itoa(c.port))
+	conn, err := c.dialer.DialContext(ctx, "tcp", dst)
+	if err != nil {
+		return fmt.Errorf("ssh dial: %w", err)
+	}
+	// The handshake is bounded by the context as well
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, dst, &ssh.ClientConfig{
+		User:            c.user,
+		Auth:            c.auth,
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		conn.Close()
+		if errors.Is(err, hostkey.ErrHostKeyMismatch) {
+			return fmt.Errorf("%w: %w", protocol.ErrAbort, err)
+		}
+		return fmt.Errorf("ssh connect: %w", err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	c.client = ssh.NewClient(sshConn, chans, reqs)
+	c.startKeepAlive()
+	return nil
+}
+
+// startKeepAlive sends keepalive requests over the connection until it is closed, so that idle connections
+// are not dropped by firewalls while long commands run.
+func (c *sshConnection) startKeepAlive() {
+	if c.keepAlive <= 0 {
+		return
+	}
+	client := c.client
+	go func() {
+		ticker := time.NewTicker(c.keepAlive)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// knownHostsCallback verifies host keys against the known_hosts file, the same way rig does.
+func knownHostsCallback() (ssh.HostKeyCallback, error) {
+	path, ok := hostkey.KnownHostsPathFromEnv()
+	if !ok {
+		var err error
+		path, err = homedir.Expand("~/.ssh/known_hosts")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if path == "" {
+		return hostkey.InsecureIgnoreHostKeyCallback, nil
+	}
+	return hostkey.KnownHostsFileCallback(path, false, false)
+}
+
+// Disconnect closes the SSH connection.
+func (c *sshConnection) Disconnect() {
+	if c.client != nil {
+		c.client.Close()
+	}
+}
+
+// StartProcess runs cmd in a new SSH session.
+func (c *sshConnection) StartProcess(ctx context.Context, cmd string, stdin io.Reader, stdout, stderr io.Writer) (protocol.Waiter, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected to %s", c)
+	}
+
+	session, err := c.client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("create ssh session: %w", err)
+	}
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	go func() {
+		<-ctx.Done()
+		_ = session.Signal(ssh.SIGINT)
+		_ = session.Close()
+	}()
+
+	if err := session.Start(cmd); err != nil {
+		return nil, fmt.Errorf("start session: %w", err)
+	}
+	return session, nil
+}
+
+func (c *sshConnection) String() string {
+	return net.JoinHostPort(c.address, strconv.Itoa(c.port))
+}
+
+func (c *sshConnection) Protocol() string {
+	return "SSH"
+}
+
+func (c *sshConnection) IPAddress() string {
+	return c.address
+}
+
+// IsWindows returns false, k0smotron only provisions Linux machines.
+func (c *sshConnection) IsWindows() bool {
+	return false
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+func TestResolveSSHConnection(t *testing.T) {
+	assert.Equal(t, sshConnectionConfig{connectTimeout: 10 * time.Second, connectRetries: 3}, resolveSSHConnection(nil, nil))
+
+	cluster := &infrastructure.SSHConnectionSettings{
+		ConnectTimeout:    metav1.Duration{Duration: time.Minute},
+		ConnectRetries:    ptr.To[int32](5),
+		KeepAliveInterval: metav1.Duration{Duration: 30 * time.Second},
+	}
+	machine := &infrastructure.SSHConnectionSettings{
+		ConnectRetries: ptr.To[int32](0),
+		CommandTimeout: metav1.Duration{Duration: time.Hour},
+	}
+	assert.Equal(t, sshConnectionConfig{
+		connectTimeout:    time.Minute,
+		connectRetries:    5,
+		keepAliveInterval: 30 * time.Second,
+	}, resolveSSHConnection(cluster, nil))
+	assert.Equal(t, sshConnectionConfig{
+		connectTimeout:    time.Minute,
+		connectRetries:    0,
+		keepAliveInterval: 30 * time.Second,
+		commandTimeout:    time.Hour,
+	}, resolveSSHConnection(cluster, machine))
+}
+
+func TestSSHConnectionHandshakeTimeout(t *testing.T) {
+	t.Setenv("SSH_KNOWN_HOSTS", "")
+
+	// The server accepts the connection but never speaks SSH
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	c := &sshConnection{address: "127.0.0.1", port: addr.Port, user: "root", dialer: &net.Dialer{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	assert.Error(t, c.Connect(ctx))
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
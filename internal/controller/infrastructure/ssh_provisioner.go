@@ -22,27 +22,94 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	api "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
 	"github.com/k0sproject/k0smotron/internal/cloudinit"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 	rig "github.com/k0sproject/rig/v2"
-	rigssh "github.com/k0sproject/rig/v2/protocol/ssh"
+	"github.com/k0sproject/rig/v2/protocol"
+	"github.com/k0sproject/rig/v2/sh"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 var regex = regexp.MustCompile(`--kubelet-root-dir[ =](/[/a-zA-Z0-9_-]+)+`)
 
+// errCommandTimeout is returned when a command runs for longer than the command timeout.
+var errCommandTimeout = errors.New("command timed out")
+
 type SSHProvisioner struct {
 	bootstrapData []byte
 	cloudInit     *cloudinit.CloudInit
 	machine       *api.RemoteMachine
 	sshKey        []byte
-	log           logr.Logger
+	// sshCertificate is the optional OpenSSH user certificate signed for sshKey.
+	sshCertificate []byte
+	// sshKeyPassphrase is the passphrase sshKey is encrypted with, if any.
+	sshKeyPassphrase []byte
+	// cloudInitSeed delivers the bootstrap data as a NoCloud seed run by cloud-init on the machine.
+	cloudInitSeed bool
+	// generatedCloudInit holds the files and commands generated by k0smotron for the machine, such as the
+	// keepalived configuration. They are written and run before the bootstrap data, however it is delivered.
+	generatedCloudInit *cloudinit.CloudInit
+	// providerID is the provider ID the machine gets once provisioned.
+	providerID string
+	// airgapDir is the directory on the k0smotron manager holding the airgap artifacts uploaded to the machine.
+	airgapDir string
+	// sshProxy, if set, is the proxy the SSH connections to the machine are dialed through.
+	sshProxy proxy.ContextDialer
+	// sshConnection tunes the SSH connections to the machine.
+	sshConnection sshConnectionConfig
+	// mode is the k0s role of the Machine, an adopted machine must run k0s with a matching role.
+	mode RemoteMachineMode
+	log  logr.Logger
+
+	// labelNode labels the node of an adopted machine in the workload cluster.
+	labelNode func(nodeName string) error
+	// nodeReady returns nil if the node of the machine is registered in the workload cluster and ready.
+	nodeReady func() error
+
+	// reportPhase, if set, is called each time the provisioning enters a new phase.
+	reportPhase func(phase api.RemoteMachinePhase)
+	// reportProgress, if set, is called each time a bootstrap step is done.
+	reportProgress func(completed, total int)
+	// reportCommand, if set, is called with each bootstrap command once it is run.
+	reportCommand func(cmd string, err error)
+	// reportPlatform, if set, is called with the operating system and the architecture detected on the machine.
+	reportPlatform func(osName, arch string)
+	// reportAddress, if set, is called with the address of the machine once the network configuration is applied.
+	reportAddress func(address string)
+	// reportReboot, if set, is called with the name of the hook once the machine is back from the reboot following it.
+	reportReboot func(hook string)
+}
+
+// bootstrapCloudInit returns the files and commands bootstrapping the machine, with the placeholders replaced:
+// the generated ones followed by the bootstrap data, or the NoCloud seed holding it.
+func (p *SSHProvisioner) bootstrapCloudInit(placeholders *strings.Replacer) *cloudinit.CloudInit {
+	ci := substitutePlaceholders(p.cloudInit, placeholders)
+	if p.cloudInitSeed {
+		ci = nocloudSeed(p.machine, []byte(placeholders.Replace(string(p.bootstrapData))))
+	}
+	if p.generatedCloudInit == nil {
+		return ci
+	}
+
+	generated := substitutePlaceholders(p.generatedCloudInit, placeholders)
+	ci.Files = append(slices.Clone(generated.Files), ci.Files...)
+	ci.RunCmds = append(generated.RunCmds, ci.RunCmds...)
+	return ci
 }
 
 const stopCommandTemplate = `(command -v systemctl > /dev/null 2>&1 && systemctl stop %s) || ` + // systemd
@@ -55,65 +122,356 @@ const (
 	workerService = "k0sworker"
 )
 
+const (
+	// networkReconnectTimeout is how long the machine has to come back after applying the network configuration.
+	networkReconnectTimeout = 3 * time.Minute
+	reconnectInterval       = 5 * time.Second
+	// rebootTimeout is how long the machine has to come back after a reboot.
+	rebootTimeout = 10 * time.Minute
+
+	bootIDCommand = "cat /proc/sys/kernel/random/boot_id"
+)
+
 // Provision provisions a new machine
 // The provisioning process is as follows:
 // 1. Open SSH connection to the machine
-// 2. Execute the bootstrap script
-// 3. Check sentinel file at /run/cluster-api/bootstrap-success.complete
-// 4. success
-func (p *SSHProvisioner) Provision(ctx context.Context) error {
+// 2. Detect the operating system and the architecture of the machine
+// 3. If the machine is adopted, verify k0s runs on it, label its node and skip to success
+// 4. Apply the static network configuration and reconnect to the machine
+// 5. Run the pre-bootstrap hooks
+// 6. Upload the airgap artifacts and the bootstrap files
+// 7. Execute the bootstrap script
+// 8. Check sentinel file at /run/cluster-api/bootstrap-success.complete
+// 9. Run the post-bootstrap hooks
+// 10. success
+func (p *SSHProvisioner) Provision(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "SSHProvisioner.Provision", attribute.String("k0smotron.remotemachine", p.machine.Name))
+	defer func() { tracing.End(span, err) }()
+
 	log := log.FromContext(ctx).WithValues("remotemachine", p.machine.Name)
 
-	authM, err := rigssh.ParseSSHPrivateKey(p.sshKey, nil)
+	preHooks, postHooks, err := provisionHooks(p.machine)
 	if err != nil {
-		return fmt.Errorf("failed to parse ssh key: %w", err)
+		return err
 	}
 
-	config := rigssh.Config{
-		Address:     p.machine.Spec.Address,
-		Port:        p.machine.Spec.Port,
-		User:        p.machine.Spec.User,
-		AuthMethods: authM,
+	network, err := networkCloudInit(p.machine.Spec.Network)
+	if err != nil {
+		return err
 	}
-	rigClient, err := rig.NewClient(rig.WithConnectionConfigurer(&config))
+
+	p.setPhase(ctx, api.RemoteMachinePhaseConnecting)
+	rigClient, err := p.connect(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create SSH client: %w", err)
+		return err
 	}
+	// The client is replaced when reconnecting to the machine, and nil if reconnecting failed
+	defer func() {
+		if rigClient != nil {
+			rigClient.Disconnect()
+		}
+	}()
 
-	err = rigClient.Connect(ctx)
+	uname, err := rigClient.ExecOutput("uname -s -m")
 	if err != nil {
-		return fmt.Errorf("failed to connect to host: %w", err)
+		return fmt.Errorf("failed to detect the platform of the machine: %w", err)
+	}
+	osName, arch, err := parsePlatform(uname)
+	if err != nil {
+		return err
+	}
+	log.Info("detected platform", "os", osName, "arch", arch)
+	if p.reportPlatform != nil {
+		p.reportPlatform(osName, arch)
 	}
-	defer rigClient.Disconnect()
 
-	if p.machine.Spec.UseSudo {
-		// If sudo is required, wrap the client with sudo capabilities
-		rigClient = rigClient.Sudo()
+	if p.machine.Spec.Adopt != nil {
+		p.setPhase(ctx, api.RemoteMachinePhaseVerifying)
+		nodeName, err := p.verifyAdoption(ctx, rigClient)
+		if err != nil {
+			return fmt.Errorf("failed to adopt the machine: %w", err)
+		}
+		if p.labelNode != nil {
+			if err := p.labelNode(nodeName); err != nil {
+				return fmt.Errorf("failed to adopt the machine: %w", err)
+			}
+		}
+		log.Info("adopted machine", "node", nodeName)
+		return nil
+	}
+
+	if len(network.Files) > 0 && !filesUpToDate(rigClient.Sudo().FS(), network.Files) {
+		p.setPhase(ctx, api.RemoteMachinePhaseConfiguringNetwork)
+		rigClient, err = p.configureNetwork(ctx, rigClient, network)
+		if err != nil {
+			return fmt.Errorf("failed to configure network: %w", err)
+		}
+	}
+
+	placeholders := placeholderReplacer(osName, arch, p.providerID)
+	ci := p.bootstrapCloudInit(placeholders)
+	preHooks = substitutePlaceholders(preHooks, placeholders)
+	postHooks = substitutePlaceholders(postHooks, placeholders)
+
+	artifacts, err := airgapArtifacts(p.airgapDir, p.machine.Spec.Airgap, arch)
+	if err != nil {
+		return err
+	}
+
+	stepDone := newStepCounter(p.reportProgress, len(artifacts), preHooks, ci, postHooks).done
+
+	if len(preHooks.RunCmds) > 0 {
+		p.setPhase(ctx, api.RemoteMachinePhaseRunningPreBootstrapHooks)
+		rigClient, err = p.runHooks(ctx, rigClient, p.machine.Spec.ProvisionHooks.PreBootstrap, preHooks, stepDone)
+		if err != nil {
+			return fmt.Errorf("pre-bootstrap hook failed: %w", err)
+		}
 	}
 
 	// Write files first
-	for _, file := range p.cloudInit.Files {
+	p.setPhase(ctx, api.RemoteMachinePhaseUploading)
+	for _, a := range artifacts {
+		if err := uploadArtifact(rigClient.Sudo().FS(), a); err != nil {
+			return fmt.Errorf("failed to upload airgap artifact: %w", err)
+		}
+		log.Info("uploaded airgap artifact", "path", a.dst)
+		stepDone()
+	}
+	if err := p.uploadFiles(rigClient, ci.Files, stepDone); err != nil {
+		return err
+	}
+
+	// Execute the bootstrap script commands
+	p.setPhase(ctx, api.RemoteMachinePhaseRunningBootstrap)
+	if err := p.runCommands(ctx, log, rigClient, ci.RunCmds, stepDone); err != nil {
+		return err
+	}
+
+	// Check for sentinel file
+	if _, err := rigClient.Sudo().FS().Stat("/run/cluster-api/bootstrap-success.complete"); err != nil {
+		return errors.New("bootstrap sentinel file not found")
+	}
+
+	if len(postHooks.RunCmds) > 0 {
+		p.setPhase(ctx, api.RemoteMachinePhaseRunningPostBootstrapHooks)
+		rigClient, err = p.runHooks(ctx, rigClient, p.machine.Spec.ProvisionHooks.PostBootstrap, postHooks, stepDone)
+		if err != nil {
+			return fmt.Errorf("post-bootstrap hook failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// stepCounter counts the bootstrap steps done on a machine: the uploaded airgap artifacts and the uploaded files
+// and executed commands of the hooks and the bootstrap.
+type stepCounter struct {
+	completed, total int
+	report           func(completed, total int)
+}
+
+// newStepCounter returns a counter of the steps of the given artifacts and cloud-inits, reporting each step done
+// to report, if set.
+func newStepCounter(report func(completed, total int), artifacts int, cis ...*cloudinit.CloudInit) *stepCounter {
+	c := &stepCounter{total: artifacts, report: report}
+	for _, ci := range cis {
+		c.total += len(ci.Files) + len(ci.RunCmds)
+	}
+	return c
+}
+
+// done records a step done.
+func (c *stepCounter) done() {
+	c.completed++
+	if c.report != nil {
+		c.report(c.completed, c.total)
+	}
+}
+
+// runHooks runs the hooks of a stage, ci holding one command per hook. The machine is rebooted after the hooks
+// asking for it, the hooks already followed by a reboot in an earlier attempt are skipped.
+func (p *SSHProvisioner) runHooks(ctx context.Context, rigClient *rig.Client, hooks []api.ProvisionHook, ci *cloudinit.CloudInit, stepDone func()) (_ *rig.Client, err error) {
+	ctx, span := tracing.Start(ctx, "SSHProvisioner.runHooks", attribute.Int("k0smotron.hooks", len(hooks)))
+	defer func() { tracing.End(span, err) }()
+
+	log := log.FromContext(ctx).WithValues("remotemachine", p.machine.Name)
+
+	if err := p.uploadFiles(rigClient, ci.Files, stepDone); err != nil {
+		return rigClient, err
+	}
+
+	resumeAt := hooksResumeIndex(hooks, p.machine.Status.RebootedAfterHooks)
+	for i, hook := range hooks {
+		if i < resumeAt {
+			log.Info("skipping hook run before a reboot", "hook", hook.Name)
+			stepDone()
+			continue
+		}
+		if err := p.runCommands(ctx, log, rigClient, ci.RunCmds[i:i+1], stepDone); err != nil {
+			return rigClient, fmt.Errorf("hook %s: %w", hook.Name, err)
+		}
+		if hook.RebootAfter {
+			phase := p.machine.Status.Phase
+			p.setPhase(ctx, api.RemoteMachinePhaseRebooting)
+			var err error
+			rigClient, err = p.reboot(ctx, rigClient)
+			if err != nil {
+				return rigClient, fmt.Errorf("machine did not come back after the reboot following hook %s: %w", hook.Name, err)
+			}
+			if p.reportReboot != nil {
+				p.reportReboot(hook.Name)
+			}
+			p.setPhase(ctx, phase)
+		}
+	}
+	return rigClient, nil
+}
+
+// hooksResumeIndex returns the index of the first hook to run, following the last hook after which
+// the machine was rebooted.
+func hooksResumeIndex(hooks []api.ProvisionHook, rebootedAfter []string) int {
+	resumeAt := 0
+	for i, hook := range hooks {
+		if hook.RebootAfter && slices.Contains(rebootedAfter, hook.Name) {
+			resumeAt = i + 1
+		}
+	}
+	return resumeAt
+}
+
+// reboot reboots the machine and reconnects to it once it is back.
+func (p *SSHProvisioner) reboot(ctx context.Context, rigClient *rig.Client) (_ *rig.Client, err error) {
+	ctx, span := tracing.Start(ctx, "SSHProvisioner.reboot")
+	defer func() { tracing.End(span, err) }()
+
+	log := log.FromContext(ctx).WithValues("remotemachine", p.machine.Name)
+
+	bootID, err := rigClient.ExecOutput(bootIDCommand)
+	if err != nil {
+		return rigClient, fmt.Errorf("failed to read boot ID: %w", err)
+	}
+	if _, err := rigClient.ExecOutput(detach("reboot")); err != nil {
+		return rigClient, fmt.Errorf("failed to reboot: %w", err)
+	}
+	rigClient.Disconnect()
+	log.Info("rebooting machine")
+
+	ctx, cancel := context.WithTimeout(ctx, rebootTimeout)
+	defer cancel()
+	for {
+		// The machine may still be reachable until it actually goes down, wait for a new boot ID
+		rigClient, err = p.waitForSSH(ctx, machineAddress(p.machine), rebootTimeout)
+		if err != nil {
+			return nil, err
+		}
+		newBootID, err := rigClient.ExecOutput(bootIDCommand)
+		if err == nil && newBootID != bootID {
+			log.Info("machine is back after reboot")
+			return rigClient, nil
+		}
+		rigClient.Disconnect()
+	}
+}
+
+// configureNetwork applies the network configuration in the background, so that the command returns before
+// the network changes, and reconnects to the machine at its new address.
+func (p *SSHProvisioner) configureNetwork(ctx context.Context, rigClient *rig.Client, network *cloudinit.CloudInit) (_ *rig.Client, err error) {
+	ctx, span := tracing.Start(ctx, "SSHProvisioner.configureNetwork")
+	defer func() { tracing.End(span, err) }()
+
+	log := log.FromContext(ctx).WithValues("remotemachine", p.machine.Name)
+
+	if err := p.uploadFiles(rigClient, network.Files, func() {}); err != nil {
+		return rigClient, err
+	}
+	if err := p.runCommands(ctx, log, rigClient, []string{detach(network.RunCmds[0])}, func() {}); err != nil {
+		return rigClient, err
+	}
+	rigClient.Disconnect()
+
+	address := machineAddress(p.machine)
+	if p.machine.Spec.Network.Address != "" {
+		address = p.machine.Spec.Network.Address
+	}
+	if p.reportAddress != nil {
+		p.reportAddress(address)
+	}
+
+	log.Info("applied network configuration, reconnecting", "address", address)
+	return p.waitForSSH(ctx, address, networkReconnectTimeout)
+}
+
+// waitForSSH connects to the machine at address, retrying until it succeeds or the timeout expires.
+func (p *SSHProvisioner) waitForSSH(ctx context.Context, address string, timeout time.Duration) (*rig.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("machine not reachable at %s after %s: %w", address, timeout, lastErr)
+		case <-time.After(reconnectInterval):
+		}
+
+		rigClient, err := p.connectTo(ctx, address)
+		if err == nil {
+			return rigClient, nil
+		}
+		lastErr = err
+	}
+}
+
+func (p *SSHProvisioner) uploadFiles(rigClient *rig.Client, files []cloudinit.File, stepDone func()) error {
+	for _, file := range files {
 		if err := p.uploadFile(rigClient, file); err != nil {
 			return fmt.Errorf("failed to upload file: %w", err)
 		}
+		stepDone()
 	}
+	return nil
+}
 
-	// Execute the bootstrap script commands
-	for _, cmd := range p.cloudInit.RunCmds {
-		output, err := rigClient.ExecOutput(cmd)
+func (p *SSHProvisioner) runCommands(ctx context.Context, log logr.Logger, rigClient *rig.Client, cmds []string, stepDone func()) (err error) {
+	ctx, span := tracing.Start(ctx, "SSHProvisioner.runCommands", attribute.Int("k0smotron.commands", len(cmds)))
+	defer func() { tracing.End(span, err) }()
+
+	for _, cmd := range cmds {
+		output, err := p.exec(ctx, rigClient, cmd)
+		if p.reportCommand != nil {
+			p.reportCommand(cmd, err)
+		}
 		if err != nil {
 			p.log.Error(err, "failed to run command", "output", output)
 			return fmt.Errorf("failed to run command: %w", err)
 		}
 		log.Info("executed command", "command", cmd, "output", output)
+		stepDone()
 	}
+	return nil
+}
 
-	// Check for sentinel file
-	if _, err := rigClient.Sudo().FS().Stat("/run/cluster-api/bootstrap-success.complete"); err != nil {
-		return errors.New("bootstrap sentinel file not found")
+// exec runs a provisioning or cleanup command, bounded by the command timeout if one is set.
+func (p *SSHProvisioner) exec(ctx context.Context, rigClient *rig.Client, cmd string) (string, error) {
+	timeout := p.sshConnection.commandTimeout
+	if timeout <= 0 {
+		return rigClient.ExecOutputContext(ctx, cmd)
 	}
 
-	return nil
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	output, err := rigClient.ExecOutputContext(ctx, cmd)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("%w after %s: %w", errCommandTimeout, timeout, err)
+	}
+	return output, err
+}
+
+func (p *SSHProvisioner) setPhase(ctx context.Context, phase api.RemoteMachinePhase) {
+	trace.SpanFromContext(ctx).AddEvent(string(phase))
+	if p.reportPhase != nil {
+		p.reportPhase(phase)
+	}
 }
 
 // Cleanup cleans up a machine
@@ -123,40 +481,18 @@ func (p *SSHProvisioner) Provision(ctx context.Context) error {
 // 3. Removes node from etcd
 // 4. Runs k0s reset
 func (p *SSHProvisioner) Cleanup(ctx context.Context, mode RemoteMachineMode) error {
-	authM, err := rigssh.ParseSSHPrivateKey(p.sshKey, nil)
-	if err != nil {
-		return fmt.Errorf("failed to parse ssh key: %w", err)
-	}
-
-	config := rigssh.Config{
-		Address:     p.machine.Spec.Address,
-		Port:        p.machine.Spec.Port,
-		User:        p.machine.Spec.User,
-		AuthMethods: authM,
-	}
-
-	rigClient, err := rig.NewClient(rig.WithConnectionConfigurer(&config))
-	if err != nil {
-		return fmt.Errorf("failed to create SSH client: %w", err)
-	}
-
-	err = rigClient.Connect(ctx)
+	rigClient, err := p.connect(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to connect to host: %w", err)
+		return err
 	}
 	defer rigClient.Disconnect()
 
-	if p.machine.Spec.UseSudo {
-		// If sudo is required, wrap the client with sudo capabilities
-		rigClient = rigClient.Sudo()
-	}
-
 	// When k0s is not the bootstrap provider, the user can set custom commands for the clean up process.
 	if mode == ModeNonK0s {
 		if p.machine.Spec.CustomCleanUpCommands != nil {
 			p.log.Info("Cleaning up remote machine...")
 			for _, cmd := range p.machine.Spec.CustomCleanUpCommands {
-				output, err := rigClient.ExecOutput(cmd)
+				output, err := p.exec(ctx, rigClient, cmd)
 				if err != nil {
 					p.log.Error(err, "failed to run command", "command", cmd, "output", output)
 				} else {
@@ -195,7 +531,7 @@ func (p *SSHProvisioner) Cleanup(ctx context.Context, mode RemoteMachineMode) er
 
 	p.log.Info("Cleaning up remote machine...")
 	for _, cmd := range cmds {
-		output, err := rigClient.ExecOutput(cmd)
+		output, err := p.exec(ctx, rigClient, cmd)
 		if err != nil {
 			p.log.Error(err, "failed to run command", "output", output)
 		}
@@ -204,6 +540,182 @@ func (p *SSHProvisioner) Cleanup(ctx context.Context, mode RemoteMachineMode) er
 	return nil
 }
 
+// Reset brings a machine whose RemoteMachine no longer exists back to a clean state,
+// so it can be handed out again by the pool. Since the bootstrap config of the
+// original Machine is gone, both k0s services are stopped before running k0s reset.
+// If custom clean up commands are set, those are run instead.
+func (p *SSHProvisioner) Reset(ctx context.Context) error {
+	rigClient, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer rigClient.Disconnect()
+
+	cmds := p.machine.Spec.CustomCleanUpCommands
+	if len(cmds) == 0 {
+		cmds = []string{
+			fmt.Sprintf(stopCommandTemplate, ctrlService, ctrlService, ctrlService),
+			fmt.Sprintf(stopCommandTemplate, workerService, workerService, workerService),
+			"k0s reset",
+		}
+	}
+
+	p.log.Info("Resetting remote machine...")
+	for _, cmd := range cmds {
+		output, err := p.exec(ctx, rigClient, cmd)
+		if err != nil {
+			p.log.Error(err, "failed to run command", "command", cmd, "output", output)
+		}
+	}
+
+	return nil
+}
+
+// Probe checks the machine is reachable over SSH and returns the uptime and
+// the free disk space, in megabytes, of the filesystem holding diskPath.
+func (p *SSHProvisioner) Probe(ctx context.Context, diskPath string) (probeResult, error) {
+	rigClient, err := p.connect(ctx)
+	if err != nil {
+		return probeResult{}, err
+	}
+	defer rigClient.Disconnect()
+
+	uptime, err := rigClient.ExecOutput("cat /proc/uptime")
+	if err != nil {
+		return probeResult{}, fmt.Errorf("failed to read uptime: %w", err)
+	}
+	df, err := rigClient.ExecOutput(fmt.Sprintf("df -Pm %s | tail -n 1", sh.Quote(diskPath)))
+	if err != nil {
+		return probeResult{}, fmt.Errorf("failed to read free disk space: %w", err)
+	}
+
+	return parseProbeOutput(uptime, df)
+}
+
+type probeResult struct {
+	uptimeSeconds   int64
+	freeDiskSpaceMB int64
+}
+
+// parseProbeOutput parses the output of `cat /proc/uptime` and the last line of `df -Pm`.
+func parseProbeOutput(uptime, df string) (probeResult, error) {
+	var res probeResult
+
+	uptimeFields := strings.Fields(uptime)
+	if len(uptimeFields) == 0 {
+		return res, fmt.Errorf("unexpected uptime output: %q", uptime)
+	}
+	seconds, err := strconv.ParseFloat(uptimeFields[0], 64)
+	if err != nil {
+		return res, fmt.Errorf("failed to parse uptime %q: %w", uptimeFields[0], err)
+	}
+	res.uptimeSeconds = int64(seconds)
+
+	// Filesystem 1048576-blocks Used Available Capacity Mounted on
+	dfFields := strings.Fields(df)
+	if len(dfFields) < 6 {
+		return res, fmt.Errorf("unexpected df output: %q", df)
+	}
+	res.freeDiskSpaceMB, err = strconv.ParseInt(dfFields[3], 10, 64)
+	if err != nil {
+		return res, fmt.Errorf("failed to parse free disk space %q: %w", dfFields[3], err)
+	}
+
+	return res, nil
+}
+
+// connect opens an SSH connection to the machine, wrapping the client with sudo if required.
+func (p *SSHProvisioner) connect(ctx context.Context) (*rig.Client, error) {
+	return p.connectTo(ctx, machineAddress(p.machine))
+}
+
+// connectTo opens an SSH connection to the machine at address. Each attempt is bounded by the connect timeout,
+// the failed attempts are retried unless the host key does not match.
+func (p *SSHProvisioner) connectTo(ctx context.Context, address string) (*rig.Client, error) {
+	authM, err := sshAuthMethods(p.sshKey, p.sshCertificate, p.sshKeyPassphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var dialer proxy.ContextDialer = &net.Dialer{}
+	if p.sshProxy != nil {
+		dialer = p.sshProxy
+	}
+	rigClient, err := rig.NewClient(rig.WithConnection(&sshConnection{
+		address:   address,
+		port:      p.machine.Spec.Port,
+		user:      p.machine.Spec.User,
+		auth:      authM,
+		dialer:    dialer,
+		keepAlive: p.sshConnection.keepAliveInterval,
+	}), rig.WithRetry(false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH client: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, p.sshConnection.connectTimeout)
+		err = rigClient.Connect(attemptCtx)
+		cancel()
+		if err == nil {
+			break
+		}
+		if attempt >= p.sshConnection.connectRetries || errors.Is(err, protocol.ErrAbort) {
+			return nil, fmt.Errorf("failed to connect to host: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to connect to host: %w", err)
+		case <-time.After(reconnectInterval):
+		}
+	}
+
+	if p.machine.Spec.UseSudo {
+		// If sudo is required, wrap the client with sudo capabilities
+		rigClient = rigClient.Sudo()
+	}
+
+	return rigClient, nil
+}
+
+// sshAuthMethods returns the auth methods for the private key, decrypted with the passphrase if the key is encrypted.
+// If a user certificate is given, the key is used to authenticate with the certificate instead of as a raw public key.
+func sshAuthMethods(key, certificate, passphrase []byte) ([]ssh.AuthMethod, error) {
+	signer, err := ssh.ParsePrivateKey(key)
+	var missingErr *ssh.PassphraseMissingError
+	if errors.As(err, &missingErr) {
+		if len(passphrase) == 0 {
+			return nil, errors.New("ssh key is encrypted but no passphrase is set")
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, passphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh key: %w", err)
+	}
+
+	if len(certificate) == 0 {
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(certificate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh certificate: %w", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("ssh certificate is not an OpenSSH certificate")
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, errors.New("ssh certificate is not a user certificate")
+	}
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("ssh certificate does not match the ssh key: %w", err)
+	}
+
+	return []ssh.AuthMethod{ssh.PublicKeys(certSigner)}, nil
+}
+
 func (p *SSHProvisioner) uploadFile(client *rig.Client, file cloudinit.File) error {
 	fsys := client.Sudo().FS()
 	// Ensure base dir exists for target
@@ -0,0 +1,150 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/cloudinit"
+)
+
+func TestSSHAuthMethods(t *testing.T) {
+	_, userKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(userKey, "")
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(block)
+
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	caSigner, err := ssh.NewSignerFromKey(caKey)
+	require.NoError(t, err)
+	userPub, err := ssh.NewPublicKey(userKey.Public())
+	require.NoError(t, err)
+
+	newCert := func(certType uint32) []byte {
+		cert := &ssh.Certificate{
+			Key:             userPub,
+			CertType:        certType,
+			ValidPrincipals: []string{"root"},
+			ValidBefore:     ssh.CertTimeInfinity,
+		}
+		require.NoError(t, cert.SignCert(rand.Reader, caSigner))
+		return ssh.MarshalAuthorizedKey(cert)
+	}
+
+	authM, err := sshAuthMethods(keyPEM, nil, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, authM)
+
+	authM, err = sshAuthMethods(keyPEM, newCert(ssh.UserCert), nil)
+	require.NoError(t, err)
+	assert.Len(t, authM, 1)
+
+	_, err = sshAuthMethods(keyPEM, newCert(ssh.HostCert), nil)
+	assert.Error(t, err)
+
+	_, err = sshAuthMethods(keyPEM, ssh.MarshalAuthorizedKey(userPub), nil)
+	assert.Error(t, err)
+}
+
+func TestSSHAuthMethodsEncryptedKey(t *testing.T) {
+	_, userKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(userKey, "", []byte("secret"))
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(block)
+
+	authM, err := sshAuthMethods(keyPEM, nil, []byte("secret"))
+	require.NoError(t, err)
+	assert.Len(t, authM, 1)
+
+	_, err = sshAuthMethods(keyPEM, nil, nil)
+	assert.ErrorContains(t, err, "no passphrase")
+
+	_, err = sshAuthMethods(keyPEM, nil, []byte("wrong"))
+	assert.Error(t, err)
+}
+
+func TestBootstrapCloudInit(t *testing.T) {
+	keepalived := &cloudinit.CloudInit{
+		Files:   []cloudinit.File{{Path: "/etc/keepalived/keepalived.conf", Content: "vrrp_instance", Permissions: "0600"}},
+		RunCmds: []string{"systemctl restart keepalived"},
+	}
+	p := &SSHProvisioner{
+		bootstrapData: []byte("#cloud-config\nruncmd:\n- k0s install controller --provider-id ${K0S_PROVIDER_ID}\n"),
+		cloudInit: &cloudinit.CloudInit{
+			Files:   []cloudinit.File{{Path: "/etc/k0s.token", Content: "token"}},
+			RunCmds: []string{"k0s install controller --provider-id ${K0S_PROVIDER_ID}"},
+		},
+		machine:            &infrastructure.RemoteMachine{ObjectMeta: metav1.ObjectMeta{Name: "rm-0", UID: "1234"}},
+		generatedCloudInit: keepalived,
+	}
+	placeholders := placeholderReplacer("linux", "amd64", "remote-machine://rm-0")
+
+	ci := p.bootstrapCloudInit(placeholders)
+	assert.Equal(t, []cloudinit.File{keepalived.Files[0], {Path: "/etc/k0s.token", Content: "token"}}, ci.Files)
+	assert.Equal(t, []string{"systemctl restart keepalived", "k0s install controller --provider-id remote-machine://rm-0"}, ci.RunCmds)
+
+	// The generated files and commands are kept when the bootstrap data is delivered as a NoCloud seed
+	p.cloudInitSeed = true
+	ci = p.bootstrapCloudInit(placeholders)
+	require.Len(t, ci.Files, 3)
+	assert.Equal(t, keepalived.Files[0], ci.Files[0])
+	assert.Equal(t, nocloudSeedDir+"/user-data", ci.Files[1].Path)
+	assert.Contains(t, ci.Files[1].Content, "--provider-id remote-machine://rm-0")
+	assert.Equal(t, "systemctl restart keepalived", ci.RunCmds[0])
+	assert.Equal(t, "cloud-init clean --logs", ci.RunCmds[1])
+
+	// The generated cloud-init is left untouched
+	assert.Len(t, keepalived.Files, 1)
+	assert.Equal(t, []string{"systemctl restart keepalived"}, keepalived.RunCmds)
+}
+
+func TestStepCounter(t *testing.T) {
+	var reported [][2]int
+	report := func(completed, total int) {
+		reported = append(reported, [2]int{completed, total})
+	}
+	preHooks := &cloudinit.CloudInit{RunCmds: []string{"apt-get update"}}
+	ci := &cloudinit.CloudInit{
+		Files:   []cloudinit.File{{Path: "/etc/k0s.yaml"}, {Path: "/etc/k0s/token"}},
+		RunCmds: []string{"k0s install controller", "k0s start"},
+	}
+	postHooks := &cloudinit.CloudInit{}
+
+	c := newStepCounter(report, 1, preHooks, ci, postHooks)
+	assert.Equal(t, 6, c.total)
+	c.done()
+	c.done()
+	assert.Equal(t, [][2]int{{1, 6}, {2, 6}}, reported)
+
+	// The steps are still counted without a reporter
+	c = newStepCounter(nil, 0, ci)
+	c.done()
+	assert.Equal(t, 1, c.completed)
+	assert.Equal(t, 4, c.total)
+}
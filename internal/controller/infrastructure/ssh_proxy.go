@@ -0,0 +1,124 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// proxyCredentials holds the credentials used to authenticate to an SSH proxy.
+type proxyCredentials struct {
+	username string
+	password string
+}
+
+// proxyDialer returns a dialer dialing through the SOCKS5 or HTTP CONNECT proxy at proxyURL.
+func proxyDialer(proxyURL string, creds *proxyCredentials) (proxy.ContextDialer, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh proxy url: %w", err)
+	}
+	if u.Host == "" || u.User != nil {
+		return nil, fmt.Errorf("invalid ssh proxy url %q: expected <scheme>://<host>:<port>", proxyURL)
+	}
+
+	switch u.Scheme {
+	case "socks5":
+		var auth *proxy.Auth
+		if creds != nil {
+			auth = &proxy.Auth{User: creds.username, Password: creds.password}
+		}
+		d, err := proxy.SOCKS5("tcp", u.Host, auth, &net.Dialer{})
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh proxy: %w", err)
+		}
+		return d.(proxy.ContextDialer), nil
+	case "http":
+		return &httpConnectDialer{address: u.Host, creds: creds}, nil
+	default:
+		return nil, fmt.Errorf("unsupported ssh proxy scheme %q", u.Scheme)
+	}
+}
+
+// httpConnectDialer dials through an HTTP proxy using the CONNECT method.
+type httpConnectDialer struct {
+	address string
+	creds   *proxyCredentials
+}
+
+// DialContext opens a tunnel to address through the proxy.
+func (d *httpConnectDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, network, d.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial ssh proxy: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if d.creds != nil {
+		auth := base64.StdEncoding.EncodeToString([]byte(d.creds.username + ":" + d.creds.password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT request to ssh proxy: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read CONNECT response from ssh proxy: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("ssh proxy refused to connect to %s: %s", address, resp.Status)
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	// The SSH server speaks first, its banner may already be buffered
+	return &bufferedConn{Conn: conn, r: br}, nil
+}
+
+// bufferedConn is a connection whose reads are served from a buffered reader first.
+type bufferedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
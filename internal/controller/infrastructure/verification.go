@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	k0smoutil "github.com/k0sproject/k0smotron/internal/controller/util"
+)
+
+// verificationInterval is the delay between two verifications of a provisioned machine.
+const verificationInterval = 15 * time.Second
+
+var (
+	errK0sNotRunning = errors.New("k0s is not running")
+	errNodeNotReady  = errors.New("node is not ready")
+)
+
+// verifier is implemented by the provisioners able to verify k0s runs on a provisioned machine.
+type verifier interface {
+	Verify(ctx context.Context) error
+}
+
+// Verify checks the k0s service is running on the machine and, if the machine runs a worker,
+// that its node is registered and ready.
+func (p *SSHProvisioner) Verify(ctx context.Context) error {
+	rigClient, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer rigClient.Disconnect()
+
+	status, err := rigClient.ExecOutput("k0s status")
+	if err != nil {
+		return fmt.Errorf("%w: %w", errK0sNotRunning, err)
+	}
+	role, err := parseK0sRole(status)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errK0sNotRunning, err)
+	}
+
+	if !runsWorker(role) || p.nodeReady == nil {
+		return nil
+	}
+	return p.nodeReady()
+}
+
+// runsWorker returns true if a k0s node of the role runs a kubelet, and so registers a node.
+func runsWorker(role string) bool {
+	return strings.Contains(role, "worker") || role == "single"
+}
+
+// machineNodeReady returns nil if the node of the machine is registered in the workload cluster and ready.
+func (r *RemoteMachineController) machineNodeReady(ctx context.Context, machine *clusterv1.Machine) error {
+	kc, err := r.workloadClient(ctx, machine)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := kc.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", k0smoutil.MachineNameNodeLabel, machine.Name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	if len(nodes.Items) == 0 {
+		return fmt.Errorf("%w: no node registered for machine %s", errNodeNotReady, machine.Name)
+	}
+	return nodeReady(&nodes.Items[0])
+}
+
+// nodeReady returns nil if the Ready condition of the node is true.
+func nodeReady(node *corev1.Node) error {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			if c.Status == corev1.ConditionTrue {
+				return nil
+			}
+			return fmt.Errorf("%w: node %s: %s", errNodeNotReady, node.Name, c.Message)
+		}
+	}
+	return fmt.Errorf("%w: node %s has no Ready condition", errNodeNotReady, node.Name)
+}
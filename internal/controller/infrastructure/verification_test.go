@@ -0,0 +1,45 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunsWorker(t *testing.T) {
+	assert.True(t, runsWorker("worker"))
+	assert.True(t, runsWorker("controller+worker"))
+	assert.True(t, runsWorker("single"))
+	assert.False(t, runsWorker("controller"))
+}
+
+func TestNodeReady(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}}
+	assert.ErrorIs(t, nodeReady(node), errNodeNotReady)
+
+	node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Message: "container runtime is down"}}
+	err := nodeReady(node)
+	assert.ErrorIs(t, err, errNodeNotReady)
+	assert.ErrorContains(t, err, "container runtime is down")
+
+	node.Status.Conditions[0].Status = corev1.ConditionTrue
+	assert.NoError(t, nodeReady(node))
+}
@@ -41,6 +41,9 @@ import (
 	km "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
 	"github.com/k0sproject/k0smotron/internal/controller/util"
 	"github.com/k0sproject/k0smotron/internal/exec"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 )
 
 // JoinTokenRequestReconciler reconciles a JoinTokenRequest object
@@ -159,7 +162,7 @@ func (r *JoinTokenRequestReconciler) reconcileSecret(ctx context.Context, jtr km
 		return err
 	}
 
-	return r.Client.Patch(ctx, &cm, client.Apply, patchOpts...)
+	return util.Apply(ctx, r.Client, &cm)
 }
 
 func (r *JoinTokenRequestReconciler) generateSecret(jtr *km.JoinTokenRequest, token string) (v1.Secret, error) {
@@ -202,9 +205,15 @@ func (r *JoinTokenRequestReconciler) updateStatus(ctx context.Context, jtr km.Jo
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *JoinTokenRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	metrics.RegisterObjects("JoinTokenRequest", mgr.GetClient(), func() client.ObjectList { return &km.JoinTokenRequestList{} }, jtrClusterName)
+
+	if err := health.AddChecks(mgr, "jointokenrequest", &km.JoinTokenRequest{}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&km.JoinTokenRequest{}).
-		Complete(r)
+		Complete(metrics.Instrument("jointokenrequest", mgr.GetClient(), func() client.Object { return &km.JoinTokenRequest{} }, jtrClusterName, tracing.Instrument("jointokenrequest", health.Instrument("jointokenrequest", r))))
 }
 
 func getTokenID(token, role string) (string, error) {
@@ -249,3 +258,11 @@ func tokenDecode(token string) ([]byte, error) {
 
 	return output, err
 }
+
+// jtrClusterName labels the JoinTokenRequest metrics with the name of the requested cluster.
+func jtrClusterName(obj client.Object) string {
+	if jtr, ok := obj.(*km.JoinTokenRequest); ok {
+		return jtr.Spec.ClusterRef.Name
+	}
+	return ""
+}
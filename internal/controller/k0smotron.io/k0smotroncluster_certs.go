@@ -36,6 +36,9 @@ import (
 	km "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
 )
 
+// etcdCertificatePurposes are the certificates of the etcd members, signed by the etcd CA.
+var etcdCertificatePurposes = []secret.Purpose{"apiserver-etcd-client", "etcd-server", "etcd-peer"}
+
 func (scope *kmcScope) ensureEtcdCertificates(ctx context.Context, kmc *km.Cluster) error {
 	certificates := secret.NewCertificatesForInitialControlPlane(&bootstrapv1.ClusterConfiguration{})
 	err := certificates.LookupCached(ctx, scope.secretCachingClient, scope.client, util.ObjectKey(kmc))
@@ -64,10 +67,9 @@ func (scope *kmcScope) ensureEtcdCertificates(ctx context.Context, kmc *km.Clust
 
 	g := &csr.Generator{Validator: genkey.Validator}
 
-	etcdCerts := secret.Certificates{
-		&secret.Certificate{Purpose: "apiserver-etcd-client"},
-		&secret.Certificate{Purpose: "etcd-server"},
-		&secret.Certificate{Purpose: "etcd-peer"},
+	var etcdCerts secret.Certificates
+	for _, purpose := range etcdCertificatePurposes {
+		etcdCerts = append(etcdCerts, &secret.Certificate{Purpose: purpose})
 	}
 
 	err = etcdCerts.LookupCached(ctx, scope.secretCachingClient, scope.client, util.ObjectKey(kmc))
@@ -85,6 +87,7 @@ func (scope *kmcScope) ensureEtcdCertificates(ctx context.Context, kmc *km.Clust
 				},
 				Hosts: []string{
 					"127.0.0.1",
+					"::1",
 					"localhost",
 					kmc.GetEtcdServiceName(),
 					fmt.Sprintf("%s.%s.svc", kmc.GetEtcdServiceName(), kmc.GetNamespace()),
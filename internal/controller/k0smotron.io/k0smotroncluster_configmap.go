@@ -149,7 +149,7 @@ func (scope *kmcScope) reconcileK0sConfig(ctx context.Context, kmc *km.Cluster,
 		logger.Error(err, "failed to reconcile dynamic config, kubeconfig may not be available yet")
 	}
 
-	return scope.client.Patch(ctx, &cm, client.Apply, patchOpts...)
+	return kcontrollerutil.Apply(ctx, scope.client, &cm)
 }
 
 func reconcileDynamicConfig(ctx context.Context, kmc *km.Cluster, k0sConfig map[string]interface{}, c client.Client) error {
@@ -183,14 +183,12 @@ func detectExternalAddress(ctx context.Context, c client.Client) (string, error)
 		return "", err
 	}
 	for _, node := range nodes.Items {
-		for _, addr := range node.Status.Addresses {
-			if internalAddress == "" && addr.Type == v1.NodeInternalIP {
-				internalAddress = addr.Address
-			}
+		if internalAddress == "" {
+			internalAddress = kcontrollerutil.NodeAddress(node, v1.NodeInternalIP)
+		}
 
-			if addr.Type == v1.NodeExternalDNS || addr.Type == v1.NodeExternalIP {
-				return addr.Address, nil
-			}
+		if addr := kcontrollerutil.NodeAddress(node, v1.NodeExternalDNS, v1.NodeExternalIP); addr != "" {
+			return addr, nil
 		}
 	}
 
@@ -219,6 +217,12 @@ func genSANs(kmc *km.Cluster, c client.Client) ([]string, error) {
 
 	sans = append(sans, fmt.Sprintf("%s.svc.cluster.local", svcNamespacedName))
 
+	// The kubernetes service of the hosted cluster is resolved in its own cluster domain
+	if kmc.Spec.K0sConfig != nil {
+		clusterDomain, _, _ := unstructured.NestedString(kmc.Spec.K0sConfig.Object, "spec", "network", "clusterDomain")
+		sans = append(sans, kcontrollerutil.ClusterDomainSANs(clusterDomain)...)
+	}
+
 	// Sort the sans to ensure stable output order
 	sort.Strings(sans)
 
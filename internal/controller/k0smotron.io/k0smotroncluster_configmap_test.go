@@ -23,9 +23,12 @@ import (
 	km "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestGenerateCM(t *testing.T) {
@@ -89,3 +92,27 @@ func TestGenerateCM(t *testing.T) {
 		assert.True(t, strings.Contains(conf, "my.san.address2"))
 	})
 }
+
+func TestGenSANs(t *testing.T) {
+	kmc := &km.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: km.ClusterSpec{
+			Service: km.ServiceSpec{Type: v1.ServiceTypeClusterIP},
+			K0sConfig: &unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"network": map[string]interface{}{"clusterDomain": "example.internal"},
+				},
+			}},
+		},
+	}
+	c := fake.NewClientBuilder().WithObjects(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: kmc.GetServiceName(), Namespace: "default"},
+		Spec:       v1.ServiceSpec{ClusterIPs: []string{"10.96.0.10"}},
+	}).Build()
+
+	sans, err := genSANs(kmc, c)
+	require.NoError(t, err)
+	assert.Contains(t, sans, "10.96.0.10")
+	assert.Contains(t, sans, "kmc-test.default.svc.cluster.local")
+	assert.Contains(t, sans, "kubernetes.default.svc.example.internal")
+}
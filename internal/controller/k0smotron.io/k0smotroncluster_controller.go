@@ -28,6 +28,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
@@ -36,18 +37,18 @@ import (
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	km "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/health"
+	"github.com/k0sproject/k0smotron/internal/metrics"
+	"github.com/k0sproject/k0smotron/internal/tracing"
 )
 
 var (
-	patchOpts []client.PatchOption = []client.PatchOption{
-		client.FieldOwner("k0smotron-operator"),
-		client.ForceOwnership,
-	}
 	// ErrNotReady is returned when the statefulset does not have a ready replica.
 	ErrNotReady = fmt.Errorf("waiting for the state")
 )
@@ -60,11 +61,23 @@ type ClusterReconciler struct {
 	ClientSet           *kubernetes.Clientset
 	RESTConfig          *rest.Config
 	Recorder            record.EventRecorder
+	// ResyncPeriod is the base period at which each Cluster is reconciled again once successfully reconciled, to
+	// correct the drift of the resources which are not watched. Disabled if zero.
+	ResyncPeriod time.Duration
+	// WatchSecrets watches the metadata of the Secrets labelled with a cluster name. It requires the manager cache
+	// to be limited to these Secrets.
+	WatchSecrets bool
 }
 
 const (
 	clusterUIDLabel  = "k0smotron.io/cluster-uid"
 	clusterFinalizer = "k0smotron.io/finalizer"
+
+	// operationActor is the actor of the operations recorded in the status of the k0smotron Cluster.
+	operationActor = "k0smotron-cluster-controller"
+
+	// resyncJitterFactor is the maximum fraction of the resync period added to it.
+	resyncJitterFactor = 0.5
 )
 
 type kmcScope struct {
@@ -80,6 +93,8 @@ type kmcScope struct {
 	restConfig *rest.Config
 	// secretCachingClient is the client used to cache secrets for certificate generation.
 	secretCachingClient client.Client
+	// recorder records the events of the k0smotron Cluster.
+	recorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=k0smotron.io,resources=clusters,verbs=get;list;watch;create;update;patch;delete
@@ -97,7 +112,7 @@ type kmcScope struct {
 // +kubebuilder:rbac:groups=core,resources=pods/exec,verbs=create
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=list
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -199,19 +214,6 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, nil
 	}
 
-	patchHelper, err = patch.NewHelper(kmc, r.Client)
-	if err != nil {
-		logger.Error(err, "Failed to configure the patch helper")
-		return ctrl.Result{Requeue: true}, nil
-	}
-
-	defer func() {
-		err = patchHelper.Patch(ctx, kmc)
-		if err != nil {
-			logger.Error(err, "Unable to update k0smotron Cluster")
-		}
-	}()
-
 	logger.Info("Reconciling services")
 	if err := kmcScope.reconcileServices(ctx, kmc); err != nil {
 		kmc.Status.ReconciliationStatus = "Failed reconciling services"
@@ -237,8 +239,10 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 	if kmc.Spec.CertificateRefs == nil {
 		if err := kmcScope.ensureCertificates(ctx, kmc); err != nil {
+			r.Recorder.Eventf(kmc, v1.EventTypeWarning, "CertificatesGenerationFailed", "Failed to generate the cluster certificates: %s", err)
 			return ctrl.Result{Requeue: true, RequeueAfter: time.Minute}, err
 		}
+		r.Recorder.Event(kmc, v1.EventTypeNormal, "CertificatesGenerated", "Generated the cluster certificates")
 		kmc.Spec.CertificateRefs = []km.CertificateRef{
 			{
 				Type: string(secret.ClusterCA),
@@ -299,6 +303,7 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 
 		kmc.Status.ReconciliationStatus = fmt.Sprintf("Failed reconciling statefulset, %+v", err)
+		r.Recorder.Eventf(kmc, v1.EventTypeWarning, "StatefulSetReconcileFailed", "Failed to reconcile the statefulset: %s", err)
 		return ctrl.Result{Requeue: true, RequeueAfter: time.Minute}, err
 	}
 
@@ -309,17 +314,37 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 	kmc.Status.ReconciliationStatus = "Reconciliation successful"
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: r.resyncAfter()}, nil
+}
+
+// resyncAfter returns the delay after which a reconciled Cluster is reconciled again. The delay is jittered so the
+// resyncs of the Clusters are spread over time instead of all happening at once.
+func (r *ClusterReconciler) resyncAfter() time.Duration {
+	if r.ResyncPeriod <= 0 {
+		return 0
+	}
+	return wait.Jitter(r.ResyncPeriod, resyncJitterFactor)
 }
 
-func (scope *kmcScope) ensureCertificates(ctx context.Context, kmc *km.Cluster) error {
+func (scope *kmcScope) ensureCertificates(ctx context.Context, kmc *km.Cluster) (err error) {
+	ctx, span := tracing.Start(ctx, "ensureCertificates", tracing.Cluster(kmc.Name))
+	defer func() { tracing.End(span, err) }()
+
 	certificates := secret.NewCertificatesForInitialControlPlane(&bootstrapv1.ClusterConfiguration{})
-	err := certificates.LookupOrGenerateCached(ctx, scope.secretCachingClient, scope.client, util.ObjectKey(kmc), *metav1.NewControllerRef(kmc, km.GroupVersion.WithKind("Cluster")))
+	err = certificates.LookupOrGenerateCached(ctx, scope.secretCachingClient, scope.client, util.ObjectKey(kmc), *metav1.NewControllerRef(kmc, km.GroupVersion.WithKind("Cluster")))
 	if err != nil {
 		return fmt.Errorf("error generating cluster certificates: %w", err)
 	}
 
-	return nil
+	// The certificates restored from a backup are owned by the Cluster again, see kutil.RestoredAnnotation
+	names := make([]string, 0, len(certificates)+len(etcdCertificatePurposes))
+	for _, c := range certificates {
+		names = append(names, secret.Name(kmc.Name, c.Purpose))
+	}
+	for _, purpose := range etcdCertificatePurposes {
+		names = append(names, secret.Name(kmc.Name, purpose))
+	}
+	return kutil.ReadoptSecrets(ctx, scope.secretCachingClient, kmc, km.GroupVersion.WithKind("Cluster"), names...)
 }
 
 func (r *ClusterReconciler) getKmcScope(ctx context.Context, kmc *km.Cluster) (*kmcScope, error) {
@@ -333,6 +358,7 @@ func (r *ClusterReconciler) getKmcScope(ctx context.Context, kmc *km.Cluster) (*
 		clienSet:            r.ClientSet,
 		restConfig:          r.RESTConfig,
 		secretCachingClient: r.SecretCachingClient,
+		recorder:            r.Recorder,
 	}
 
 	if kmc.Spec.KubeconfigRef != nil {
@@ -408,8 +434,21 @@ func (scope *kmcScope) getDescendants(ctx context.Context, kmc *km.Cluster) []cl
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	metrics.RegisterObjects("Cluster", mgr.GetClient(), func() client.ObjectList { return &km.ClusterList{} }, metrics.ClusterNameFromName)
+
+	if err := health.AddChecks(mgr, "k0smotroncluster", &km.Cluster{}); err != nil {
+		return err
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&km.Cluster{}).
 		Owns(&apps.StatefulSet{}).
-		Complete(r)
+		Owns(&v1.Service{}).
+		Owns(&batchv1.CronJob{}).
+		// Watching the metadata is enough to notice the changes, and keeps the data of the config maps and secrets out of the cache.
+		Owns(&v1.ConfigMap{}, builder.OnlyMetadata)
+	if r.WatchSecrets {
+		b = b.Owns(&v1.Secret{}, builder.OnlyMetadata)
+	}
+	return b.Complete(metrics.Instrument("k0smotroncluster", mgr.GetClient(), func() client.Object { return &km.Cluster{} }, metrics.ClusterNameFromName, tracing.Instrument("k0smotroncluster", health.Instrument("k0smotroncluster", r))))
 }
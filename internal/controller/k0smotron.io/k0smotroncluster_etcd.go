@@ -37,11 +37,13 @@ import (
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var etcdEntrypointScriptTmpl *template.Template
 
+// etcdUID is the user and group etcd runs as.
+const etcdUID = int64(1001)
+
 func init() {
 	etcdEntrypointScriptTmpl = template.Must(template.New("entrypoint.sh").Parse(etcdEntrypointScriptTemplate))
 }
@@ -102,7 +104,7 @@ func (scope *kmcScope) reconcileEtcdSvc(ctx context.Context, kmc *km.Cluster) er
 
 	_ = ctrl.SetControllerReference(kmc, &svc, scope.client.Scheme())
 
-	return scope.client.Patch(ctx, &svc, client.Apply, patchOpts...)
+	return kcontrollerutil.Apply(ctx, scope.client, &svc)
 }
 
 func (scope *kmcScope) reconcileEtcdDefragJob(ctx context.Context, kmc *km.Cluster) error {
@@ -129,12 +131,14 @@ func (scope *kmcScope) reconcileEtcdDefragJob(ctx context.Context, kmc *km.Clust
 							Labels: labels,
 						},
 						Spec: v1.PodSpec{
-							RestartPolicy: v1.RestartPolicyOnFailure,
+							RestartPolicy:   v1.RestartPolicyOnFailure,
+							SecurityContext: kcontrollerutil.RestrictedPodSecurityContext(kcontrollerutil.NonRootUID),
 							Containers: []v1.Container{
 								{
 									Name:            "etcd-defrag",
 									Image:           kmc.Spec.Etcd.DefragJob.Image,
 									ImagePullPolicy: v1.PullIfNotPresent,
+									SecurityContext: kcontrollerutil.RestrictedSecurityContext(),
 									Args: []string{
 										fmt.Sprintf("--endpoints=https://%s:2379", kmc.GetEtcdServiceName()),
 										"--cacert=/var/lib/k0s/pki/etcd/ca.crt",
@@ -184,7 +188,7 @@ func (scope *kmcScope) reconcileEtcdDefragJob(ctx context.Context, kmc *km.Clust
 
 	_ = ctrl.SetControllerReference(kmc, &cronJob, scope.client.Scheme())
 
-	return scope.client.Patch(ctx, &cronJob, client.Apply, patchOpts...)
+	return kcontrollerutil.Apply(ctx, scope.client, &cronJob)
 }
 
 func (scope *kmcScope) reconcileEtcdStatefulSet(ctx context.Context, kmc *km.Cluster) error {
@@ -215,7 +219,7 @@ func (scope *kmcScope) reconcileEtcdStatefulSet(ctx context.Context, kmc *km.Clu
 
 	_ = ctrl.SetControllerReference(kmc, &statefulSet, scope.client.Scheme())
 
-	return scope.client.Patch(ctx, &statefulSet, client.Apply, patchOpts...)
+	return kcontrollerutil.Apply(ctx, scope.client, &statefulSet)
 }
 
 func generateEtcdStatefulSet(kmc *km.Cluster, existingSts *apps.StatefulSet, replicas int32) apps.StatefulSet {
@@ -330,18 +334,18 @@ func generateEtcdStatefulSet(kmc *km.Cluster, existingSts *apps.StatefulSet, rep
 							},
 						},
 					}},
-					SecurityContext: &v1.PodSecurityContext{
-						FSGroup: ptr.To(int64(1001)),
-					},
-					InitContainers: generateEtcdInitContainers(kmc, existingSts),
+					SecurityContext: etcdPodSecurityContext(),
+					InitContainers:  generateEtcdInitContainers(kmc, existingSts),
 					Containers: []v1.Container{{
 						Name:            "etcd",
 						Image:           kmc.Spec.Etcd.Image,
 						ImagePullPolicy: v1.PullIfNotPresent,
+						SecurityContext: kcontrollerutil.RestrictedSecurityContext(),
 						Command:         []string{"/bin/bash"},
 						Args:            []string{"-c", etcdEntrypointScriptBuf.String()},
 						Env: []v1.EnvVar{
 							{Name: "SVC_NAME", Value: kmc.GetEtcdServiceName()},
+							{Name: "POD_IP", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "status.podIP"}}},
 							{Name: "ETCDCTL_ENDPOINTS", Value: fmt.Sprintf("https://%s:2379", kmc.GetEtcdServiceName())},
 							{Name: "ETCDCTL_CACERT", Value: "/var/lib/k0s/pki/etcd/ca.crt"},
 							{Name: "ETCDCTL_CERT", Value: "/var/lib/k0s/pki/etcd/server.crt"},
@@ -396,6 +400,13 @@ func generateEtcdStatefulSet(kmc *km.Cluster, existingSts *apps.StatefulSet, rep
 	return statefulSet
 }
 
+// etcdPodSecurityContext runs etcd as the owner group of its data volume.
+func etcdPodSecurityContext() *v1.PodSecurityContext {
+	sc := kcontrollerutil.RestrictedPodSecurityContext(etcdUID)
+	sc.FSGroup = ptr.To(etcdUID)
+	return sc
+}
+
 func initialCluster(kmc *km.Cluster, replicas int32) string {
 	var members []string
 	stsName := kmc.GetEtcdStatefulSetName()
@@ -423,8 +434,9 @@ func generateEtcdInitContainers(kmc *km.Cluster, existingSts *apps.StatefulSet)
 			Name:            "dns-check",
 			Image:           checkImage,
 			ImagePullPolicy: v1.PullIfNotPresent,
+			SecurityContext: kcontrollerutil.RestrictedSecurityContext(),
 			Command:         []string{"/bin/sh", "-c"},
-			Args:            []string{"getent ahostsv4 ${HOSTNAME}.${SVC_NAME}." + kmc.Namespace + ".svc"},
+			Args:            []string{"getent ahosts ${HOSTNAME}.${SVC_NAME}." + kmc.Namespace + ".svc"},
 			Env: []v1.EnvVar{
 				{Name: "SVC_NAME", Value: kmc.GetEtcdServiceName()},
 			},
@@ -433,6 +445,7 @@ func generateEtcdInitContainers(kmc *km.Cluster, existingSts *apps.StatefulSet)
 			Name:            "init",
 			Image:           kmc.Spec.Etcd.Image,
 			ImagePullPolicy: v1.PullIfNotPresent,
+			SecurityContext: kcontrollerutil.RestrictedSecurityContext(),
 			Command:         []string{"/bin/bash"},
 			Args:            []string{"-c", initEntryScript},
 			Env: []v1.EnvVar{
@@ -475,9 +488,15 @@ if [[ -f /var/lib/k0s/etcd/existing ]]; then
   export ETCD_INITIAL_CLUSTER_STATE="existing"
 fi
 
+# Listen on IPv6 on IPv6 single-stack clusters
+LISTEN_HOST="0.0.0.0"
+if [[ "${POD_IP}" == *:* ]]; then
+  LISTEN_HOST="[::]"
+fi
+
 etcd --name ${HOSTNAME} \
-  --listen-peer-urls=https://0.0.0.0:2380 \
-  --listen-client-urls=https://0.0.0.0:2379 \
+  --listen-peer-urls=https://${LISTEN_HOST}:2380 \
+  --listen-client-urls=https://${LISTEN_HOST}:2379 \
   --advertise-client-urls=https://${HOSTNAME}.${SVC_NAME}:2379 \
   --initial-advertise-peer-urls=https://${HOSTNAME}.${SVC_NAME}:2380 \
   --client-cert-auth=true \
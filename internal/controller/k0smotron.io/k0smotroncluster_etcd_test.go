@@ -161,3 +161,17 @@ func TestEtcd_generateEtcdStatefulSet(t *testing.T) {
 		})
 	}
 }
+
+func TestEtcd_securityContext(t *testing.T) {
+	sts := generateEtcdStatefulSet(&km.Cluster{}, nil, 1)
+	podSpec := sts.Spec.Template.Spec
+
+	assert.True(t, *podSpec.SecurityContext.RunAsNonRoot)
+	assert.Equal(t, etcdUID, *podSpec.SecurityContext.RunAsUser)
+	assert.Equal(t, etcdUID, *podSpec.SecurityContext.FSGroup)
+	assert.Equal(t, corev1.SeccompProfileTypeRuntimeDefault, podSpec.SecurityContext.SeccompProfile.Type)
+	for _, c := range append(podSpec.InitContainers, podSpec.Containers...) {
+		assert.False(t, *c.SecurityContext.AllowPrivilegeEscalation, c.Name)
+		assert.Equal(t, []corev1.Capability{"ALL"}, c.SecurityContext.Capabilities.Drop, c.Name)
+	}
+}
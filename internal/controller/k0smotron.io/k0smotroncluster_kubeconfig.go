@@ -76,7 +76,7 @@ func (scope *kmcScope) reconcileKubeConfigSecret(ctx context.Context, management
 
 	_ = ctrl.SetControllerReference(kmc, &secret, managementClusterClient.Scheme())
 
-	return managementClusterClient.Patch(ctx, &secret, client.Apply, patchOpts...)
+	return kcontrollerutil.Apply(ctx, managementClusterClient, &secret)
 }
 
 func rewriteKubeconfigNames(kubeconfigYAML string, clusterName string) (string, error) {
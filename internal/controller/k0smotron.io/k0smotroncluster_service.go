@@ -138,7 +138,7 @@ func (scope *kmcScope) reconcileServices(ctx context.Context, kmc *km.Cluster) e
 
 	_ = ctrl.SetControllerReference(kmc, &svc, scope.client.Scheme())
 
-	if err := scope.client.Patch(ctx, &svc, client.Apply, patchOpts...); err != nil {
+	if err := util.Apply(ctx, scope.client, &svc); err != nil {
 		return err
 	}
 	// Wait for LB address to be available
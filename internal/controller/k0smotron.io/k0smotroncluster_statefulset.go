@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
 	km "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
@@ -35,7 +36,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubernetes/pkg/controller"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var entrypointDefaultMode = int32(0744)
@@ -79,6 +79,11 @@ func (scope *kmcScope) generateStatefulSet(kmc *km.Cluster) (apps.StatefulSet, e
 				},
 				Spec: v1.PodSpec{
 					AutomountServiceAccountToken: ptr.To(false),
+					// k0s runs as root to run the control plane components as their own users, so the pod complies
+					// with the baseline Pod Security Standard only.
+					SecurityContext: &v1.PodSecurityContext{
+						SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+					},
 					Affinity: &v1.Affinity{PodAntiAffinity: &v1.PodAntiAffinity{
 						PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{
 							{
@@ -286,7 +291,7 @@ data:
 	}
 	_ = ctrl.SetControllerReference(kmc, cm, scope.client.Scheme())
 
-	if err := scope.client.Patch(context.Background(), cm, client.Apply, patchOpts...); err != nil {
+	if err := util.Apply(context.Background(), scope.client, cm); err != nil {
 		return apps.StatefulSet{}, err
 	}
 	statefulSet.Spec.Template.Spec.Volumes = append(statefulSet.Spec.Template.Spec.Volumes, v1.Volume{
@@ -556,22 +561,35 @@ func (scope *kmcScope) reconcileStatefulSet(ctx context.Context, kmc *km.Cluster
 	foundStatefulSet, err := scope.clienSet.AppsV1().StatefulSets(statefulSet.Namespace).Get(ctx, statefulSet.Name, metav1.GetOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
 		kmc.Status.Replicas = 0
-		return scope.client.Patch(ctx, &statefulSet, client.Apply, patchOpts...)
+		if err := util.Apply(ctx, scope.client, &statefulSet); err != nil {
+			return err
+		}
+		scope.recorder.Eventf(kmc, v1.EventTypeNormal, "StatefulSetCreated", "Created the statefulset %s", statefulSet.Name)
+		return nil
 	} else if err == nil {
 		detectAndSetCurrentClusterVersion(foundStatefulSet, kmc)
 
 		if !isStatefulSetsEqual(&statefulSet, foundStatefulSet) {
-			return scope.client.Patch(ctx, &statefulSet, client.Apply, patchOpts...)
+			if err := util.Apply(ctx, scope.client, &statefulSet); err != nil {
+				return err
+			}
+			recordStatefulSetOperations(kmc, foundStatefulSet, &statefulSet)
+			scope.recorder.Eventf(kmc, v1.EventTypeNormal, "StatefulSetUpdated", "Updated the statefulset %s", statefulSet.Name)
+			return nil
 		}
 
 		kmc.Status.Replicas = foundStatefulSet.Status.Replicas
 		if foundStatefulSet.Status.ReadyReplicas == kmc.Spec.Replicas {
 			kmc.Status.Ready = true
+			if foundStatefulSet.Status.UpdatedReplicas == kmc.Spec.Replicas {
+				kmc.Status.Operations = km.CompleteOperation(kmc.Status.Operations, km.OperationScale, km.OperationSucceeded, "")
+				kmc.Status.Operations = km.CompleteOperation(kmc.Status.Operations, km.OperationUpgrade, km.OperationSucceeded, "")
+			}
 		}
 	}
 
 	if !isStatefulSetsEqual(&statefulSet, foundStatefulSet) {
-		return scope.client.Patch(ctx, &statefulSet, client.Apply, patchOpts...)
+		return util.Apply(ctx, scope.client, &statefulSet)
 	}
 
 	if foundStatefulSet.Status.ReadyReplicas == 0 {
@@ -582,6 +600,22 @@ func (scope *kmcScope) reconcileStatefulSet(ctx context.Context, kmc *km.Cluster
 	return nil
 }
 
+// recordStatefulSetOperations records the scale and upgrade operations started by an update of the statefulset.
+func recordStatefulSetOperations(kmc *km.Cluster, found, desired *apps.StatefulSet) {
+	if found.Spec.Replicas != nil && desired.Spec.Replicas != nil && *found.Spec.Replicas != *desired.Spec.Replicas {
+		kmc.Status.Operations = km.StartOperation(kmc.Status.Operations, km.OperationScale, operationActor,
+			strconv.Itoa(int(*desired.Spec.Replicas)),
+			fmt.Sprintf("Scaling from %d to %d replicas", *found.Spec.Replicas, *desired.Spec.Replicas))
+	}
+
+	foundImage := found.Spec.Template.Spec.Containers[0].Image
+	desiredImage := desired.Spec.Template.Spec.Containers[0].Image
+	if foundImage != desiredImage {
+		kmc.Status.Operations = km.StartOperation(kmc.Status.Operations, km.OperationUpgrade, operationActor, desiredImage,
+			fmt.Sprintf("Upgrading from %s to %s", foundImage, desiredImage))
+	}
+}
+
 // If the version is empty from the spec, we try to detect it from the statefulset image.
 func detectAndSetCurrentClusterVersion(foundStatefulSet *apps.StatefulSet, kmc *km.Cluster) {
 	if kmc.Spec.Version == "" {
@@ -0,0 +1,49 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// TransformCachedObject returns the transform applied to the objects before they are stored in the manager cache.
+// The managed fields of all the objects are dropped. The pods and statefulsets are only watched, or listed to compute
+// the status of the control planes, so their pod specs, which account for most of their size, are dropped as well.
+// The statefulsets compared to their desired state are read from the API server.
+func TransformCachedObject() toolscache.TransformFunc {
+	stripManagedFields := cache.TransformStripManagedFields()
+	return func(in any) (any, error) {
+		obj, err := stripManagedFields(in)
+		if err != nil {
+			return obj, err
+		}
+
+		switch o := obj.(type) {
+		case *corev1.Pod:
+			o.Spec = corev1.PodSpec{NodeName: o.Spec.NodeName}
+			o.Status.InitContainerStatuses = nil
+			o.Status.ContainerStatuses = nil
+			o.Status.EphemeralContainerStatuses = nil
+		case *apps.StatefulSet:
+			o.Spec.Template.Spec = corev1.PodSpec{}
+		}
+		return obj, nil
+	}
+}
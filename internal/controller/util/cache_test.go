@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestTransformCachedObject(t *testing.T) {
+	transform := TransformCachedObject()
+	meta := metav1.ObjectMeta{
+		Name:          "kmc-test-0",
+		Labels:        map[string]string{"app": "k0smotron"},
+		ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "k0smotron"}},
+	}
+
+	t.Run("pod", func(t *testing.T) {
+		pod := &corev1.Pod{
+			ObjectMeta: *meta.DeepCopy(),
+			Spec: corev1.PodSpec{
+				NodeName:   "node",
+				Containers: []corev1.Container{{Name: "controller", Image: "k0sproject/k0s"}},
+			},
+			Status: corev1.PodStatus{
+				Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "controller"}},
+			},
+		}
+		out, err := transform(pod)
+		require.NoError(t, err)
+		assert.Equal(t, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "kmc-test-0", Labels: map[string]string{"app": "k0smotron"}},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}, out)
+	})
+
+	t.Run("statefulset", func(t *testing.T) {
+		sts := &apps.StatefulSet{
+			ObjectMeta: *meta.DeepCopy(),
+			Spec: apps.StatefulSetSpec{
+				Replicas: ptr.To[int32](3),
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "k0smotron"}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "controller"}}},
+				},
+			},
+			Status: apps.StatefulSetStatus{ReadyReplicas: 2},
+		}
+		out, err := transform(sts)
+		require.NoError(t, err)
+		assert.Equal(t, &apps.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "kmc-test-0", Labels: map[string]string{"app": "k0smotron"}},
+			Spec: apps.StatefulSetSpec{
+				Replicas: ptr.To[int32](3),
+				Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "k0smotron"}}},
+			},
+			Status: apps.StatefulSetStatus{ReadyReplicas: 2},
+		}, out)
+	})
+
+	t.Run("other objects", func(t *testing.T) {
+		svc := &corev1.Service{ObjectMeta: *meta.DeepCopy(), Spec: corev1.ServiceSpec{ClusterIP: "10.96.0.10"}}
+		out, err := transform(svc)
+		require.NoError(t, err)
+		assert.Equal(t, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "kmc-test-0", Labels: map[string]string{"app": "k0smotron"}},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.10"},
+		}, out)
+	})
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+const (
+	// MachineControlPlaneField indexes the Machines by the name of the K0sControlPlane controlling them.
+	MachineControlPlaneField = "machine.controlPlane"
+	// ControllerConfigMachineField indexes the K0sControllerConfigs by the name of the Machine controlling them.
+	ControllerConfigMachineField = "k0sControllerConfig.machine"
+	// PooledMachineReservationField indexes the PooledRemoteMachines by the namespace/name of the RemoteMachine
+	// holding their reservation.
+	PooledMachineReservationField = "pooledRemoteMachine.reservation"
+)
+
+// AddIndexes registers the cache indexes the controllers use to look up the objects of a cluster without listing
+// all the objects of the namespace. It requires the Cluster API types to be installed.
+func AddIndexes(ctx context.Context, mgr ctrl.Manager) error {
+	indexer := mgr.GetFieldIndexer()
+
+	err := indexer.IndexField(ctx, &clusterv1.Machine{}, MachineControlPlaneField, controllerNameIndexFunc(cpv1beta1.GroupVersion.WithKind("K0sControlPlane").GroupKind()))
+	if err != nil {
+		return fmt.Errorf("failed to index machines by control plane: %w", err)
+	}
+
+	err = indexer.IndexField(ctx, &bootstrapv1.K0sControllerConfig{}, ControllerConfigMachineField, controllerNameIndexFunc(clusterv1.GroupVersion.WithKind("Machine").GroupKind()))
+	if err != nil {
+		return fmt.Errorf("failed to index K0sControllerConfigs by machine: %w", err)
+	}
+
+	return nil
+}
+
+// AddInfrastructureIndexes registers the cache indexes of the infrastructure controllers. It requires the
+// infrastructure provider types to be installed, which the other providers don't install.
+func AddInfrastructureIndexes(ctx context.Context, mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(ctx, &infrastructure.PooledRemoteMachine{}, PooledMachineReservationField, PooledMachineReservationIndexFunc)
+	if err != nil {
+		return fmt.Errorf("failed to index PooledRemoteMachines by reservation: %w", err)
+	}
+	return nil
+}
+
+// controllerNameIndexFunc indexes the objects by the name of their controller, if it is of the given kind.
+func controllerNameIndexFunc(gk schema.GroupKind) client.IndexerFunc {
+	return func(obj client.Object) []string {
+		ref := metav1.GetControllerOf(obj)
+		if ref == nil || ref.Kind != gk.Kind {
+			return nil
+		}
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil || gv.Group != gk.Group {
+			return nil
+		}
+		return []string{ref.Name}
+	}
+}
+
+// PooledMachineReservationIndexFunc indexes the reserved PooledRemoteMachines by the namespace/name of the
+// RemoteMachine holding their reservation. The RemoteMachine may be in another namespace than the pool.
+func PooledMachineReservationIndexFunc(obj client.Object) []string {
+	pm, ok := obj.(*infrastructure.PooledRemoteMachine)
+	if !ok || !pm.Status.Reserved || pm.Status.MachineRef.Name == "" {
+		return nil
+	}
+	namespace := pm.Status.MachineRef.Namespace
+	if namespace == "" {
+		namespace = pm.Namespace
+	}
+	return []string{types.NamespacedName{Namespace: namespace, Name: pm.Status.MachineRef.Name}.String()}
+}
+
+// GetControlPlaneMachines returns the machines controlled by the K0sControlPlane which match the filters.
+func GetControlPlaneMachines(ctx context.Context, c client.Reader, kcp *cpv1beta1.K0sControlPlane, filters ...collections.Func) (collections.Machines, error) {
+	ml := &clusterv1.MachineList{}
+	err := c.List(ctx, ml, client.InNamespace(kcp.Namespace), client.MatchingFields{MachineControlPlaneField: kcp.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	return collections.FromMachineList(ml).Filter(filters...), nil
+}
+
+// GetControllerConfigForMachine returns the K0sControllerConfig controlled by the machine, or nil if there is none.
+func GetControllerConfigForMachine(ctx context.Context, c client.Reader, machine *clusterv1.Machine) (*bootstrapv1.K0sControllerConfig, error) {
+	configs := &bootstrapv1.K0sControllerConfigList{}
+	err := c.List(ctx, configs, client.InNamespace(machine.Namespace), client.MatchingFields{ControllerConfigMachineField: machine.Name})
+	if err != nil {
+		return nil, err
+	}
+	if len(configs.Items) == 0 {
+		return nil, nil
+	}
+
+	return &configs.Items[0], nil
+}
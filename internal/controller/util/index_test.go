@@ -0,0 +1,122 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+	infrastructure "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
+)
+
+func TestControllerNameIndexFunc(t *testing.T) {
+	indexFunc := controllerNameIndexFunc(cpv1beta1.GroupVersion.WithKind("K0sControlPlane").GroupKind())
+
+	tests := []struct {
+		name string
+		refs []metav1.OwnerReference
+		want []string
+	}{
+		{
+			name: "no owner",
+		},
+		{
+			name: "controlled by the kind",
+			refs: []metav1.OwnerReference{{APIVersion: "controlplane.cluster.x-k8s.io/v1beta1", Kind: "K0sControlPlane", Name: "kcp", Controller: ptr.To(true)}},
+			want: []string{"kcp"},
+		},
+		{
+			name: "owned but not controlled by the kind",
+			refs: []metav1.OwnerReference{{APIVersion: "controlplane.cluster.x-k8s.io/v1beta1", Kind: "K0sControlPlane", Name: "kcp"}},
+		},
+		{
+			name: "controlled by the same kind of another group",
+			refs: []metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "K0sControlPlane", Name: "kcp", Controller: ptr.To(true)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{OwnerReferences: tt.refs}}
+			assert.Equal(t, tt.want, indexFunc(m))
+		})
+	}
+}
+
+func TestGetControlPlaneMachines(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clusterv1.AddToScheme(scheme))
+	require.NoError(t, cpv1beta1.AddToScheme(scheme))
+
+	kcp := &cpv1beta1.K0sControlPlane{ObjectMeta: metav1.ObjectMeta{Name: "kcp", Namespace: "default"}}
+	machine := func(name, namespace, owner string) *clusterv1.Machine {
+		return &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: cpv1beta1.GroupVersion.String(),
+				Kind:       "K0sControlPlane",
+				Name:       owner,
+				Controller: ptr.To(true),
+			}},
+		}}
+	}
+	deleted := machine("kcp-deleted", "default", "kcp")
+	deleted.DeletionTimestamp = ptr.To(metav1.Now())
+	deleted.Finalizers = []string{"test"}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&clusterv1.Machine{}, MachineControlPlaneField, controllerNameIndexFunc(cpv1beta1.GroupVersion.WithKind("K0sControlPlane").GroupKind())).
+		WithObjects(
+			machine("kcp-0", "default", "kcp"),
+			deleted,
+			machine("other-0", "default", "other"),
+			machine("kcp-0", "other", "kcp"),
+		).
+		Build()
+
+	machines, err := GetControlPlaneMachines(context.Background(), c, kcp)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"kcp-0", "kcp-deleted"}, machines.Names())
+
+	machines, err = GetControlPlaneMachines(context.Background(), c, kcp, collections.ActiveMachines)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"kcp-0"}, machines.Names())
+}
+
+func TestPooledMachineReservationIndexFunc(t *testing.T) {
+	pm := func(reserved bool, ref infrastructure.RemoteMachineRef) *infrastructure.PooledRemoteMachine {
+		return &infrastructure.PooledRemoteMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "pm", Namespace: "pool"},
+			Status:     infrastructure.PooledRemoteMachineStatus{Reserved: reserved, MachineRef: ref},
+		}
+	}
+
+	assert.Nil(t, PooledMachineReservationIndexFunc(pm(false, infrastructure.RemoteMachineRef{})))
+	assert.Equal(t, []string{"team-a/rm"}, PooledMachineReservationIndexFunc(pm(true, infrastructure.RemoteMachineRef{Name: "rm", Namespace: "team-a"})))
+	assert.Equal(t, []string{"pool/rm"}, PooledMachineReservationIndexFunc(pm(true, infrastructure.RemoteMachineRef{Name: "rm"})))
+}
@@ -14,6 +14,8 @@ import (
 	capiutil "sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k0sproject/k0smotron/internal/tracing"
 )
 
 func GetKubeClient(ctx context.Context, client client.Client, cluster *clusterv1.Cluster) (*kubernetes.Clientset, error) {
@@ -53,6 +55,8 @@ func GetKubeClient(ctx context.Context, client client.Client, cluster *clusterv1
 		ExpectContinueTimeout: 1 * time.Second,
 		TLSClientConfig:       tlsCfg,
 	}
+	// Trace the requests to the workload cluster API
+	cl.Transport = tracing.Transport(cl.Transport)
 
 	return kubernetes.NewForConfigAndClient(restConfig, cl)
 }
@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"k8s.io/kubernetes/cmd/kubeadm/app/constants"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// DefaultKubernetesServiceIP is the IP of the kubernetes service of the clusters which don't set a service CIDR.
+const DefaultKubernetesServiceIP = "10.96.0.1"
+
+// SplitDualStackCIDRs returns the primary CIDR block of a cluster network range, and the IPv6 one of a dual-stack
+// range. k0s only supports dual-stack clusters with IPv4 as the primary family, so the IPv4 block is primary whatever
+// the order of the blocks. The block of a single-stack range is primary, whatever its family.
+func SplitDualStackCIDRs(ranges *clusterv1.NetworkRanges) (primary string, ipv6 string, err error) {
+	if ranges == nil {
+		return "", "", nil
+	}
+
+	var v4, v6 []string
+	for _, block := range ranges.CIDRBlocks {
+		ip, _, err := net.ParseCIDR(block)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid CIDR block %q: %w", block, err)
+		}
+		if ip.To4() != nil {
+			v4 = append(v4, block)
+		} else {
+			v6 = append(v6, block)
+		}
+	}
+
+	switch {
+	case len(v4) > 1 || len(v6) > 1:
+		return "", "", fmt.Errorf("at most one CIDR block per IP family is supported, got %s", ranges.String())
+	case len(v4) == 1 && len(v6) == 1:
+		return v4[0], v6[0], nil
+	case len(v4) == 1:
+		return v4[0], "", nil
+	case len(v6) == 1:
+		return v6[0], "", nil
+	}
+	return "", "", nil
+}
+
+// KubernetesServiceIP returns the IP of the kubernetes service of the cluster, the first IP of its primary service
+// CIDR block.
+func KubernetesServiceIP(cluster *clusterv1.Cluster) (string, error) {
+	if cluster.Spec.ClusterNetwork == nil {
+		return DefaultKubernetesServiceIP, nil
+	}
+	primary, _, err := SplitDualStackCIDRs(cluster.Spec.ClusterNetwork.Services)
+	if err != nil {
+		return "", err
+	}
+	if primary == "" {
+		return DefaultKubernetesServiceIP, nil
+	}
+	ip, err := constants.GetAPIServerVirtualIP(primary)
+	if err != nil {
+		return "", err
+	}
+	return ip.String(), nil
+}
+
+// SANHost returns the host of an endpoint as expected in a certificate SAN, i.e. an IPv6 address without the
+// brackets it is written with in URLs.
+func SANHost(host string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+}
+
+// EndpointURL returns the HTTPS URL of an endpoint, enclosing IPv6 addresses in brackets.
+func EndpointURL(host string, port string) string {
+	return "https://" + net.JoinHostPort(SANHost(host), port)
+}
+
+// IsIPv6 reports whether the host of an endpoint is an IPv6 address, with or without brackets.
+func IsIPv6(host string) bool {
+	ip := net.ParseIP(SANHost(host))
+	return ip != nil && ip.To4() == nil
+}
+
+// DefaultClusterDomain is the DNS domain of the clusters which don't set one.
+const DefaultClusterDomain = "cluster.local"
+
+// ClusterDomainSANs returns the API server certificate SANs of the kubernetes service FQDN in a custom cluster domain.
+// It returns nil for the default domain, so the configs of the clusters using it are left unchanged.
+func ClusterDomainSANs(clusterDomain string) []string {
+	if clusterDomain == "" || clusterDomain == DefaultClusterDomain {
+		return nil
+	}
+	return []string{"kubernetes.default.svc." + clusterDomain}
+}
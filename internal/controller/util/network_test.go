@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func TestSplitDualStackCIDRs(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  []string
+		primary string
+		ipv6    string
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "ipv4", blocks: []string{"10.96.0.0/12"}, primary: "10.96.0.0/12"},
+		{name: "ipv6 only", blocks: []string{"fd01::/108"}, primary: "fd01::/108"},
+		{name: "dual-stack", blocks: []string{"10.96.0.0/12", "fd01::/108"}, primary: "10.96.0.0/12", ipv6: "fd01::/108"},
+		{name: "dual-stack ipv6 first", blocks: []string{"fd01::/108", "10.96.0.0/12"}, primary: "10.96.0.0/12", ipv6: "fd01::/108"},
+		{name: "same family", blocks: []string{"10.96.0.0/12", "10.112.0.0/12"}, wantErr: true},
+		{name: "invalid", blocks: []string{"10.96.0.0"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, ipv6, err := SplitDualStackCIDRs(&clusterv1.NetworkRanges{CIDRBlocks: tt.blocks})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.primary, primary)
+			assert.Equal(t, tt.ipv6, ipv6)
+		})
+	}
+}
+
+func TestKubernetesServiceIP(t *testing.T) {
+	ip, err := KubernetesServiceIP(&clusterv1.Cluster{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultKubernetesServiceIP, ip)
+
+	ip, err = KubernetesServiceIP(&clusterv1.Cluster{Spec: clusterv1.ClusterSpec{ClusterNetwork: &clusterv1.ClusterNetwork{
+		Services: &clusterv1.NetworkRanges{CIDRBlocks: []string{"fd01::/108", "10.128.0.0/12"}},
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, "10.128.0.1", ip)
+
+	ip, err = KubernetesServiceIP(&clusterv1.Cluster{Spec: clusterv1.ClusterSpec{ClusterNetwork: &clusterv1.ClusterNetwork{
+		Services: &clusterv1.NetworkRanges{CIDRBlocks: []string{"fd01::/108"}},
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, "fd01::1", ip)
+}
+
+func TestEndpointURL(t *testing.T) {
+	assert.Equal(t, "https://10.0.0.1:6443", EndpointURL("10.0.0.1", "6443"))
+	assert.Equal(t, "https://[fd00::1]:6443", EndpointURL("fd00::1", "6443"))
+	assert.Equal(t, "https://[fd00::1]:6443", EndpointURL("[fd00::1]", "6443"))
+	assert.Equal(t, "fd00::1", SANHost("[fd00::1]"))
+}
+
+func TestIsIPv6(t *testing.T) {
+	assert.False(t, IsIPv6("10.0.0.1"))
+	assert.False(t, IsIPv6("example.com"))
+	assert.False(t, IsIPv6("::ffff:10.0.0.1"))
+	assert.True(t, IsIPv6("fd00::1"))
+	assert.True(t, IsIPv6("[fd00::1]"))
+}
+
+func TestClusterDomainSANs(t *testing.T) {
+	assert.Nil(t, ClusterDomainSANs(""))
+	assert.Nil(t, ClusterDomainSANs("cluster.local"))
+	assert.Equal(t, []string{"kubernetes.default.svc.example.internal"}, ClusterDomainSANs("example.internal"))
+}
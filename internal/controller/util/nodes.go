@@ -1,29 +1,42 @@
 package util
 
 import (
-	v1 "k8s.io/api/core/v1"
 	"math/rand"
+	"net"
+
+	v1 "k8s.io/api/core/v1"
 )
 
+// MachineNameNodeLabel is the node label the k0s bootstrap provider sets to match a node with its Machine.
+const MachineNameNodeLabel = "k0smotron.io/machine-name"
+
 // FindNodeAddress returns a random node address preferring external address if one is found
 func FindNodeAddress(nodes *v1.NodeList) string {
-	extAddr, intAddr := "", ""
-
 	// Get random node from list
 	node := nodes.Items[rand.Intn(len(nodes.Items))]
 
-	for _, addr := range node.Status.Addresses {
-		if addr.Type == v1.NodeExternalIP {
-			extAddr = addr.Address
-			break
+	return NodeAddress(node, v1.NodeExternalIP, v1.NodeInternalIP)
+}
+
+// NodeAddress returns the first address of the node of the given types, in order of preference. IPv4 addresses are
+// preferred over the IPv6 addresses of the same type, so the IPv6 addresses are only used on IPv6 single-stack nodes.
+func NodeAddress(node v1.Node, types ...v1.NodeAddressType) string {
+	for _, t := range types {
+		var fallback string
+		for _, addr := range node.Status.Addresses {
+			if addr.Type != t {
+				continue
+			}
+			if ip := net.ParseIP(addr.Address); ip == nil || ip.To4() != nil {
+				return addr.Address
+			}
+			if fallback == "" {
+				fallback = addr.Address
+			}
 		}
-		if addr.Type == v1.NodeInternalIP {
-			intAddr = addr.Address
+		if fallback != "" {
+			return fallback
 		}
 	}
-
-	if extAddr != "" {
-		return extAddr
-	}
-	return intAddr
+	return ""
 }
@@ -102,6 +102,50 @@ func TestFindNodeAddress(t *testing.T) {
 			},
 			want: "1.1.1.1",
 		},
+		{
+			name: "when the external address is dual-stack",
+			nodes: &v1.NodeList{
+				Items: []v1.Node{
+					{
+						Status: v1.NodeStatus{
+							Addresses: []v1.NodeAddress{
+								{
+									Type:    v1.NodeExternalIP,
+									Address: "2001:db8::1",
+								},
+								{
+									Type:    v1.NodeExternalIP,
+									Address: "1.1.1.1",
+								},
+							},
+						},
+					},
+				},
+			},
+			want: "1.1.1.1",
+		},
+		{
+			name: "when only IPv6 is set",
+			nodes: &v1.NodeList{
+				Items: []v1.Node{
+					{
+						Status: v1.NodeStatus{
+							Addresses: []v1.NodeAddress{
+								{
+									Type:    v1.NodeInternalIP,
+									Address: "fd00::2",
+								},
+								{
+									Type:    v1.NodeExternalIP,
+									Address: "2001:db8::1",
+								},
+							},
+						},
+					},
+				},
+			},
+			want: "2001:db8::1",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
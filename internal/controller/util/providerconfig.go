@@ -0,0 +1,41 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+)
+
+// GetProviderConfig returns the ProviderConfig of the namespace, or nil if the namespace has none or the
+// ProviderConfig CRD is not installed.
+func GetProviderConfig(ctx context.Context, c client.Reader, namespace string) (*kapi.ProviderConfig, error) {
+	pc := &kapi.ProviderConfig{}
+	err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: kapi.DefaultProviderConfigName}, pc)
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
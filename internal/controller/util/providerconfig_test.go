@@ -0,0 +1,55 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kapi "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+)
+
+func TestGetProviderConfig(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kapi.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&kapi.ProviderConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: kapi.DefaultProviderConfigName, Namespace: "team-a"},
+			Spec:       kapi.ProviderConfigSpec{SSH: &kapi.ProviderConfigSSH{KeyRef: "team-a-ssh"}},
+		},
+		&kapi.ProviderConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-b"},
+			Spec:       kapi.ProviderConfigSpec{SSH: &kapi.ProviderConfigSSH{KeyRef: "team-b-ssh"}},
+		},
+	).Build()
+
+	pc, err := GetProviderConfig(context.Background(), c, "team-a")
+	require.NoError(t, err)
+	require.NotNil(t, pc)
+	assert.Equal(t, "team-a-ssh", pc.Spec.SSH.KeyRef)
+
+	// Only the ProviderConfig named default applies to its namespace
+	pc, err = GetProviderConfig(context.Background(), c, "team-b")
+	require.NoError(t, err)
+	assert.Nil(t, pc)
+}
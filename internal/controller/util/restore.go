@@ -0,0 +1,92 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RestoredAnnotation marks the objects restored from a backup without their owner references, e.g. by a Velero
+// resource modifier, so the controller of their former owner adopts them again.
+const RestoredAnnotation = "k0smotron.io/restored"
+
+// Readopt points the owner references of the object to the owner again after a restore, which gives the owner a new
+// UID. The references to an owner of the same kind and name with another UID are updated, and the object gets the
+// owner as controller if it is annotated with RestoredAnnotation and has no controller. It returns whether the object
+// was changed.
+func Readopt(obj client.Object, owner client.Object, gvk schema.GroupVersionKind) bool {
+	changed := false
+	refs := obj.GetOwnerReferences()
+	for i := range refs {
+		gv, err := schema.ParseGroupVersion(refs[i].APIVersion)
+		if err != nil || gv.Group != gvk.Group || refs[i].Kind != gvk.Kind || refs[i].Name != owner.GetName() {
+			continue
+		}
+		if refs[i].UID != owner.GetUID() {
+			refs[i].APIVersion = gvk.GroupVersion().String()
+			refs[i].UID = owner.GetUID()
+			changed = true
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[RestoredAnnotation]; ok {
+		if metav1.GetControllerOfNoCopy(obj) == nil {
+			refs = append(refs, *metav1.NewControllerRef(owner, gvk))
+		}
+		delete(annotations, RestoredAnnotation)
+		obj.SetAnnotations(annotations)
+		changed = true
+	}
+
+	obj.SetOwnerReferences(refs)
+	return changed
+}
+
+// ReadoptObject readopts the object, see Readopt, and patches it if it changed.
+func ReadoptObject(ctx context.Context, c client.Client, obj client.Object, owner client.Object, gvk schema.GroupVersionKind) error {
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	if !Readopt(obj, owner, gvk) {
+		return nil
+	}
+	if err := c.Patch(ctx, obj, patch); err != nil {
+		return fmt.Errorf("failed to readopt %s %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, client.ObjectKeyFromObject(obj), err)
+	}
+	return nil
+}
+
+// ReadoptSecrets readopts the secrets with the given names in the namespace of the owner. The secrets not found are
+// skipped.
+func ReadoptSecrets(ctx context.Context, c client.Client, owner client.Object, gvk schema.GroupVersionKind, names ...string) error {
+	for _, name := range names {
+		s := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: owner.GetNamespace(), Name: name}, s); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if err := ReadoptObject(ctx, c, s, owner, gvk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
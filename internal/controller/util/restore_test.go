@@ -0,0 +1,69 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
+)
+
+func TestReadopt(t *testing.T) {
+	gvk := cpv1beta1.GroupVersion.WithKind("K0sControlPlane")
+	owner := &cpv1beta1.K0sControlPlane{ObjectMeta: metav1.ObjectMeta{Name: "kcp", Namespace: "default", UID: "new"}}
+
+	t.Run("stale owner reference", func(t *testing.T) {
+		s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: gvk.GroupVersion().String(), Kind: gvk.Kind, Name: "kcp", UID: "old", Controller: ptr.To(true)},
+			{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "other"},
+		}}}
+		assert.True(t, Readopt(s, owner, gvk))
+		assert.Equal(t, "new", string(s.OwnerReferences[0].UID))
+		assert.True(t, *s.OwnerReferences[0].Controller)
+		assert.Equal(t, "other", string(s.OwnerReferences[1].UID))
+
+		assert.False(t, Readopt(s, owner, gvk))
+	})
+
+	t.Run("restored without owner", func(t *testing.T) {
+		s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{RestoredAnnotation: "true"}}}
+		assert.True(t, Readopt(s, owner, gvk))
+		assert.Equal(t, []metav1.OwnerReference{*metav1.NewControllerRef(owner, gvk)}, s.OwnerReferences)
+		assert.NotContains(t, s.Annotations, RestoredAnnotation)
+	})
+
+	t.Run("orphan not restored", func(t *testing.T) {
+		s := &corev1.Secret{}
+		assert.False(t, Readopt(s, owner, gvk))
+		assert.Empty(t, s.OwnerReferences)
+	})
+
+	t.Run("restored with another controller", func(t *testing.T) {
+		s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Annotations:     map[string]string{RestoredAnnotation: "true"},
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "other", Controller: ptr.To(true)}},
+		}}
+		assert.True(t, Readopt(s, owner, gvk))
+		assert.Len(t, s.OwnerReferences, 1)
+		assert.NotContains(t, s.Annotations, RestoredAnnotation)
+	})
+}
@@ -5,11 +5,73 @@ import (
 	"sort"
 
 	km "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// FieldOwner is the field manager k0smotron server-side applies the resources it manages with.
+const FieldOwner = "k0smotron"
+
+// ApplyOptions are the options to server-side apply the resources managed by k0smotron. The ownership of the fields is
+// forced, so fields last written by another field manager, like the ones used by previous versions of k0smotron, are
+// taken over instead of failing with a conflict.
+var ApplyOptions = []client.PatchOption{client.FieldOwner(FieldOwner), client.ForceOwnership}
+
+// ManagedByLabel is set to FieldOwner on the ConfigMaps applied by k0smotron. The ConfigMaps watched by the controllers
+// are limited to the ones with this label.
+const ManagedByLabel = "app.kubernetes.io/managed-by"
+
+// legacyFieldOwners are the field managers previous versions of k0smotron applied the resources with.
+var legacyFieldOwners = sets.New("k0s-bootstrap", "k0smotron-operator")
+
+// Apply server-side applies the object with ApplyOptions, then migrates the managed fields of the object left by
+// previous versions of k0smotron with UpgradeManagedFields. The ConfigMaps are labelled with ManagedByLabel.
+func Apply(ctx context.Context, c client.Client, obj client.Object) error {
+	if _, ok := obj.(*corev1.ConfigMap); ok {
+		labels := map[string]string{}
+		for k, v := range obj.GetLabels() {
+			labels[k] = v
+		}
+		labels[ManagedByLabel] = FieldOwner
+		obj.SetLabels(labels)
+	}
+	if err := c.Patch(ctx, obj, client.Apply, ApplyOptions...); err != nil {
+		return err
+	}
+	return UpgradeManagedFields(ctx, c, obj)
+}
+
+// UpgradeManagedFields moves the fields owned by the field managers of previous versions of k0smotron, and the fields
+// written by k0smotron with updates or merge patches, over to the k0smotron apply field manager. Otherwise, the fields
+// k0smotron no longer sets would stay owned by the old field managers and never be removed. The object must be the
+// latest version read from, or returned by, the API server, as the managed fields aren't kept in the cache.
+func UpgradeManagedFields(ctx context.Context, c client.Client, obj client.Object) error {
+	managers := sets.New(FieldOwner)
+	managedFields := make([]metav1.ManagedFieldsEntry, 0, len(obj.GetManagedFields()))
+	for _, entry := range obj.GetManagedFields() {
+		// The old field managers used apply operations as well, they are upgraded like updates.
+		if legacyFieldOwners.Has(entry.Manager) && entry.Subresource == "" {
+			entry.Operation = metav1.ManagedFieldsOperationUpdate
+			managers.Insert(entry.Manager)
+		}
+		managedFields = append(managedFields, entry)
+	}
+
+	legacy := obj.DeepCopyObject().(client.Object)
+	legacy.SetManagedFields(managedFields)
+	upgradePatch, err := csaupgrade.UpgradeManagedFieldsPatch(legacy, managers, FieldOwner)
+	if err != nil || upgradePatch == nil {
+		return err
+	}
+	return c.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, upgradePatch))
+}
+
 func DefaultK0smotronClusterLabels(kmc *km.Cluster) map[string]string {
 	return map[string]string{
 		"app":     "k0smotron",
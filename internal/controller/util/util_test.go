@@ -0,0 +1,69 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpgradeManagedFields(t *testing.T) {
+	entry := func(manager string, operation metav1.ManagedFieldsOperationType, fields string) metav1.ManagedFieldsEntry {
+		return metav1.ManagedFieldsEntry{
+			Manager:    manager,
+			Operation:  operation,
+			APIVersion: "v1",
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(fields)},
+		}
+	}
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      "kmc-test-config",
+		Namespace: "default",
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			entry(FieldOwner, metav1.ManagedFieldsOperationApply, `{"f:data":{"f:K0SMOTRON_K0S_YAML":{}}}`),
+			entry("k0smotron-operator", metav1.ManagedFieldsOperationApply, `{"f:data":{"f:K0SMOTRON_K0S_YAML":{},"f:LEGACY":{}}}`),
+			entry("kubectl", metav1.ManagedFieldsOperationUpdate, `{"f:metadata":{"f:labels":{"f:team":{}}}}`),
+		},
+	}}
+	c := fake.NewClientBuilder().WithObjects(cm).Build()
+
+	require.NoError(t, UpgradeManagedFields(context.Background(), c, cm))
+
+	got := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(cm), got))
+	var managers []string
+	for _, e := range got.ManagedFields {
+		managers = append(managers, e.Manager)
+		if e.Manager == FieldOwner {
+			assert.Equal(t, metav1.ManagedFieldsOperationApply, e.Operation)
+			assert.JSONEq(t, `{"f:data":{"f:K0SMOTRON_K0S_YAML":{},"f:LEGACY":{}}}`, string(e.FieldsV1.Raw))
+		}
+	}
+	assert.ElementsMatch(t, []string{FieldOwner, "kubectl"}, managers)
+
+	// Nothing is left to upgrade.
+	require.NoError(t, UpgradeManagedFields(context.Background(), c, got))
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package health provides the per-controller health and readiness checks of the k0smotron manager. Each controller
+// gets its own check on the healthz and readyz endpoints, so a single wedged controller can be told apart from the
+// others with e.g. /healthz?verbose or /readyz/<controller>.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// StuckReconcileThreshold is the duration after which a reconciliation still running makes the health check of its
+// controller fail.
+var StuckReconcileThreshold = time.Hour
+
+var (
+	reconciles = &tracker{inFlight: map[string]map[reconcile.Request]time.Time{}}
+	now        = time.Now
+)
+
+// AddChecks adds the health and readiness checks of the controller to the manager. The controller is healthy as long
+// as none of its reconciliations has been running for longer than StuckReconcileThreshold, and ready once the
+// informer of the objects it reconciles has synced.
+func AddChecks(mgr manager.Manager, controller string, obj client.Object) error {
+	if err := mgr.AddHealthzCheck(controller, Reconciling(controller)); err != nil {
+		return fmt.Errorf("failed to add the health check of the %s controller: %w", controller, err)
+	}
+	if err := mgr.AddReadyzCheck(controller, InformerSynced(mgr.GetCache(), obj)); err != nil {
+		return fmt.Errorf("failed to add the readiness check of the %s controller: %w", controller, err)
+	}
+	return nil
+}
+
+// Instrument wraps a reconciler to track the reconciliations of the controller in flight.
+func Instrument(controller string, r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		reconciles.start(controller, req)
+		defer reconciles.done(controller, req)
+		return r.Reconcile(ctx, req)
+	})
+}
+
+// Reconciling returns a check failing while a reconciliation of the controller has been running for longer than
+// StuckReconcileThreshold.
+func Reconciling(controller string) healthz.Checker {
+	return func(_ *http.Request) error {
+		req, started, ok := reconciles.oldest(controller)
+		if ok && now().Sub(started) > StuckReconcileThreshold {
+			return fmt.Errorf("the reconciliation of %s has been running for %s", req, now().Sub(started).Round(time.Second))
+		}
+		return nil
+	}
+}
+
+// InformerSynced returns a check failing until the informer of the kind of the object has synced.
+func InformerSynced(c cache.Cache, obj client.Object) healthz.Checker {
+	return func(req *http.Request) error {
+		informer, err := c.GetInformer(req.Context(), obj, cache.BlockUntilSynced(false))
+		if err != nil {
+			return fmt.Errorf("failed to get the informer: %w", err)
+		}
+		if !informer.HasSynced() {
+			return fmt.Errorf("the informer has not synced yet")
+		}
+		return nil
+	}
+}
+
+type tracker struct {
+	mu       sync.Mutex
+	inFlight map[string]map[reconcile.Request]time.Time
+}
+
+func (t *tracker) start(controller string, req reconcile.Request) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.inFlight[controller] == nil {
+		t.inFlight[controller] = map[reconcile.Request]time.Time{}
+	}
+	t.inFlight[controller][req] = now()
+}
+
+func (t *tracker) done(controller string, req reconcile.Request) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.inFlight[controller], req)
+}
+
+// oldest returns the reconciliation of the controller running for the longest time, if any.
+func (t *tracker) oldest(controller string) (reconcile.Request, time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var (
+		oldestReq   reconcile.Request
+		oldestStart time.Time
+		found       bool
+	)
+	for req, started := range t.inFlight[controller] {
+		if !found || started.Before(oldestStart) {
+			oldestReq, oldestStart, found = req, started, true
+		}
+	}
+	return oldestReq, oldestStart, found
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconciling(t *testing.T) {
+	t.Cleanup(func() { now = time.Now })
+	start := time.Now()
+	now = func() time.Time { return start }
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test"}}
+	check := Reconciling("test")
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	r := Instrument("test", reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		close(running)
+		<-release
+		return reconcile.Result{}, nil
+	}))
+	go func() {
+		_, _ = r.Reconcile(context.Background(), req)
+		close(finished)
+	}()
+	<-running
+
+	require.NoError(t, check(nil))
+
+	now = func() time.Time { return start.Add(StuckReconcileThreshold + time.Minute) }
+	require.ErrorContains(t, check(nil), "default/test")
+	// Other controllers are not affected.
+	require.NoError(t, Reconciling("other")(nil))
+
+	close(release)
+	<-finished
+	require.NoError(t, check(nil))
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package keystore keeps the private keys of the cluster certificate authorities out of the management cluster. The
+// certificate secrets of the clusters only hold the public certificates, the private keys are moved to a Store and
+// fetched from it when the controllers need to sign with them.
+package keystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrNotFound is returned by a Store which holds no key for the certificate.
+var ErrNotFound = errors.New("key not found")
+
+// Purposes are the certificates whose private keys are moved to the Store.
+var Purposes = []secret.Purpose{secret.ClusterCA, secret.EtcdCA, secret.FrontProxyCA, secret.ServiceAccount}
+
+// Store stores the private keys of the certificates of the clusters.
+type Store interface {
+	// Put stores the PEM encoded private key of the certificate of the cluster.
+	Put(ctx context.Context, cluster client.ObjectKey, purpose secret.Purpose, key []byte) error
+	// Get returns the PEM encoded private key of the certificate of the cluster, or ErrNotFound.
+	Get(ctx context.Context, cluster client.ObjectKey, purpose secret.Purpose) ([]byte, error)
+	// Delete removes the private key of the certificate of the cluster. Deleting a missing key is not an error.
+	Delete(ctx context.Context, cluster client.ObjectKey, purpose secret.Purpose) error
+	// Wrap returns a short-lived, single use token handing the private key of the certificate of the cluster over to
+	// a machine.
+	Wrap(ctx context.Context, cluster client.ObjectKey, purpose secret.Purpose) (string, error)
+	// UnwrapCommand returns the shell command writing the private key handed over by the token to the file.
+	UnwrapCommand(token string, file string) string
+}
+
+// Offload moves the private keys still held by the certificate secrets of the cluster to the store. The key is
+// removed from the secret only once it is stored.
+func Offload(ctx context.Context, c client.Client, store Store, cluster client.ObjectKey) error {
+	for _, purpose := range Purposes {
+		s, err := secret.GetFromNamespacedName(ctx, c, cluster, purpose)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		key, ok := s.Data[secret.TLSKeyDataName]
+		if !ok {
+			continue
+		}
+		if err := store.Put(ctx, cluster, purpose, key); err != nil {
+			return fmt.Errorf("failed to store the %s key: %w", purpose, err)
+		}
+
+		patch := client.MergeFrom(s.DeepCopy())
+		delete(s.Data, secret.TLSKeyDataName)
+		if err := c.Patch(ctx, s, patch); err != nil {
+			return fmt.Errorf("failed to remove the key from secret %s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Key returns the private key of a certificate secret of the cluster. It is read from the secret if it still holds
+// it, from the store otherwise. A nil store means the keys are kept in the secrets.
+func Key(ctx context.Context, store Store, cluster client.ObjectKey, s *corev1.Secret, purpose secret.Purpose) ([]byte, error) {
+	if key, ok := s.Data[secret.TLSKeyDataName]; ok || store == nil {
+		return key, nil
+	}
+	key, err := store.Get(ctx, cluster, purpose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the %s key: %w", purpose, err)
+	}
+	return key, nil
+}
+
+// KeyCommands returns the commands fetching the private keys of the certificates looked up from secrets which no longer
+// hold them, for the machines needing the keys. The keys are handed over through short-lived, single use tokens, so they
+// are never written in the bootstrap data.
+func KeyCommands(ctx context.Context, store Store, cluster client.ObjectKey, certificates secret.Certificates) ([]string, error) {
+	if store == nil {
+		return nil, nil
+	}
+	var commands []string
+	for _, purpose := range Purposes {
+		cert := certificates.GetByPurpose(purpose)
+		if cert == nil || cert.KeyPair == nil || len(cert.KeyPair.Key) > 0 {
+			continue
+		}
+		token, err := store.Wrap(ctx, cluster, purpose)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap the %s key: %w", purpose, err)
+		}
+		commands = append(commands, store.UnwrapCommand(token, cert.KeyFile))
+	}
+	return commands, nil
+}
+
+// DeleteKeys removes the private keys of the cluster from the store.
+func DeleteKeys(ctx context.Context, store Store, cluster client.ObjectKey) error {
+	if store == nil {
+		return nil
+	}
+	for _, purpose := range Purposes {
+		if err := store.Delete(ctx, cluster, purpose); err != nil {
+			return fmt.Errorf("failed to delete the %s key: %w", purpose, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/certs"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type memoryStore struct {
+	keys map[string][]byte
+}
+
+func (m *memoryStore) Put(_ context.Context, cluster client.ObjectKey, purpose secret.Purpose, key []byte) error {
+	m.keys[cluster.String()+"/"+string(purpose)] = key
+	return nil
+}
+
+func (m *memoryStore) Get(_ context.Context, cluster client.ObjectKey, purpose secret.Purpose) ([]byte, error) {
+	key, ok := m.keys[cluster.String()+"/"+string(purpose)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return key, nil
+}
+
+func (m *memoryStore) Delete(_ context.Context, cluster client.ObjectKey, purpose secret.Purpose) error {
+	delete(m.keys, cluster.String()+"/"+string(purpose))
+	return nil
+}
+
+func (m *memoryStore) Wrap(ctx context.Context, cluster client.ObjectKey, purpose secret.Purpose) (string, error) {
+	if _, err := m.Get(ctx, cluster, purpose); err != nil {
+		return "", err
+	}
+	return "wrapped-" + string(purpose), nil
+}
+
+func (m *memoryStore) UnwrapCommand(token string, file string) string {
+	return "unwrap " + token + " " + file
+}
+
+func TestOffloadAndLoadKeys(t *testing.T) {
+	ctx := context.Background()
+	cluster := client.ObjectKey{Namespace: "default", Name: "test"}
+	store := &memoryStore{keys: map[string][]byte{}}
+
+	ca := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: secret.Name("test", secret.ClusterCA)},
+		Data: map[string][]byte{
+			secret.TLSCrtDataName: []byte("crt"),
+			secret.TLSKeyDataName: []byte("key"),
+		},
+	}
+	c := fake.NewClientBuilder().WithObjects(ca).Build()
+
+	require.NoError(t, Offload(ctx, c, store, cluster))
+	assert.Equal(t, []byte("key"), store.keys["default/test/ca"])
+
+	s := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(ca), s))
+	assert.NotContains(t, s.Data, secret.TLSKeyDataName)
+	assert.Equal(t, []byte("crt"), s.Data[secret.TLSCrtDataName])
+
+	key, err := Key(ctx, store, cluster, s, secret.ClusterCA)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key"), key)
+
+	certificates := secret.Certificates{
+		&secret.Certificate{Purpose: secret.ClusterCA, KeyFile: "/var/lib/k0s/pki/ca.key", KeyPair: &certs.KeyPair{Cert: []byte("crt")}},
+	}
+	commands, err := KeyCommands(ctx, store, cluster, certificates)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unwrap wrapped-ca /var/lib/k0s/pki/ca.key"}, commands)
+	assert.Empty(t, certificates.GetByPurpose(secret.ClusterCA).KeyPair.Key)
+
+	require.NoError(t, DeleteKeys(ctx, store, cluster))
+	assert.Empty(t, store.keys)
+}
+
+func TestKeyWithoutStore(t *testing.T) {
+	s := &corev1.Secret{Data: map[string][]byte{secret.TLSKeyDataName: []byte("key")}}
+	key, err := Key(context.Background(), nil, client.ObjectKey{}, s, secret.ClusterCA)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key"), key)
+}
+
+func TestVault(t *testing.T) {
+	var mu sync.Mutex
+	data := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v1/kv/data/"):
+			var body struct {
+				Data map[string]string `json:"data"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			data[strings.TrimPrefix(r.URL.Path, "/v1/kv/data/")] = body.Data["key"]
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/kv/data/"):
+			key, ok := data[strings.TrimPrefix(r.URL.Path, "/v1/kv/data/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": map[string]string{"key": key}}})
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/sys/wrapping/wrap":
+			if r.Header.Get("X-Vault-Wrap-TTL") != "600" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			data["wrapped"] = body["key"]
+			_ = json.NewEncoder(w).Encode(map[string]any{"wrap_info": map[string]any{"token": "wrapping-token"}})
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/kv/metadata/"):
+			delete(data, strings.TrimPrefix(r.URL.Path, "/v1/kv/metadata/"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("VAULT_TOKEN", "token")
+	v := NewVault(VaultOptions{Address: srv.URL, Mount: "kv", Prefix: "k0smotron", MachineAddress: "https://vault.example.com/", WrapTTL: 10 * time.Minute})
+	ctx := context.Background()
+	cluster := client.ObjectKey{Namespace: "default", Name: "test"}
+
+	_, err := v.Get(ctx, cluster, secret.ClusterCA)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, v.Put(ctx, cluster, secret.ClusterCA, []byte("key")))
+	assert.Equal(t, "key", data["k0smotron/default/test/ca"])
+
+	key, err := v.Get(ctx, cluster, secret.ClusterCA)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key"), key)
+
+	token, err := v.Wrap(ctx, cluster, secret.ClusterCA)
+	require.NoError(t, err)
+	assert.Equal(t, "wrapping-token", token)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("key")), data["wrapped"])
+	delete(data, "wrapped")
+
+	command := v.UnwrapCommand(token, "/var/lib/k0s/pki/ca.key")
+	assert.Contains(t, command, `curl -fsS -X POST -H "X-Vault-Token: wrapping-token" https://vault.example.com/v1/sys/wrapping/unwrap`)
+	assert.True(t, strings.HasSuffix(command, "base64 -d > /var/lib/k0s/pki/ca.key)"))
+
+	require.NoError(t, v.Delete(ctx, cluster, secret.ClusterCA))
+	require.NoError(t, v.Delete(ctx, cluster, secret.ClusterCA))
+	assert.Empty(t, data)
+
+	t.Setenv("VAULT_TOKEN", "wrong")
+	assert.Error(t, v.Put(ctx, cluster, secret.ClusterCA, []byte("key")))
+}
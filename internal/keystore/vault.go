@@ -0,0 +1,201 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const vaultKeyField = "key"
+
+// VaultOptions configures the HashiCorp Vault store.
+type VaultOptions struct {
+	// Address is the URL of the Vault server. The Vault store is disabled if empty.
+	Address string
+	// TokenFile is the file holding the Vault token. It is read for every request, so the token can be rotated. The
+	// VAULT_TOKEN environment variable is used if empty.
+	TokenFile string
+	// Mount is the mount path of the KV version 2 secrets engine.
+	Mount string
+	// Prefix is the path the keys are stored under, as <prefix>/<namespace>/<cluster>/<purpose>.
+	Prefix string
+	// MachineAddress is the URL of the Vault server the machines fetch the keys from. Address is used if empty.
+	MachineAddress string
+	// WrapTTL is how long the tokens handing the keys over to the machines are valid.
+	WrapTTL time.Duration
+}
+
+// Vault stores the keys in the KV version 2 secrets engine of a HashiCorp Vault server.
+type Vault struct {
+	opts   VaultOptions
+	client *http.Client
+}
+
+var _ Store = &Vault{}
+
+// NewVault returns a store keeping the keys in Vault.
+func NewVault(opts VaultOptions) *Vault {
+	return &Vault{
+		opts:   opts,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (v *Vault) Put(ctx context.Context, cluster client.ObjectKey, purpose secret.Purpose, key []byte) error {
+	body, err := json.Marshal(map[string]any{
+		"data": map[string]string{vaultKeyField: string(key)},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = v.do(ctx, http.MethodPost, "data", cluster, purpose, body)
+	return err
+}
+
+func (v *Vault) Get(ctx context.Context, cluster client.ObjectKey, purpose secret.Purpose) ([]byte, error) {
+	body, err := v.do(ctx, http.MethodGet, "data", cluster, purpose, nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode the Vault response: %w", err)
+	}
+	key, ok := resp.Data.Data[vaultKeyField]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return []byte(key), nil
+}
+
+func (v *Vault) Delete(ctx context.Context, cluster client.ObjectKey, purpose secret.Purpose) error {
+	// Deleting the metadata removes all the versions of the key
+	_, err := v.do(ctx, http.MethodDelete, "metadata", cluster, purpose, nil)
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (v *Vault) Wrap(ctx context.Context, cluster client.ObjectKey, purpose secret.Purpose) (string, error) {
+	key, err := v.Get(ctx, cluster, purpose)
+	if err != nil {
+		return "", err
+	}
+	// The key is base64 encoded, so the machines can extract it from the response without a JSON parser
+	body, err := json.Marshal(map[string]string{vaultKeyField: base64.StdEncoding.EncodeToString(key)})
+	if err != nil {
+		return "", err
+	}
+	respBody, err := v.request(ctx, http.MethodPost, "/v1/sys/wrapping/wrap", body, map[string]string{
+		"X-Vault-Wrap-TTL": strconv.Itoa(int(v.opts.WrapTTL.Seconds())),
+	})
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		WrapInfo struct {
+			Token string `json:"token"`
+		} `json:"wrap_info"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", fmt.Errorf("failed to decode the Vault response: %w", err)
+	}
+	if resp.WrapInfo.Token == "" {
+		return "", fmt.Errorf("vault returned no wrapping token")
+	}
+	return resp.WrapInfo.Token, nil
+}
+
+func (v *Vault) UnwrapCommand(token string, file string) string {
+	address := v.opts.MachineAddress
+	if address == "" {
+		address = v.opts.Address
+	}
+	return fmt.Sprintf(`key=$(curl -fsS -X POST -H "X-Vault-Token: %s" %s/v1/sys/wrapping/unwrap) && (umask 077 && printf '%%s' "$key" | sed -n 's/.*"%s":"\([A-Za-z0-9+\/=]*\)".*/\1/p' | base64 -d > %s)`,
+		token, strings.TrimSuffix(address, "/"), vaultKeyField, file)
+}
+
+func (v *Vault) do(ctx context.Context, method, api string, cluster client.ObjectKey, purpose secret.Purpose, body []byte) ([]byte, error) {
+	return v.request(ctx, method, "/"+path.Join("v1", v.opts.Mount, api, v.opts.Prefix, cluster.Namespace, cluster.Name, string(purpose)), body, nil)
+}
+
+func (v *Vault) request(ctx context.Context, method, urlPath string, body []byte, header map[string]string) ([]byte, error) {
+	token, err := v.token()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(v.opts.Address, "/")+urlPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, val := range header {
+		req.Header.Set(k, val)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Vault response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("vault returned %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+func (v *Vault) token() (string, error) {
+	if v.opts.TokenFile == "" {
+		return os.Getenv("VAULT_TOKEN"), nil
+	}
+	token, err := os.ReadFile(v.opts.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the Vault token: %w", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics provides the Prometheus metrics shared by all the k0smotron controllers. The metrics are
+// registered on the controller-runtime registry, so they are served by the metrics endpoint of the manager.
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	namespace = "k0smotron"
+
+	ResultSuccess = "success"
+	ResultRequeue = "requeue"
+	ResultError   = "error"
+)
+
+var (
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_total",
+		Help:      "Total number of reconciliations per controller, cluster and result.",
+	}, []string{"controller", "cluster", "result"})
+
+	reconcileErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_errors_total",
+		Help:      "Total number of reconciliations per controller and cluster which returned an error.",
+	}, []string{"controller", "cluster"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of the reconciliations per controller and cluster.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"controller", "cluster"})
+
+	provisioningPhaseTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provisioning_phase_total",
+		Help:      "Total number of provisioning phases entered per kind, cluster and phase.",
+	}, []string{"kind", "cluster", "phase"})
+
+	objectsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "objects"),
+		"Number of k0smotron objects per kind, namespace and cluster.",
+		[]string{"kind", "namespace", "cluster"}, nil,
+	)
+
+	objects = &objectsCollector{lists: map[string]objectList{}}
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		reconcileTotal,
+		reconcileErrorsTotal,
+		reconcileDuration,
+		provisioningPhaseTotal,
+		objects,
+	)
+}
+
+// ClusterNameFunc returns the name of the cluster an object belongs to.
+type ClusterNameFunc func(obj client.Object) string
+
+// ClusterNameFromLabel returns the cluster name from the cluster-name label set by Cluster API.
+func ClusterNameFromLabel(obj client.Object) string {
+	return obj.GetLabels()[clusterv1.ClusterNameLabel]
+}
+
+// ClusterNameFromName returns the name of the object, for objects representing a cluster.
+func ClusterNameFromName(obj client.Object) string {
+	return obj.GetName()
+}
+
+// Instrument wraps a reconciler to record the reconcile metrics of the controller. The reconciled object is read
+// with the reader to label the metrics with the name of its cluster.
+func Instrument(controller string, reader client.Reader, newObject func() client.Object, clusterName ClusterNameFunc, r reconcile.Reconciler) reconcile.Reconciler {
+	if clusterName == nil {
+		clusterName = ClusterNameFromLabel
+	}
+	return &instrumentedReconciler{
+		controller:  controller,
+		reader:      reader,
+		newObject:   newObject,
+		clusterName: clusterName,
+		reconciler:  r,
+	}
+}
+
+type instrumentedReconciler struct {
+	controller  string
+	reader      client.Reader
+	newObject   func() client.Object
+	clusterName ClusterNameFunc
+	reconciler  reconcile.Reconciler
+}
+
+func (i *instrumentedReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	var cluster string
+	obj := i.newObject()
+	if err := i.reader.Get(ctx, req.NamespacedName, obj); err == nil {
+		cluster = i.clusterName(obj)
+	}
+
+	start := time.Now()
+	res, err := i.reconciler.Reconcile(ctx, req)
+	ObserveReconcile(i.controller, cluster, time.Since(start), res, err)
+
+	return res, err
+}
+
+// ObserveReconcile records a reconciliation of the controller.
+func ObserveReconcile(controller, cluster string, duration time.Duration, res reconcile.Result, err error) {
+	result := ResultSuccess
+	switch {
+	case err != nil:
+		result = ResultError
+		reconcileErrorsTotal.WithLabelValues(controller, cluster).Inc()
+	case res.Requeue || res.RequeueAfter > 0:
+		result = ResultRequeue
+	}
+	reconcileTotal.WithLabelValues(controller, cluster, result).Inc()
+	reconcileDuration.WithLabelValues(controller, cluster).Observe(duration.Seconds())
+}
+
+// ObserveProvisioningPhase records an object of the given kind entering a provisioning phase.
+func ObserveProvisioningPhase(kind, cluster, phase string) {
+	provisioningPhaseTotal.WithLabelValues(kind, cluster, phase).Inc()
+}
+
+type objectList struct {
+	reader      client.Reader
+	newList     func() client.ObjectList
+	clusterName ClusterNameFunc
+}
+
+// RegisterObjects counts the objects of the kind in the k0smotron_objects gauge. The objects are listed with the
+// reader, usually the cached client of the manager, when the metrics are collected.
+func RegisterObjects(kind string, reader client.Reader, newList func() client.ObjectList, clusterName ClusterNameFunc) {
+	if clusterName == nil {
+		clusterName = ClusterNameFromLabel
+	}
+	objects.mu.Lock()
+	defer objects.mu.Unlock()
+	objects.lists[kind] = objectList{reader: reader, newList: newList, clusterName: clusterName}
+}
+
+type objectsCollector struct {
+	mu    sync.Mutex
+	lists map[string]objectList
+}
+
+func (o *objectsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- objectsDesc
+}
+
+func (o *objectsCollector) Collect(ch chan<- prometheus.Metric) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type key struct{ namespace, cluster string }
+	for kind, l := range o.lists {
+		list := l.newList()
+		if err := l.reader.List(ctx, list); err != nil {
+			continue
+		}
+		counts := map[key]int{}
+		_ = meta.EachListItem(list, func(item runtime.Object) error {
+			if obj, ok := item.(client.Object); ok {
+				counts[key{obj.GetNamespace(), l.clusterName(obj)}]++
+			}
+			return nil
+		})
+		for k, count := range counts {
+			ch <- prometheus.MustNewConstMetric(objectsDesc, prometheus.GaugeValue, float64(count), kind, k.namespace, k.cluster)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bootstrapv1 "github.com/k0sproject/k0smotron/api/bootstrap/v1beta1"
+)
+
+func TestInstrument(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, bootstrapv1.AddToScheme(scheme))
+	config := &bootstrapv1.K0sWorkerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "worker-0",
+			Namespace: "default",
+			Labels:    map[string]string{clusterv1.ClusterNameLabel: "test-instrument"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(config).Build()
+
+	var reconcileErr error
+	r := Instrument("test", c, func() client.Object { return &bootstrapv1.K0sWorkerConfig{} }, nil,
+		reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+			return reconcile.Result{}, reconcileErr
+		}))
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(config)}
+
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	reconcileErr = errors.New("failed")
+	_, err = r.Reconcile(context.Background(), req)
+	require.Error(t, err)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(reconcileTotal.WithLabelValues("test", "test-instrument", ResultSuccess)))
+	require.Equal(t, 1.0, testutil.ToFloat64(reconcileTotal.WithLabelValues("test", "test-instrument", ResultError)))
+	require.Equal(t, 1.0, testutil.ToFloat64(reconcileErrorsTotal.WithLabelValues("test", "test-instrument")))
+}
+
+func TestObserveReconcileRequeue(t *testing.T) {
+	ObserveReconcile("test", "test-requeue", time.Second, reconcile.Result{RequeueAfter: time.Minute}, nil)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(reconcileTotal.WithLabelValues("test", "test-requeue", ResultRequeue)))
+	require.Equal(t, 0.0, testutil.ToFloat64(reconcileErrorsTotal.WithLabelValues("test", "test-requeue")))
+}
+
+func TestObjectsCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, bootstrapv1.AddToScheme(scheme))
+	newConfig := func(name, cluster string) client.Object {
+		return &bootstrapv1.K0sWorkerConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{clusterv1.ClusterNameLabel: cluster},
+			},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newConfig("a-0", "a"),
+		newConfig("a-1", "a"),
+		newConfig("b-0", "b"),
+	).Build()
+
+	collector := &objectsCollector{lists: map[string]objectList{}}
+	collector.lists["K0sWorkerConfig"] = objectList{
+		reader:      c,
+		newList:     func() client.ObjectList { return &bootstrapv1.K0sWorkerConfigList{} },
+		clusterName: ClusterNameFromLabel,
+	}
+
+	expected := `
+# HELP k0smotron_objects Number of k0smotron objects per kind, namespace and cluster.
+# TYPE k0smotron_objects gauge
+k0smotron_objects{cluster="a",kind="K0sWorkerConfig",namespace="default"} 2
+k0smotron_objects{cluster="b",kind="K0sWorkerConfig",namespace="default"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+}
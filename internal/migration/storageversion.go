@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// listLimit is the number of objects read from the API server at once.
+const listLimit = 100
+
+// StorageVersionMigrator migrates the objects of the k0smotron CRDs stored in a former version of their API to the
+// storage version, then removes the former versions from the stored versions of the CRDs, so they can be dropped
+// from the CRDs in a later release. The migration runs once, when the manager becomes the leader.
+type StorageVersionMigrator struct {
+	Client client.Client
+	// APIReader reads the CRDs and the objects from the API server, the migrated kinds are not necessarily cached.
+	APIReader client.Reader
+	// Groups are the API groups of the CRDs to migrate. Only the kinds registered in the scheme of the client are
+	// migrated, the groups shared with other providers hold CRDs k0smotron does not own.
+	Groups []string
+}
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,verbs=update;patch
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=k0sworkerconfigtemplates,verbs=update
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=k0scontrolplanetemplates;k0smotroncontrolplanetemplates,verbs=get;list;update
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, the objects are migrated by a single manager.
+func (m *StorageVersionMigrator) NeedLeaderElection() bool {
+	return true
+}
+
+// Start migrates the CRDs. A failed migration does not stop the manager, the CRD is migrated again on the next start.
+func (m *StorageVersionMigrator) Start(ctx context.Context) error {
+	log := log.FromContext(ctx).WithName("storage-version-migrator")
+
+	crds := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := m.APIReader.List(ctx, crds); err != nil {
+		log.Error(err, "Failed to list the CRDs, skipping the storage version migration")
+		return nil
+	}
+
+	for i := range crds.Items {
+		crd := &crds.Items[i]
+		storageVersion := storageVersion(crd)
+		if !m.owned(crd, storageVersion) || !needsMigration(crd, storageVersion) {
+			continue
+		}
+
+		log.Info("Migrating the stored objects to the storage version", "crd", crd.Name, "storedVersions", crd.Status.StoredVersions, "storageVersion", storageVersion)
+		if err := m.migrate(ctx, crd, storageVersion); err != nil {
+			log.Error(err, "Failed to migrate the stored objects to the storage version", "crd", crd.Name)
+		}
+	}
+	return nil
+}
+
+// migrate rewrites all the objects of the CRD, the API server stores the objects it writes in the storage version.
+func (m *StorageVersionMigrator) migrate(ctx context.Context, crd *apiextensionsv1.CustomResourceDefinition, storageVersion string) error {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: crd.Spec.Group, Version: storageVersion, Kind: crd.Spec.Names.ListKind})
+	for {
+		if err := m.APIReader.List(ctx, list, client.Limit(listLimit), client.Continue(list.GetContinue())); err != nil {
+			return fmt.Errorf("error listing %s: %w", crd.Spec.Names.Plural, err)
+		}
+		for i := range list.Items {
+			// Updating the object without changes is enough for the API server to write it again. An object updated or
+			// deleted in the meantime does not need to be migrated anymore.
+			err := m.Client.Update(ctx, &list.Items[i])
+			if err != nil && !apierrors.IsConflict(err) && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("error migrating %s %s/%s: %w", crd.Spec.Names.Kind, list.Items[i].GetNamespace(), list.Items[i].GetName(), err)
+			}
+		}
+		if list.GetContinue() == "" {
+			break
+		}
+	}
+
+	crd.Status.StoredVersions = []string{storageVersion}
+	if err := m.Client.Status().Update(ctx, crd); err != nil {
+		return fmt.Errorf("error updating the stored versions: %w", err)
+	}
+	return nil
+}
+
+func (m *StorageVersionMigrator) owned(crd *apiextensionsv1.CustomResourceDefinition, storageVersion string) bool {
+	gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: storageVersion, Kind: crd.Spec.Names.Kind}
+	return slices.Contains(m.Groups, crd.Spec.Group) && m.Client.Scheme().Recognizes(gvk)
+}
+
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+// needsMigration returns whether objects of the CRD may be stored in a version other than the storage version.
+func needsMigration(crd *apiextensionsv1.CustomResourceDefinition, storageVersion string) bool {
+	for _, v := range crd.Status.StoredVersions {
+		if v != storageVersion {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	km "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+)
+
+func TestStorageVersionMigrator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, apiextensionsv1.AddToScheme(scheme))
+	require.NoError(t, km.AddToScheme(scheme))
+
+	clusters := crd("k0smotron.io", "Cluster", "clusters", "v1alpha1", "v1beta1")
+	jtrs := crd("k0smotron.io", "JoinTokenRequest", "jointokenrequests", "v1beta1")
+	// Not registered in the scheme, owned by another provider
+	others := crd("k0smotron.io", "Other", "others", "v1alpha1", "v1beta1")
+	cluster := &km.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(clusters, jtrs, others, cluster).
+		WithStatusSubresource(clusters, jtrs, others).
+		Build()
+	ctx := context.Background()
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(cluster), cluster))
+	resourceVersion := cluster.ResourceVersion
+
+	m := &StorageVersionMigrator{Client: c, APIReader: c, Groups: []string{km.GroupVersion.Group}}
+	require.NoError(t, m.Start(ctx))
+
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(cluster), cluster))
+	assert.NotEqual(t, resourceVersion, cluster.ResourceVersion)
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(clusters), clusters))
+	assert.Equal(t, []string{"v1beta1"}, clusters.Status.StoredVersions)
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(others), others))
+	assert.Equal(t, []string{"v1alpha1", "v1beta1"}, others.Status.StoredVersions)
+}
+
+func crd(group, kind, plural string, storedVersions ...string) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: plural + "." + group},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: kind, ListKind: kind + "List", Plural: plural},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1beta1", Served: true, Storage: true},
+			},
+		},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: storedVersions},
+	}
+}
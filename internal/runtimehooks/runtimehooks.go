@@ -0,0 +1,373 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package runtimehooks calls the Cluster API Runtime SDK lifecycle hooks on the runtime extensions registered with
+// ExtensionConfigs. The Cluster API controllers discover the handlers of the extensions and list them in the status
+// of the ExtensionConfigs, the handlers are called from there.
+package runtimehooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	runtimev1 "sigs.k8s.io/cluster-api/exp/runtime/api/v1alpha1"
+	runtimecatalog "sigs.k8s.io/cluster-api/exp/runtime/catalog"
+	runtimehooksv1 "sigs.k8s.io/cluster-api/exp/runtime/hooks/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// BeforeClusterUpgrade is the hook called before the control plane is upgraded.
+	BeforeClusterUpgrade = "BeforeClusterUpgrade"
+	// AfterControlPlaneUpgrade is the hook called once all the control plane machines run the new version.
+	AfterControlPlaneUpgrade = "AfterControlPlaneUpgrade"
+)
+
+// Caller calls the handlers of the runtime extensions.
+type Caller struct {
+	client.Client
+
+	mu sync.Mutex
+	// httpClients are the clients of the extensions, by extension and server name. The clients are reused across the
+	// calls so the connections to the extensions are kept alive.
+	httpClients map[string]*extensionClient
+}
+
+type extensionClient struct {
+	caBundle []byte
+	client   *http.Client
+}
+
+// BeforeClusterUpgrade calls the BeforeClusterUpgrade handlers. A response with a non-zero RetryAfterSeconds blocks
+// the upgrade.
+func (c *Caller) BeforeClusterUpgrade(ctx context.Context, cluster *clusterv1.Cluster, fromVersion, toVersion string) (*runtimehooksv1.BeforeClusterUpgradeResponse, error) {
+	request := &runtimehooksv1.BeforeClusterUpgradeRequest{
+		Cluster:               *cleanupCluster(cluster),
+		FromKubernetesVersion: fromVersion,
+		ToKubernetesVersion:   toVersion,
+	}
+	response := &runtimehooksv1.BeforeClusterUpgradeResponse{}
+	return response, c.call(ctx, BeforeClusterUpgrade, cluster, request, response)
+}
+
+// AfterControlPlaneUpgrade calls the AfterControlPlaneUpgrade handlers. A response with a non-zero RetryAfterSeconds
+// means the hook must be called again.
+func (c *Caller) AfterControlPlaneUpgrade(ctx context.Context, cluster *clusterv1.Cluster, version string) (*runtimehooksv1.AfterControlPlaneUpgradeResponse, error) {
+	request := &runtimehooksv1.AfterControlPlaneUpgradeRequest{
+		Cluster:           *cleanupCluster(cluster),
+		KubernetesVersion: version,
+	}
+	response := &runtimehooksv1.AfterControlPlaneUpgradeResponse{}
+	return response, c.call(ctx, AfterControlPlaneUpgrade, cluster, request, response)
+}
+
+// call calls the handlers of the hook whose ExtensionConfig selects the namespace of the cluster, and aggregates their
+// responses into response: the lowest non-zero RetryAfterSeconds and the messages of all the handlers. A handler
+// responding with a failure fails the call, as does a handler which cannot be reached unless its failure policy is
+// Ignore.
+func (c *Caller) call(ctx context.Context, hook string, cluster *clusterv1.Cluster, request runtimehooksv1.RequestObject, response runtimehooksv1.RetryResponseObject) error {
+	logger := log.FromContext(ctx).WithValues("hook", hook)
+	request.GetObjectKind().SetGroupVersionKind(runtimehooksv1.GroupVersion.WithKind(hook + "Request"))
+
+	extensions := &runtimev1.ExtensionConfigList{}
+	if err := c.List(ctx, extensions); err != nil {
+		return fmt.Errorf("failed to list the runtime extensions: %w", err)
+	}
+
+	c.pruneHTTPClients(extensions.Items)
+
+	var retryAfter int32
+	var messages []string
+	for _, ext := range extensions.Items {
+		matches, err := c.matchesNamespace(ctx, ext.Spec.NamespaceSelector, cluster.Namespace)
+		if err != nil {
+			return err
+		}
+		if !matches {
+			continue
+		}
+
+		for _, handler := range ext.Status.Handlers {
+			if handler.RequestHook.Hook != hook || handler.RequestHook.APIVersion != runtimehooksv1.GroupVersion.String() {
+				continue
+			}
+
+			handlerRequest := request.DeepCopyObject().(runtimehooksv1.RequestObject)
+			handlerRequest.SetSettings(mergeSettings(ext.Spec.Settings, request.GetSettings()))
+			handlerResponse := response.DeepCopyObject().(runtimehooksv1.RetryResponseObject)
+			handlerResponse.SetRetryAfterSeconds(0)
+			handlerResponse.SetMessage("")
+			err := c.callHandler(ctx, ext, handler, handlerRequest, handlerResponse)
+			if err != nil {
+				if handler.FailurePolicy != nil && *handler.FailurePolicy == runtimev1.FailurePolicyIgnore {
+					logger.Error(err, "Ignoring the failure of the extension handler", "handler", handler.Name)
+					continue
+				}
+				return fmt.Errorf("failed to call extension handler %q: %w", handler.Name, err)
+			}
+			if handlerResponse.GetStatus() == runtimehooksv1.ResponseStatusFailure {
+				return fmt.Errorf("extension handler %q failed: %s", handler.Name, handlerResponse.GetMessage())
+			}
+
+			if r := handlerResponse.GetRetryAfterSeconds(); r > 0 && (retryAfter == 0 || r < retryAfter) {
+				retryAfter = r
+			}
+			if m := handlerResponse.GetMessage(); m != "" {
+				messages = append(messages, m)
+			}
+		}
+	}
+
+	response.SetStatus(runtimehooksv1.ResponseStatusSuccess)
+	response.SetRetryAfterSeconds(retryAfter)
+	response.SetMessage(strings.Join(messages, ", "))
+	return nil
+}
+
+func (c *Caller) matchesNamespace(ctx context.Context, selector *metav1.LabelSelector, namespace string) (bool, error) {
+	if selector == nil {
+		return true, nil
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespace selector: %w", err)
+	}
+	if s.Empty() {
+		return true, nil
+	}
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return false, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	return s.Matches(labels.Set(ns.Labels)), nil
+}
+
+func (c *Caller) callHandler(ctx context.Context, ext runtimev1.ExtensionConfig, handler runtimev1.ExtensionHandler, request runtimehooksv1.RequestObject, response runtimehooksv1.ResponseObject) error {
+	timeout := runtimehooksv1.DefaultHandlersTimeoutSeconds * time.Second
+	if handler.TimeoutSeconds != nil {
+		timeout = time.Duration(*handler.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	gvh := runtimecatalog.GroupVersionHook{
+		Group:   runtimehooksv1.GroupVersion.Group,
+		Version: runtimehooksv1.GroupVersion.Version,
+		Hook:    handler.RequestHook.Hook,
+	}
+	u, err := handlerURL(ext.Spec.ClientConfig, runtimecatalog.GVHToPath(gvh, strings.TrimSuffix(handler.Name, "."+ext.Name)))
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("timeout", timeout.String())
+	u.RawQuery = q.Encode()
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient, err := c.httpClient(ext, u.Hostname())
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got response with status code %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if err := json.Unmarshal(respBody, response); err != nil {
+		return fmt.Errorf("failed to decode the response: %w", err)
+	}
+	return nil
+}
+
+// httpClient returns the client of the extension served under the server name. The client is replaced when the CA
+// bundle of the extension changes.
+func (c *Caller) httpClient(ext runtimev1.ExtensionConfig, serverName string) (*http.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := ext.Name + "/" + serverName
+	cached, ok := c.httpClients[key]
+	if ok && bytes.Equal(cached.caBundle, ext.Spec.ClientConfig.CABundle) {
+		return cached.client, nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12}
+	if len(ext.Spec.ClientConfig.CABundle) > 0 {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ext.Spec.ClientConfig.CABundle) {
+			return nil, fmt.Errorf("invalid CA bundle in ExtensionConfig %s", ext.Name)
+		}
+	}
+	if ok {
+		cached.client.CloseIdleConnections()
+	}
+	if c.httpClients == nil {
+		c.httpClients = map[string]*extensionClient{}
+	}
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	c.httpClients[key] = &extensionClient{caBundle: ext.Spec.ClientConfig.CABundle, client: httpClient}
+	return httpClient, nil
+}
+
+// pruneHTTPClients closes and removes the clients of the extensions which no longer exist.
+func (c *Caller) pruneHTTPClients(extensions []runtimev1.ExtensionConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, cached := range c.httpClients {
+		name, _, _ := strings.Cut(key, "/")
+		if !slices.ContainsFunc(extensions, func(ext runtimev1.ExtensionConfig) bool { return ext.Name == name }) {
+			cached.client.CloseIdleConnections()
+			delete(c.httpClients, key)
+		}
+	}
+}
+
+// handlerURL returns the URL of the handler path of an extension, served over HTTPS by a service of the management
+// cluster or at an URL.
+func handlerURL(config runtimev1.ClientConfig, handlerPath string) (*url.URL, error) {
+	var u *url.URL
+	switch {
+	case config.Service != nil:
+		host := config.Service.Name + "." + config.Service.Namespace + ".svc"
+		if config.Service.Port != nil {
+			host = net.JoinHostPort(host, strconv.Itoa(int(*config.Service.Port)))
+		}
+		u = &url.URL{Scheme: "https", Host: host}
+		if config.Service.Path != nil {
+			u.Path = *config.Service.Path
+		}
+	case config.URL != nil:
+		var err error
+		if u, err = url.Parse(*config.URL); err != nil {
+			return nil, fmt.Errorf("invalid extension URL: %w", err)
+		}
+		if u.Scheme != "https" {
+			return nil, fmt.Errorf("invalid extension URL: expected https scheme, got %s", u.Scheme)
+		}
+	default:
+		return nil, fmt.Errorf("the extension defines neither a service nor an URL")
+	}
+	u.Path = path.Join(u.Path, handlerPath)
+	return u, nil
+}
+
+func mergeSettings(extension, request map[string]string) map[string]string {
+	settings := map[string]string{}
+	for k, v := range extension {
+		settings[k] = v
+	}
+	// The settings of the request take precedence
+	for k, v := range request {
+		settings[k] = v
+	}
+	return settings
+}
+
+// cleanupCluster returns a copy of the cluster without the fields the extensions have no use for.
+func cleanupCluster(cluster *clusterv1.Cluster) *clusterv1.Cluster {
+	c := cluster.DeepCopy()
+	c.ManagedFields = nil
+	delete(c.Annotations, corev1.LastAppliedConfigAnnotation)
+	return c
+}
+
+// IsPending returns whether the hook is marked as pending on the object.
+func IsPending(obj metav1.Object, hook string) bool {
+	return slices.Contains(pendingHooks(obj), hook)
+}
+
+// MarkPending marks the hook as pending on the object, so it is called even if the reconciliation is interrupted.
+func MarkPending(ctx context.Context, c client.Client, obj client.Object, hook string) error {
+	if IsPending(obj, hook) {
+		return nil
+	}
+	return setPendingHooks(ctx, c, obj, append(pendingHooks(obj), hook))
+}
+
+// MarkDone removes the hook from the pending hooks of the object.
+func MarkDone(ctx context.Context, c client.Client, obj client.Object, hook string) error {
+	if !IsPending(obj, hook) {
+		return nil
+	}
+	return setPendingHooks(ctx, c, obj, slices.DeleteFunc(pendingHooks(obj), func(h string) bool { return h == hook }))
+}
+
+func pendingHooks(obj metav1.Object) []string {
+	value := obj.GetAnnotations()[runtimev1.PendingHooksAnnotation]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// setPendingHooks patches the pending hooks annotation right away, the reconciliation may fail before the object is
+// patched with its other changes. The patch is made on a copy so these changes are kept.
+func setPendingHooks(ctx context.Context, c client.Client, obj client.Object, hooks []string) error {
+	annotations := map[string]string{}
+	for k, v := range obj.GetAnnotations() {
+		annotations[k] = v
+	}
+	if len(hooks) == 0 {
+		delete(annotations, runtimev1.PendingHooksAnnotation)
+	} else {
+		annotations[runtimev1.PendingHooksAnnotation] = strings.Join(hooks, ",")
+	}
+
+	updated := obj.DeepCopyObject().(client.Object)
+	updated.SetAnnotations(annotations)
+	if err := c.Patch(ctx, updated, client.MergeFrom(obj)); err != nil {
+		return err
+	}
+	obj.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtimehooks
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	runtimev1 "sigs.k8s.io/cluster-api/exp/runtime/api/v1alpha1"
+	runtimehooksv1 "sigs.k8s.io/cluster-api/exp/runtime/hooks/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBeforeClusterUpgrade(t *testing.T) {
+	var request runtimehooksv1.BeforeClusterUpgradeRequest
+	var path string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_ = json.NewEncoder(w).Encode(runtimehooksv1.BeforeClusterUpgradeResponse{
+			CommonRetryResponse: runtimehooksv1.CommonRetryResponse{
+				CommonResponse:    runtimehooksv1.CommonResponse{Status: runtimehooksv1.ResponseStatusSuccess, Message: "waiting for approval"},
+				RetryAfterSeconds: 30,
+			},
+		})
+	}))
+	defer srv.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	ignore := runtimev1.FailurePolicyIgnore
+	unreachable := "https://127.0.0.1:1"
+	c := newClient(t,
+		extensionConfig("approval", srv.URL, caBundle, nil, runtimev1.ExtensionHandler{
+			Name:        "before-upgrade.approval",
+			RequestHook: runtimev1.GroupVersionHook{APIVersion: runtimehooksv1.GroupVersion.String(), Hook: BeforeClusterUpgrade},
+		}),
+		extensionConfig("other-namespaces", srv.URL, caBundle, &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}}, runtimev1.ExtensionHandler{
+			Name:        "before-upgrade.other-namespaces",
+			RequestHook: runtimev1.GroupVersionHook{APIVersion: runtimehooksv1.GroupVersion.String(), Hook: BeforeClusterUpgrade},
+		}),
+		extensionConfig("ignored", unreachable, nil, nil, runtimev1.ExtensionHandler{
+			Name:          "before-upgrade.ignored",
+			RequestHook:   runtimev1.GroupVersionHook{APIVersion: runtimehooksv1.GroupVersion.String(), Hook: BeforeClusterUpgrade},
+			FailurePolicy: &ignore,
+		}),
+	)
+
+	caller := &Caller{Client: c}
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "team-a"}}
+	resp, err := caller.BeforeClusterUpgrade(context.Background(), cluster, "v1.30.3", "v1.31.1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(30), resp.RetryAfterSeconds)
+	assert.Equal(t, "waiting for approval", resp.Message)
+	assert.Equal(t, "/hooks.runtime.cluster.x-k8s.io/v1alpha1/beforeclusterupgrade/before-upgrade", path)
+	assert.Equal(t, "BeforeClusterUpgradeRequest", request.Kind)
+	assert.Equal(t, "v1.30.3", request.FromKubernetesVersion)
+	assert.Equal(t, "v1.31.1", request.ToKubernetesVersion)
+	assert.Equal(t, "test", request.Cluster.Name)
+	assert.Equal(t, map[string]string{"key": "value"}, request.Settings)
+
+	// The clients of the extensions are reused across the calls
+	approvalClient := caller.httpClients["approval/127.0.0.1"]
+	require.NotNil(t, approvalClient)
+	_, err = caller.BeforeClusterUpgrade(context.Background(), cluster, "v1.30.3", "v1.31.1")
+	require.NoError(t, err)
+	assert.Same(t, approvalClient, caller.httpClients["approval/127.0.0.1"])
+	caller.pruneHTTPClients(nil)
+	assert.Empty(t, caller.httpClients)
+
+	// A handler failing with the default failure policy fails the call
+	c = newClient(t, extensionConfig("failing", unreachable, nil, nil, runtimev1.ExtensionHandler{
+		Name:        "before-upgrade.failing",
+		RequestHook: runtimev1.GroupVersionHook{APIVersion: runtimehooksv1.GroupVersion.String(), Hook: BeforeClusterUpgrade},
+	}))
+	_, err = (&Caller{Client: c}).BeforeClusterUpgrade(context.Background(), cluster, "v1.30.3", "v1.31.1")
+	assert.Error(t, err)
+}
+
+func TestPendingHooks(t *testing.T) {
+	ctx := context.Background()
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+	c := newClient(t, cluster)
+
+	assert.False(t, IsPending(cluster, AfterControlPlaneUpgrade))
+	require.NoError(t, MarkPending(ctx, c, cluster, AfterControlPlaneUpgrade))
+	assert.True(t, IsPending(cluster, AfterControlPlaneUpgrade))
+
+	stored := &clusterv1.Cluster{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(cluster), stored))
+	assert.Equal(t, AfterControlPlaneUpgrade, stored.Annotations[runtimev1.PendingHooksAnnotation])
+
+	require.NoError(t, MarkDone(ctx, c, cluster, AfterControlPlaneUpgrade))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(cluster), stored))
+	assert.NotContains(t, stored.Annotations, runtimev1.PendingHooksAnnotation)
+	assert.False(t, IsPending(cluster, AfterControlPlaneUpgrade))
+}
+
+func newClient(t *testing.T, objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, clusterv1.AddToScheme(scheme))
+	require.NoError(t, runtimev1.AddToScheme(scheme))
+	objs = append(objs, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}})
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func extensionConfig(name, url string, caBundle []byte, selector *metav1.LabelSelector, handler runtimev1.ExtensionHandler) *runtimev1.ExtensionConfig {
+	return &runtimev1.ExtensionConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: runtimev1.ExtensionConfigSpec{
+			ClientConfig:      runtimev1.ClientConfig{URL: &url, CABundle: caBundle},
+			NamespaceSelector: selector,
+			Settings:          map[string]string{"key": "value"},
+		},
+		Status: runtimev1.ExtensionConfigStatus{Handlers: []runtimev1.ExtensionHandler{handler}},
+	}
+}
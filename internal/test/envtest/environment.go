@@ -43,6 +43,7 @@ import (
 	cpv1beta1 "github.com/k0sproject/k0smotron/api/controlplane/v1beta1"
 	infrastructurev1beta1 "github.com/k0sproject/k0smotron/api/infrastructure/v1beta1"
 	k0smotronv1beta1 "github.com/k0sproject/k0smotron/api/k0smotron.io/v1beta1"
+	"github.com/k0sproject/k0smotron/internal/controller/util"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -158,6 +159,13 @@ func newEnvironment(setupSecretCachingClient setupSecretCachingClientFn) *Enviro
 		panic(fmt.Errorf("failed to setup secret caching client: %w", err))
 	}
 
+	if err := util.AddIndexes(context.Background(), mgr); err != nil {
+		panic(fmt.Errorf("failed to setup cache indexes: %w", err))
+	}
+	if err := util.AddInfrastructureIndexes(context.Background(), mgr); err != nil {
+		panic(fmt.Errorf("failed to setup infrastructure cache indexes: %w", err))
+	}
+
 	if kubeconfigPath := os.Getenv("TEST_ENV_KUBECONFIG"); kubeconfigPath != "" {
 		klog.Infof("Writing test env kubeconfig to %q", kubeconfigPath)
 		config := kubeconfig.FromEnvTestConfig(env.Config, &clusterv1.Cluster{
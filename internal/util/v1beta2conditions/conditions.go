@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1beta2conditions maintains the conditions of the k0smotron objects following the Cluster API v1beta2
+// contract, i.e. metav1.Condition under status.v1beta2.conditions, next to the legacy Cluster API v1beta1 conditions
+// which are kept during the deprecation window.
+package v1beta2conditions
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// ReadyCondition is true if all the other conditions of the object, except the informational ones, are true.
+	ReadyCondition = "Ready"
+
+	// ReadyReason surfaces that all the conditions summarized in the Ready condition are true.
+	ReadyReason = "Ready"
+	// NotReadyReason surfaces that at least one of the conditions summarized in the Ready condition is false.
+	NotReadyReason = "NotReady"
+	// ReadyUnknownReason surfaces that at least one of the conditions summarized in the Ready condition is unknown.
+	ReadyUnknownReason = "ReadyUnknown"
+	// NoReasonReported is the reason of a mirrored condition which had no reason in its legacy version.
+	NoReasonReported = "NoReasonReported"
+)
+
+// Mirror converts the legacy conditions into v1beta2 conditions and computes the Ready condition summarizing them.
+// The conditions of the types passed as informational, e.g. Paused, are mirrored but not summarized.
+// The transition time of the Ready condition is taken over from the existing conditions if its status did not change.
+func Mirror(existing []metav1.Condition, legacy clusterv1.Conditions, generation int64, informational ...clusterv1.ConditionType) []metav1.Condition {
+	ready := metav1.Condition{
+		Type:               ReadyCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             ReadyReason,
+	}
+	conditions := []metav1.Condition{}
+	var notReady, unknown []string
+	var legacyReady *clusterv1.Condition
+	for i, c := range legacy {
+		if c.Type == clusterv1.ReadyCondition {
+			legacyReady = &legacy[i]
+			continue
+		}
+		conditions = append(conditions, fromLegacy(c, generation))
+		if slices.Contains(informational, c.Type) {
+			continue
+		}
+		switch c.Status {
+		case "False":
+			notReady = append(notReady, summary(c))
+		case "Unknown":
+			unknown = append(unknown, summary(c))
+		}
+	}
+
+	switch {
+	case len(notReady) > 0:
+		ready.Status, ready.Reason, ready.Message = metav1.ConditionFalse, NotReadyReason, strings.Join(notReady, "; ")
+	case len(unknown) > 0:
+		ready.Status, ready.Reason, ready.Message = metav1.ConditionUnknown, ReadyUnknownReason, strings.Join(unknown, "; ")
+	case len(conditions) == 0 && legacyReady != nil:
+		// Nothing to summarize, the legacy Ready condition is set by the controller itself
+		ready = fromLegacy(*legacyReady, generation)
+	case len(conditions) == 0:
+		return nil
+	}
+
+	ready.LastTransitionTime = metav1.Now()
+	for _, c := range existing {
+		if c.Type == ReadyCondition && c.Status == ready.Status {
+			ready.LastTransitionTime = c.LastTransitionTime
+		}
+	}
+
+	return append([]metav1.Condition{ready}, conditions...)
+}
+
+// Get returns the condition of the given type, nil if not found.
+func Get(conditions []metav1.Condition, conditionType string) *metav1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func fromLegacy(c clusterv1.Condition, generation int64) metav1.Condition {
+	reason := camelCase(c.Reason)
+	if reason == "" {
+		reason = NoReasonReported
+		if c.Status == "True" {
+			reason = string(c.Type)
+		}
+	}
+	lastTransitionTime := c.LastTransitionTime
+	if lastTransitionTime.IsZero() {
+		lastTransitionTime = metav1.Now()
+	}
+	return metav1.Condition{
+		Type:               string(c.Type),
+		Status:             metav1.ConditionStatus(c.Status),
+		ObservedGeneration: generation,
+		LastTransitionTime: lastTransitionTime,
+		Reason:             reason,
+		Message:            c.Message,
+	}
+}
+
+// camelCase turns a free-form legacy reason into a CamelCase v1beta2 reason, e.g. "Unable to connect" into
+// "UnableToConnect".
+func camelCase(reason string) string {
+	isWordChar := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_'
+	}
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(reason, func(r rune) bool { return !isWordChar(r) }) {
+		if b.Len() == 0 && (word[0] < 'A' || word[0] > 'z' || word[0] == '_') {
+			// The reason must start with a letter
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+func summary(c clusterv1.Condition) string {
+	switch {
+	case c.Message != "":
+		return fmt.Sprintf("%s: %s", c.Type, c.Message)
+	case c.Reason != "":
+		return fmt.Sprintf("%s: %s", c.Type, c.Reason)
+	}
+	return string(c.Type)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2conditions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func TestMirror(t *testing.T) {
+	transition := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	legacy := clusterv1.Conditions{
+		{Type: clusterv1.ReadyCondition, Status: "False", LastTransitionTime: transition},
+		{Type: "Provisioned", Status: "True", LastTransitionTime: transition},
+		{Type: "Verified", Status: "False", Reason: "Unable to connect", Message: "timeout", LastTransitionTime: transition},
+		{Type: "Paused", Status: "True", LastTransitionTime: transition},
+	}
+
+	conditions := Mirror(nil, legacy, 3, "Paused")
+	require.Len(t, conditions, 4)
+
+	ready := Get(conditions, ReadyCondition)
+	require.NotNil(t, ready)
+	require.Equal(t, metav1.ConditionFalse, ready.Status)
+	require.Equal(t, NotReadyReason, ready.Reason)
+	require.Equal(t, "Verified: timeout", ready.Message)
+	require.Equal(t, int64(3), ready.ObservedGeneration)
+
+	provisioned := Get(conditions, "Provisioned")
+	require.Equal(t, metav1.ConditionTrue, provisioned.Status)
+	require.Equal(t, "Provisioned", provisioned.Reason)
+	require.Equal(t, transition, provisioned.LastTransitionTime)
+
+	verified := Get(conditions, "Verified")
+	require.Equal(t, "UnableToConnect", verified.Reason)
+	require.Equal(t, "timeout", verified.Message)
+
+	// The transition time of Ready is kept as long as its status does not change
+	readyTransition := metav1.NewTime(transition.Add(time.Minute))
+	conditions[0].LastTransitionTime = readyTransition
+	conditions = Mirror(conditions, legacy, 4, "Paused")
+	require.Equal(t, readyTransition, Get(conditions, ReadyCondition).LastTransitionTime)
+
+	legacy[2].Status = "True"
+	conditions = Mirror(conditions, legacy, 5, "Paused")
+	ready = Get(conditions, ReadyCondition)
+	require.Equal(t, metav1.ConditionTrue, ready.Status)
+	require.Equal(t, ReadyReason, ready.Reason)
+	require.NotEqual(t, readyTransition, ready.LastTransitionTime)
+}
+
+func TestMirrorLegacyReadyOnly(t *testing.T) {
+	require.Nil(t, Mirror(nil, nil, 1))
+
+	conditions := Mirror(nil, clusterv1.Conditions{{Type: clusterv1.ReadyCondition, Status: "Unknown"}}, 1)
+	require.Len(t, conditions, 1)
+	require.Equal(t, metav1.ConditionUnknown, conditions[0].Status)
+	require.Equal(t, NoReasonReported, conditions[0].Reason)
+	require.False(t, conditions[0].LastTransitionTime.IsZero())
+}
+
+func TestCamelCase(t *testing.T) {
+	require.Equal(t, "UnableToConnectToTheWorkloadClusterAPI", camelCase("Unable to connect to the workload cluster API"))
+	require.Equal(t, "DataSecretGenerationFailed", camelCase("DataSecretGenerationFailed"))
+	require.Equal(t, "Failed", camelCase("42 failed"))
+	require.Equal(t, "", camelCase(""))
+}